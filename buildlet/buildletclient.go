@@ -18,6 +18,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -573,6 +574,19 @@ func (c *Client) RemoveAll(paths ...string) error {
 	return c.doOK(req)
 }
 
+// Drain asks the buildlet to stop accepting new work, finish any
+// commands it's currently running, and then exit, as for Close.
+// Unlike Close, it returns as soon as the buildlet acknowledges the
+// request, without waiting for the buildlet to go away.
+// It requires buildlet version 19 or later.
+func (c *Client) Drain() error {
+	req, err := http.NewRequest("POST", c.URL()+"/drain", nil)
+	if err != nil {
+		return err
+	}
+	return c.doOK(req)
+}
+
 // DestroyVM shuts down the buildlet and destroys the VM instance.
 func (c *Client) DestroyVM(ts oauth2.TokenSource, proj, zone, instance string) error {
 	// TODO(bradfitz): move GCE stuff out of this package?
@@ -624,6 +638,11 @@ func (c *Client) DestroyVM(ts oauth2.TokenSource, proj, zone, instance string) e
 // to do with a buildlet.
 type Status struct {
 	Version int // buildlet version, coordinator rejects any value less than 1.
+
+	// Draining is whether the buildlet has been asked to drain:
+	// it refuses new work and exits once its current work is done.
+	// It's only reported by buildlet version 19 and later.
+	Draining bool `json:",omitempty"`
 }
 
 // Status returns an Status value describing this buildlet.
@@ -771,34 +790,75 @@ func (c *Client) dialWithNetDial() (net.Conn, error) {
 // The authorizedPubKey must be a line from an ~/.ssh/authorized_keys file
 // and correspond to the private key to be used to communicate over the net.Conn.
 func (c *Client) ConnectSSH(user, authorizedPubKey string) (net.Conn, error) {
-	conn, err := c.getDialer()()
-	if err != nil {
-		return nil, fmt.Errorf("error dialing HTTP connection before SSH upgrade: %v", err)
-	}
 	req, err := http.NewRequest("POST", "/connect-ssh", nil)
 	if err != nil {
-		conn.Close()
 		return nil, err
 	}
 	req.Header.Add("X-Go-Ssh-User", user)
 	req.Header.Add("X-Go-Authorized-Key", authorizedPubKey)
+	return c.connectUpgrade(req)
+}
+
+// ConnectTCP opens a TCP connection to the given port on the
+// buildlet's own host, as if dialed from the buildlet to localhost.
+// For remote buildlets, the connection is tunneled through the
+// coordinator.
+func (c *Client) ConnectTCP(port int) (net.Conn, error) {
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	req, err := http.NewRequest("POST", "/connect-tcp", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("X-Go-Tcp-Port", strconv.Itoa(port))
+	return c.connectUpgrade(req)
+}
+
+// connectUpgrade sends req on a new connection to the buildlet and
+// returns that connection once the buildlet has switched protocols.
+func (c *Client) connectUpgrade(req *http.Request) (net.Conn, error) {
+	conn, err := c.getDialer()()
+	if err != nil {
+		return nil, fmt.Errorf("error dialing HTTP connection before %s upgrade: %v", req.URL.Path, err)
+	}
+	if c.password != "" {
+		req.SetBasicAuth(c.authUsername(), c.password)
+	}
+	if c.remoteBuildlet != "" {
+		req.Header.Set("X-Buildlet-Proxy", c.remoteBuildlet)
+	}
 	if err := req.Write(conn); err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("writing /connect-ssh HTTP request failed: %v", err)
+		return nil, fmt.Errorf("writing %s HTTP request failed: %v", req.URL.Path, err)
 	}
 	bufr := bufio.NewReader(conn)
 	res, err := http.ReadResponse(bufr, req)
 	if err != nil {
 		conn.Close()
-		return nil, fmt.Errorf("reading /connect-ssh response: %v", err)
+		return nil, fmt.Errorf("reading %s response: %v", req.URL.Path, err)
 	}
 	if res.StatusCode != http.StatusSwitchingProtocols {
 		slurp, _ := ioutil.ReadAll(res.Body)
-		return nil, fmt.Errorf("unexpected /connect-ssh response: %v, %s", res.Status, slurp)
+		conn.Close()
+		return nil, fmt.Errorf("unexpected %s response: %v, %s", req.URL.Path, res.Status, slurp)
+	}
+	if bufr.Buffered() > 0 {
+		// The other side spoke first; don't lose what it said.
+		return &bufferedConn{conn, bufr}, nil
 	}
 	return conn, nil
 }
 
+// bufferedConn is a net.Conn whose first reads come from a
+// bufio.Reader that may have read ahead.
+type bufferedConn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) { return c.br.Read(p) }
+
 func condRun(fn func()) {
 	if fn != nil {
 		fn()
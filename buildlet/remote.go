@@ -11,6 +11,7 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -75,15 +76,51 @@ func (cc *CoordinatorClient) client() (*http.Client, error) {
 // It may expire at any time.
 // To release it, call Client.Destroy.
 func (cc *CoordinatorClient) CreateBuildlet(builderType string) (*Client, error) {
+	return cc.CreateBuildletWithOpts(builderType, CreateOpts{})
+}
+
+// CreateOpts are options for CreateBuildletWithOpts.
+type CreateOpts struct {
+	// Lease optionally specifies how long the buildlet lives
+	// without being used or renewed. If zero, the coordinator's
+	// default is used.
+	Lease time.Duration
+
+	// OnStatus optionally specifies a func to be called with
+	// progress updates while the coordinator waits for a free
+	// buildlet, such as when a reverse host type is busy.
+	OnStatus func(CreateStatus)
+}
+
+// CreateStatus is a progress update sent by the coordinator while a
+// create request waits for a buildlet.
+type CreateStatus struct {
+	QueuePosition int    // 1-based place in line for the host type
+	Message       string // human-readable description
+}
+
+// createMessage is one JSON message of the coordinator's reply to a
+// create request. Exactly one field is set.
+type createMessage struct {
+	Status   *CreateStatus
+	Buildlet *RemoteBuildlet
+	Error    string
+}
+
+// CreateBuildletWithOpts is like CreateBuildlet but with options.
+func (cc *CoordinatorClient) CreateBuildletWithOpts(builderType string, opts CreateOpts) (*Client, error) {
 	hc, err := cc.client()
 	if err != nil {
 		return nil, err
 	}
 	ipPort, _ := cc.instance().TLSHostPort() // must succeed if client did
 	form := url.Values{
-		"version":     {"20160922"}, // checked by cmd/coordinator/remote.go
+		"version":     {"20181019"}, // checked by cmd/coordinator/remote.go
 		"builderType": {builderType},
 	}
+	if opts.Lease != 0 {
+		form.Set("lease", opts.Lease.String())
+	}
 	req, _ := http.NewRequest("POST",
 		"https://"+ipPort+"/buildlet/create",
 		strings.NewReader(form.Encode()))
@@ -99,9 +136,23 @@ func (cc *CoordinatorClient) CreateBuildlet(builderType string) (*Client, error)
 		slurp, _ := ioutil.ReadAll(res.Body)
 		return nil, fmt.Errorf("%s: %s", res.Status, slurp)
 	}
-	var rb RemoteBuildlet
-	if err := json.NewDecoder(res.Body).Decode(&rb); err != nil {
-		return nil, err
+	dec := json.NewDecoder(res.Body)
+	var rb *RemoteBuildlet
+	for rb == nil {
+		var msg createMessage
+		if err := dec.Decode(&msg); err != nil {
+			return nil, fmt.Errorf("buildlet: reading create response: %v", err)
+		}
+		switch {
+		case msg.Error != "":
+			return nil, errors.New(msg.Error)
+		case msg.Status != nil:
+			if opts.OnStatus != nil {
+				opts.OnStatus(*msg.Status)
+			}
+		case msg.Buildlet != nil:
+			rb = msg.Buildlet
+		}
 	}
 	if rb.Name == "" {
 		return nil, errors.New("buildlet: failed to create remote buildlet; unexpected missing name in response")
@@ -114,6 +165,7 @@ func (cc *CoordinatorClient) CreateBuildlet(builderType string) (*Client, error)
 }
 
 type RemoteBuildlet struct {
+	User        string // "user-bradfitz"
 	HostType    string // "host-linux-jessie"
 	BuilderType string // "linux-386-387"
 	Name        string // "buildlet-adg-openbsd-386-2"
@@ -121,6 +173,96 @@ type RemoteBuildlet struct {
 	Expires     time.Time
 }
 
+// RenewBuildlet extends the lease of the named remote buildlet to
+// lease from now. A zero lease means the coordinator's default.
+func (cc *CoordinatorClient) RenewBuildlet(name string, lease time.Duration) (*RemoteBuildlet, error) {
+	form := url.Values{"name": {name}}
+	if lease != 0 {
+		form.Set("lease", lease.String())
+	}
+	res, err := cc.do("POST", "/buildlet/renew", form)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	rb := new(RemoteBuildlet)
+	if err := json.NewDecoder(res.Body).Decode(rb); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// Leases is the coordinator's view of all remote buildlets, as
+// returned by CoordinatorClient.Leases.
+type Leases struct {
+	Buildlets []RemoteBuildlet
+	Waiting   []LeaseWaiter
+}
+
+// LeaseWaiter is a create request waiting in line for a buildlet.
+type LeaseWaiter struct {
+	User        string // "user-bradfitz"
+	HostType    string // "host-darwin-10_12"
+	BuilderType string // "darwin-amd64-10_12"
+	Since       time.Time
+	Position    int // 1-based place in line for HostType
+}
+
+// Leases returns all remote buildlets and queued create requests of
+// all users. It requires gomote administrator privileges.
+func (cc *CoordinatorClient) Leases() (*Leases, error) {
+	res, err := cc.do("GET", "/buildlet/leases", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	ls := new(Leases)
+	if err := json.NewDecoder(res.Body).Decode(ls); err != nil {
+		return nil, err
+	}
+	return ls, nil
+}
+
+// RevokeBuildlet destroys the named remote buildlet, regardless of
+// which user owns it. It requires gomote administrator privileges.
+func (cc *CoordinatorClient) RevokeBuildlet(name string) error {
+	res, err := cc.do("POST", "/buildlet/revoke", url.Values{"name": {name}})
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+// do sends an authenticated request to the coordinator. For POST
+// requests, form is sent as the body. A non-200 response is returned
+// as an error.
+func (cc *CoordinatorClient) do(method, path string, form url.Values) (*http.Response, error) {
+	hc, err := cc.client()
+	if err != nil {
+		return nil, err
+	}
+	ipPort, _ := cc.instance().TLSHostPort() // must succeed if client did
+	var req *http.Request
+	if method == "POST" {
+		req, _ = http.NewRequest(method, "https://"+ipPort+path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req, _ = http.NewRequest(method, "https://"+ipPort+path, nil)
+	}
+	req.SetBasicAuth(cc.Auth.Username, cc.Auth.Password)
+	res, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != 200 {
+		slurp, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, fmt.Errorf("%s: %s", res.Status, slurp)
+	}
+	return res, nil
+}
+
 func (cc *CoordinatorClient) RemoteBuildlets() ([]RemoteBuildlet, error) {
 	hc, err := cc.client()
 	if err != nil {
@@ -153,12 +295,14 @@ func (cc *CoordinatorClient) NamedBuildlet(name string) (*Client, error) {
 		return nil, err
 	}
 	ipPort, _ := cc.instance().TLSHostPort() // must succeed if client did
+	dialTLS := cc.instance().TLSDialer()
 	c := &Client{
 		baseURL:        "https://" + ipPort,
 		remoteBuildlet: name,
 		httpClient:     hc,
 		authUser:       cc.Auth.Username,
 		password:       cc.Auth.Password,
+		dialer:         func() (net.Conn, error) { return dialTLS("tcp", ipPort) },
 	}
 	c.setCommon()
 	return c, nil
@@ -188,8 +332,9 @@ func username() string {
 	return os.Getenv("USER")
 }
 
-// configDir finds the OS-dependent config dir.
-func configDir() string {
+// ConfigDir finds the OS-dependent directory holding gomote's
+// configuration, such as user tokens.
+func ConfigDir() string {
 	if runtime.GOOS == "windows" {
 		return filepath.Join(os.Getenv("APPDATA"), "Gomote")
 	}
@@ -204,7 +349,7 @@ func userToken() (string, error) {
 	if gomoteUserFlag == "" {
 		panic("userToken called with user flag empty")
 	}
-	keyDir := configDir()
+	keyDir := ConfigDir()
 	userPath := filepath.Join(keyDir, "user-"+gomoteUserFlag+".user")
 	b, err := ioutil.ReadFile(userPath)
 	if err == nil {
@@ -73,7 +73,9 @@ var (
 //   15: ssh support
 //   16: make macstadium builders always haltEntireOS
 //   17: make macstadium halts use sudo
-const buildletVersion = 17
+//   18: /connect-tcp for gomote port forwarding
+//   19: /drain
+const buildletVersion = 19
 
 func defaultListenAddr() string {
 	if runtime.GOOS == "darwin" {
@@ -208,16 +210,18 @@ func main() {
 	requireAuth := func(handler func(w http.ResponseWriter, r *http.Request)) http.Handler {
 		return requirePasswordHandler{http.HandlerFunc(handler), password}
 	}
-	http.Handle("/writetgz", requireAuth(handleWriteTGZ))
-	http.Handle("/write", requireAuth(handleWrite))
-	http.Handle("/exec", requireAuth(handleExec))
+	http.Handle("/writetgz", requireAuth(doesWork(handleWriteTGZ)))
+	http.Handle("/write", requireAuth(doesWork(handleWrite)))
+	http.Handle("/exec", requireAuth(doesWork(handleExec)))
 	http.Handle("/halt", requireAuth(handleHalt))
+	http.Handle("/drain", requireAuth(handleDrain))
 	http.Handle("/tgz", requireAuth(handleGetTGZ))
 	http.Handle("/removeall", requireAuth(handleRemoveAll))
 	http.Handle("/workdir", requireAuth(handleWorkDir))
 	http.Handle("/status", requireAuth(handleStatus))
 	http.Handle("/ls", requireAuth(handleLs))
 	http.Handle("/connect-ssh", requireAuth(handleConnectSSH))
+	http.Handle("/connect-tcp", requireAuth(handleConnectTCP))
 
 	if !isReverse {
 		listenForCoordinator()
@@ -1125,6 +1129,65 @@ func handleHalt(w http.ResponseWriter, r *http.Request) {
 	time.AfterFunc(1*time.Second, doHalt)
 }
 
+// work tracks the requests doing work on the buildlet, so a drain
+// can wait for them to finish.
+var work struct {
+	sync.Mutex
+	draining bool
+	active   int           // number of in-flight doesWork requests
+	idle     chan struct{} // closed when draining and active reaches zero
+}
+
+// doesWork wraps a handler that starts new work on the buildlet,
+// refusing it once the buildlet is draining.
+func doesWork(handler func(w http.ResponseWriter, r *http.Request)) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		work.Lock()
+		if work.draining {
+			work.Unlock()
+			http.Error(w, "buildlet is draining", http.StatusServiceUnavailable)
+			return
+		}
+		work.active++
+		work.Unlock()
+
+		defer func() {
+			work.Lock()
+			defer work.Unlock()
+			work.active--
+			if work.draining && work.active == 0 {
+				close(work.idle)
+			}
+		}()
+		handler(w, r)
+	}
+}
+
+// handleDrain makes the buildlet refuse new work and halt, as for
+// /halt, once its in-flight requests finish. It returns immediately.
+func handleDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "requires POST method", http.StatusBadRequest)
+		return
+	}
+	work.Lock()
+	defer work.Unlock()
+	if work.draining {
+		return
+	}
+	work.draining = true
+	work.idle = make(chan struct{})
+	if work.active == 0 {
+		close(work.idle)
+	}
+	log.Printf("Draining; waiting for %d in-flight requests before halting.", work.active)
+	go func() {
+		<-work.idle
+		log.Printf("Drained; halting in 1 second.")
+		time.AfterFunc(1*time.Second, doHalt)
+	}()
+}
+
 func doHalt() {
 	if *rebootOnHalt {
 		if err := exec.Command("reboot").Run(); err != nil {
@@ -1239,8 +1302,12 @@ func handleStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "requires GET method", http.StatusBadRequest)
 		return
 	}
+	work.Lock()
+	draining := work.draining
+	work.Unlock()
 	status := buildlet.Status{
-		Version: buildletVersion,
+		Version:  buildletVersion,
+		Draining: draining,
 	}
 	b, err := json.Marshal(status)
 	if err != nil {
@@ -1344,27 +1411,60 @@ func handleConnectSSH(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	defer sshConn.Close()
+	proxyUpgraded(w, sshConn, "ssh")
+}
+
+// handleConnectTCP connects the client to a TCP port on localhost,
+// so gomote users can reach debuggers, pprof endpoints and the like
+// running on the buildlet's host.
+func handleConnectTCP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "requires POST method", http.StatusBadRequest)
+		return
+	}
+	if r.ContentLength != 0 {
+		http.Error(w, "requires zero Content-Length", http.StatusBadRequest)
+		return
+	}
+	port, err := strconv.Atoi(r.Header.Get("X-Go-Tcp-Port"))
+	if err != nil || port <= 0 || port > 65535 {
+		http.Error(w, "missing or invalid X-Go-Tcp-Port header", http.StatusBadRequest)
+		return
+	}
+	backend, err := net.Dial("tcp", net.JoinHostPort("localhost", strconv.Itoa(port)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer backend.Close()
+	proxyUpgraded(w, backend, "tcp")
+}
+
+// proxyUpgraded hijacks the client's connection, tells it that the
+// connection has switched to proto, and then copies bytes between it
+// and backend until either side is done.
+func proxyUpgraded(w http.ResponseWriter, backend net.Conn, proto string) {
 	hj, ok := w.(http.Hijacker)
 	if !ok {
-		log.Printf("conn can't hijack for ssh proxy; HTTP/2 enabled by default?")
+		log.Printf("conn can't hijack for %s proxy; HTTP/2 enabled by default?", proto)
 		http.Error(w, "conn can't hijack", http.StatusInternalServerError)
 		return
 	}
 	conn, _, err := hj.Hijack()
 	if err != nil {
-		log.Printf("ssh hijack error: %v", err)
-		http.Error(w, "ssh hijack error: "+err.Error(), http.StatusInternalServerError)
+		log.Printf("%s hijack error: %v", proto, err)
+		http.Error(w, proto+" hijack error: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 	defer conn.Close()
-	fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: ssh\r\nConnection: Upgrade\r\n\r\n")
+	fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: %s\r\nConnection: Upgrade\r\n\r\n", proto)
 	errc := make(chan error, 1)
 	go func() {
-		_, err := io.Copy(sshConn, conn)
+		_, err := io.Copy(backend, conn)
 		errc <- err
 	}()
 	go func() {
-		_, err := io.Copy(conn, sshConn)
+		_, err := io.Copy(conn, backend)
 		errc <- err
 	}()
 	<-errc
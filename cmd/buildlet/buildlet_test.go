@@ -5,9 +5,17 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"runtime"
+	"strings"
 	"testing"
+
+	"golang.org/x/build/buildlet"
 )
 
 func TestSetPathEnv(t *testing.T) {
@@ -59,3 +67,79 @@ func TestSetPathEnv(t *testing.T) {
 		}
 	}
 }
+
+func TestConnectTCP(t *testing.T) {
+	// An echo server standing in for something like a debugger
+	// listening on the buildlet's host.
+	echo, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			c, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				io.Copy(c, c)
+			}()
+		}
+	}()
+
+	ts := httptest.NewServer(http.HandlerFunc(handleConnectTCP))
+	defer ts.Close()
+	bc := buildlet.NewClient(strings.TrimPrefix(ts.URL, "http://"), buildlet.NoKeyPair)
+
+	conn, err := bc.ConnectTCP(echo.Addr().(*net.TCPAddr).Port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	const msg = "hello, gomote\n"
+	if _, err := io.WriteString(conn, msg); err != nil {
+		t.Fatal(err)
+	}
+	got, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != msg {
+		t.Errorf("read %q; want %q", got, msg)
+	}
+
+	if _, err := bc.ConnectTCP(0); err == nil {
+		t.Error("ConnectTCP(0) succeeded; want error")
+	}
+}
+
+func TestDoesWorkRefusesWhileDraining(t *testing.T) {
+	ran := 0
+	h := doesWork(func(w http.ResponseWriter, r *http.Request) { ran++ })
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("POST", "/exec", nil))
+	if rec.Code != 200 || ran != 1 {
+		t.Fatalf("before drain: code %d, ran %d; want 200, 1", rec.Code, ran)
+	}
+
+	// Drain by hand rather than with handleDrain, which would
+	// halt the test process.
+	work.Lock()
+	work.draining = true
+	work.idle = make(chan struct{})
+	work.Unlock()
+	defer func() {
+		work.Lock()
+		work.draining = false
+		work.Unlock()
+	}()
+
+	rec = httptest.NewRecorder()
+	h(rec, httptest.NewRequest("POST", "/exec", nil))
+	if rec.Code != http.StatusServiceUnavailable || ran != 1 {
+		t.Errorf("while draining: code %d, ran %d; want 503, 1", rec.Code, ran)
+	}
+}
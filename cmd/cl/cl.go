@@ -269,12 +269,12 @@ func updateReviewStatus(cl *CL, gerritAccounts *GerritAccounts) {
 			continue
 		}
 
-		codeReviewIdx := strings.Index(meta.Commit.Msg, tagCodeReview)
+		codeReviewIdx := strings.Index(meta.Commit.GetMsg(), tagCodeReview)
 
 		if codeReviewIdx > 0 {
 			prefix := len(tagCodeReview)
 			// Extract and convert the point(s). This line takes the form "Label: Code-Review=+1".
-			val, err := strconv.Atoi(meta.Commit.Msg[codeReviewIdx+prefix : codeReviewIdx+prefix+2])
+			val, err := strconv.Atoi(meta.Commit.GetMsg()[codeReviewIdx+prefix : codeReviewIdx+prefix+2])
 			if err == nil {
 				cl.scores[reviewer.Email] = val
 			}
@@ -331,7 +331,7 @@ func updateReviewStatus(cl *CL, gerritAccounts *GerritAccounts) {
 			continue
 		}
 
-		if strings.Contains(meta.Commit.Msg, "Uploaded patch set ") || accountInfo.Email != cl.reviewerEmail {
+		if strings.Contains(meta.Commit.GetMsg(), "Uploaded patch set ") || accountInfo.Email != cl.reviewerEmail {
 			cl.needsReview = true
 			cl.needsReviewChanged = meta.Commit.CommitTime
 		}
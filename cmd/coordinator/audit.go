@@ -0,0 +1,506 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to auditing what gomote users do on shared builders:
+// a log of gomote operations and transcripts of SSH sessions.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	auditDir       = flag.String("audit_dir", "", "If non-empty, the directory in which to persist the gomote audit log and SSH session transcripts. Otherwise they're only kept in memory.")
+	auditRetention = flag.Duration("audit_retention", 30*24*time.Hour, "How long to keep gomote audit log entries and SSH session transcripts.")
+)
+
+// maxMemTranscript is the most SSH session output kept per session
+// when transcripts aren't persisted to disk.
+const maxMemTranscript = 1 << 20
+
+// audit is the coordinator's gomote audit log.
+var audit = &auditLog{retention: 30 * 24 * time.Hour}
+
+// An auditEvent is one gomote operation by a user.
+type auditEvent struct {
+	Time       time.Time
+	User       string // "user-bradfitz"
+	Instance   string `json:",omitempty"`
+	Action     string // "create", "destroy", "exec", "put", "rm", "ssh", ...
+	Detail     string `json:",omitempty"`
+	Transcript string `json:",omitempty"` // ID of the SSH session transcript, for "ssh"
+}
+
+// auditLog records gomote operations and SSH session transcripts,
+// keeping them in memory and, if dir is set, on disk, for up to
+// retention.
+type auditLog struct {
+	dir       string // if non-empty, where events and transcripts are persisted
+	retention time.Duration
+
+	mu          sync.Mutex
+	events      []auditEvent // oldest first
+	transcripts map[string]*sshTranscript
+	unwritten   []auditEvent // recorded but not yet persisted
+	writing     bool         // whether writeLoop is running
+
+	writers sync.WaitGroup // running writeLoops
+}
+
+// init configures the audit log from flags and loads any
+// persisted events. It must be called before the log is used
+// concurrently.
+func (a *auditLog) init(dir string, retention time.Duration) error {
+	a.dir = dir
+	a.retention = retention
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "events-*.jsonl"))
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-retention)
+	for _, file := range files { // sorted by date, from the file names
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		bs := bufio.NewScanner(f)
+		for bs.Scan() {
+			var ev auditEvent
+			if err := json.Unmarshal(bs.Bytes(), &ev); err != nil {
+				log.Printf("audit: skipping bad line in %s: %v", file, err)
+				continue
+			}
+			if ev.Time.After(cutoff) {
+				a.events = append(a.events, ev)
+			}
+		}
+		err = bs.Err()
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("audit: loaded %d events from %s", len(a.events), dir)
+	return nil
+}
+
+// pruneLoop periodically discards events and transcripts older
+// than the retention period. It runs forever.
+func (a *auditLog) pruneLoop() {
+	for {
+		a.prune(time.Now())
+		time.Sleep(time.Hour)
+	}
+}
+
+func (a *auditLog) prune(now time.Time) {
+	cutoff := now.Add(-a.retention)
+	a.mu.Lock()
+	i := 0
+	for i < len(a.events) && a.events[i].Time.Before(cutoff) {
+		i++
+	}
+	a.events = append([]auditEvent(nil), a.events[i:]...)
+	for id, t := range a.transcripts {
+		if t.Start.Before(cutoff) && t.done() {
+			delete(a.transcripts, id)
+		}
+	}
+	a.mu.Unlock()
+
+	if a.dir == "" {
+		return
+	}
+	files, err := ioutil.ReadDir(a.dir)
+	if err != nil {
+		log.Printf("audit: pruning: %v", err)
+		return
+	}
+	for _, fi := range files {
+		name := fi.Name()
+		old := fi.ModTime().Before(cutoff)
+		if strings.HasPrefix(name, "events-") {
+			// Event files are appended to all day; only
+			// remove them once the day is entirely too old.
+			day, err := time.Parse("2006-01-02", strings.TrimSuffix(strings.TrimPrefix(name, "events-"), ".jsonl"))
+			old = err == nil && day.Add(24*time.Hour).Before(cutoff)
+		}
+		if old {
+			if err := os.Remove(filepath.Join(a.dir, name)); err != nil {
+				log.Printf("audit: pruning: %v", err)
+			}
+		}
+	}
+}
+
+// record adds ev to the log, setting its time if unset. Events are
+// persisted in the background, so record doesn't wait for disk I/O
+// and may be called with other locks held.
+func (a *auditLog) record(ev auditEvent) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, ev)
+	if a.dir == "" {
+		return
+	}
+	a.unwritten = append(a.unwritten, ev)
+	if !a.writing {
+		a.writing = true
+		a.writers.Add(1)
+		go a.writeLoop()
+	}
+}
+
+// writeLoop persists unwritten events, in order, until there are
+// none left. At most one runs at a time.
+func (a *auditLog) writeLoop() {
+	defer a.writers.Done()
+	for {
+		a.mu.Lock()
+		evs := a.unwritten
+		a.unwritten = nil
+		if len(evs) == 0 {
+			a.writing = false
+			a.mu.Unlock()
+			return
+		}
+		a.mu.Unlock()
+		for _, ev := range evs {
+			a.write(ev)
+		}
+	}
+}
+
+// flush waits for recorded events to be persisted.
+func (a *auditLog) flush() { a.writers.Wait() }
+
+// write appends ev to its day's event file.
+func (a *auditLog) write(ev auditEvent) {
+	j, err := json.Marshal(ev)
+	if err != nil {
+		log.Printf("audit: %v", err)
+		return
+	}
+	name := filepath.Join(a.dir, "events-"+ev.Time.UTC().Format("2006-01-02")+".jsonl")
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		log.Printf("audit: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(j, '\n')); err != nil {
+		log.Printf("audit: %v", err)
+	}
+}
+
+// recordProxy records the notable gomote operations among the
+// buildlet HTTP requests proxied on behalf of user.
+//
+// It may read r's body, in which case r.Body is replaced so the
+// request can still be proxied.
+func (a *auditLog) recordProxy(user string, rb *remoteBuildlet, r *http.Request) {
+	ev := auditEvent{User: user, Instance: rb.Name}
+	switch r.URL.Path {
+	case "/exec":
+		form, err := peekForm(r)
+		if err != nil {
+			ev.Detail = fmt.Sprintf("(unreadable request: %v)", err)
+		} else {
+			ev.Detail = strings.Join(append([]string{form.Get("cmd")}, form["cmdArg"]...), " ")
+			if dir := form.Get("dir"); dir != "" {
+				ev.Detail += " (in " + dir + ")"
+			}
+		}
+		ev.Action = "exec"
+	case "/writetgz":
+		ev.Action = "put"
+		ev.Detail = "tar.gz to " + orDot(r.URL.Query().Get("dir"))
+		if r.Method == "POST" {
+			if form, err := peekForm(r); err == nil {
+				ev.Detail += " from " + form.Get("url")
+			}
+		}
+	case "/write":
+		ev.Action = "put"
+		ev.Detail = r.URL.Query().Get("path")
+	case "/removeall":
+		ev.Action = "rm"
+		if form, err := peekForm(r); err == nil {
+			ev.Detail = strings.Join(form["path"], " ")
+		}
+	case "/halt":
+		ev.Action = "destroy"
+	case "/connect-tcp":
+		ev.Action = "forward"
+		ev.Detail = "port " + r.Header.Get("X-Go-Tcp-Port")
+	default:
+		return
+	}
+	a.record(ev)
+}
+
+func orDot(dir string) string {
+	if dir == "" {
+		return "."
+	}
+	return dir
+}
+
+// peekForm parses the url-encoded form in r's body without
+// consuming it.
+func peekForm(r *http.Request) (url.Values, error) {
+	const maxFormSize = 1 << 20
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxFormSize+1))
+	r.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxFormSize {
+		return nil, fmt.Errorf("form larger than %d bytes", maxFormSize)
+	}
+	return url.ParseQuery(string(body))
+}
+
+// query returns the events matching the non-empty filters, oldest
+// first.
+func (a *auditLog) query(user, instance, action string, since time.Time) []auditEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	res := make([]auditEvent, 0) // so it's never JSON "null"
+	for _, ev := range a.events {
+		if ev.Time.Before(since) ||
+			user != "" && ev.User != user ||
+			instance != "" && ev.Instance != instance ||
+			action != "" && ev.Action != action {
+			continue
+		}
+		res = append(res, ev)
+	}
+	return res
+}
+
+// sshTranscript records an SSH session in asciicast v2 format:
+// a JSON header line followed by one JSON array per chunk of
+// output ("o") or input ("i"), timestamped relative to Start.
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md.
+type sshTranscript struct {
+	ID       string
+	User     string
+	Instance string
+	Start    time.Time
+
+	mu        sync.Mutex
+	f         *os.File     // if persisted
+	buf       bytes.Buffer // if not persisted
+	truncated bool
+	closed    bool
+}
+
+// startTranscript begins recording an SSH session by user to
+// instance, with the given terminal type and size.
+func (a *auditLog) startTranscript(user, instance, term string, width, height int) *sshTranscript {
+	now := time.Now()
+	t := &sshTranscript{
+		ID:       fmt.Sprintf("%s-%s-%d", now.UTC().Format("20060102T150405"), instance, now.UnixNano()%1e9),
+		User:     user,
+		Instance: instance,
+		Start:    now,
+	}
+	if a.dir != "" {
+		f, err := os.OpenFile(filepath.Join(a.dir, t.ID+".cast"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			log.Printf("audit: recording SSH session to memory: %v", err)
+		} else {
+			t.f = f
+		}
+	}
+	header, _ := json.Marshal(struct {
+		Version   int               `json:"version"`
+		Width     int               `json:"width"`
+		Height    int               `json:"height"`
+		Timestamp int64             `json:"timestamp"`
+		Title     string            `json:"title"`
+		Env       map[string]string `json:"env"`
+	}{2, width, height, now.Unix(), user + " on " + instance, map[string]string{"TERM": term}})
+	t.write(append(header, '\n'))
+
+	a.mu.Lock()
+	if a.transcripts == nil {
+		a.transcripts = make(map[string]*sshTranscript)
+	}
+	a.transcripts[t.ID] = t
+	a.mu.Unlock()
+	a.record(auditEvent{Time: now, User: user, Instance: instance, Action: "ssh", Transcript: t.ID})
+	return t
+}
+
+func (t *sshTranscript) write(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	if t.f != nil {
+		if _, err := t.f.Write(p); err != nil {
+			log.Printf("audit: writing transcript %s: %v", t.ID, err)
+		}
+		return
+	}
+	if t.buf.Len()+len(p) > maxMemTranscript {
+		t.truncated = true
+		return
+	}
+	t.buf.Write(p)
+}
+
+// Writer returns an io.Writer recording everything written to it
+// as events of the given kind: "o" for output or "i" for input.
+func (t *sshTranscript) Writer(kind string) io.Writer {
+	return transcriptWriter{t, kind}
+}
+
+type transcriptWriter struct {
+	t    *sshTranscript
+	kind string
+}
+
+func (w transcriptWriter) Write(p []byte) (int, error) {
+	j, err := json.Marshal([]interface{}{time.Since(w.t.Start).Seconds(), w.kind, string(p)})
+	if err != nil {
+		return 0, err
+	}
+	w.t.write(append(j, '\n'))
+	return len(p), nil
+}
+
+// Close finishes the transcript.
+func (t *sshTranscript) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	if t.f != nil {
+		return t.f.Close()
+	}
+	return nil
+}
+
+func (t *sshTranscript) done() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// writeTranscript writes the transcript with the given ID, as
+// recorded so far, to w.
+func (a *auditLog) writeTranscript(w io.Writer, id string) error {
+	a.mu.Lock()
+	t, ok := a.transcripts[id]
+	a.mu.Unlock()
+	if ok {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.f == nil {
+			_, err := w.Write(t.buf.Bytes())
+			return err
+		}
+	}
+	if a.dir == "" || !validTranscriptID(id) {
+		return os.ErrNotExist
+	}
+	f, err := os.Open(filepath.Join(a.dir, id+".cast"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func validTranscriptID(id string) bool {
+	if id == "" || strings.HasPrefix(id, ".") {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// always wrapped in requireBuildletProxyAuth.
+//
+// handleAudit serves the audit log as JSON, optionally filtered by
+// the "user", "instance", "action" and "since" (RFC 3339) query
+// parameters. With a "transcript" parameter, it instead serves that
+// SSH session's asciicast transcript.
+func handleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "GET required", 400)
+		return
+	}
+	if user, _, _ := r.BasicAuth(); !isGomoteAdmin(user) {
+		http.Error(w, "gomote admin required", http.StatusForbidden)
+		return
+	}
+	if id := r.FormValue("transcript"); id != "" {
+		var buf bytes.Buffer
+		if err := audit.writeTranscript(&buf, id); err != nil {
+			if os.IsNotExist(err) {
+				http.Error(w, "unknown transcript", http.StatusNotFound)
+			} else {
+				http.Error(w, err.Error(), 500)
+			}
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-asciicast")
+		w.Write(buf.Bytes())
+		return
+	}
+	var since time.Time
+	if v := r.FormValue("since"); v != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "bad 'since' parameter: "+err.Error(), 400)
+			return
+		}
+	}
+	res := audit.query(r.FormValue("user"), r.FormValue("instance"), r.FormValue("action"), since)
+	jenc, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	jenc = append(jenc, '\n')
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(jenc)
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditRecordProxy(t *testing.T) {
+	a := new(auditLog)
+	rb := &remoteBuildlet{Name: "user-foo-linux-amd64-0"}
+
+	form := url.Values{"cmd": {"go/bin/go"}, "cmdArg": {"test", "-short", "os"}, "dir": {"go/src"}}
+	body := form.Encode()
+	req := httptest.NewRequest("POST", "/exec", strings.NewReader(body))
+	a.recordProxy("user-foo", rb, req)
+	if got, _ := ioutil.ReadAll(req.Body); string(got) != body {
+		t.Errorf("after recordProxy, body = %q; want %q", got, body)
+	}
+
+	a.recordProxy("user-foo", rb, httptest.NewRequest("PUT", "/write?path=go%2Fsrc%2Fx.go", nil))
+	a.recordProxy("user-foo", rb, httptest.NewRequest("GET", "/ls", nil)) // not recorded
+
+	evs := a.query("user-foo", "", "", time.Time{})
+	if len(evs) != 2 {
+		t.Fatalf("got %d events; want 2: %+v", len(evs), evs)
+	}
+	if evs[0].Action != "exec" || evs[0].Detail != "go/bin/go test -short os (in go/src)" {
+		t.Errorf("exec event = %+v", evs[0])
+	}
+	if evs[1].Action != "put" || evs[1].Detail != "go/src/x.go" {
+		t.Errorf("put event = %+v", evs[1])
+	}
+	if evs := a.query("", "", "exec", time.Time{}); len(evs) != 1 {
+		t.Errorf("query by action = %d events; want 1", len(evs))
+	}
+	if evs := a.query("user-bar", "", "", time.Time{}); len(evs) != 0 {
+		t.Errorf("query for other user = %d events; want 0", len(evs))
+	}
+}
+
+func TestAuditPersistAndPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	a := new(auditLog)
+	if err := a.init(dir, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	a.record(auditEvent{Time: now.Add(-48 * time.Hour), User: "user-old", Action: "create"})
+	a.record(auditEvent{Time: now, User: "user-new", Action: "create"})
+	a.flush()
+
+	// A fresh log loads only the events within retention.
+	a2 := new(auditLog)
+	if err := a2.init(dir, 24*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if evs := a2.query("", "", "", time.Time{}); len(evs) != 1 || evs[0].User != "user-new" {
+		t.Errorf("reloaded events = %+v; want just user-new", evs)
+	}
+
+	a.prune(now)
+	if evs := a.query("", "", "", time.Time{}); len(evs) != 1 || evs[0].User != "user-new" {
+		t.Errorf("pruned events = %+v; want just user-new", evs)
+	}
+	old := "events-" + now.Add(-48*time.Hour).UTC().Format("2006-01-02") + ".jsonl"
+	if _, err := os.Stat(dir + "/" + old); !os.IsNotExist(err) {
+		t.Errorf("old event file %s not pruned; stat = %v", old, err)
+	}
+}
+
+func TestAuditTranscript(t *testing.T) {
+	a := new(auditLog)
+	tr := a.startTranscript("user-foo", "user-foo-linux-amd64-0", "xterm", 80, 24)
+	io.WriteString(tr.Writer("i"), "ls\r")
+	io.WriteString(tr.Writer("o"), "README\r\n")
+	tr.Close()
+
+	evs := a.query("", "", "ssh", time.Time{})
+	if len(evs) != 1 || evs[0].Transcript != tr.ID {
+		t.Fatalf("ssh events = %+v; want one referencing %s", evs, tr.ID)
+	}
+	var buf bytes.Buffer
+	if err := a.writeTranscript(&buf, tr.ID); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("transcript has %d lines; want 3:\n%s", len(lines), buf.Bytes())
+	}
+	var header struct {
+		Version, Width, Height int
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 {
+		t.Errorf("header = %+v", header)
+	}
+	var ev []interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &ev); err != nil {
+		t.Fatal(err)
+	}
+	if len(ev) != 3 || ev[1] != "o" || ev[2] != "README\r\n" {
+		t.Errorf("output event = %v", ev)
+	}
+	if err := a.writeTranscript(&buf, "../../etc/passwd"); !os.IsNotExist(err) {
+		t.Errorf("bogus transcript ID: err = %v; want not exist", err)
+	}
+}
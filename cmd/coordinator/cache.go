@@ -0,0 +1,110 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to the coordinator's disk cache of source tarballs,
+// bootstrap toolchains and snapshots.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/build/buildlet"
+	"golang.org/x/build/internal/contentcache"
+	"golang.org/x/build/internal/sourcecache"
+)
+
+var (
+	contentCacheDir  = flag.String("content_cache_dir", "", "If non-empty, a directory in which to cache source tarballs, bootstrap toolchains and snapshots on disk, so bursts of builds don't refetch them. Cache files left in it by an earlier run are deleted at startup; other files are left alone.")
+	contentCacheSize = flag.Int64("content_cache_size", 20<<30, "Maximum number of bytes to keep in -content_cache_dir.")
+)
+
+// contentCache is the coordinator's disk cache, or nil if
+// -content_cache_dir is empty.
+var contentCache *contentcache.Cache
+
+func initContentCache() error {
+	if *contentCacheDir == "" {
+		return nil
+	}
+	c, err := contentcache.New(*contentCacheDir, *contentCacheSize)
+	if err != nil {
+		return err
+	}
+	contentCache = c
+	sourcecache.SetDiskCache(c)
+	return nil
+}
+
+// putTarFromURL writes the tar.gz file at tarURL to dir on bc, a
+// buildlet of st. With the content cache enabled, the coordinator
+// fetches the file into the cache under key, once for all buildlets,
+// and sends it to bc itself. Otherwise, or if that fails, bc fetches
+// tarURL directly.
+//
+// Reverse buildlets always fetch tarURL themselves: they're far from
+// the coordinator, and skip downloads of files they already have.
+func (st *buildStatus) putTarFromURL(bc *buildlet.Client, key, tarURL, dir string) error {
+	if contentCache == nil || st.conf.IsReverse() {
+		return bc.PutTarFromURL(tarURL, dir)
+	}
+	rc, err := contentCache.Get(key, func(w io.Writer) error {
+		return fetchURL(tarURL, w)
+	})
+	if err != nil {
+		log.Printf("content cache: fetching %s: %v; having buildlet %s fetch it", tarURL, err, bc.Name())
+		return bc.PutTarFromURL(tarURL, dir)
+	}
+	defer rc.Close()
+	return bc.PutTar(rc, dir)
+}
+
+var contentCacheHTTPClient = &http.Client{
+	Timeout: 10 * time.Minute, // snapshots can be big
+}
+
+// fetchURL copies the body of a successful GET of u to w.
+func fetchURL(u string, w io.Writer) error {
+	res, err := contentCacheHTTPClient.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		slurp, _ := ioutil.ReadAll(io.LimitReader(res.Body, 4<<10))
+		return fmt.Errorf("fetching %s: %v; body: %s", u, res.Status, slurp)
+	}
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+// handleDebugCache lists the content cache's statistics and entries.
+func handleDebugCache(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if contentCache == nil {
+		io.WriteString(w, "content cache disabled; see -content_cache_dir\n")
+		return
+	}
+	st := contentCache.Stats()
+	fmt.Fprintf(w, "%v\n\n", contentCache)
+	fmt.Fprintf(w, "hits: %d\nmisses: %d\nfetch errors: %d\nuncacheable: %d\nevictions: %d (%d bytes)\n\n",
+		st.Hits, st.Misses, st.FetchErrors, st.Uncacheable, st.Evictions, st.EvictedBytes)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "KEY\tSIZE\tSHA-256\tHITS\tADDED\tLAST USED\n")
+	now := time.Now()
+	for _, e := range contentCache.Entries() {
+		fmt.Fprintf(tw, "%s\t%d\t%.12s\t%d\t%s ago\t%s ago\n",
+			e.Key, e.Size, e.Digest, e.Hits,
+			friendlyDuration(now.Sub(e.Added)), friendlyDuration(now.Sub(e.LastUsed)))
+	}
+	tw.Flush()
+}
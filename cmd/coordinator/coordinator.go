@@ -111,7 +111,9 @@ var (
 // LOCK ORDER:
 //   statusMu, buildStatus.mu, trySet.mu
 // (Other locks, such as the remoteBuildlet mutex should
-// not be used along with other locks)
+// not be used along with other locks, except that leases.mu
+// and audit.mu may be acquired while holding remoteBuildlets,
+// and health.mu while holding reversePool.mu)
 
 var (
 	statusMu   sync.Mutex // guards the following four structures; see LOCK ORDER comment above
@@ -278,6 +280,15 @@ func main() {
 
 	go updateInstanceRecord()
 
+	if err := audit.init(*auditDir, *auditRetention); err != nil {
+		log.Fatalf("initializing gomote audit log: %v", err)
+	}
+	go audit.pruneLoop()
+	if err := initContentCache(); err != nil {
+		log.Fatalf("initializing content cache: %v", err)
+	}
+	health.init(*healthWindow, *healthMaxFailures, *healthSlowFactor)
+
 	switch *mode {
 	case "dev", "prod":
 		log.Printf("Running in %s mode", *mode)
@@ -293,6 +304,7 @@ func main() {
 
 	http.HandleFunc("/", handleStatus)
 	http.HandleFunc("/debug/goroutines", handleDebugGoroutines)
+	http.HandleFunc("/debug/cache", handleDebugCache)
 	http.HandleFunc("/debug/watcher/", handleDebugWatcher)
 	http.HandleFunc("/builders", handleBuilders)
 	http.HandleFunc("/temporarylogs", handleLogs)
@@ -303,6 +315,13 @@ func main() {
 	http.HandleFunc("/status/reverse.json", reversePool.ServeReverseStatusJSON)
 	http.Handle("/buildlet/create", requireBuildletProxyAuth(http.HandlerFunc(handleBuildletCreate)))
 	http.Handle("/buildlet/list", requireBuildletProxyAuth(http.HandlerFunc(handleBuildletList)))
+	http.Handle("/buildlet/renew", requireBuildletProxyAuth(http.HandlerFunc(handleBuildletRenew)))
+	http.Handle("/buildlet/leases", requireBuildletProxyAuth(http.HandlerFunc(handleBuildletLeases)))
+	http.Handle("/buildlet/revoke", requireBuildletProxyAuth(http.HandlerFunc(handleBuildletRevoke)))
+	http.Handle("/audit", requireBuildletProxyAuth(http.HandlerFunc(handleAudit)))
+	http.Handle("/drain", requireBuildletProxyAuth(http.HandlerFunc(handleDrain)))
+	http.Handle("/quarantine", requireBuildletProxyAuth(http.HandlerFunc(handleQuarantine)))
+	http.Handle("/ssh-keys/flush", requireBuildletProxyAuth(http.HandlerFunc(handleSSHKeysFlush)))
 	go func() {
 		if *mode == "dev" {
 			return
@@ -1391,6 +1410,15 @@ type BuildletPool interface {
 	// and highPriorityOpt.
 	GetBuildlet(ctx context.Context, hostType string, lg logger) (*buildlet.Client, error)
 
+	// SetDraining sets whether the pool is draining hostType.
+	// While a host type is draining, GetBuildlet blocks instead
+	// of handing out buildlets of that type, and buildlets
+	// already in use are left to finish their work.
+	SetDraining(hostType string, draining bool)
+
+	// IsDraining reports whether hostType is draining.
+	IsDraining(hostType string) bool
+
 	String() string // TODO(bradfitz): more status stuff
 }
 
@@ -1451,6 +1479,28 @@ func poolForConf(conf dashboard.BuildConfig) BuildletPool {
 	}
 }
 
+// poolForHostType returns the BuildletPool that provides buildlets
+// of hostType.
+func poolForHostType(hostType string) (BuildletPool, error) {
+	hconf, ok := dashboard.Hosts[hostType]
+	if !ok {
+		return nil, fmt.Errorf("unknown host type %q", hostType)
+	}
+	switch {
+	case hconf.IsVM():
+		return gcePool, nil
+	case hconf.IsContainer():
+		if buildEnv.PreferContainersOnCOS || kubeErr != nil {
+			return gcePool, nil
+		}
+		return kubePool, nil
+	case hconf.IsReverse:
+		return reversePool, nil
+	default:
+		return nil, fmt.Errorf("no buildlet pool for host type %q", hostType)
+	}
+}
+
 func newBuild(rev buildgo.BuilderRev) (*buildStatus, error) {
 	// Note: can't acquire statusMu in newBuild, as this is called
 	// from findTryWork -> newTrySet, which holds statusMu.
@@ -1670,6 +1720,9 @@ func (st *buildStatus) build() error {
 	if inStaging {
 		err := storageClient.Bucket(buildEnv.SnapBucket).Object(st.SnapshotObjectName()).Delete(context.Background())
 		st.LogEventTime("deleted_snapshot", fmt.Sprint(err))
+		if contentCache != nil {
+			contentCache.Remove(st.snapshotCacheKey())
+		}
 	}
 	snapshotExists := st.useSnapshot()
 	sp.Done(nil)
@@ -1692,16 +1745,28 @@ func (st *buildStatus) build() error {
 	}
 	atomic.StoreInt32(&st.hasBuildlet, 1)
 	defer bc.Close()
+	defer st.removeSnapshotCache()
 	st.mu.Lock()
 	st.bc = bc
 	st.mu.Unlock()
 
 	st.LogEventTime("using_buildlet", bc.IPPort())
 
+	// For reverse buildlets, record how the build went on this
+	// machine, so misbehaving machines can be quarantined.
+	// infraErr is any failure not caused by the code under test.
+	var infraErr error
+	if hostname := reversePool.hostnameOf(bc); hostname != "" {
+		defer func() {
+			health.recordBuild(st.conf.HostType, hostname, infraErr, st.makeBashDur)
+		}()
+	}
+
 	if st.useSnapshot() {
 		sp := st.CreateSpan("write_snapshot_tar")
-		if err := bc.PutTarFromURL(st.SnapshotURL(buildEnv), "go"); err != nil {
-			return sp.Done(fmt.Errorf("failed to put snapshot to buildlet: %v", err))
+		if err := st.putTarFromURL(bc, st.snapshotCacheKey(), st.SnapshotURL(buildEnv), "go"); err != nil {
+			infraErr = fmt.Errorf("failed to put snapshot to buildlet: %v", err)
+			return sp.Done(infraErr)
 		}
 		sp.Done(nil)
 	} else {
@@ -1710,6 +1775,7 @@ func (st *buildStatus) build() error {
 		grp.Go(st.writeGoSource)
 		grp.Go(st.writeBootstrapToolchain)
 		if err := grp.Err(); err != nil {
+			infraErr = err
 			return err
 		}
 	}
@@ -1742,6 +1808,7 @@ func (st *buildStatus) build() error {
 		doneMsg = "comm error: " + err.Error()
 	}
 	if err != nil {
+		infraErr = err
 		// Return the error *before* we create the magic
 		// "done" event. (which the try coordinator looks for)
 		return err
@@ -1869,6 +1936,7 @@ func (st *buildStatus) runAllSharded() (remoteErr, err error) {
 	st.getHelpersReadySoon()
 
 	if !st.useSnapshot() {
+		t0 := time.Now()
 		remoteErr, err = st.goBuilder().RunMake(st.bc, st)
 		if err != nil {
 			return nil, err
@@ -1876,6 +1944,7 @@ func (st *buildStatus) runAllSharded() (remoteErr, err error) {
 		if remoteErr != nil {
 			return fmt.Errorf("build failed: %v", remoteErr), nil
 		}
+		st.makeBashDur = time.Since(t0)
 	}
 	if st.conf.StopAfterMake {
 		return nil, nil
@@ -2061,7 +2130,7 @@ func (st *buildStatus) writeBootstrapToolchain() error {
 	}
 	const bootstrapDir = "go1.4" // might be newer; name is the default
 	sp := st.CreateSpan("write_go_bootstrap_tar")
-	return sp.Done(st.bc.PutTarFromURL(u, bootstrapDir))
+	return sp.Done(st.putTarFromURL(st.bc, "bootstrap/"+u, u, bootstrapDir))
 }
 
 func (st *buildStatus) cleanForSnapshot(bc *buildlet.Client) error {
@@ -2093,13 +2162,39 @@ func (st *buildStatus) writeSnapshot(bc *buildlet.Client) (err error) {
 	wr := storageClient.Bucket(buildEnv.SnapBucket).Object(st.SnapshotObjectName()).NewWriter(ctx)
 	wr.ContentType = "application/octet-stream"
 	wr.ACL = append(wr.ACL, storage.ACLRule{Entity: storage.AllUsers, Role: storage.RoleReader})
-	if _, err := io.Copy(wr, tgz); err != nil {
+
+	// Keep a local copy for test helpers, if they can use it.
+	var dst io.Writer = wr
+	var cache *snapshotCache
+	if st.cacheSnapshot() {
+		cache = newSnapshotCache()
+	}
+	if cache != nil {
+		dst = io.MultiWriter(wr, cache)
+	}
+	if _, err := io.Copy(dst, tgz); err != nil {
 		st.logf("failed to write snapshot to GCS: %v", err)
 		wr.CloseWithError(err)
+		if cache != nil {
+			cache.remove()
+		}
 		return err
 	}
-
-	return wr.Close()
+	if err := wr.Close(); err != nil {
+		if cache != nil {
+			cache.remove()
+		}
+		return err
+	}
+	if cache != nil {
+		if err := cache.finish(); err != nil {
+			log.Printf("not caching snapshot for test helpers: %v", err)
+			cache.remove()
+		} else {
+			st.setSnapshotCache(cache)
+		}
+	}
+	return nil
 }
 
 // reportErr reports an error to Stackdriver.
@@ -2785,7 +2880,7 @@ func (st *buildStatus) runTests(helpers <-chan *buildlet.Client) (remoteErr, err
 					defer st.LogEventTime("DEV_HELPER_SLEEP", bc.Name())
 				}
 				st.LogEventTime("got_empty_test_helper", bc.String())
-				if err := bc.PutTarFromURL(st.SnapshotURL(buildEnv), "go"); err != nil {
+				if err := st.putSnapshotOnHelper(bc); err != nil {
 					log.Printf("failed to extract snapshot for helper %s: %v", bc.Name(), err)
 					return
 				}
@@ -3244,7 +3339,8 @@ type buildStatus struct {
 
 	hasBuildlet int32 // atomic: non-zero if this build has a buildlet; for status.go.
 
-	hasBenchResults bool // set by runTests, may only be used when build() returns.
+	hasBenchResults bool          // set by runTests, may only be used when build() returns.
+	makeBashDur     time.Duration // set by runAllSharded if it ran make.bash successfully
 
 	mu              sync.Mutex       // guards following
 	failURL         string           // if non-empty, permanent URL of failure
@@ -3254,7 +3350,8 @@ type buildStatus struct {
 	output          livelog.Buffer   // stdout and stderr
 	startedPinging  bool             // started pinging the go dashboard
 	events          []eventAndTime
-	useSnapshotMemo *bool // if non-nil, memoized result of useSnapshot
+	useSnapshotMemo *bool          // if non-nil, memoized result of useSnapshot
+	snapCache       *snapshotCache // if non-nil, local copy of the snapshot for test helpers
 }
 
 func (st *buildStatus) setDone(succeeded bool) {
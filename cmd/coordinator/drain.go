@@ -0,0 +1,194 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to draining host types and reverse buildlets: taking
+// them out of rotation without killing the builds already using them.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// drainSet is the set of host types a BuildletPool has been asked to
+// drain. While a host type is draining, the pool's GetBuildlet
+// blocks rather than handing out new buildlets of that type, and
+// buildlets already handed out are left to finish their work.
+//
+// Its exported methods implement the draining part of BuildletPool.
+type drainSet struct {
+	mu      sync.Mutex
+	since   map[string]time.Time // hostType => when draining started
+	changed chan struct{}        // closed when since changes; or nil if nobody's waiting
+}
+
+// SetDraining sets whether hostType is draining.
+func (d *drainSet) SetDraining(hostType string, draining bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.since[hostType]; ok == draining {
+		return
+	}
+	if draining {
+		if d.since == nil {
+			d.since = make(map[string]time.Time)
+		}
+		d.since[hostType] = time.Now()
+	} else {
+		delete(d.since, hostType)
+	}
+	if d.changed != nil {
+		close(d.changed)
+		d.changed = nil
+	}
+}
+
+// IsDraining reports whether hostType is draining.
+func (d *drainSet) IsDraining(hostType string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.since[hostType]
+	return ok
+}
+
+// draining returns when each draining host type started draining.
+func (d *drainSet) draining() map[string]time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m := make(map[string]time.Time, len(d.since))
+	for hostType, t := range d.since {
+		m[hostType] = t
+	}
+	return m
+}
+
+// awaitNotDraining blocks until hostType isn't draining or ctx is done.
+func (d *drainSet) awaitNotDraining(ctx context.Context, hostType string, lg logger) error {
+	if !d.IsDraining(hostType) {
+		return nil
+	}
+	sp := lg.CreateSpan("wait_host_type_drain", hostType)
+	for {
+		d.mu.Lock()
+		if _, ok := d.since[hostType]; !ok {
+			d.mu.Unlock()
+			return sp.Done(nil)
+		}
+		if d.changed == nil {
+			d.changed = make(chan struct{})
+		}
+		changed := d.changed
+		d.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return sp.Done(ctx.Err())
+		}
+	}
+}
+
+// writeHTMLStatus writes the draining host types, if any, as part
+// of a pool's HTML status.
+func (d *drainSet) writeHTMLStatus(w io.Writer) {
+	since := d.draining()
+	if len(since) == 0 {
+		return
+	}
+	var hostTypes []string
+	for hostType := range since {
+		hostTypes = append(hostTypes, hostType)
+	}
+	sort.Strings(hostTypes)
+	io.WriteString(w, "<br>draining:")
+	for _, hostType := range hostTypes {
+		fmt.Fprintf(w, " %s (for %s)", html.EscapeString(hostType), friendlyDuration(time.Since(since[hostType])))
+	}
+}
+
+// drainStatus is the JSON response of the /drain handler.
+type drainStatus struct {
+	HostTypes map[string]time.Time // draining host type => when draining started
+	Hosts     []string             // draining reverse buildlet hostnames
+}
+
+// handleDrain reports (for GET) or changes (for POST) which host
+// types and reverse buildlet hosts are draining. A POST takes either
+// a "hostType" or a reverse buildlet "host" parameter, and an
+// optional "undrain" boolean to put it back in rotation.
+//
+// always wrapped in requireBuildletProxyAuth.
+func handleDrain(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	if !isGomoteAdmin(user) {
+		http.Error(w, "gomote admin required", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case "GET":
+	case "POST":
+		draining := true
+		if v := r.FormValue("undrain"); v != "" {
+			undrain, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "bad undrain value", http.StatusBadRequest)
+				return
+			}
+			draining = !undrain
+		}
+		hostType, host := r.FormValue("hostType"), r.FormValue("host")
+		switch {
+		case hostType != "" && host == "":
+			pool, err := poolForHostType(hostType)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			pool.SetDraining(hostType, draining)
+		case host != "" && hostType == "":
+			reversePool.SetHostDraining(host, draining)
+		default:
+			http.Error(w, "exactly one of hostType or host required", http.StatusBadRequest)
+			return
+		}
+		audit.record(auditEvent{User: user, Action: drainAction(draining), Detail: hostType + host})
+	default:
+		http.Error(w, "GET or POST required", http.StatusBadRequest)
+		return
+	}
+
+	res := drainStatus{HostTypes: make(map[string]time.Time)}
+	for _, pool := range []interface {
+		draining() map[string]time.Time
+	}{gcePool, kubePool, reversePool} {
+		for hostType, t := range pool.draining() {
+			res.HostTypes[hostType] = t
+		}
+	}
+	res.Hosts = reversePool.drainingHosts()
+	jenc, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	jenc = append(jenc, '\n')
+	w.Write(jenc)
+}
+
+func drainAction(draining bool) string {
+	if draining {
+		return "drain"
+	}
+	return "undrain"
+}
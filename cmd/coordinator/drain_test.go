@@ -0,0 +1,85 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/build/buildlet"
+)
+
+func TestDrainSet(t *testing.T) {
+	var d drainSet
+	lg := loggerFunc(func(event string, optText ...string) {})
+	ctx := context.Background()
+
+	if err := d.awaitNotDraining(ctx, "host-a", lg); err != nil {
+		t.Fatalf("awaitNotDraining on undrained host type = %v", err)
+	}
+
+	d.SetDraining("host-a", true)
+	if !d.IsDraining("host-a") || d.IsDraining("host-b") {
+		t.Fatalf("IsDraining = %v, %v; want true, false", d.IsDraining("host-a"), d.IsDraining("host-b"))
+	}
+
+	// Canceled waiters give up.
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := d.awaitNotDraining(cctx, "host-a", lg); err != context.DeadlineExceeded {
+		t.Errorf("awaitNotDraining while draining = %v; want deadline exceeded", err)
+	}
+
+	// Other waiters are released when draining ends.
+	done := make(chan error, 1)
+	go func() { done <- d.awaitNotDraining(ctx, "host-a", lg) }()
+	select {
+	case err := <-done:
+		t.Fatalf("awaitNotDraining returned %v while still draining", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	d.SetDraining("host-a", false)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("awaitNotDraining after undrain = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("awaitNotDraining didn't return after undrain")
+	}
+	if len(d.draining()) != 0 {
+		t.Errorf("draining() = %v; want empty", d.draining())
+	}
+}
+
+func TestReverseHostDraining(t *testing.T) {
+	p := new(reverseBuildletPool)
+	p.SetHostDraining("mac-1", true)
+	b := &reverseBuildlet{
+		hostname: "mac-1",
+		hostType: "host-darwin-10_12",
+		client:   buildlet.NewClient("mac-1", buildlet.NoKeyPair),
+	}
+	p.addBuildlet(b)
+	if !b.draining {
+		t.Fatal("reconnected buildlet of drained host isn't draining")
+	}
+	if bc, _ := p.tryToGrab("host-darwin-10_12"); bc != nil {
+		t.Fatal("grabbed a draining buildlet")
+	}
+	st := p.buildReverseStatusJSON()
+	if m := st.HostTypes["host-darwin-10_12"].Machines["mac-1"]; m == nil || !m.Draining {
+		t.Errorf("status of mac-1 = %+v; want draining", m)
+	}
+	if got := p.drainingHosts(); len(got) != 1 || got[0] != "mac-1" {
+		t.Errorf("drainingHosts = %q; want [mac-1]", got)
+	}
+
+	p.SetHostDraining("mac-1", false)
+	if b.draining {
+		t.Error("buildlet still draining after undrain")
+	}
+}
@@ -221,6 +221,8 @@ var _ BuildletPool = (*gceBuildletPool)(nil)
 const maxInstances = 190
 
 type gceBuildletPool struct {
+	drainSet
+
 	mu sync.Mutex // guards all following
 
 	disabled bool
@@ -283,6 +285,9 @@ func (p *gceBuildletPool) GetBuildlet(ctx context.Context, hostType string, lg l
 	if !ok {
 		return nil, fmt.Errorf("gcepool: unknown host type %q", hostType)
 	}
+	if err := p.awaitNotDraining(ctx, hostType, lg); err != nil {
+		return nil, err
+	}
 	qsp := lg.CreateSpan("awaiting_gce_quota")
 	err = p.awaitVMCountQuota(ctx, hconf.GCENumCPU())
 	qsp.Done(err)
@@ -367,6 +372,7 @@ func (p *gceBuildletPool) putBuildlet(bc *buildlet.Client, hostType, instName st
 
 func (p *gceBuildletPool) WriteHTMLStatus(w io.Writer) {
 	fmt.Fprintf(w, "<b>GCE pool</b> capacity: %s", p.capacityString())
+	p.drainSet.writeHTMLStatus(w)
 	const show = 6 // must be even
 	active := p.instancesActive()
 	if len(active) > 0 {
@@ -0,0 +1,396 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to tracking the health of reverse buildlet hosts and
+// quarantining the ones that misbehave, so one broken machine
+// doesn't keep failing builds that would pass elsewhere.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/build/buildlet"
+)
+
+var (
+	healthWindow      = flag.Int("health_window", 10, "Number of most recent builds per reverse buildlet host considered when deciding whether to quarantine it.")
+	healthMaxFailures = flag.Int("health_max_failures", 3, "Quarantine a reverse buildlet host after this many infrastructure failures or timeouts within its most recent -health_window builds. Zero disables.")
+	healthSlowFactor  = flag.Float64("health_slow_factor", 2, "Quarantine a reverse buildlet host whose average make.bash time is this many times the median of the other hosts of its host type. Zero disables.")
+)
+
+// health tracks the reverse buildlet hosts. It's configured by
+// main from flags.
+var health = new(healthTracker)
+
+// minMakeBashSamples is how many make.bash timings a host needs
+// before it's compared with its peers, and how many each peer needs
+// to count.
+const minMakeBashSamples = 3
+
+// buildOutcome is how a build went, from the point of view of the
+// machine it ran on.
+type buildOutcome int
+
+const (
+	outcomeOK      buildOutcome = iota // passed, or failed because of the code under test
+	outcomeInfra                       // failed talking to or setting up the buildlet
+	outcomeTimeout                     // timed out
+)
+
+// healthTracker records per-machine build outcomes for reverse
+// buildlet hosts and decides which hosts to quarantine. A quarantined
+// host stays connected but isn't given work until an operator
+// un-quarantines it.
+//
+// Its mutex may be acquired while holding reverseBuildletPool.mu,
+// so it must not call into the reverse pool itself.
+type healthTracker struct {
+	window      int     // number of recent builds to consider
+	maxFailures int     // bad builds in window that trigger quarantine; 0 to disable
+	slowFactor  float64 // make.bash slowness relative to peers that triggers quarantine; 0 to disable
+
+	mu       sync.Mutex
+	machines map[string]*machineHealth // hostname => health
+}
+
+// machineHealth is what the healthTracker knows about one host.
+type machineHealth struct {
+	hostname string
+	hostType string
+
+	recent   []buildOutcome  // most recent last, at most window long
+	makeBash []time.Duration // recent successful make.bash times, at most window long
+
+	quarantined time.Time // zero if not quarantined
+	reason      string    // why it's quarantined
+}
+
+func (h *healthTracker) init(window, maxFailures int, slowFactor float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.window = window
+	h.maxFailures = maxFailures
+	h.slowFactor = slowFactor
+}
+
+func (h *healthTracker) machineLocked(hostType, hostname string) *machineHealth {
+	if h.machines == nil {
+		h.machines = make(map[string]*machineHealth)
+	}
+	m, ok := h.machines[hostname]
+	if !ok {
+		m = &machineHealth{hostname: hostname}
+		h.machines[hostname] = m
+	}
+	if hostType != "" {
+		m.hostType = hostType
+	}
+	return m
+}
+
+// classifyBuildErr returns the outcome of a build that ended with
+// infraErr, an error not caused by the code under test.
+func classifyBuildErr(infraErr error) buildOutcome {
+	switch {
+	case infraErr == nil:
+		return outcomeOK
+	case strings.Contains(infraErr.Error(), buildlet.ErrTimeout.Error()),
+		strings.Contains(infraErr.Error(), "timeout"),
+		strings.Contains(infraErr.Error(), "deadline exceeded"):
+		return outcomeTimeout
+	default:
+		return outcomeInfra
+	}
+}
+
+// recordBuild records a build on hostname, which failed with
+// infraErr if non-nil. If make.bash ran successfully, makeBash is
+// how long it took; otherwise it's zero.
+func (h *healthTracker) recordBuild(hostType, hostname string, infraErr error, makeBash time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m := h.machineLocked(hostType, hostname)
+	m.recent = appendWindow(m.recent, classifyBuildErr(infraErr), h.window)
+	if makeBash > 0 {
+		m.makeBash = append(m.makeBash, makeBash)
+		if h.window > 0 && len(m.makeBash) > h.window {
+			m.makeBash = m.makeBash[len(m.makeBash)-h.window:]
+		}
+	}
+	h.evaluateLocked(m)
+}
+
+// recordHealthCheckFailure records that hostname failed a health
+// check with err, which counts as a failed build.
+func (h *healthTracker) recordHealthCheckFailure(hostType, hostname string, err error) {
+	h.recordBuild(hostType, hostname, fmt.Errorf("health check: %v", err), 0)
+}
+
+func appendWindow(s []buildOutcome, o buildOutcome, window int) []buildOutcome {
+	s = append(s, o)
+	if window > 0 && len(s) > window {
+		s = s[len(s)-window:]
+	}
+	return s
+}
+
+// evaluateLocked quarantines m if it's misbehaving, unless that would
+// leave too few healthy hosts of its type.
+func (h *healthTracker) evaluateLocked(m *machineHealth) {
+	if !m.quarantined.IsZero() {
+		return
+	}
+	var reason string
+	var infra, timeouts int
+	for _, o := range m.recent {
+		switch o {
+		case outcomeInfra:
+			infra++
+		case outcomeTimeout:
+			timeouts++
+		}
+	}
+	if h.maxFailures > 0 && infra+timeouts >= h.maxFailures {
+		reason = fmt.Sprintf("%d infrastructure failures and %d timeouts in its last %d builds", infra, timeouts, len(m.recent))
+	} else if slow, median := h.slowLocked(m); slow > 0 {
+		reason = fmt.Sprintf("make.bash averages %v, %.1fx the host type's median of %v",
+			slow.Round(time.Second), float64(slow)/float64(median), median.Round(time.Second))
+	}
+	if reason == "" {
+		return
+	}
+
+	// Only quarantine outliers: never more than half of a
+	// host type's machines. If they're all failing, the problem
+	// is probably elsewhere.
+	var total, quarantined int
+	for _, o := range h.machines {
+		if o.hostType == m.hostType {
+			total++
+			if !o.quarantined.IsZero() {
+				quarantined++
+			}
+		}
+	}
+	if quarantined+1 > total/2 {
+		log.Printf("health: not quarantining %s (%s): %s; already %d of %d hosts quarantined",
+			m.hostname, m.hostType, reason, quarantined, total)
+		return
+	}
+	log.Printf("health: quarantining %s (%s): %s", m.hostname, m.hostType, reason)
+	m.quarantined = time.Now()
+	m.reason = reason
+}
+
+// slowLocked returns m's average make.bash time and its host type's
+// median if m is slower than the median by more than slowFactor.
+// Otherwise it returns zeros.
+func (h *healthTracker) slowLocked(m *machineHealth) (avg, median time.Duration) {
+	if h.slowFactor <= 0 || len(m.makeBash) < minMakeBashSamples {
+		return 0, 0
+	}
+	var peers []time.Duration
+	for _, o := range h.machines {
+		if o != m && o.hostType == m.hostType && len(o.makeBash) >= minMakeBashSamples {
+			peers = append(peers, averageDuration(o.makeBash))
+		}
+	}
+	if len(peers) < 2 {
+		return 0, 0
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i] < peers[j] })
+	median = peers[len(peers)/2]
+	avg = averageDuration(m.makeBash)
+	if float64(avg) <= h.slowFactor*float64(median) {
+		return 0, 0
+	}
+	return avg, median
+}
+
+func averageDuration(ds []time.Duration) time.Duration {
+	var sum time.Duration
+	for _, d := range ds {
+		sum += d
+	}
+	return sum / time.Duration(len(ds))
+}
+
+// isQuarantined reports whether hostname is quarantined.
+func (h *healthTracker) isQuarantined(hostname string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	m, ok := h.machines[hostname]
+	return ok && !m.quarantined.IsZero()
+}
+
+// quarantineReason returns why hostname is quarantined, or the empty
+// string if it isn't.
+func (h *healthTracker) quarantineReason(hostname string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if m, ok := h.machines[hostname]; ok && !m.quarantined.IsZero() {
+		return m.reason
+	}
+	return ""
+}
+
+// setQuarantined quarantines or un-quarantines hostname on behalf of
+// an operator. Un-quarantining forgets the host's history, giving it
+// a fresh start.
+func (h *healthTracker) setQuarantined(hostname string, quarantined bool, reason string) (hostType string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if quarantined {
+		m := h.machineLocked("", hostname)
+		if m.quarantined.IsZero() {
+			m.quarantined = time.Now()
+		}
+		m.reason = reason
+		return m.hostType, nil
+	}
+	m, ok := h.machines[hostname]
+	if !ok || m.quarantined.IsZero() {
+		return "", fmt.Errorf("host %q isn't quarantined", hostname)
+	}
+	m.quarantined = time.Time{}
+	m.reason = ""
+	m.recent = nil
+	m.makeBash = nil
+	return m.hostType, nil
+}
+
+// machineHealthStatus is the JSON form of a machineHealth.
+type machineHealthStatus struct {
+	Hostname         string
+	HostType         string
+	Builds           int       // number of recent builds considered
+	InfraFailures    int       // of Builds
+	Timeouts         int       // of Builds
+	MakeBashAvg      float64   `json:",omitempty"` // seconds
+	Quarantined      time.Time `json:",omitempty"`
+	QuarantineReason string    `json:",omitempty"`
+}
+
+// status returns the health of every known host, sorted by host type
+// and hostname. If quarantinedOnly, only quarantined hosts are
+// included.
+func (h *healthTracker) status(quarantinedOnly bool) []machineHealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	res := []machineHealthStatus{}
+	for _, m := range h.machines {
+		if quarantinedOnly && m.quarantined.IsZero() {
+			continue
+		}
+		st := machineHealthStatus{
+			Hostname:         m.hostname,
+			HostType:         m.hostType,
+			Builds:           len(m.recent),
+			Quarantined:      m.quarantined,
+			QuarantineReason: m.reason,
+		}
+		for _, o := range m.recent {
+			switch o {
+			case outcomeInfra:
+				st.InfraFailures++
+			case outcomeTimeout:
+				st.Timeouts++
+			}
+		}
+		if len(m.makeBash) > 0 {
+			st.MakeBashAvg = averageDuration(m.makeBash).Seconds()
+		}
+		res = append(res, st)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].HostType != res[j].HostType {
+			return res[i].HostType < res[j].HostType
+		}
+		return res[i].Hostname < res[j].Hostname
+	})
+	return res
+}
+
+// writeHTMLStatus writes the quarantined hosts, if any, for the
+// status page.
+func (h *healthTracker) writeHTMLStatus(w io.Writer) {
+	q := h.status(true)
+	if len(q) == 0 {
+		return
+	}
+	io.WriteString(w, "<b>Quarantined reverse hosts</b><ul>")
+	for _, st := range q {
+		fmt.Fprintf(w, "<li>%s (%s), for %s: %s</li>\n",
+			html.EscapeString(st.Hostname),
+			html.EscapeString(st.HostType),
+			friendlyDuration(time.Since(st.Quarantined)),
+			html.EscapeString(st.QuarantineReason))
+	}
+	io.WriteString(w, "</ul>")
+}
+
+// handleQuarantine reports (for GET) the health of the reverse
+// buildlet hosts, or (for POST) quarantines or un-quarantines the
+// host in the "host" parameter according to the "quarantine" boolean.
+//
+// always wrapped in requireBuildletProxyAuth.
+func handleQuarantine(w http.ResponseWriter, r *http.Request) {
+	user, _, _ := r.BasicAuth()
+	if !isGomoteAdmin(user) {
+		http.Error(w, "gomote admin required", http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case "GET":
+	case "POST":
+		host := r.FormValue("host")
+		if host == "" {
+			http.Error(w, "missing host", http.StatusBadRequest)
+			return
+		}
+		quarantine, err := strconv.ParseBool(r.FormValue("quarantine"))
+		if err != nil {
+			http.Error(w, "bad or missing quarantine value", http.StatusBadRequest)
+			return
+		}
+		hostType, err := health.setQuarantined(host, quarantine, "quarantined by "+user)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		action := "quarantine"
+		if !quarantine {
+			action = "unquarantine"
+			if hostType != "" {
+				reversePool.noteBuildletAvailable(hostType)
+			}
+		}
+		log.Printf("health: %s of %s by %s", action, host, user)
+		audit.record(auditEvent{User: user, Action: action, Detail: host})
+	default:
+		http.Error(w, "GET or POST required", http.StatusBadRequest)
+		return
+	}
+
+	jenc, err := json.MarshalIndent(health.status(false), "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	jenc = append(jenc, '\n')
+	w.Write(jenc)
+}
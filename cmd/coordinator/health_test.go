@@ -0,0 +1,94 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/build/buildlet"
+)
+
+func TestClassifyBuildErr(t *testing.T) {
+	tests := []struct {
+		err  error
+		want buildOutcome
+	}{
+		{nil, outcomeOK},
+		{errors.New("failed to put snapshot to buildlet: EOF"), outcomeInfra},
+		{fmt.Errorf("runTests: %v", buildlet.ErrTimeout), outcomeTimeout},
+		{errors.New("health check: health check timeout"), outcomeTimeout},
+	}
+	for _, tt := range tests {
+		if got := classifyBuildErr(tt.err); got != tt.want {
+			t.Errorf("classifyBuildErr(%v) = %v; want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestHealthQuarantinesFailingOutlier(t *testing.T) {
+	h := &healthTracker{window: 10, maxFailures: 3}
+	const typ = "host-linux-arm5spacemonkey"
+	for _, host := range []string{"arm-1", "arm-2", "arm-3", "arm-4"} {
+		h.recordBuild(typ, host, nil, 0)
+	}
+	infra := errors.New("failed to put snapshot to buildlet: connection reset")
+	h.recordBuild(typ, "arm-1", infra, 0)
+	h.recordBuild(typ, "arm-1", infra, 0)
+	if h.isQuarantined("arm-1") {
+		t.Fatal("quarantined after 2 failures; want 3")
+	}
+	h.recordBuild(typ, "arm-1", fmt.Errorf("exec: %v", buildlet.ErrTimeout), 0)
+	if !h.isQuarantined("arm-1") {
+		t.Fatal("not quarantined after 3 failures")
+	}
+	if got := h.quarantineReason("arm-1"); got != "2 infrastructure failures and 1 timeouts in its last 4 builds" {
+		t.Errorf("reason = %q", got)
+	}
+
+	// Never more than half of a host type.
+	for i := 0; i < 3; i++ {
+		h.recordBuild(typ, "arm-2", infra, 0)
+		h.recordBuild(typ, "arm-3", infra, 0)
+	}
+	if h.isQuarantined("arm-2") && h.isQuarantined("arm-3") {
+		t.Error("quarantined 3 of 4 hosts")
+	}
+	if n := len(h.status(true)); n != 2 {
+		t.Errorf("%d hosts quarantined; want 2", n)
+	}
+
+	// Un-quarantining gives a fresh start.
+	if _, err := h.setQuarantined("arm-1", false, ""); err != nil {
+		t.Fatal(err)
+	}
+	h.recordBuild(typ, "arm-1", infra, 0)
+	if h.isQuarantined("arm-1") {
+		t.Error("re-quarantined right after un-quarantine")
+	}
+	if _, err := h.setQuarantined("arm-4", false, ""); err == nil {
+		t.Error("un-quarantining a healthy host succeeded")
+	}
+}
+
+func TestHealthQuarantinesSlowOutlier(t *testing.T) {
+	h := &healthTracker{window: 10, slowFactor: 2}
+	const typ = "host-darwin-10_12"
+	for i := 0; i < minMakeBashSamples; i++ {
+		h.recordBuild(typ, "mac-1", nil, 100*time.Second)
+		h.recordBuild(typ, "mac-2", nil, 110*time.Second)
+		h.recordBuild(typ, "mac-3", nil, 120*time.Second)
+		h.recordBuild(typ, "mac-4", nil, 300*time.Second)
+		h.recordBuild(typ, "mac-5", nil, 90*time.Second)
+	}
+	for _, st := range h.status(false) {
+		want := st.Hostname == "mac-4"
+		if got := !st.Quarantined.IsZero(); got != want {
+			t.Errorf("%s quarantined = %v; want %v (reason %q)", st.Hostname, got, want, st.QuarantineReason)
+		}
+	}
+}
@@ -82,6 +82,8 @@ func initKube() error {
 
 // kubeBuildletPool is the Kubernetes buildlet pool.
 type kubeBuildletPool struct {
+	drainSet
+
 	mu sync.Mutex // guards all following
 
 	pods             map[string]podHistory // pod instance name -> podHistory
@@ -213,6 +215,9 @@ func (p *kubeBuildletPool) GetBuildlet(ctx context.Context, hostType string, lg
 	if kubeErr != nil {
 		return nil, kubeErr
 	}
+	if err := p.awaitNotDraining(ctx, hostType, lg); err != nil {
+		return nil, err
+	}
 	if buildletsKubeClient == nil {
 		panic("expect non-nil buildletsKubeClient")
 	}
@@ -281,6 +286,7 @@ func (p *kubeBuildletPool) GetBuildlet(ctx context.Context, hostType string, lg
 
 func (p *kubeBuildletPool) WriteHTMLStatus(w io.Writer) {
 	fmt.Fprintf(w, "<b>Kubernetes pool</b> capacity: %s", p.capacityString())
+	p.drainSet.writeHTMLStatus(w)
 	const show = 6 // must be even
 	active := p.podsActive()
 	if len(active) > 0 {
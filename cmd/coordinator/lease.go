@@ -0,0 +1,274 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to gomote leases: how long remote buildlets live,
+// how many each user and host type may hold, and the queue of
+// gomote create requests waiting for a scarce host type.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/build/dashboard"
+)
+
+var (
+	gomoteUserQuota = flag.Int("gomote_user_quota", 10, "Maximum number of gomote buildlets a single user may hold or be waiting for at once. Zero means unlimited.")
+	gomoteAdmins    = flag.String("gomote_admins", "adg,bradfitz", "Comma-separated list of gomote usernames (without the \"user-\" prefix) allowed to list and revoke everybody's gomote leases.")
+)
+
+// remoteBuildletMaxLease is the longest lease a gomote user may
+// request, either at creation time or when renewing.
+const remoteBuildletMaxLease = 24 * time.Hour
+
+// errUserQuota is returned by leaseQueue.acquire when the user
+// already holds (or is waiting for) their quota of buildlets.
+var errUserQuota = errors.New("gomote user quota exceeded; destroy some buildlets first")
+
+// parseLease parses the optional "lease" form value sent by gomote
+// clients. An empty string means the default lease.
+func parseLease(s string) (time.Duration, error) {
+	if s == "" {
+		return remoteBuildletIdleTimeout, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lease duration %q: %v", s, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid lease duration %q: must be positive", s)
+	}
+	if d > remoteBuildletMaxLease {
+		return 0, fmt.Errorf("lease duration %v exceeds maximum of %v", d, remoteBuildletMaxLease)
+	}
+	return d, nil
+}
+
+// isGomoteAdmin reports whether the gomote user (of the form
+// "user-foo") may manage other users' leases.
+func isGomoteAdmin(user string) bool {
+	name := strings.TrimPrefix(user, "user-")
+	if name == user || name == "" {
+		return false
+	}
+	for _, admin := range strings.Split(*gomoteAdmins, ",") {
+		if strings.TrimSpace(admin) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hostTypeQuota returns the maximum number of gomote buildlets of
+// hostType that may be leased at once, across all users. Zero means
+// unlimited.
+//
+// Only reverse host types are limited, and only to half of the
+// expected machines, so regular builds and trybots can still make
+// progress while people debug on them.
+func hostTypeQuota(hostType string) int {
+	hc, ok := dashboard.Hosts[hostType]
+	if !ok || !hc.IsReverse || hc.ExpectNum == 0 {
+		return 0
+	}
+	if n := hc.ExpectNum / 2; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// leases is the coordinator's gomote lease accounting.
+var leases = newLeaseQueue(hostTypeQuota)
+
+// leaseQueue tracks the number of gomote buildlets held (or being
+// created) per user and per host type, and the FIFO of create
+// requests waiting for a host type to drop below its quota.
+type leaseQueue struct {
+	quota func(hostType string) int
+
+	mu      sync.Mutex
+	users   map[string]int            // user => buildlets held or waiting
+	held    map[string]int            // hostType => buildlets held
+	waiting map[string][]*leaseWaiter // hostType => FIFO of waiters
+}
+
+// leaseWaiter is a gomote create request waiting in a leaseQueue.
+type leaseWaiter struct {
+	User        string
+	HostType    string
+	BuilderType string
+	Since       time.Time
+	Position    int // 1-based place in line; only set by leaseQueue.waiters
+
+	admitted bool          // guarded by leaseQueue.mu
+	wake     chan struct{} // buffered; poked when the queue changes
+}
+
+func newLeaseQueue(quota func(hostType string) int) *leaseQueue {
+	return &leaseQueue{
+		quota:   quota,
+		users:   make(map[string]int),
+		held:    make(map[string]int),
+		waiting: make(map[string][]*leaseWaiter),
+	}
+}
+
+// acquire blocks until user may create a buildlet of hostType or
+// until ctx is done. While it waits behind other requests, position
+// is called with the request's 1-based place in line each time it
+// changes.
+//
+// On success, the caller must eventually call release with the same
+// user and hostType.
+func (q *leaseQueue) acquire(ctx context.Context, user, hostType, builderType string, position func(int)) error {
+	q.mu.Lock()
+	if max := *gomoteUserQuota; max > 0 && q.users[user] >= max {
+		q.mu.Unlock()
+		return errUserQuota
+	}
+	q.users[user]++
+	w := &leaseWaiter{
+		User:        user,
+		HostType:    hostType,
+		BuilderType: builderType,
+		Since:       time.Now(),
+		wake:        make(chan struct{}, 1),
+	}
+	q.waiting[hostType] = append(q.waiting[hostType], w)
+	q.admitLocked(hostType)
+	q.mu.Unlock()
+
+	lastPos := 0
+	for {
+		q.mu.Lock()
+		if w.admitted {
+			q.mu.Unlock()
+			return nil
+		}
+		pos := q.positionLocked(w)
+		q.mu.Unlock()
+		if pos != lastPos && position != nil {
+			position(pos)
+		}
+		lastPos = pos
+
+		select {
+		case <-w.wake:
+		case <-ctx.Done():
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			if w.admitted {
+				// Lost the race; give the slot to the next in line.
+				q.releaseLocked(user, hostType)
+			} else {
+				if q.users[user]--; q.users[user] <= 0 {
+					delete(q.users, user)
+				}
+				q.removeLocked(w)
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// release returns a slot previously obtained from acquire.
+func (q *leaseQueue) release(user, hostType string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked(user, hostType)
+}
+
+func (q *leaseQueue) releaseLocked(user, hostType string) {
+	if q.users[user]--; q.users[user] <= 0 {
+		delete(q.users, user)
+	}
+	if q.held[hostType]--; q.held[hostType] <= 0 {
+		delete(q.held, hostType)
+	}
+	q.admitLocked(hostType)
+}
+
+// admitLocked lets waiters at the head of hostType's queue through
+// while the host type is under its quota, and tells the rest that
+// their position may have changed.
+func (q *leaseQueue) admitLocked(hostType string) {
+	max := q.quota(hostType)
+	for len(q.waiting[hostType]) > 0 && (max == 0 || q.held[hostType] < max) {
+		w := q.waiting[hostType][0]
+		q.waiting[hostType] = q.waiting[hostType][1:]
+		w.admitted = true
+		q.held[hostType]++
+		w.poke()
+	}
+	for _, w := range q.waiting[hostType] {
+		w.poke()
+	}
+	if len(q.waiting[hostType]) == 0 {
+		delete(q.waiting, hostType)
+	}
+}
+
+func (q *leaseQueue) removeLocked(w *leaseWaiter) {
+	ws := q.waiting[w.HostType]
+	for i, w2 := range ws {
+		if w2 == w {
+			q.waiting[w.HostType] = append(ws[:i:i], ws[i+1:]...)
+			break
+		}
+	}
+	q.admitLocked(w.HostType)
+}
+
+func (q *leaseQueue) positionLocked(w *leaseWaiter) int {
+	for i, w2 := range q.waiting[w.HostType] {
+		if w2 == w {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// waiters returns a snapshot of all queued requests, grouped by host
+// type and in queue order.
+func (q *leaseQueue) waiters() []leaseWaiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var ret []leaseWaiter
+	for _, hostType := range sortedHostTypes(q.waiting) {
+		for i, w := range q.waiting[hostType] {
+			ret = append(ret, leaseWaiter{
+				User:        w.User,
+				HostType:    w.HostType,
+				BuilderType: w.BuilderType,
+				Since:       w.Since,
+				Position:    i + 1,
+			})
+		}
+	}
+	return ret
+}
+
+func (w *leaseWaiter) poke() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func sortedHostTypes(m map[string][]*leaseWaiter) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLease(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", remoteBuildletIdleTimeout, false},
+		{"2h", 2 * time.Hour, false},
+		{"0s", 0, true},
+		{"-1h", 0, true},
+		{"forever", 0, true},
+		{"48h", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseLease(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLease(%q) error = %v; want error: %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseLease(%q) = %v; want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsGomoteAdmin(t *testing.T) {
+	defer func(old string) { *gomoteAdmins = old }(*gomoteAdmins)
+	*gomoteAdmins = "alice, bob"
+	for user, want := range map[string]bool{
+		"user-alice": true,
+		"user-bob":   true,
+		"user-eve":   false,
+		"alice":      false,
+		"user-":      false,
+	} {
+		if got := isGomoteAdmin(user); got != want {
+			t.Errorf("isGomoteAdmin(%q) = %v; want %v", user, got, want)
+		}
+	}
+}
+
+func TestLeaseQueue(t *testing.T) {
+	q := newLeaseQueue(func(hostType string) int {
+		if hostType == "host-scarce" {
+			return 1
+		}
+		return 0
+	})
+	ctx := context.Background()
+
+	if err := q.acquire(ctx, "user-a", "host-plenty", "b", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.acquire(ctx, "user-a", "host-scarce", "b", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	positions := make(chan int, 10)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- q.acquire(ctx, "user-b", "host-scarce", "b", func(pos int) { positions <- pos })
+	}()
+	if pos := <-positions; pos != 1 {
+		t.Fatalf("position = %d; want 1", pos)
+	}
+	if ws := q.waiters(); len(ws) != 1 || ws[0].User != "user-b" || ws[0].Position != 1 {
+		t.Fatalf("waiters = %+v; want user-b at position 1", ws)
+	}
+
+	// A waiter that gives up must leave the queue.
+	cctx, cancel := context.WithCancel(ctx)
+	cerrc := make(chan error, 1)
+	go func() {
+		cerrc <- q.acquire(cctx, "user-c", "host-scarce", "b", nil)
+	}()
+	for len(q.waiters()) != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-cerrc; err != context.Canceled {
+		t.Fatalf("canceled acquire = %v; want context.Canceled", err)
+	}
+
+	q.release("user-a", "host-scarce")
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for queued acquire")
+	}
+	if ws := q.waiters(); len(ws) != 0 {
+		t.Errorf("waiters = %+v; want none", ws)
+	}
+	if _, ok := q.users["user-c"]; ok {
+		t.Errorf("canceled user-c still counted against quota")
+	}
+}
+
+func TestLeaseQueueUserQuota(t *testing.T) {
+	defer func(old int) { *gomoteUserQuota = old }(*gomoteUserQuota)
+	*gomoteUserQuota = 2
+	q := newLeaseQueue(func(string) int { return 0 })
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := q.acquire(ctx, "user-a", "host-x", "b", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.acquire(ctx, "user-a", "host-x", "b", nil); err != errUserQuota {
+		t.Fatalf("third acquire = %v; want errUserQuota", err)
+	}
+	q.release("user-a", "host-x")
+	if err := q.acquire(ctx, "user-a", "host-x", "b", nil); err != nil {
+		t.Fatalf("acquire after release = %v", err)
+	}
+}
@@ -17,7 +17,8 @@ import (
 )
 
 // reportMetrics gathers and reports buildlet metrics to Stackdriver.
-// It currently only reports count of running reverse buildlets per type.
+// It reports the count of running reverse buildlets per type and,
+// if it's enabled, the content cache's counters.
 func reportMetrics(ctx context.Context) {
 	for {
 		err := reportReverseCountMetrics(ctx)
@@ -25,6 +26,12 @@ func reportMetrics(ctx context.Context) {
 			log.Printf("error reporting %q metrics: %v\n",
 				metrics.ReverseCount.Name, err)
 		}
+		if contentCache != nil {
+			if err := reportContentCacheMetrics(ctx); err != nil {
+				log.Printf("error reporting %q metrics: %v\n",
+					metrics.ContentCache.Name, err)
+			}
+		}
 
 		time.Sleep(5 * time.Minute)
 	}
@@ -32,14 +39,31 @@ func reportMetrics(ctx context.Context) {
 }
 
 func reportReverseCountMetrics(ctx context.Context) error {
-	m := metrics.ReverseCount
 	// 1. Gather # buildlets up per reverse builder type
 	totals := reversePool.hostTypeCount()
 	// 2. Write counts to Stackdriver
+	return writeLabeledGauge(ctx, metrics.ReverseCount, totals)
+}
+
+func reportContentCacheMetrics(ctx context.Context) error {
+	st := contentCache.Stats()
+	return writeLabeledGauge(ctx, metrics.ContentCache, map[string]int{
+		"hits":          int(st.Hits),
+		"misses":        int(st.Misses),
+		"fetch_errors":  int(st.FetchErrors),
+		"evictions":     int(st.Evictions),
+		"evicted_bytes": int(st.EvictedBytes),
+		"bytes":         int(st.Bytes),
+	})
+}
+
+// writeLabeledGauge writes to Stackdriver one point of the metric m,
+// which has a single label, for each label value in vals.
+func writeLabeledGauge(ctx context.Context, m *metrics.Metric, vals map[string]int) error {
 	ts := []*monpb.TimeSeries{}
 	now := ptypes.TimestampNow()
-	for hostType, n := range totals {
-		labels, err := m.Labels(hostType)
+	for lv, n := range vals {
+		labels, err := m.Labels(lv)
 		if err != nil {
 			return err
 		}
@@ -39,10 +39,29 @@ var ReverseCount = &Metric{
 	},
 }
 
+// ContentCache is the Stackdriver metric for monitoring the
+// coordinator's content cache. The "stat" label is one of hits,
+// misses, fetch_errors, evictions, evicted_bytes or bytes.
+var ContentCache = &Metric{
+	Name: "coordinator/content_cache",
+	Descriptor: &metpb.MetricDescriptor{
+		Type: "custom.googleapis.com/coordinator/content_cache",
+		Labels: []*label.LabelDescriptor{
+			{
+				Key:       "stat",
+				ValueType: label.LabelDescriptor_STRING,
+			},
+		},
+		MetricKind: metpb.MetricDescriptor_GAUGE,
+		ValueType:  metpb.MetricDescriptor_INT64,
+	},
+}
+
 // Metrics is the set of all Stackdriver metrics being used
 // to monitor the Go build system.
 var Metrics = []*Metric{
 	ReverseCount,
+	ContentCache,
 }
 
 // DescriptorPath returns the unique path for this metric among all
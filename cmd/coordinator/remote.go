@@ -7,7 +7,6 @@
 package main // import "golang.org/x/build/cmd/coordinator"
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -37,7 +36,6 @@ import (
 	"github.com/kr/pty"
 	"golang.org/x/build/buildlet"
 	"golang.org/x/build/dashboard"
-	"golang.org/x/build/internal/gophers"
 	gossh "golang.org/x/crypto/ssh"
 )
 
@@ -65,7 +63,7 @@ type remoteBuildlet struct {
 	HostType    string
 	BuilderType string // default builder config to use if not overwritten
 	Created     time.Time
-	Expires     time.Time
+	Expires     time.Time // end of lease; pushed out by use and by renewal
 
 	buildlet *buildlet.Client
 }
@@ -81,11 +79,21 @@ func (rb *remoteBuildlet) renew(ctx context.Context) {
 	default:
 	}
 	if got := remoteBuildlets.m[rb.Name]; got == rb {
-		rb.Expires = time.Now().Add(remoteBuildletIdleTimeout)
+		rb.touchLocked()
 		time.AfterFunc(time.Minute, func() { rb.renew(ctx) })
 	}
 }
 
+// touchLocked notes that rb was just used, making sure it lives for
+// at least another remoteBuildletIdleTimeout. It never shortens an
+// explicitly requested lease.
+// remoteBuildlets must be locked.
+func (rb *remoteBuildlet) touchLocked() {
+	if idle := time.Now().Add(remoteBuildletIdleTimeout); rb.Expires.Before(idle) {
+		rb.Expires = idle
+	}
+}
+
 func addRemoteBuildlet(rb *remoteBuildlet) (name string) {
 	remoteBuildlets.Lock()
 	defer remoteBuildlets.Unlock()
@@ -108,12 +116,27 @@ func expireBuildlets() {
 	now := time.Now()
 	for name, rb := range remoteBuildlets.m {
 		if !rb.Expires.IsZero() && rb.Expires.Before(now) {
-			go rb.buildlet.Close()
-			delete(remoteBuildlets.m, name)
+			log.Printf("lease of buildlet %v for %v expired", name, rb.User)
+			audit.record(auditEvent{User: rb.User, Instance: name, Action: "destroy", Detail: "lease expired"})
+			removeRemoteBuildletLocked(name)
 		}
 	}
 }
 
+// removeRemoteBuildletLocked forgets the named remote buildlet,
+// closes it in the background, and returns its lease to the pool.
+// remoteBuildlets must be locked.
+func removeRemoteBuildletLocked(name string) *remoteBuildlet {
+	rb, ok := remoteBuildlets.m[name]
+	if !ok {
+		return nil
+	}
+	delete(remoteBuildlets.m, name)
+	go rb.buildlet.Close()
+	leases.release(rb.User, rb.HostType)
+	return rb
+}
+
 // always wrapped in requireBuildletProxyAuth.
 func handleBuildletCreate(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -121,10 +144,17 @@ func handleBuildletCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	const serverVersion = "20160922" // sent by cmd/gomote via buildlet/remote.go
-	if version := r.FormValue("version"); version < serverVersion {
+	version := r.FormValue("version")
+	if version < serverVersion {
 		http.Error(w, fmt.Sprintf("gomote client version %q is too old; predates server version %q", version, serverVersion), 400)
 		return
 	}
+	// Clients at least this new understand a stream of JSON
+	// messages reporting their place in line, ending in either
+	// the buildlet or an error.
+	const streamVersion = "20181019"
+	stream := version >= streamVersion
+
 	builderType := r.FormValue("builderType")
 	if builderType == "" {
 		http.Error(w, "missing 'builderType' parameter", 400)
@@ -135,6 +165,11 @@ func handleBuildletCreate(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "unknown builder type in 'builderType' parameter", 400)
 		return
 	}
+	lease, err := parseLease(r.FormValue("lease"))
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
 	user, _, _ := r.BasicAuth()
 	pool := poolForConf(bconf)
 
@@ -154,9 +189,20 @@ func handleBuildletCreate(w http.ResponseWriter, r *http.Request) {
 		ctx = context.WithValue(ctx, highPriorityOpt{}, true)
 	}
 
+	posc := make(chan int)
 	resc := make(chan *buildlet.Client)
 	errc := make(chan error)
 	go func() {
+		err := leases.acquire(ctx, user, bconf.HostType, builderType, func(pos int) {
+			select {
+			case posc <- pos:
+			case <-ctx.Done():
+			}
+		})
+		if err != nil {
+			errc <- err
+			return
+		}
 		bc, err := pool.GetBuildlet(ctx, bconf.HostType, loggerFunc(func(event string, optText ...string) {
 			var extra string
 			if len(optText) > 0 {
@@ -168,34 +214,73 @@ func handleBuildletCreate(w http.ResponseWriter, r *http.Request) {
 			resc <- bc
 			return
 		}
+		leases.release(user, bconf.HostType)
 		errc <- err
 	}()
+
+	// streamed is whether we've started replying with a stream of
+	// messages, after which errors can no longer be sent as an
+	// HTTP status.
+	streamed := false
+	writeMsg := func(msg interface{}) {
+		if !streamed {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			streamed = true
+		}
+		json.NewEncoder(w).Encode(msg)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
 	for {
 		select {
+		case pos := <-posc:
+			log.Printf("buildlet %s for %s waiting; %d in line", bconf.HostType, user, pos)
+			if stream {
+				writeMsg(createMessage{Status: &buildlet.CreateStatus{
+					QueuePosition: pos,
+					Message:       fmt.Sprintf("waiting for a %s buildlet; %d in line", bconf.HostType, pos),
+				}})
+			}
 		case bc := <-resc:
+			now := time.Now()
 			rb := &remoteBuildlet{
 				User:        user,
 				BuilderType: builderType,
 				HostType:    bconf.HostType,
 				buildlet:    bc,
-				Created:     time.Now(),
-				Expires:     time.Now().Add(remoteBuildletIdleTimeout),
+				Created:     now,
+				Expires:     now.Add(lease),
 			}
 			rb.Name = addRemoteBuildlet(rb)
+			log.Printf("created buildlet %v for %v (%s), leased for %v", rb.Name, rb.User, bc.String(), lease)
+			audit.record(auditEvent{User: user, Instance: rb.Name, Action: "create", Detail: fmt.Sprintf("%s, lease %v", builderType, lease)})
+			if stream {
+				writeMsg(createMessage{Buildlet: rb})
+				return
+			}
 			jenc, err := json.MarshalIndent(rb, "", "  ")
 			if err != nil {
 				http.Error(w, err.Error(), 500)
 				log.Print(err)
 				return
 			}
-			log.Printf("created buildlet %v for %v (%s)", rb.Name, rb.User, bc.String())
 			w.Header().Set("Content-Type", "application/json; charset=utf-8")
 			jenc = append(jenc, '\n')
 			w.Write(jenc)
 			return
 		case err := <-errc:
 			log.Printf("error creating buildlet: %v", err)
-			http.Error(w, err.Error(), 500)
+			audit.record(auditEvent{User: user, Action: "create", Detail: fmt.Sprintf("%s failed: %v", builderType, err)})
+			if streamed {
+				writeMsg(createMessage{Error: err.Error()})
+				return
+			}
+			code := 500
+			if err == errUserQuota {
+				code = http.StatusTooManyRequests
+			}
+			http.Error(w, err.Error(), code)
 			return
 		case <-closeNotify:
 			log.Printf("client went away during buildlet create request")
@@ -205,6 +290,108 @@ func handleBuildletCreate(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// createMessage is one JSON message in the stream sent in reply to
+// a buildlet create request from new enough clients.
+// Exactly one field is set.
+type createMessage struct {
+	Status   *buildlet.CreateStatus `json:",omitempty"`
+	Buildlet *remoteBuildlet        `json:",omitempty"`
+	Error    string                 `json:",omitempty"`
+}
+
+// always wrapped in requireBuildletProxyAuth.
+func handleBuildletRenew(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST required", 400)
+		return
+	}
+	lease, err := parseLease(r.FormValue("lease"))
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+	name := r.FormValue("name")
+	user, _, _ := r.BasicAuth()
+	remoteBuildlets.Lock()
+	defer remoteBuildlets.Unlock()
+	rb, ok := remoteBuildlets.m[name]
+	if !ok {
+		http.Error(w, "unknown or expired buildlet", http.StatusNotFound)
+		return
+	}
+	if rb.User != user && !isGomoteAdmin(user) {
+		http.Error(w, "you don't own that buildlet", http.StatusUnauthorized)
+		return
+	}
+	rb.Expires = time.Now().Add(lease)
+	log.Printf("buildlet %v renewed by %v for %v", name, user, lease)
+	audit.record(auditEvent{User: user, Instance: name, Action: "renew", Detail: lease.String()})
+	jenc, err := json.MarshalIndent(rb, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	jenc = append(jenc, '\n')
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(jenc)
+}
+
+// always wrapped in requireBuildletProxyAuth.
+func handleBuildletLeases(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "GET required", 400)
+		return
+	}
+	if user, _, _ := r.BasicAuth(); !isGomoteAdmin(user) {
+		http.Error(w, "gomote admin required", http.StatusForbidden)
+		return
+	}
+	res := struct {
+		Buildlets []*remoteBuildlet
+		Waiting   []leaseWaiter
+	}{
+		Buildlets: make([]*remoteBuildlet, 0), // so it's never JSON "null"
+		Waiting:   leases.waiters(),
+	}
+	remoteBuildlets.Lock()
+	for _, rb := range remoteBuildlets.m {
+		res.Buildlets = append(res.Buildlets, rb)
+	}
+	sort.Sort(byBuildletName(res.Buildlets))
+	jenc, err := json.MarshalIndent(res, "", "  ")
+	remoteBuildlets.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+	jenc = append(jenc, '\n')
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(jenc)
+}
+
+// always wrapped in requireBuildletProxyAuth.
+func handleBuildletRevoke(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST required", 400)
+		return
+	}
+	user, _, _ := r.BasicAuth()
+	if !isGomoteAdmin(user) {
+		http.Error(w, "gomote admin required", http.StatusForbidden)
+		return
+	}
+	name := r.FormValue("name")
+	remoteBuildlets.Lock()
+	rb := removeRemoteBuildletLocked(name)
+	remoteBuildlets.Unlock()
+	if rb == nil {
+		http.Error(w, "unknown or expired buildlet", http.StatusNotFound)
+		return
+	}
+	log.Printf("buildlet %v of %v revoked by %v", name, rb.User, user)
+	audit.record(auditEvent{User: user, Instance: name, Action: "revoke", Detail: "owned by " + rb.User})
+}
+
 // always wrapped in requireBuildletProxyAuth.
 func handleBuildletList(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -241,7 +428,8 @@ func remoteBuildletStatus() string {
 	remoteBuildlets.Lock()
 	defer remoteBuildlets.Unlock()
 
-	if len(remoteBuildlets.m) == 0 {
+	waiting := leases.waiters()
+	if len(remoteBuildlets.m) == 0 && len(waiting) == 0 {
 		return "<i>(none)</i>"
 	}
 
@@ -258,6 +446,11 @@ func remoteBuildletStatus() string {
 			html.EscapeString(rb.Name),
 			time.Since(rb.Created), rb.Expires.Sub(time.Now()))
 	}
+	for _, lw := range waiting {
+		fmt.Fprintf(&buf, "<li><i>%s</i> for %s, #%d in line for %v</li>\n",
+			html.EscapeString(lw.BuilderType), html.EscapeString(lw.User),
+			lw.Position, time.Since(lw.Since).Round(time.Second))
+	}
 	buf.WriteString("</ul>")
 
 	return buf.String()
@@ -289,7 +482,7 @@ func proxyBuildletHTTP(w http.ResponseWriter, r *http.Request) {
 	remoteBuildlets.Lock()
 	rb, ok := remoteBuildlets.m[buildletName]
 	if ok {
-		rb.Expires = time.Now().Add(remoteBuildletIdleTimeout)
+		rb.touchLocked()
 	}
 	remoteBuildlets.Unlock()
 	if !ok {
@@ -301,15 +494,20 @@ func proxyBuildletHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "you don't own that buildlet", http.StatusUnauthorized)
 		return
 	}
+	audit.recordProxy(user, rb, r)
+
+	if r.Method == "POST" && r.URL.Path == "/connect-tcp" {
+		proxyBuildletTCP(w, r, rb)
+		return
+	}
 
 	if r.Method == "POST" && r.URL.Path == "/halt" {
 		err := rb.buildlet.Close()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		}
-		rb.buildlet.Close()
 		remoteBuildlets.Lock()
-		delete(remoteBuildlets.m, buildletName)
+		removeRemoteBuildletLocked(buildletName)
 		remoteBuildlets.Unlock()
 		return
 	}
@@ -330,6 +528,53 @@ func proxyBuildletHTTP(w http.ResponseWriter, r *http.Request) {
 	proxy.ServeHTTP(w, outReq)
 }
 
+// proxyBuildletTCP handles a gomote port forwarding request by
+// connecting to the requested port on rb's host (over revdial, for
+// reverse buildlets) and splicing that connection to the client's.
+// The buildlet's lease is kept alive while the connection is open.
+func proxyBuildletTCP(w http.ResponseWriter, r *http.Request, rb *remoteBuildlet) {
+	port, err := strconv.Atoi(r.Header.Get("X-Go-Tcp-Port"))
+	if err != nil {
+		http.Error(w, "missing or invalid X-Go-Tcp-Port header", http.StatusBadRequest)
+		return
+	}
+	backend, err := rb.buildlet.ConnectTCP(port)
+	if err != nil {
+		log.Printf("forwarding to port %d of buildlet %s: %v", port, rb.Name, err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer backend.Close()
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "conn can't hijack", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		log.Printf("hijack error forwarding to buildlet %s: %v", rb.Name, err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go rb.renew(ctx)
+
+	log.Printf("forwarding TCP from %v to port %d of buildlet %s", r.RemoteAddr, port, rb.Name)
+	fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: tcp\r\nConnection: Upgrade\r\n\r\n")
+	errc := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(backend, conn)
+		errc <- err
+	}()
+	go func() {
+		_, err := io.Copy(conn, backend)
+		errc <- err
+	}()
+	<-errc
+}
+
 func requireBuildletProxyAuth(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user, pass, ok := r.BasicAuth()
@@ -407,6 +652,11 @@ func listenAndServeSSH() {
 		return
 	}
 
+	if err := initSSHKeys(); err != nil {
+		log.Printf("ssh: bad SSH key configuration: %v; not running SSH server", err)
+		return
+	}
+
 	s := &ssh.Server{
 		Addr:             listenAddr,
 		Handler:          handleIncomingSSHPostAuth,
@@ -426,19 +676,10 @@ func handleSSHPublicKeyAuth(ctx ssh.Context, key ssh.PublicKey) bool {
 	if user == "" {
 		return false
 	}
-	// Map the gomote username to the github username, and use the
-	// github user's public ssh keys for authentication. This is
-	// mostly of laziness and pragmatism, not wanting to invent or
-	// maintain a new auth mechanism or password/key registry.
-	githubUser := gophers.GithubOfGomoteUser(user)
-	keys := githubPublicKeys(githubUser)
-	for _, authKey := range keys {
-		if ssh.KeysEqual(key, authKey.PublicKey) {
-			log.Printf("for instance %q, github user %q key matched: %s", inst, githubUser, authKey.AuthorizedLine)
-			return true
-		}
+	if sshKeys == nil {
+		return false
 	}
-	return false
+	return sshKeys.authorized(ctx, user, key)
 }
 
 func handleIncomingSSHPostAuth(s ssh.Session) {
@@ -595,15 +836,17 @@ func handleIncomingSSHPostAuth(s ssh.Session) {
 		return
 	}
 	defer f.Close()
+	transcript := audit.startTranscript("user-"+user, inst, ptyReq.Term, ptyReq.Window.Width, ptyReq.Window.Height)
+	defer transcript.Close()
 	go func() {
 		for win := range winCh {
 			setWinsize(f, win.Width, win.Height)
 		}
 	}()
 	go func() {
-		io.Copy(f, s) // stdin
+		io.Copy(f, io.TeeReader(s, transcript.Writer("i"))) // stdin
 	}()
-	io.Copy(s, f) // stdout
+	io.Copy(io.MultiWriter(s, transcript.Writer("o")), f) // stdout
 	cmd.Process.Kill()
 	cmd.Wait()
 }
@@ -639,46 +882,3 @@ func userFromGomoteInstanceName(name string) string {
 	}
 	return user[:hyphen]
 }
-
-// authorizedKey is a Github user's SSH authorized key, in both string and parsed format.
-type authorizedKey struct {
-	AuthorizedLine string // e.g. "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILj8HGIG9NsT34PHxO8IBq0riSBv7snp30JM8AanBGoV"
-	PublicKey      ssh.PublicKey
-}
-
-func githubPublicKeys(user string) []authorizedKey {
-	// TODO: caching, rate limiting.
-	req, err := http.NewRequest("GET", "https://github.com/"+user+".keys", nil)
-	if err != nil {
-		return nil
-	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	req = req.WithContext(ctx)
-	res, err := http.DefaultClient.Do(req)
-	if err != nil {
-		log.Printf("getting %s github keys: %v", user, err)
-		return nil
-	}
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return nil
-	}
-	var keys []authorizedKey
-	bs := bufio.NewScanner(res.Body)
-	for bs.Scan() {
-		key, _, _, _, err := ssh.ParseAuthorizedKey(bs.Bytes())
-		if err != nil {
-			log.Printf("parsing github user %q key %q: %v", user, bs.Text(), err)
-			continue
-		}
-		keys = append(keys, authorizedKey{
-			PublicKey:      key,
-			AuthorizedLine: strings.TrimSpace(bs.Text()),
-		})
-	}
-	if err := bs.Err(); err != nil {
-		return nil
-	}
-	return keys
-}
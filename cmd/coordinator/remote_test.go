@@ -21,6 +21,8 @@ import (
 )
 
 type TestBuildletPool struct {
+	drainSet
+
 	clients map[string]*buildlet.Client
 	mu      sync.Mutex
 }
@@ -56,11 +56,14 @@ var reversePool = new(reverseBuildletPool)
 type token struct{}
 
 type reverseBuildletPool struct {
+	drainSet
+
 	mu sync.Mutex // guards all fields, including fields of *reverseBuildlet
 	// TODO: switch to a map[hostType][]buildlets or map of set.
-	buildlets []*reverseBuildlet
-	wakeChan  map[string]chan token // hostType => best-effort wake-up chan when buildlet free
-	waiters   map[string]int        // hostType => number waiters blocked in GetBuildlet
+	buildlets    []*reverseBuildlet
+	wakeChan     map[string]chan token // hostType => best-effort wake-up chan when buildlet free
+	waiters      map[string]int        // hostType => number waiters blocked in GetBuildlet
+	drainedHosts map[string]bool       // hostnames drained by SetHostDraining
 }
 
 func (p *reverseBuildletPool) ServeReverseStatusJSON(w http.ResponseWriter, r *http.Request) {
@@ -86,6 +89,12 @@ func (p *reverseBuildletPool) buildReverseStatusJSON() *types.ReverseBuilderStat
 			HostType:     b.hostType,
 			ConnectedSec: time.Since(b.regTime).Seconds(),
 			Version:      b.version,
+			Draining:     b.draining,
+		}
+		if reason := health.quarantineReason(b.hostname); reason != "" {
+			hs.Quarantined++
+			bs.Quarantined = true
+			bs.QuarantineReason = reason
 		}
 		if b.inUse && !b.inHealthCheck {
 			hs.Busy++
@@ -106,6 +115,9 @@ func (p *reverseBuildletPool) buildReverseStatusJSON() *types.ReverseBuilderStat
 			status.Host(hostType).Expect = hc.ExpectNum
 		}
 	}
+	for hostType := range p.drainSet.draining() {
+		status.Host(hostType).Draining = true
+	}
 	return status
 }
 
@@ -124,6 +136,9 @@ func (p *reverseBuildletPool) tryToGrab(hostType string) (bc *buildlet.Client, b
 			busy++
 			continue
 		}
+		if b.draining || health.isQuarantined(b.hostname) {
+			continue
+		}
 		// Found an unused match.
 		b.inUse = true
 		b.inUseTime = time.Now()
@@ -214,6 +229,7 @@ func (p *reverseBuildletPool) healthCheckBuildlet(b *reverseBuildlet) bool {
 	if err != nil {
 		// remove bad buildlet
 		log.Printf("Health check fail; removing reverse buildlet %v (type %v): %v", b.hostname, b.hostType, err)
+		health.recordHealthCheckFailure(b.hostType, b.hostname, err)
 		go b.client.Close()
 		go p.nukeBuildlet(b.client)
 		return false
@@ -265,6 +281,9 @@ func (p *reverseBuildletPool) GetBuildlet(ctx context.Context, hostType string,
 	isHighPriority, _ := ctx.Value(highPriorityOpt{}).(bool)
 	sp := lg.CreateSpan("wait_static_builder", hostType)
 	for {
+		if err := p.awaitNotDraining(ctx, hostType, lg); err != nil {
+			return nil, sp.Done(err)
+		}
 		bc, busy := p.tryToGrab(hostType)
 		if bc != nil {
 			select {
@@ -333,6 +352,12 @@ func (p *reverseBuildletPool) WriteHTMLStatus(w io.Writer) {
 		if b.inUse {
 			machStatus = "working"
 		}
+		if b.draining {
+			machStatus += " (draining)"
+		}
+		if health.isQuarantined(b.hostname) {
+			machStatus += " (quarantined)"
+		}
 		fmt.Fprintf(&buf, "<li>%s (%s) version %s, %s: connected %s, %s for %s</li>\n",
 			b.hostname,
 			b.conn.RemoteAddr(),
@@ -359,16 +384,21 @@ func (p *reverseBuildletPool) WriteHTMLStatus(w io.Writer) {
 		io.WriteString(w, "<li>no connections</li>")
 	}
 	for _, typ := range typs {
+		var draining string
+		if p.IsDraining(typ) {
+			draining = " (draining)"
+		}
 		if dashboard.Hosts[typ] != nil && total[typ] < dashboard.Hosts[typ].ExpectNum {
-			fmt.Fprintf(w, "<li>%s: %d/%d (%d missing)</li>",
-				typ, inUse[typ], total[typ], dashboard.Hosts[typ].ExpectNum-total[typ])
+			fmt.Fprintf(w, "<li>%s: %d/%d (%d missing)%s</li>",
+				typ, inUse[typ], total[typ], dashboard.Hosts[typ].ExpectNum-total[typ], draining)
 		} else {
-			fmt.Fprintf(w, "<li>%s: %d/%d</li>", typ, inUse[typ], total[typ])
+			fmt.Fprintf(w, "<li>%s: %d/%d%s</li>", typ, inUse[typ], total[typ], draining)
 		}
 	}
 	io.WriteString(w, "</ul>")
 
 	fmt.Fprintf(w, "<b>Reverse pool machine detail</b><ul>%s</ul>", buf.Bytes())
+	health.writeHTMLStatus(w)
 }
 
 // hostTypeCount iterates through the running reverse buildlets, and
@@ -422,10 +452,113 @@ func (p *reverseBuildletPool) addBuildlet(b *reverseBuildlet) {
 	p.mu.Lock()
 	defer p.noteBuildletAvailable(b.hostType)
 	defer p.mu.Unlock()
+	if p.drainedHosts[b.hostname] {
+		// Still drained from a previous connection;
+		// keep it out of rotation.
+		b.draining = true
+	}
 	p.buildlets = append(p.buildlets, b)
 	go p.healthCheckBuildletLoop(b)
 }
 
+// SetDraining sets whether hostType is draining. Draining also asks
+// the idle buildlets of hostType to exit; the busy ones exit when
+// their builds close them, as usual.
+func (p *reverseBuildletPool) SetDraining(hostType string, draining bool) {
+	p.drainSet.SetDraining(hostType, draining)
+	if draining {
+		p.drainIdle(func(b *reverseBuildlet) bool { return b.hostType == hostType })
+	}
+}
+
+// SetHostDraining sets whether the reverse buildlet host named
+// hostname is draining. A draining host isn't handed out for new
+// work, even across reconnects, and when it's idle it's asked to
+// exit.
+func (p *reverseBuildletPool) SetHostDraining(hostname string, draining bool) {
+	p.mu.Lock()
+	if draining {
+		if p.drainedHosts == nil {
+			p.drainedHosts = make(map[string]bool)
+		}
+		p.drainedHosts[hostname] = true
+	} else {
+		delete(p.drainedHosts, hostname)
+	}
+	var hostTypes []string
+	for _, b := range p.buildlets {
+		if b.hostname == hostname {
+			b.draining = draining
+			hostTypes = append(hostTypes, b.hostType)
+		}
+	}
+	p.mu.Unlock()
+
+	if draining {
+		p.drainIdle(func(b *reverseBuildlet) bool { return b.hostname == hostname })
+		return
+	}
+	for _, hostType := range hostTypes {
+		p.noteBuildletAvailable(hostType)
+	}
+}
+
+// hostnameOf returns the hostname of the reverse buildlet whose
+// client is bc, or the empty string if bc isn't a reverse buildlet.
+func (p *reverseBuildletPool) hostnameOf(bc *buildlet.Client) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, b := range p.buildlets {
+		if b.client == bc {
+			return b.hostname
+		}
+	}
+	return ""
+}
+
+// drainingHosts returns the sorted hostnames drained by SetHostDraining.
+func (p *reverseBuildletPool) drainingHosts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	hosts := []string{}
+	for hostname := range p.drainedHosts {
+		hosts = append(hosts, hostname)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// drainIdle asks each idle buildlet matching match to drain, marking
+// it in use so nothing else grabs it in the meantime. Once the
+// buildlet exits, its connection goes away and it's removed from the
+// pool.
+func (p *reverseBuildletPool) drainIdle(match func(*reverseBuildlet) bool) {
+	p.mu.Lock()
+	var idle []*reverseBuildlet
+	for _, b := range p.buildlets {
+		if b.inUse || !match(b) {
+			continue
+		}
+		b.inUse = true
+		b.inUseTime = time.Now()
+		idle = append(idle, b)
+	}
+	p.mu.Unlock()
+
+	for _, b := range idle {
+		go func(b *reverseBuildlet) {
+			log.Printf("Draining idle reverse buildlet %v (type %v)", b.hostname, b.hostType)
+			if err := b.client.Drain(); err != nil {
+				// Probably an older buildlet without
+				// /drain. It's idle, so halting it is
+				// just as good.
+				log.Printf("Draining reverse buildlet %v: %v; closing it instead", b.hostname, err)
+				b.client.Close()
+			}
+		}(b)
+	}
+}
+
 // reverseBuildlet is a registered reverse buildlet.
 // Its immediate fields are guarded by the reverseBuildletPool mutex.
 type reverseBuildlet struct {
@@ -453,6 +586,10 @@ type reverseBuildlet struct {
 	inUse         bool
 	inUseTime     time.Time
 	inHealthCheck bool
+
+	// draining is whether the buildlet's host has been drained
+	// with SetHostDraining, keeping it out of rotation.
+	draining bool
 }
 
 func handleReverse(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,211 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to getting the post-make.bash snapshot onto sharded
+// test helper buildlets.
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+
+	"golang.org/x/build/buildlet"
+)
+
+var helperSnapshotP2P = flag.Bool("helper_snapshot_p2p", true, "Whether test helpers in the GCE and Kubernetes pools get the post-make.bash tree from the coordinator's copy of the snapshot, before falling back to the snapshot in GCS.")
+
+const (
+	// maxSnapshotCache is the largest snapshot the coordinator
+	// keeps a local copy of for test helpers. Bigger ones come
+	// from GCS.
+	maxSnapshotCache = 1 << 30
+
+	// maxSnapshotCacheTotal bounds the disk used by the snapshot
+	// caches of all concurrent builds. Snapshots that don't fit
+	// come from GCS.
+	maxSnapshotCacheTotal = 8 << 30
+)
+
+var (
+	snapshotCacheMu    sync.Mutex
+	snapshotCacheBytes int64 // bytes in all snapshotCaches' files
+)
+
+// reserveSnapshotCache reserves n bytes of the snapshot caches'
+// total, reporting whether they fit.
+func reserveSnapshotCache(n int64) bool {
+	snapshotCacheMu.Lock()
+	defer snapshotCacheMu.Unlock()
+	if snapshotCacheBytes+n > maxSnapshotCacheTotal {
+		return false
+	}
+	snapshotCacheBytes += n
+	return true
+}
+
+func releaseSnapshotCache(n int64) {
+	snapshotCacheMu.Lock()
+	defer snapshotCacheMu.Unlock()
+	snapshotCacheBytes -= n
+}
+
+// snapshotCache is the coordinator's local copy of a build's
+// snapshot tarball, teed off while writeSnapshot uploads it to GCS.
+type snapshotCache struct {
+	f    *os.File
+	n    int64 // bytes reserved with reserveSnapshotCache
+	err  error // first write error, after which the cache is useless
+	done bool  // whether the whole snapshot was written and f closed
+}
+
+// newSnapshotCache returns a new empty cache, or nil if the
+// coordinator shouldn't or can't keep one.
+func newSnapshotCache() *snapshotCache {
+	f, err := ioutil.TempFile("", "snapshot-cache-")
+	if err != nil {
+		log.Printf("not caching snapshot: %v", err)
+		return nil
+	}
+	return &snapshotCache{f: f}
+}
+
+// Write implements io.Writer. It never fails, so it can be used in
+// an io.MultiWriter alongside the real destination; failures just
+// make the cache unusable.
+func (c *snapshotCache) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return len(p), nil
+	}
+	if c.n+int64(len(p)) > maxSnapshotCache {
+		c.err = errors.New("snapshot too large to cache")
+		return len(p), nil
+	}
+	if !reserveSnapshotCache(int64(len(p))) {
+		c.err = errors.New("snapshot caches full")
+		return len(p), nil
+	}
+	c.n += int64(len(p))
+	if _, err := c.f.Write(p); err != nil {
+		c.err = err
+	}
+	return len(p), nil
+}
+
+// finish closes the cache's file after the whole snapshot has been
+// written, making the cache usable if nothing failed.
+func (c *snapshotCache) finish() error {
+	if err := c.f.Close(); err != nil && c.err == nil {
+		c.err = err
+	}
+	if c.err != nil {
+		return c.err
+	}
+	c.done = true
+	return nil
+}
+
+// open returns a reader of the cached snapshot, if it's complete.
+func (c *snapshotCache) open() (io.ReadCloser, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if !c.done {
+		return nil, errors.New("snapshot cache incomplete")
+	}
+	return os.Open(c.f.Name())
+}
+
+// remove deletes the cache's file.
+func (c *snapshotCache) remove() {
+	c.f.Close()
+	os.Remove(c.f.Name())
+	releaseSnapshotCache(c.n)
+	c.n = 0
+}
+
+// cacheSnapshot reports whether writeSnapshot should keep a local
+// copy of the snapshot for test helpers.
+func (st *buildStatus) cacheSnapshot() bool {
+	return st.canShareSnapshot() && !st.IsSubrepo() && st.conf.NumTestHelpers(st.isTry()) > 0
+}
+
+// canShareSnapshot reports whether test helpers may get the
+// snapshot from the coordinator rather than GCS: the helpers come
+// from a pool the coordinator is close to on the network.
+func (st *buildStatus) canShareSnapshot() bool {
+	if !*helperSnapshotP2P {
+		return false
+	}
+	switch st.buildletPool() {
+	case gcePool, kubePool:
+		return true
+	}
+	return false
+}
+
+// setSnapshotCache records c as the build's complete snapshot cache.
+func (st *buildStatus) setSnapshotCache(c *snapshotCache) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.snapCache = c
+}
+
+// removeSnapshotCache deletes the build's snapshot cache, if any.
+func (st *buildStatus) removeSnapshotCache() {
+	st.mu.Lock()
+	c := st.snapCache
+	st.snapCache = nil
+	st.mu.Unlock()
+	if c != nil {
+		c.remove()
+	}
+}
+
+// putSnapshotOnHelper writes the post-make.bash tree to the test
+// helper bc. It prefers the build's own cached copy of the snapshot,
+// and falls back to the snapshot in GCS, by way of the content cache
+// if it's enabled. Each attempt is its own span, so the logs show
+// where the tree came from.
+//
+// The primary buildlet's tree isn't used, even when the snapshot
+// isn't cached, as the primary may already be running tests that
+// write to it.
+func (st *buildStatus) putSnapshotOnHelper(bc *buildlet.Client) error {
+	if st.canShareSnapshot() {
+		st.mu.Lock()
+		c := st.snapCache
+		st.mu.Unlock()
+		if c != nil {
+			sp := st.CreateSpan("write_snapshot_from_coordinator", bc.Name())
+			err := st.putSnapshotFromCache(bc, c)
+			if sp.Done(err) == nil {
+				return nil
+			}
+			log.Printf("failed to put cached snapshot on helper %s; trying GCS: %v", bc.Name(), err)
+		}
+	}
+	sp := st.CreateSpan("write_snapshot_from_gcs", bc.Name())
+	return sp.Done(st.putTarFromURL(bc, st.snapshotCacheKey(), st.SnapshotURL(buildEnv), "go"))
+}
+
+// snapshotCacheKey returns the content cache key of the build's
+// snapshot in GCS.
+func (st *buildStatus) snapshotCacheKey() string {
+	return "snapshot/" + st.SnapshotObjectName()
+}
+
+func (st *buildStatus) putSnapshotFromCache(bc *buildlet.Client, c *snapshotCache) error {
+	r, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return bc.PutTar(r, "go")
+}
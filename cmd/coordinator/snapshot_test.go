@@ -0,0 +1,98 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotCache(t *testing.T) {
+	c := newSnapshotCache()
+	if c == nil {
+		t.Fatal("newSnapshotCache = nil")
+	}
+	defer c.remove()
+
+	var dst bytes.Buffer
+	const tgz = "pretend this is a tarball"
+	if _, err := io.Copy(io.MultiWriter(&dst, c), strings.NewReader(tgz)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.open(); err == nil {
+		t.Error("open of incomplete cache succeeded")
+	}
+	if err := c.finish(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := c.open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != tgz || dst.String() != tgz {
+		t.Errorf("cache has %q, destination %q; want %q for both", got, dst.String(), tgz)
+	}
+
+	c.remove()
+	if _, err := os.Stat(c.f.Name()); !os.IsNotExist(err) {
+		t.Errorf("cache file still exists after remove; stat = %v", err)
+	}
+	if snapshotCacheBytes != 0 {
+		t.Errorf("after remove, snapshotCacheBytes = %d; want 0", snapshotCacheBytes)
+	}
+}
+
+func TestSnapshotCacheTooBig(t *testing.T) {
+	c := newSnapshotCache()
+	if c == nil {
+		t.Fatal("newSnapshotCache = nil")
+	}
+	defer c.remove()
+	if !reserveSnapshotCache(maxSnapshotCache - 1) {
+		t.Fatal("reserveSnapshotCache failed")
+	}
+	c.n = maxSnapshotCache - 1
+	if n, err := c.Write([]byte("xx")); n != 2 || err != nil {
+		t.Fatalf("Write = %v, %v; want 2, nil so the real destination isn't affected", n, err)
+	}
+	if err := c.finish(); err == nil {
+		t.Error("finish of oversized cache succeeded")
+	}
+	if _, err := c.open(); err == nil {
+		t.Error("open of oversized cache succeeded")
+	}
+}
+
+func TestSnapshotCacheTotal(t *testing.T) {
+	// Other builds' caches use all but one byte.
+	if !reserveSnapshotCache(maxSnapshotCacheTotal - 1) {
+		t.Fatal("reserveSnapshotCache failed")
+	}
+	defer releaseSnapshotCache(maxSnapshotCacheTotal - 1)
+
+	c := newSnapshotCache()
+	if c == nil {
+		t.Fatal("newSnapshotCache = nil")
+	}
+	defer c.remove()
+	if n, err := c.Write([]byte("xx")); n != 2 || err != nil {
+		t.Fatalf("Write = %v, %v; want 2, nil so the real destination isn't affected", n, err)
+	}
+	if err := c.finish(); err == nil {
+		t.Error("finish of cache over the total limit succeeded")
+	}
+	if _, err := c.open(); err == nil {
+		t.Error("open of cache over the total limit succeeded")
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Code related to finding which SSH keys may be used to "gomote ssh"
+// into a user's buildlets.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"golang.org/x/build/internal/gophers"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+var (
+	sshKeySources  = flag.String("ssh_key_sources", "github", "Comma-separated list of where to find gomote users' authorized SSH keys, tried in order: \"github\" for the keys of the user's GitHub account; \"file:PATH\" for a file of lines of the form \"<gomote-user> <authorized_keys line>\"; or an http or https URL in which \"{user}\" is replaced by the gomote user, serving authorized_keys lines.")
+	sshKeyCacheTTL = flag.Duration("ssh_key_cache_ttl", 5*time.Minute, "How long to cache each user's authorized SSH keys from network sources.")
+	sshRevokedKeys = flag.String("ssh_revoked_keys", "", "Optional path to a file of revoked SSH keys, one per line, as authorized_keys lines or SHA256 fingerprints (\"SHA256:...\"). Revoked keys are refused regardless of source. The file is re-read when it changes.")
+)
+
+// authorizedKey is a user's SSH authorized key, in both string and parsed format.
+type authorizedKey struct {
+	AuthorizedLine string // e.g. "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILj8HGIG9NsT34PHxO8IBq0riSBv7snp30JM8AanBGoV"
+	PublicKey      ssh.PublicKey
+}
+
+// A keySource finds the SSH public keys authorized for gomote users.
+type keySource interface {
+	// Keys returns the keys authorized for the gomote user, such
+	// as "bradfitz" (without the "user-" prefix).
+	Keys(ctx context.Context, user string) ([]authorizedKey, error)
+
+	// String describes the source, for logging.
+	String() string
+}
+
+// sshKeys is the coordinator's configured SSH key sources and
+// revocation list. It's set by initSSHKeys.
+var sshKeys *sshKeyChecker
+
+// sshKeyChecker decides whether an SSH public key may be used to
+// connect to a gomote user's buildlets.
+type sshKeyChecker struct {
+	sources []keySource
+	revoked *revokedKeys // or nil
+}
+
+// initSSHKeys configures sshKeys from flags.
+func initSSHKeys() error {
+	srcs, err := parseKeySources(*sshKeySources, *sshKeyCacheTTL)
+	if err != nil {
+		return err
+	}
+	sshKeys = &sshKeyChecker{sources: srcs}
+	if *sshRevokedKeys != "" {
+		sshKeys.revoked = &revokedKeys{path: *sshRevokedKeys}
+	}
+	return nil
+}
+
+// parseKeySources parses the -ssh_key_sources flag value.
+// Network sources are cached for ttl.
+func parseKeySources(v string, ttl time.Duration) ([]keySource, error) {
+	var srcs []keySource
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		switch {
+		case f == "":
+			continue
+		case f == "github":
+			srcs = append(srcs, newCachingKeySource(githubKeySource{}, ttl))
+		case strings.HasPrefix(f, "file:"):
+			srcs = append(srcs, &fileKeySource{path: strings.TrimPrefix(f, "file:")})
+		case strings.HasPrefix(f, "http://"), strings.HasPrefix(f, "https://"):
+			if !strings.Contains(f, "{user}") {
+				return nil, fmt.Errorf("SSH key source URL %q lacks a {user} placeholder", f)
+			}
+			srcs = append(srcs, newCachingKeySource(httpKeySource{urlTmpl: f}, ttl))
+		default:
+			return nil, fmt.Errorf("unknown SSH key source %q", f)
+		}
+	}
+	if len(srcs) == 0 {
+		return nil, errors.New("no SSH key sources configured")
+	}
+	return srcs, nil
+}
+
+// authorized reports whether key may be used to connect to the
+// buildlets of the gomote user.
+func (c *sshKeyChecker) authorized(ctx context.Context, user string, key ssh.PublicKey) bool {
+	if c.revoked != nil && c.revoked.contains(key) {
+		log.Printf("ssh: refusing revoked key %s for user %q", gossh.FingerprintSHA256(key), user)
+		return false
+	}
+	for _, src := range c.sources {
+		keys, err := src.Keys(ctx, user)
+		if err != nil {
+			log.Printf("ssh: getting keys of user %q from %v: %v", user, src, err)
+			continue
+		}
+		for _, authKey := range keys {
+			if ssh.KeysEqual(key, authKey.PublicKey) {
+				log.Printf("ssh: for user %q, %v key matched: %s", user, src, authKey.AuthorizedLine)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseAuthorizedKeys parses r as an authorized_keys file, logging
+// and skipping bad lines.
+func parseAuthorizedKeys(r io.Reader, what string) ([]authorizedKey, error) {
+	var keys []authorizedKey
+	bs := bufio.NewScanner(r)
+	for bs.Scan() {
+		line := bytes.TrimSpace(bs.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, _, _, _, err := ssh.ParseAuthorizedKey(line)
+		if err != nil {
+			log.Printf("parsing %s key %q: %v", what, line, err)
+			continue
+		}
+		keys = append(keys, authorizedKey{
+			PublicKey:      key,
+			AuthorizedLine: string(line),
+		})
+	}
+	if err := bs.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// githubKeySource maps gomote users to GitHub users with
+// internal/gophers and uses their GitHub public keys. This is mostly
+// out of laziness and pragmatism, not wanting to invent or maintain
+// a new auth mechanism or password/key registry.
+type githubKeySource struct{}
+
+func (githubKeySource) String() string { return "github" }
+
+func (githubKeySource) Keys(ctx context.Context, user string) ([]authorizedKey, error) {
+	githubUser := gophers.GithubOfGomoteUser(user)
+	if githubUser == "" {
+		return nil, nil
+	}
+	return fetchAuthorizedKeys(ctx, "https://github.com/"+githubUser+".keys", "github user "+githubUser)
+}
+
+// httpKeySource fetches authorized_keys files from an HTTP server.
+type httpKeySource struct {
+	urlTmpl string // containing "{user}"
+}
+
+func (s httpKeySource) String() string { return s.urlTmpl }
+
+func (s httpKeySource) Keys(ctx context.Context, user string) ([]authorizedKey, error) {
+	u := strings.Replace(s.urlTmpl, "{user}", url.PathEscape(user), -1)
+	return fetchAuthorizedKeys(ctx, u, "user "+user)
+}
+
+// fetchAuthorizedKeys fetches and parses the authorized_keys file at
+// url. A 404 means the user has no keys.
+func fetchAuthorizedKeys(ctx context.Context, url, what string) ([]authorizedKey, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req = req.WithContext(ctx)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if res.StatusCode != 200 {
+		return nil, fmt.Errorf("fetching %s: %v", url, res.Status)
+	}
+	return parseAuthorizedKeys(io.LimitReader(res.Body, 1<<20), what)
+}
+
+// fileKeySource reads keys from a local file of lines of the form
+// "<gomote-user> <authorized_keys line>". The file is re-read
+// whenever its modification time changes, so removing a line
+// revokes that key.
+type fileKeySource struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	keys    map[string][]authorizedKey // gomote user => keys
+}
+
+func (s *fileKeySource) String() string { return "file:" + s.path }
+
+func (s *fileKeySource) Keys(ctx context.Context, user string) ([]authorizedKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fi, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if s.keys == nil || !fi.ModTime().Equal(s.modTime) {
+		slurp, err := ioutil.ReadFile(s.path)
+		if err != nil {
+			return nil, err
+		}
+		keys := make(map[string][]authorizedKey)
+		for _, line := range strings.Split(string(slurp), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			f := strings.SplitN(line, " ", 2)
+			if len(f) != 2 {
+				log.Printf("%v: skipping malformed line %q", s, line)
+				continue
+			}
+			ak, err := parseAuthorizedKeys(strings.NewReader(f[1]), "user "+f[0])
+			if err != nil {
+				return nil, err
+			}
+			keys[f[0]] = append(keys[f[0]], ak...)
+		}
+		s.keys = keys
+		s.modTime = fi.ModTime()
+	}
+	return s.keys[user], nil
+}
+
+// cachingKeySource caches another keySource's results per user.
+// Failures aren't cached.
+type cachingKeySource struct {
+	src keySource
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedKeys // user => keys
+}
+
+type cachedKeys struct {
+	keys    []authorizedKey
+	fetched time.Time
+}
+
+func newCachingKeySource(src keySource, ttl time.Duration) *cachingKeySource {
+	return &cachingKeySource{src: src, ttl: ttl, cache: make(map[string]cachedKeys)}
+}
+
+func (s *cachingKeySource) String() string { return s.src.String() }
+
+func (s *cachingKeySource) Keys(ctx context.Context, user string) ([]authorizedKey, error) {
+	s.mu.Lock()
+	ck, ok := s.cache[user]
+	s.mu.Unlock()
+	if ok && time.Since(ck.fetched) < s.ttl {
+		return ck.keys, nil
+	}
+	keys, err := s.src.Keys(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[user] = cachedKeys{keys, time.Now()}
+	s.mu.Unlock()
+	return keys, nil
+}
+
+// forget drops any cached keys for user, or for all users if user
+// is empty.
+func (s *cachingKeySource) forget(user string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if user == "" {
+		s.cache = make(map[string]cachedKeys)
+		return
+	}
+	delete(s.cache, user)
+}
+
+// revokedKeys is a file of revoked keys, as authorized_keys lines or
+// SHA256 fingerprints, re-read whenever its modification time
+// changes.
+type revokedKeys struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	fps     map[string]bool // SHA256 fingerprints
+}
+
+func (r *revokedKeys) contains(key ssh.PublicKey) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fi, err := os.Stat(r.path)
+	if err != nil {
+		// Fail closed: if we can't tell what's revoked,
+		// don't let anybody in.
+		log.Printf("ssh: reading revoked keys: %v", err)
+		return true
+	}
+	if r.fps == nil || !fi.ModTime().Equal(r.modTime) {
+		slurp, err := ioutil.ReadFile(r.path)
+		if err != nil {
+			log.Printf("ssh: reading revoked keys: %v", err)
+			return true
+		}
+		fps := make(map[string]bool)
+		for _, line := range strings.Split(string(slurp), "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "", strings.HasPrefix(line, "#"):
+			case strings.HasPrefix(line, "SHA256:"):
+				fps[line] = true
+			default:
+				k, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+				if err != nil {
+					log.Printf("ssh: skipping bad revoked key %q: %v", line, err)
+					continue
+				}
+				fps[gossh.FingerprintSHA256(k)] = true
+			}
+		}
+		r.fps = fps
+		r.modTime = fi.ModTime()
+	}
+	return r.fps[gossh.FingerprintSHA256(key)]
+}
+
+// handleSSHKeysFlush drops cached SSH keys, for the gomote user in
+// the "user" parameter or for everybody, so that keys removed from a
+// source stop working immediately.
+//
+// always wrapped in requireBuildletProxyAuth.
+func handleSSHKeysFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "POST required", 400)
+		return
+	}
+	if user, _, _ := r.BasicAuth(); !isGomoteAdmin(user) {
+		http.Error(w, "gomote admin required", http.StatusForbidden)
+		return
+	}
+	if sshKeys == nil {
+		http.Error(w, "SSH not configured", http.StatusNotFound)
+		return
+	}
+	for _, src := range sshKeys.sources {
+		if cs, ok := src.(*cachingKeySource); ok {
+			cs.forget(r.FormValue("user"))
+		}
+	}
+	fmt.Fprintf(w, "flushed\n")
+}
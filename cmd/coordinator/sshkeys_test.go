@@ -0,0 +1,155 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	testKey1 = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAILj8HGIG9NsT34PHxO8IBq0riSBv7snp30JM8AanBGoV"
+	testKey2 = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIIcqVU0Mq0rbUr6VzLDKRI8VRINQb2bVOuSEOu+5Jo8U"
+)
+
+func mustParseKey(t *testing.T, line string) ssh.PublicKey {
+	k, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestParseKeySources(t *testing.T) {
+	srcs, err := parseKeySources("github, file:/etc/gomote_keys,https://keys.example.com/{user}.keys", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range srcs {
+		got = append(got, s.String())
+	}
+	want := "[github file:/etc/gomote_keys https://keys.example.com/{user}.keys]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("sources = %v; want %v", got, want)
+	}
+	for _, bad := range []string{"", "ldap", "https://keys.example.com/keys"} {
+		if _, err := parseKeySources(bad, time.Minute); err == nil {
+			t.Errorf("parseKeySources(%q) succeeded; want error", bad)
+		}
+	}
+}
+
+func TestFileKeySource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sshkeys-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys")
+	if err := ioutil.WriteFile(path, []byte("# comment\nfoo "+testKey1+" foo@laptop\nbar "+testKey2+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := &sshKeyChecker{sources: []keySource{&fileKeySource{path: path}}}
+	ctx := context.Background()
+	if !c.authorized(ctx, "foo", mustParseKey(t, testKey1)) {
+		t.Error("foo's key not authorized")
+	}
+	if c.authorized(ctx, "foo", mustParseKey(t, testKey2)) {
+		t.Error("bar's key authorized for foo")
+	}
+
+	// Removing foo's line revokes the key.
+	if err := ioutil.WriteFile(path, []byte("bar "+testKey2+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if c.authorized(ctx, "foo", mustParseKey(t, testKey1)) {
+		t.Error("foo's key still authorized after removal from file")
+	}
+}
+
+func TestHTTPKeySourceCaching(t *testing.T) {
+	hits := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if r.URL.Path != "/keys/foo" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, testKey1)
+	}))
+	defer ts.Close()
+
+	src := newCachingKeySource(httpKeySource{urlTmpl: ts.URL + "/keys/{user}"}, time.Hour)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		keys, err := src.Keys(ctx, "foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || keys[0].AuthorizedLine != testKey1 {
+			t.Fatalf("keys = %+v; want just testKey1", keys)
+		}
+	}
+	if keys, err := src.Keys(ctx, "nobody"); err != nil || len(keys) != 0 {
+		t.Errorf("keys for unknown user = %v, %v; want none", keys, err)
+	}
+	if hits != 2 {
+		t.Errorf("server hit %d times; want 2", hits)
+	}
+	src.forget("foo")
+	src.Keys(ctx, "foo")
+	if hits != 3 {
+		t.Errorf("after forget, server hit %d times; want 3", hits)
+	}
+}
+
+func TestRevokedKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sshkeys-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keysPath := filepath.Join(dir, "keys")
+	revokedPath := filepath.Join(dir, "revoked")
+	if err := ioutil.WriteFile(keysPath, []byte("foo "+testKey1+"\nfoo "+testKey2+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fp := gossh.FingerprintSHA256(mustParseKey(t, testKey2))
+	if err := ioutil.WriteFile(revokedPath, []byte(fp+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := &sshKeyChecker{
+		sources: []keySource{&fileKeySource{path: keysPath}},
+		revoked: &revokedKeys{path: revokedPath},
+	}
+	ctx := context.Background()
+	if !c.authorized(ctx, "foo", mustParseKey(t, testKey1)) {
+		t.Error("unrevoked key not authorized")
+	}
+	if c.authorized(ctx, "foo", mustParseKey(t, testKey2)) {
+		t.Error("revoked key authorized")
+	}
+
+	// A missing revocation list fails closed.
+	os.Remove(revokedPath)
+	if c.authorized(ctx, "foo", mustParseKey(t, testKey1)) {
+		t.Error("key authorized with missing revocation list")
+	}
+}
@@ -377,7 +377,7 @@ func (b *bot) processPullRequest(ctx context.Context, pr *github.PullRequest) er
 	shortLink := prShortLink(pr)
 	cl := b.importedPRs[shortLink]
 
-	if cl != nil && b.pendingCLs[shortLink] == cl.Commit.Msg {
+	if cl != nil && b.pendingCLs[shortLink] == cl.Commit.GetMsg() {
 		delete(b.pendingCLs, shortLink)
 	}
 	if b.pendingCLs[shortLink] != "" {
@@ -415,7 +415,7 @@ func (b *bot) processPullRequest(ctx context.Context, pr *github.PullRequest) er
 		return fmt.Errorf("syncGerritCommentsToGitHub: %v", err)
 	}
 
-	if cmsg == cl.Commit.Msg {
+	if cmsg == cl.Commit.GetMsg() {
 		log.Printf("Change https://go-review.googlesource.com/q/%s is up to date; nothing to do.",
 			cl.ChangeID())
 		return nil
@@ -445,7 +445,7 @@ func (b *bot) syncGerritCommentsToGitHub(ctx context.Context, pr *github.PullReq
 ---
 Please don’t reply on this GitHub thread. Visit [golang.org/cl/%d](https://go-review.googlesource.com/c/%s/+/%d#message-%s).
 After addressing review feedback, remember to [publish your drafts](https://github.com/golang/go/wiki/GerritBot#i-left-a-reply-to-a-comment-in-gerrit-but-no-one-but-me-can-see-it)!`,
-			m.Author.Name(), m.Message, cl.Number, cl.Project.Project(), cl.Number, m.Meta.Hash.String())
+			m.Author.Name(), m.GetMessage(), cl.Number, cl.Project.Project(), cl.Number, m.Meta.Hash.String())
 		if err := b.postGitHubMessageNoDup(ctx, repo.GetOwner().GetLogin(), repo.GetName(), pr.GetNumber(), msg); err != nil {
 			return fmt.Errorf("postGitHubMessageNoDup: %v", err)
 		}
@@ -753,14 +753,14 @@ func (b *bot) postGitHubMessageNoDup(ctx context.Context, org, repo string, issu
 		gi.ForeachComment(func(c *maintner.GitHubComment) error {
 			since = c.Updated
 			// TODO: check for exact match?
-			if strings.Contains(c.Body, msg) {
+			if strings.Contains(c.GetBody(), msg) {
 				dup = true
 				return nil
 			}
-			if c.User.ID == ownerID && strings.HasPrefix(c.Body, "/comments ") {
-				if strings.HasPrefix(c.Body, "/comments off") {
+			if c.User.ID == ownerID && strings.HasPrefix(c.GetBody(), "/comments ") {
+				if strings.HasPrefix(c.GetBody(), "/comments off") {
 					noComment = true
-				} else if strings.HasPrefix(c.Body, "/comments on") {
+				} else if strings.HasPrefix(c.GetBody(), "/comments on") {
 					noComment = false
 				}
 			}
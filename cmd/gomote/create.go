@@ -10,6 +10,7 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/build/buildlet"
 	"golang.org/x/build/dashboard"
@@ -35,42 +36,52 @@ func create(args []string) error {
 		}
 		os.Exit(1)
 	}
-	// TODO(bradfitz): restore this option, and send it to the coordinator:
-	// For now, comment it out so it's not misleading.
-	// var timeout time.Duration
-	// fs.DurationVar(&timeout, "timeout", 60*time.Minute, "how long the VM will live before being deleted.")
+	var lease time.Duration
+	fs.DurationVar(&lease, "lease", 0, "how long the buildlet lives without being used or renewed; zero means the coordinator's default")
 
 	fs.Parse(args)
 	if fs.NArg() != 1 {
 		fs.Usage()
 	}
-	builderType := fs.Arg(0)
-	_, ok := dashboard.Builders[builderType]
-	if !ok {
-		var valid []string
-		var prefixMatch []string
-		for k := range dashboard.Builders {
-			valid = append(valid, k)
-			if strings.HasPrefix(k, builderType) {
-				prefixMatch = append(prefixMatch, k)
-			}
-		}
-		if len(prefixMatch) == 1 {
-			builderType = prefixMatch[0]
-		} else {
-			sort.Strings(valid)
-			return fmt.Errorf("Invalid builder type %q. Valid options include: %q", builderType, valid)
-		}
+	builderType, err := resolveBuilderType(fs.Arg(0))
+	if err != nil {
+		return err
 	}
 
 	cc, err := buildlet.NewCoordinatorClientFromFlags()
 	if err != nil {
 		return fmt.Errorf("failed to create coordinator client: %v", err)
 	}
-	client, err := cc.CreateBuildlet(builderType)
+	client, err := cc.CreateBuildletWithOpts(builderType, buildlet.CreateOpts{
+		Lease: lease,
+		OnStatus: func(st buildlet.CreateStatus) {
+			fmt.Fprintf(os.Stderr, "# %s\n", st.Message)
+		},
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create buildlet: %v", err)
 	}
 	fmt.Println(client.RemoteName())
 	return nil
 }
+
+// resolveBuilderType returns the builder type named by builderType,
+// which may be an unambiguous prefix of a builder type.
+func resolveBuilderType(builderType string) (string, error) {
+	if _, ok := dashboard.Builders[builderType]; ok {
+		return builderType, nil
+	}
+	var valid []string
+	var prefixMatch []string
+	for k := range dashboard.Builders {
+		valid = append(valid, k)
+		if strings.HasPrefix(k, builderType) {
+			prefixMatch = append(prefixMatch, k)
+		}
+	}
+	if len(prefixMatch) == 1 {
+		return prefixMatch[0], nil
+	}
+	sort.Strings(valid)
+	return "", fmt.Errorf("Invalid builder type %q. Valid options include: %q", builderType, valid)
+}
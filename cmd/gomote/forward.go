@@ -0,0 +1,97 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func forward(args []string) error {
+	fs := flag.NewFlagSet("forward", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "forward usage: gomote forward <instance> [<localport>:]<remoteport>...")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "Forwards connections to localhost:<localport> to <remoteport> on the")
+		fmt.Fprintln(os.Stderr, "instance's host, via the coordinator, until interrupted.")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+	}
+	name := fs.Arg(0)
+	bc, _, err := clientAndConf(name)
+	if err != nil {
+		return err
+	}
+
+	errc := make(chan error)
+	for _, spec := range fs.Args()[1:] {
+		localPort, remotePort, err := parseForwardSpec(spec)
+		if err != nil {
+			return err
+		}
+		ln, err := net.Listen("tcp", net.JoinHostPort("localhost", strconv.Itoa(localPort)))
+		if err != nil {
+			return err
+		}
+		log.Printf("forwarding %v to port %d on %s", ln.Addr(), remotePort, name)
+		go func() {
+			for {
+				c, err := ln.Accept()
+				if err != nil {
+					errc <- err
+					return
+				}
+				go func() {
+					defer c.Close()
+					rc, err := bc.ConnectTCP(remotePort)
+					if err != nil {
+						log.Printf("connecting to port %d on %s: %v", remotePort, name, err)
+						return
+					}
+					defer rc.Close()
+					done := make(chan struct{}, 2)
+					go func() {
+						io.Copy(rc, c)
+						done <- struct{}{}
+					}()
+					go func() {
+						io.Copy(c, rc)
+						done <- struct{}{}
+					}()
+					<-done
+				}()
+			}
+		}()
+	}
+	return <-errc
+}
+
+// parseForwardSpec parses a port forwarding spec of the form
+// "localport:remoteport", or just "port" to use the same port on
+// both ends.
+func parseForwardSpec(spec string) (localPort, remotePort int, err error) {
+	local, remote := spec, spec
+	if i := strings.Index(spec, ":"); i >= 0 {
+		local, remote = spec[:i], spec[i+1:]
+	}
+	localPort, err = strconv.Atoi(local)
+	if err == nil {
+		remotePort, err = strconv.Atoi(remote)
+	}
+	if err != nil || localPort < 0 || localPort > 65535 || remotePort <= 0 || remotePort > 65535 {
+		return 0, 0, fmt.Errorf("invalid port forwarding spec %q; want [<localport>:]<remoteport>", spec)
+	}
+	return localPort, remotePort, nil
+}
@@ -16,19 +16,47 @@ import (
 func getTar(args []string) error {
 	fs := flag.NewFlagSet("get", flag.ContinueOnError)
 	fs.Usage = func() {
-		fmt.Fprintln(os.Stderr, "create usage: gomote gettar [get-opts] <buildlet-name>")
+		fmt.Fprintln(os.Stderr, "create usage: gomote gettar [get-opts] {<buildlet-name>|-group=<group>}")
 		fs.PrintDefaults()
 		os.Exit(1)
 	}
 	var dir string
 	fs.StringVar(&dir, "dir", "", "relative directory from buildlet's work dir to tar up")
+	var groupName string
+	fs.StringVar(&groupName, "group", "", "get a tar.gz from every instance of the named group, writing each to <instance>.tar.gz in the current directory")
 
 	fs.Parse(args)
-	if fs.NArg() != 1 {
+	if groupName == "" && fs.NArg() != 1 || groupName != "" && fs.NArg() != 0 {
 		fs.Usage()
 	}
 
-	name := fs.Arg(0)
+	if groupName == "" {
+		return getTarFrom(fs.Arg(0), dir, os.Stdout)
+	}
+	names, err := groupInstances(groupName)
+	if err != nil {
+		return err
+	}
+	errs := forEachInstance(names, func(_ int, name string) error {
+		f, err := os.Create(name + ".tar.gz")
+		if err != nil {
+			return err
+		}
+		if err := getTarFrom(name, dir, f); err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "wrote %s.tar.gz\n", name)
+		return nil
+	})
+	return firstError(errs)
+}
+
+// getTarFrom writes a tar.gz of dir on the named instance to w.
+func getTarFrom(name, dir string, w io.Writer) error {
 	bc, _, err := clientAndConf(name)
 	if err != nil {
 		return err
@@ -38,6 +66,6 @@ func getTar(args []string) error {
 		return err
 	}
 	defer tgz.Close()
-	_, err = io.Copy(os.Stdout, tgz)
+	_, err = io.Copy(w, tgz)
 	return err
 }
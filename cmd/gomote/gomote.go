@@ -25,7 +25,10 @@ To list the subcommands, run "gomote" without arguments:
 
     create     create a buildlet; with no args, list types of buildlets
     destroy    destroy a buildlet
+    forward    forward local TCP ports to ports on a buildlet
     gettar     extract a tar.gz from a buildlet
+    group      manage named groups of buildlets
+    leases     list all users' buildlets and queued requests (admins only)
     list       list active buildlets
     ls         list the contents of a directory on a buildlet
     ping       test whether a buildlet is alive and reachable
@@ -33,6 +36,8 @@ To list the subcommands, run "gomote" without arguments:
     put        put files on a buildlet
     put14      put Go 1.4 in place
     puttar     extract a tar.gz to a buildlet
+    renew      extend the lease of a buildlet
+    revoke     destroy any user's buildlet (admins only)
     rm         delete files or directories
     run        run a command on a buildlet
     ssh        ssh to a buildlet
@@ -70,6 +75,30 @@ The "gomote run" command has many of its own flags:
     -system
           run inside the system, and not inside the workdir; this is implicit if cmd starts with '/'
 
+Watching for changes
+
+"gomote push -watch" keeps running after the initial push, checking
+$GOROOT for edits and pushing just the changed and deleted files to
+one or more instances. With -run, it also runs a command on each
+instance after every sync:
+
+  $ gomote push -watch -run='go/bin/go test -short os' user-username-linux-arm-0
+
+Groups of buildlets
+
+To debug on several ports at once, "gomote group create" creates
+buildlets of one or more types as a named group, remembered in the
+gomote config directory:
+
+  $ gomote group create arm linux-arm linux-arm64:2 linux-ppc64le
+  $ gomote push -group=arm
+  $ gomote run -group=arm go/src/make.bash
+
+The push, run and gettar commands accept -group in place of an
+instance name and operate on all of the group's instances in
+parallel. "gomote run -group" prints each instance's output in turn,
+followed by a summary of every instance's result.
+
 Debugging buildlets directly
 
 Using "gomote create" contacts the build coordinator
@@ -141,7 +170,10 @@ func registerCommand(name, des string, run func([]string) error) {
 func registerCommands() {
 	registerCommand("create", "create a buildlet; with no args, list types of buildlets", create)
 	registerCommand("destroy", "destroy a buildlet", destroy)
+	registerCommand("forward", "forward local TCP ports to ports on a buildlet", forward)
 	registerCommand("gettar", "extract a tar.gz from a buildlet", getTar)
+	registerCommand("group", "manage named groups of buildlets", groupCmd)
+	registerCommand("leases", "list all users' buildlets and queued requests (admins only)", leases)
 	registerCommand("ls", "list the contents of a directory on a buildlet", ls)
 	registerCommand("list", "list active buildlets", list)
 	registerCommand("ping", "test whether a buildlet is alive and reachable ", ping)
@@ -149,6 +181,8 @@ func registerCommands() {
 	registerCommand("put", "put files on a buildlet", put)
 	registerCommand("put14", "put Go 1.4 in place", put14)
 	registerCommand("puttar", "extract a tar.gz to a buildlet", putTar)
+	registerCommand("renew", "extend the lease of a buildlet", renew)
+	registerCommand("revoke", "destroy any user's buildlet (admins only)", revoke)
 	registerCommand("rm", "delete files or directories", rm)
 	registerCommand("run", "run a command on a buildlet", run)
 	registerCommand("ssh", "ssh to a buildlet", ssh)
@@ -0,0 +1,359 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/build/buildlet"
+)
+
+// A group is a named set of gomote instances, persisted in the
+// user's gomote config directory so later commands can operate on
+// all of them at once.
+type group struct {
+	Name      string
+	Instances []string
+}
+
+func groupDir() string {
+	return filepath.Join(buildlet.ConfigDir(), "groups")
+}
+
+func validGroupName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return name[0] != '.'
+}
+
+func loadGroup(name string) (*group, error) {
+	if !validGroupName(name) {
+		return nil, fmt.Errorf("invalid group name %q", name)
+	}
+	slurp, err := ioutil.ReadFile(filepath.Join(groupDir(), name+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no such group %q", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	g := new(group)
+	if err := json.Unmarshal(slurp, g); err != nil {
+		return nil, fmt.Errorf("parsing group %q: %v", name, err)
+	}
+	return g, nil
+}
+
+func (g *group) save() error {
+	if err := os.MkdirAll(groupDir(), 0700); err != nil {
+		return err
+	}
+	j, err := json.MarshalIndent(g, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(groupDir(), g.Name+".json"), append(j, '\n'), 0600)
+}
+
+func (g *group) remove() error {
+	return os.Remove(filepath.Join(groupDir(), g.Name+".json"))
+}
+
+// parseTypeCount parses a "group create" argument of the form
+// <type>[:count]. The count defaults to 1.
+func parseTypeCount(arg string) (typ string, n int, err error) {
+	i := strings.LastIndex(arg, ":")
+	if i < 0 {
+		return arg, 1, nil
+	}
+	if i == 0 {
+		return "", 0, fmt.Errorf("missing type in %q", arg)
+	}
+	n, err = strconv.Atoi(arg[i+1:])
+	if err != nil || n < 1 {
+		return "", 0, fmt.Errorf("invalid count in %q", arg)
+	}
+	return arg[:i], n, nil
+}
+
+// groupInstances returns the instances of the named group.
+func groupInstances(name string) ([]string, error) {
+	g, err := loadGroup(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Instances) == 0 {
+		return nil, fmt.Errorf("group %q has no instances", name)
+	}
+	return g.Instances, nil
+}
+
+// forEachInstance runs fn concurrently for each instance, passing
+// its index in names, and returns the errors in the same order.
+func forEachInstance(names []string, fn func(i int, name string) error) []error {
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = fn(i, name)
+		}(i, name)
+	}
+	wg.Wait()
+	return errs
+}
+
+// firstError returns the first non-nil error in errs, annotated with
+// how many instances failed.
+func firstError(errs []error) error {
+	var first error
+	n := 0
+	for _, err := range errs {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("%v (and %d more failures)", first, n-1)
+	}
+	return first
+}
+
+// printInstanceResults writes each instance's buffered output in
+// turn, followed by a table of every instance's result.
+func printInstanceResults(names []string, outs []bytes.Buffer, errs []error) {
+	for i, name := range names {
+		fmt.Printf("=== %s\n", name)
+		os.Stdout.Write(outs[i].Bytes())
+	}
+	fmt.Println("===")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for i, name := range names {
+		result := "ok"
+		if errs[i] != nil {
+			result = errs[i].Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, result)
+	}
+	tw.Flush()
+}
+
+func groupCmd(args []string) error {
+	fs := flag.NewFlagSet("group", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "group usage: gomote group <subcommand> [args...]")
+		fmt.Fprintln(os.Stderr, "")
+		fmt.Fprintln(os.Stderr, "  create [create-opts] <group> <type>[:count]...  create buildlets as a new group")
+		fmt.Fprintln(os.Stderr, "  destroy <group>                                   destroy a group's buildlets and the group")
+		fmt.Fprintln(os.Stderr, "  list                                              list groups and their instances")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() < 1 {
+		fs.Usage()
+	}
+	switch sub, args := fs.Arg(0), fs.Args()[1:]; sub {
+	case "create":
+		return groupCreate(args)
+	case "destroy":
+		return groupDestroy(args)
+	case "list":
+		return groupList(args)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown group subcommand %q\n", sub)
+		fs.Usage()
+	}
+	return nil
+}
+
+func groupCreate(args []string) error {
+	fs := flag.NewFlagSet("group create", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "group create usage: gomote group create [create-opts] <group> <type>[:count]...")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	var lease time.Duration
+	fs.DurationVar(&lease, "lease", 0, "how long each buildlet lives without being used or renewed; zero means the coordinator's default")
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		fs.Usage()
+	}
+	name := fs.Arg(0)
+	if !validGroupName(name) {
+		return fmt.Errorf("invalid group name %q", name)
+	}
+	if _, err := loadGroup(name); err == nil {
+		return fmt.Errorf("group %q already exists", name)
+	}
+
+	var types []string
+	for _, arg := range fs.Args()[1:] {
+		typ, n, err := parseTypeCount(arg)
+		if err != nil {
+			return err
+		}
+		typ, err = resolveBuilderType(typ)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			types = append(types, typ)
+		}
+	}
+
+	cc, err := buildlet.NewCoordinatorClientFromFlags()
+	if err != nil {
+		return fmt.Errorf("failed to create coordinator client: %v", err)
+	}
+	insts := make([]string, len(types))
+	errs := make([]error, len(types))
+	var wg sync.WaitGroup
+	for i, typ := range types {
+		wg.Add(1)
+		go func(i int, typ string) {
+			defer wg.Done()
+			client, err := cc.CreateBuildletWithOpts(typ, buildlet.CreateOpts{
+				Lease: lease,
+				OnStatus: func(st buildlet.CreateStatus) {
+					fmt.Fprintf(os.Stderr, "# %s: %s\n", typ, st.Message)
+				},
+			})
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to create %s buildlet: %v", typ, err)
+				return
+			}
+			insts[i] = client.RemoteName()
+		}(i, typ)
+	}
+	wg.Wait()
+
+	g := &group{Name: name}
+	for _, inst := range insts {
+		if inst != "" {
+			g.Instances = append(g.Instances, inst)
+			fmt.Println(inst)
+		}
+	}
+	if len(g.Instances) > 0 {
+		if err := g.save(); err != nil {
+			return err
+		}
+	}
+	return firstError(errs)
+}
+
+func groupDestroy(args []string) error {
+	fs := flag.NewFlagSet("group destroy", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "group destroy usage: gomote group destroy <group>")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+	g, err := loadGroup(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	live, err := liveInstances()
+	if err != nil {
+		return err
+	}
+	errs := forEachInstance(g.Instances, func(_ int, inst string) error {
+		if !live[inst] {
+			return nil // already gone
+		}
+		bc, _, err := clientAndConf(inst)
+		if err != nil {
+			return err
+		}
+		return bc.Close()
+	})
+	if err := firstError(errs); err != nil {
+		return err
+	}
+	return g.remove()
+}
+
+func groupList(args []string) error {
+	fs := flag.NewFlagSet("group list", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "group list usage: gomote group list")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+	files, err := filepath.Glob(filepath.Join(groupDir(), "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+	live, err := liveInstances()
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		g, err := loadGroup(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", g.Name)
+		for _, inst := range g.Instances {
+			state := "expired"
+			if live[inst] {
+				state = "live"
+			}
+			fmt.Printf("\t%s\t%s\n", inst, state)
+		}
+	}
+	return nil
+}
+
+// liveInstances returns the set of the user's current remote
+// buildlet names.
+func liveInstances() (map[string]bool, error) {
+	cc, err := buildlet.NewCoordinatorClientFromFlags()
+	if err != nil {
+		return nil, err
+	}
+	rbs, err := cc.RemoteBuildlets()
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool)
+	for _, rb := range rbs {
+		live[rb.Name] = true
+	}
+	return live, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidGroupName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"", false},
+		{"linux", true},
+		{"arm-builders_2.x", true},
+		{".hidden", false},
+		{"a/b", false},
+		{"../up", false},
+		{"with space", false},
+		{"héllo", false},
+	}
+	for _, tt := range tests {
+		if got := validGroupName(tt.name); got != tt.want {
+			t.Errorf("validGroupName(%q) = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTypeCount(t *testing.T) {
+	tests := []struct {
+		arg     string
+		typ     string
+		n       int
+		wantErr bool
+	}{
+		{arg: "linux-amd64", typ: "linux-amd64", n: 1},
+		{arg: "linux-amd64:3", typ: "linux-amd64", n: 3},
+		{arg: "host-linux-arm:2", typ: "host-linux-arm", n: 2},
+		{arg: "linux-amd64:0", wantErr: true},
+		{arg: "linux-amd64:-1", wantErr: true},
+		{arg: "linux-amd64:x", wantErr: true},
+		{arg: "linux-amd64:", wantErr: true},
+		{arg: ":2", wantErr: true},
+	}
+	for _, tt := range tests {
+		typ, n, err := parseTypeCount(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTypeCount(%q) error = %v; want error: %v", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if typ != tt.typ || n != tt.n {
+			t.Errorf("parseTypeCount(%q) = %q, %d; want %q, %d", tt.arg, typ, n, tt.typ, tt.n)
+		}
+	}
+}
+
+func TestFirstError(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	tests := []struct {
+		errs []error
+		want string // "" for nil
+	}{
+		{nil, ""},
+		{[]error{nil, nil}, ""},
+		{[]error{nil, a}, "a"},
+		{[]error{a, nil, b}, "a (and 1 more failures)"},
+		{[]error{b, a, a}, "b (and 2 more failures)"},
+	}
+	for _, tt := range tests {
+		err := firstError(tt.errs)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("firstError(%v) = %q; want %q", tt.errs, got, tt.want)
+		}
+	}
+}
+
+func TestForEachInstance(t *testing.T) {
+	names := []string{"a", "b", "c"}
+	errs := forEachInstance(names, func(i int, name string) error {
+		if names[i] != name {
+			t.Errorf("fn(%d, %q): name doesn't match index", i, name)
+		}
+		if name == "b" {
+			return errors.New("failed " + name)
+		}
+		return nil
+	})
+	if len(errs) != 3 || errs[0] != nil || errs[1] == nil || errs[1].Error() != "failed b" || errs[2] != nil {
+		t.Errorf("errs = %v; want [<nil> failed b <nil>]", errs)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/build/buildlet"
+)
+
+func leases(args []string) error {
+	fs := flag.NewFlagSet("leases", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "leases usage: gomote leases")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+	}
+
+	cc, err := buildlet.NewCoordinatorClientFromFlags()
+	if err != nil {
+		return err
+	}
+	ls, err := cc.Leases()
+	if err != nil {
+		return err
+	}
+	for _, rb := range ls.Buildlets {
+		fmt.Printf("%s\t%s\t%s\t%s\texpires in %v\n", rb.Name, rb.User, rb.BuilderType, rb.HostType, rb.Expires.Sub(time.Now()))
+	}
+	for _, lw := range ls.Waiting {
+		fmt.Printf("(waiting #%d)\t%s\t%s\t%s\tfor %v\n", lw.Position, lw.User, lw.BuilderType, lw.HostType, time.Since(lw.Since).Round(time.Second))
+	}
+	return nil
+}
+
+func revoke(args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "revoke usage: gomote revoke <instance>")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	cc, err := buildlet.NewCoordinatorClientFromFlags()
+	if err != nil {
+		return err
+	}
+	return cc.RevokeBuildlet(fs.Arg(0))
+}
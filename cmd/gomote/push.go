@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"golang.org/x/build/buildlet"
 )
@@ -28,8 +29,16 @@ func push(args []string) error {
 	fs := flag.NewFlagSet("push", flag.ContinueOnError)
 	var dryRun bool
 	fs.BoolVar(&dryRun, "dry-run", false, "print what would be done only")
+	var groupName string
+	fs.StringVar(&groupName, "group", "", "push to every instance of the named group, in parallel")
+	var watch bool
+	fs.BoolVar(&watch, "watch", false, "after pushing, keep watching $GOROOT and push changed and deleted files as they happen")
+	var interval time.Duration
+	fs.DurationVar(&interval, "interval", time.Second, "with -watch, how often to check $GOROOT for changes")
+	var runCmd string
+	fs.StringVar(&runCmd, "run", "", "with -watch, a command to run on each instance after every sync, such as 'go/bin/go test -short os'")
 	fs.Usage = func() {
-		fmt.Fprintln(os.Stderr, "create usage: gomote push <instance>")
+		fmt.Fprintln(os.Stderr, "create usage: gomote push [push-opts] {<instance>...|-group=<group>}")
 		fs.PrintDefaults()
 		os.Exit(1)
 	}
@@ -47,10 +56,39 @@ func push(args []string) error {
 		}
 	}
 
-	if fs.NArg() != 1 {
+	if groupName == "" && fs.NArg() < 1 || groupName != "" && fs.NArg() != 0 {
 		fs.Usage()
 	}
-	name := fs.Arg(0)
+	if runCmd != "" && !watch {
+		return errors.New("-run requires -watch")
+	}
+	names := fs.Args()
+	if groupName != "" {
+		var err error
+		names, err = groupInstances(groupName)
+		if err != nil {
+			return err
+		}
+	}
+	if len(names) == 1 && !watch {
+		return pushInstance(names[0], goroot, dryRun, log.New(os.Stderr, "", log.LstdFlags))
+	}
+	errs := forEachInstance(names, func(_ int, name string) error {
+		err := pushInstance(name, goroot, dryRun, log.New(os.Stderr, name+": ", log.LstdFlags))
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		return nil
+	})
+	if err := firstError(errs); err != nil || !watch || dryRun {
+		return err
+	}
+	return watchAndPush(goroot, names, interval, strings.Fields(runCmd))
+}
+
+// pushInstance syncs goroot to the named instance, logging its
+// progress to lg.
+func pushInstance(name, goroot string, dryRun bool, lg *log.Logger) error {
 	bc, conf, err := clientAndConf(name)
 	if err != nil {
 		return err
@@ -89,9 +127,9 @@ func push(args []string) error {
 
 	if !haveGo14 {
 		if u := conf.GoBootstrapURL(buildEnv); u != "" {
-			log.Printf("installing go1.4")
+			lg.Printf("installing go1.4")
 			if dryRun {
-				log.Printf("(Dry-run) Would have pushed go1.4")
+				lg.Printf("(Dry-run) Would have pushed go1.4")
 			} else {
 				if err := bc.PutTarFromURL(u, "go1.4"); err != nil {
 					return err
@@ -100,44 +138,8 @@ func push(args []string) error {
 		}
 	}
 
-	// Invoke 'git check-ignore' and use it to query whether paths have been gitignored.
-	// If anything goes wrong at any point, fall back to assuming that nothing is gitignored.
-	var isGitIgnored func(string) bool
-	gci := exec.Command("git", "check-ignore", "--stdin", "-n", "-v", "-z")
-	gci.Env = append(os.Environ(), "GIT_FLUSH=1")
-	gciIn, errIn := gci.StdinPipe()
-	defer gciIn.Close() // allow git process to exit
-	gciOut, errOut := gci.StdoutPipe()
-	errStart := gci.Start()
-	if errIn != nil || errOut != nil || errStart != nil {
-		isGitIgnored = func(string) bool { return false }
-	} else {
-		var failed bool
-		br := bufio.NewReader(gciOut)
-		isGitIgnored = func(path string) bool {
-			if failed {
-				return false
-			}
-			fmt.Fprintf(gciIn, "%s\x00", path)
-			// Response is of form "<source> <NULL> <linenum> <NULL> <pattern> <NULL> <pathname> <NULL>"
-			// Read all four and check that the path is correct.
-			// If so, the path is ignored iff the source (reason why ignored) is non-empty.
-			var resp [4][]byte
-			for i := range resp {
-				b, err := br.ReadBytes(0)
-				if err != nil {
-					failed = true
-					return false
-				}
-				resp[i] = b[:len(b)-1] // drop trailing NULL
-			}
-			// Sanity check
-			if string(resp[3]) != path {
-				panic("git check-ignore path did not roundtrip, got " + string(resp[3]) + " sent " + path)
-			}
-			return len(resp[0]) > 0
-		}
-	}
+	isGitIgnored, closeGitIgnore := gitIgnoreChecker()
+	defer closeGitIgnore()
 
 	type fileInfo struct {
 		fi   os.FileInfo
@@ -182,31 +184,7 @@ func push(args []string) error {
 
 	var toDel []string
 	for rel := range remote {
-		if rel == "VERSION" {
-			// Don't delete this. It's harmless, and
-			// necessary. Clients can overwrite it if they
-			// want. But if there's no VERSION file there,
-			// make.bash/bat assumes there's a git repo in
-			// place, but there's not only not a git repo
-			// there with gomote, but there's no git tool
-			// available either.
-			continue
-		}
-		// Also don't delete the auto-generated files from cmd/dist.
-		// Otherwise gomote users can't gomote push + gomote run make.bash
-		// and then iteratively:
-		// -- hack locally
-		// -- gomote push
-		// -- gomote run go test -v ...
-		// Because the go test would fail remotely without
-		// these files if they were deleted by gomote push.
-		switch rel {
-		case "src/cmd/cgo/zdefaultcc.go",
-			"src/cmd/go/internal/cfg/zdefaultcc.go",
-			"src/cmd/go/internal/cfg/zosarch.go",
-			"src/cmd/internal/objabi/zbootstrap.go",
-			"src/go/build/zcgo.go",
-			"src/runtime/internal/sys/zversion.go":
+		if keepRemote(rel) {
 			continue
 		}
 		if isGitIgnored(rel) {
@@ -228,9 +206,9 @@ func push(args []string) error {
 		}
 		sort.Strings(withGo)
 		if dryRun {
-			log.Printf("(Dry-run) Would have deleted remote files: %q", withGo)
+			lg.Printf("(Dry-run) Would have deleted remote files: %q", withGo)
 		} else {
-			log.Printf("Deleting remote files: %q", withGo)
+			lg.Printf("Deleting remote files: %q", withGo)
 			if err := bc.RemoveAll(withGo...); err != nil {
 				return fmt.Errorf("Deleting remote unwanted files: %v", err)
 			}
@@ -241,9 +219,7 @@ func push(args []string) error {
 	notHave := 0
 	const maxNotHavePrint = 5
 	for rel, inf := range local {
-		switch rel {
-		case "VERSION.cache", "src/runtime/internal/sys/zversion.go", "src/cmd/internal/objabi/zbootstrap.go",
-			"src/go/build/zcgo.go":
+		if neverSend(rel) {
 			continue
 		}
 		if !inf.fi.Mode().IsRegular() {
@@ -251,28 +227,28 @@ func push(args []string) error {
 			// for now, so empty directories, symlinks, etc aren't
 			// supported. revisit if that's a problem.
 			if !inf.fi.IsDir() {
-				log.Printf("Ignoring local non-regular, non-directory file %s: %v", rel, inf.fi.Mode())
+				lg.Printf("Ignoring local non-regular, non-directory file %s: %v", rel, inf.fi.Mode())
 			}
 			continue
 		}
 		rem, ok := remote[rel]
 		if !ok {
 			if notHave++; notHave <= maxNotHavePrint {
-				log.Printf("Remote doesn't have %q", rel)
+				lg.Printf("Remote doesn't have %q", rel)
 			}
 			toSend = append(toSend, rel)
 			continue
 		}
 		if rem.Digest() != inf.sha1 {
-			log.Printf("Remote's %s digest is %q; want %q", rel, rem.Digest(), inf.sha1)
+			lg.Printf("Remote's %s digest is %q; want %q", rel, rem.Digest(), inf.sha1)
 			toSend = append(toSend, rel)
 		}
 	}
 	if notHave > maxNotHavePrint {
-		log.Printf("Remote doesn't have %d files (only showed %d).", notHave, maxNotHavePrint)
+		lg.Printf("Remote doesn't have %d files (only showed %d).", notHave, maxNotHavePrint)
 	}
 	if _, hasVersion := remote["VERSION"]; !hasVersion {
-		log.Printf("Remote lacks a VERSION file; sending a fake one")
+		lg.Printf("Remote lacks a VERSION file; sending a fake one")
 		toSend = append(toSend, "VERSION")
 	}
 	if len(toSend) > 0 {
@@ -281,9 +257,9 @@ func push(args []string) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("Uploading %d new/changed files; %d byte .tar.gz", len(toSend), tgz.Len())
+		lg.Printf("Uploading %d new/changed files; %d byte .tar.gz", len(toSend), tgz.Len())
 		if dryRun {
-			log.Printf("(Dry-run mode; not doing anything.")
+			lg.Printf("(Dry-run mode; not doing anything.")
 			return nil
 		}
 		if err := bc.PutTar(tgz, "go"); err != nil {
@@ -293,6 +269,97 @@ func push(args []string) error {
 	return nil
 }
 
+// keepRemote reports whether push should leave the remote file rel
+// alone even though it doesn't exist locally.
+func keepRemote(rel string) bool {
+	if rel == "VERSION" {
+		// Don't delete this. It's harmless, and
+		// necessary. Clients can overwrite it if they
+		// want. But if there's no VERSION file there,
+		// make.bash/bat assumes there's a git repo in
+		// place, but there's not only not a git repo
+		// there with gomote, but there's no git tool
+		// available either.
+		return true
+	}
+	// Also don't delete the auto-generated files from cmd/dist.
+	// Otherwise gomote users can't gomote push + gomote run make.bash
+	// and then iteratively:
+	// -- hack locally
+	// -- gomote push
+	// -- gomote run go test -v ...
+	// Because the go test would fail remotely without
+	// these files if they were deleted by gomote push.
+	switch rel {
+	case "src/cmd/cgo/zdefaultcc.go",
+		"src/cmd/go/internal/cfg/zdefaultcc.go",
+		"src/cmd/go/internal/cfg/zosarch.go",
+		"src/cmd/internal/objabi/zbootstrap.go",
+		"src/go/build/zcgo.go",
+		"src/runtime/internal/sys/zversion.go":
+		return true
+	}
+	return false
+}
+
+// neverSend reports whether push should never send the local file
+// rel, as it's generated or specific to the local machine.
+func neverSend(rel string) bool {
+	switch rel {
+	case "VERSION.cache", "src/runtime/internal/sys/zversion.go", "src/cmd/internal/objabi/zbootstrap.go",
+		"src/go/build/zcgo.go":
+		return true
+	}
+	return false
+}
+
+// gitIgnoreChecker returns a func reporting whether a path has been
+// gitignored, and a func to release its resources. The returned
+// func is not safe for concurrent use.
+//
+// It invokes 'git check-ignore' and uses it to query whether paths
+// have been gitignored. If anything goes wrong at any point, it falls
+// back to assuming that nothing is gitignored.
+func gitIgnoreChecker() (isGitIgnored func(string) bool, close func()) {
+	gci := exec.Command("git", "check-ignore", "--stdin", "-n", "-v", "-z")
+	gci.Env = append(os.Environ(), "GIT_FLUSH=1")
+	gciIn, errIn := gci.StdinPipe()
+	gciOut, errOut := gci.StdoutPipe()
+	errStart := gci.Start()
+	if errIn != nil || errOut != nil || errStart != nil {
+		if gciIn != nil {
+			gciIn.Close()
+		}
+		return func(string) bool { return false }, func() {}
+	}
+	var failed bool
+	br := bufio.NewReader(gciOut)
+	isGitIgnored = func(path string) bool {
+		if failed {
+			return false
+		}
+		fmt.Fprintf(gciIn, "%s\x00", path)
+		// Response is of form "<source> <NULL> <linenum> <NULL> <pattern> <NULL> <pathname> <NULL>"
+		// Read all four and check that the path is correct.
+		// If so, the path is ignored iff the source (reason why ignored) is non-empty.
+		var resp [4][]byte
+		for i := range resp {
+			b, err := br.ReadBytes(0)
+			if err != nil {
+				failed = true
+				return false
+			}
+			resp[i] = b[:len(b)-1] // drop trailing NULL
+		}
+		// Sanity check
+		if string(resp[3]) != path {
+			panic("git check-ignore path did not roundtrip, got " + string(resp[3]) + " sent " + path)
+		}
+		return len(resp[0]) > 0
+	}
+	return isGitIgnored, func() { gciIn.Close() } // allow git process to exit
+}
+
 func isEditorBackup(path string) bool {
 	base := filepath.Base(path)
 	if strings.HasPrefix(base, ".") && strings.HasSuffix(base, ".swp") {
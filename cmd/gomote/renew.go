@@ -0,0 +1,40 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/build/buildlet"
+)
+
+func renew(args []string) error {
+	fs := flag.NewFlagSet("renew", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "renew usage: gomote renew [renew-opts] <instance>")
+		fs.PrintDefaults()
+		os.Exit(1)
+	}
+	var lease time.Duration
+	fs.DurationVar(&lease, "lease", 0, "new lease, starting now; zero means the coordinator's default")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+	}
+
+	cc, err := buildlet.NewCoordinatorClientFromFlags()
+	if err != nil {
+		return err
+	}
+	rb, err := cc.RenewBuildlet(fs.Arg(0), lease)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\texpires in %v\n", rb.Name, rb.Expires.Sub(time.Now()))
+	return nil
+}
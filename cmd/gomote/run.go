@@ -5,8 +5,10 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -18,7 +20,7 @@ import (
 func run(args []string) error {
 	fs := flag.NewFlagSet("run", flag.ContinueOnError)
 	fs.Usage = func() {
-		fmt.Fprintln(os.Stderr, "create usage: gomote run [run-opts] <instance> <cmd> [args...]")
+		fmt.Fprintln(os.Stderr, "create usage: gomote run [run-opts] {<instance>|-group=<group>} <cmd> [args...]")
 		fs.PrintDefaults()
 		os.Exit(1)
 	}
@@ -36,30 +38,21 @@ func run(args []string) error {
 	var builderEnv string
 	fs.StringVar(&builderEnv, "builderenv", "", "Optional alternate builder to act like. Must share the same underlying buildlet host type, or it's an error. For instance, linux-amd64-race or linux-386-387 are compatible with linux-amd64, but openbsd-amd64 and openbsd-386 are different hosts.")
 
-	fs.Parse(args)
-	if fs.NArg() < 2 {
-		fs.Usage()
-	}
-	name, cmd := fs.Arg(0), fs.Arg(1)
-
-	var conf dashboard.BuildConfig
-
-	bc, conf, err := clientAndConf(name)
-	if err != nil {
-		return err
-	}
+	var groupName string
+	fs.StringVar(&groupName, "group", "", "Run on every instance of the named group (see 'gomote group') instead of a single instance, which is then omitted from the arguments. Each instance's output is shown once all have finished.")
 
-	if builderEnv != "" {
-		altConf, ok := dashboard.Builders[builderEnv]
-		if !ok {
-			return fmt.Errorf("unknown --builderenv=%q builder value", builderEnv)
-		}
-		if altConf.HostType != conf.HostType {
-			return fmt.Errorf("--builderEnv=%q has host type %q, which is not compatible with the named buildlet's host type %q",
-				builderEnv, altConf.HostType, conf.HostType)
+	fs.Parse(args)
+	var name string
+	cmdArgs := fs.Args()
+	if groupName == "" {
+		if fs.NArg() < 2 {
+			fs.Usage()
 		}
-		conf = altConf
+		name, cmdArgs = cmdArgs[0], cmdArgs[1:]
+	} else if fs.NArg() < 1 {
+		fs.Usage()
 	}
+	cmd := cmdArgs[0]
 
 	var pathOpt []string
 	if path == "EMPTY" {
@@ -68,19 +61,52 @@ func run(args []string) error {
 		pathOpt = strings.Split(path, ",")
 	}
 
-	remoteErr, execErr := bc.Exec(cmd, buildlet.ExecOpts{
-		Dir:         dir,
-		SystemLevel: sys || strings.HasPrefix(cmd, "/"),
-		Output:      os.Stdout,
-		Args:        fs.Args()[2:],
-		ExtraEnv:    envutil.Dedup(conf.GOOS() == "windows", append(conf.Env(), []string(env)...)),
-		Debug:       debug,
-		Path:        pathOpt,
-	})
-	if execErr != nil {
-		return fmt.Errorf("Error trying to execute %s: %v", cmd, execErr)
+	runOn := func(name string, out io.Writer) error {
+		bc, conf, err := clientAndConf(name)
+		if err != nil {
+			return err
+		}
+
+		if builderEnv != "" {
+			altConf, ok := dashboard.Builders[builderEnv]
+			if !ok {
+				return fmt.Errorf("unknown --builderenv=%q builder value", builderEnv)
+			}
+			if altConf.HostType != conf.HostType {
+				return fmt.Errorf("--builderEnv=%q has host type %q, which is not compatible with the named buildlet's host type %q",
+					builderEnv, altConf.HostType, conf.HostType)
+			}
+			conf = altConf
+		}
+
+		remoteErr, execErr := bc.Exec(cmd, buildlet.ExecOpts{
+			Dir:         dir,
+			SystemLevel: sys || strings.HasPrefix(cmd, "/"),
+			Output:      out,
+			Args:        cmdArgs[1:],
+			ExtraEnv:    envutil.Dedup(conf.GOOS() == "windows", append(conf.Env(), []string(env)...)),
+			Debug:       debug,
+			Path:        pathOpt,
+		})
+		if execErr != nil {
+			return fmt.Errorf("Error trying to execute %s: %v", cmd, execErr)
+		}
+		return remoteErr
 	}
-	return remoteErr
+
+	if groupName == "" {
+		return runOn(name, os.Stdout)
+	}
+	names, err := groupInstances(groupName)
+	if err != nil {
+		return err
+	}
+	outs := make([]bytes.Buffer, len(names))
+	errs := forEachInstance(names, func(i int, name string) error {
+		return runOn(name, &outs[i])
+	})
+	printInstanceResults(names, outs, errs)
+	return firstError(errs)
 }
 
 // stringSlice implements flag.Value, specifically for storing environment
@@ -0,0 +1,186 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/build/buildlet"
+	"golang.org/x/build/dashboard"
+	"golang.org/x/build/envutil"
+)
+
+// fileStamp is what "gomote push -watch" remembers about a local
+// file to notice when it changes.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// scanGoroot returns a stamp for each regular file in goroot that
+// push would send, keyed by forward-slash relative path.
+func scanGoroot(goroot string, isGitIgnored func(string) bool) (map[string]fileStamp, error) {
+	files := make(map[string]fileStamp)
+	err := filepath.Walk(goroot, func(path string, fi os.FileInfo, err error) error {
+		if isEditorBackup(path) {
+			return nil
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil // deleted while we were walking
+			}
+			return err
+		}
+		rel := strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(path, goroot)), "/")
+		if rel == "" {
+			return nil
+		}
+		if fi.IsDir() {
+			switch rel {
+			case ".git", "pkg", "bin":
+				return filepath.SkipDir
+			}
+		}
+		if isGitIgnored(path) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if fi.Mode().IsRegular() && !neverSend(rel) {
+			files[rel] = fileStamp{fi.Size(), fi.ModTime(), fi.Mode()}
+		}
+		return nil
+	})
+	return files, err
+}
+
+// diffScans returns the files that are new or changed in cur, and
+// those in prev that no longer exist in cur, both sorted.
+func diffScans(prev, cur map[string]fileStamp) (changed, deleted []string) {
+	for rel, st := range cur {
+		if old, ok := prev[rel]; !ok || old != st {
+			changed = append(changed, rel)
+		}
+	}
+	for rel := range prev {
+		if _, ok := cur[rel]; !ok && !keepRemote(rel) {
+			deleted = append(deleted, rel)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(deleted)
+	return
+}
+
+// watchAndPush polls goroot for changes every interval and pushes
+// changed and deleted files to each of the named instances, which
+// are assumed to already be in sync. If runArgs is non-empty, it's
+// run as a command on every instance after each sync.
+// It only returns on error.
+func watchAndPush(goroot string, names []string, interval time.Duration, runArgs []string) error {
+	type inst struct {
+		bc   *buildlet.Client
+		conf dashboard.BuildConfig
+	}
+	insts := make([]inst, len(names))
+	for i, name := range names {
+		bc, conf, err := clientAndConf(name)
+		if err != nil {
+			return err
+		}
+		insts[i] = inst{bc, conf}
+	}
+
+	isGitIgnored, closeGitIgnore := gitIgnoreChecker()
+	defer closeGitIgnore()
+	prev, err := scanGoroot(goroot, isGitIgnored)
+	if err != nil {
+		return fmt.Errorf("error enumerating local GOROOT files: %v", err)
+	}
+	log.Printf("watching %s for changes; press Ctrl-C to stop", goroot)
+
+	for {
+		time.Sleep(interval)
+		cur, err := scanGoroot(goroot, isGitIgnored)
+		if err != nil {
+			return fmt.Errorf("error enumerating local GOROOT files: %v", err)
+		}
+		changed, deleted := diffScans(prev, cur)
+		if len(changed) == 0 && len(deleted) == 0 {
+			continue
+		}
+
+		var tgz []byte
+		if len(changed) > 0 {
+			buf, err := generateDeltaTgz(goroot, changed)
+			if err != nil {
+				// Most likely a file went away mid-edit;
+				// try again on the next pass.
+				log.Printf("error building tarball of changed files: %v", err)
+				continue
+			}
+			tgz = buf.Bytes()
+		}
+		withGo := make([]string, len(deleted))
+		for i, rel := range deleted {
+			withGo[i] = "go/" + rel
+		}
+		log.Printf("syncing %d changed and %d deleted files", len(changed), len(deleted))
+
+		errs := forEachInstance(names, func(i int, name string) error {
+			if len(withGo) > 0 {
+				if err := insts[i].bc.RemoveAll(withGo...); err != nil {
+					return fmt.Errorf("deleting remote files: %v", err)
+				}
+			}
+			if tgz != nil {
+				if err := insts[i].bc.PutTar(bytes.NewReader(tgz), "go"); err != nil {
+					return fmt.Errorf("writing tarball to buildlet: %v", err)
+				}
+			}
+			return nil
+		})
+		for i, err := range errs {
+			if err != nil {
+				log.Printf("%s: %v", names[i], err)
+			}
+		}
+		if err := firstError(errs); err != nil {
+			// Leave prev alone so the next pass retries
+			// everything that failed.
+			continue
+		}
+		prev = cur
+
+		if len(runArgs) == 0 {
+			continue
+		}
+		cmd := runArgs[0]
+		outs := make([]bytes.Buffer, len(names))
+		errs = forEachInstance(names, func(i int, name string) error {
+			conf := insts[i].conf
+			remoteErr, execErr := insts[i].bc.Exec(cmd, buildlet.ExecOpts{
+				SystemLevel: strings.HasPrefix(cmd, "/"),
+				Output:      &outs[i],
+				Args:        runArgs[1:],
+				ExtraEnv:    envutil.Dedup(conf.GOOS() == "windows", conf.Env()),
+			})
+			if execErr != nil {
+				return fmt.Errorf("Error trying to execute %s: %v", cmd, execErr)
+			}
+			return remoteErr
+		})
+		printInstanceResults(names, outs, errs)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDiffScans(t *testing.T) {
+	t0 := time.Unix(1e9, 0)
+	stamp := func(size int64, sec int) fileStamp {
+		return fileStamp{size, t0.Add(time.Duration(sec) * time.Second), 0644}
+	}
+	tests := []struct {
+		name             string
+		prev, cur        map[string]fileStamp
+		changed, deleted []string
+	}{
+		{
+			name: "unchanged",
+			prev: map[string]fileStamp{"a": stamp(1, 0)},
+			cur:  map[string]fileStamp{"a": stamp(1, 0)},
+		},
+		{
+			name:    "added and modified",
+			prev:    map[string]fileStamp{"b": stamp(1, 0), "c": stamp(1, 0)},
+			cur:     map[string]fileStamp{"a": stamp(1, 0), "b": stamp(1, 1), "c": stamp(2, 0)},
+			changed: []string{"a", "b", "c"},
+		},
+		{
+			name:    "mode change",
+			prev:    map[string]fileStamp{"run.bash": stamp(1, 0)},
+			cur:     map[string]fileStamp{"run.bash": {1, t0, 0755}},
+			changed: []string{"run.bash"},
+		},
+		{
+			name: "deleted",
+			prev: map[string]fileStamp{
+				"src/x.go":                           stamp(1, 0),
+				"src/a.go":                           stamp(1, 0),
+				"VERSION":                            stamp(1, 0),
+				"src/cmd/go/internal/cfg/zosarch.go": stamp(1, 0),
+			},
+			cur:     map[string]fileStamp{},
+			deleted: []string{"src/a.go", "src/x.go"},
+		},
+	}
+	for _, tt := range tests {
+		changed, deleted := diffScans(tt.prev, tt.cur)
+		if !reflect.DeepEqual(changed, tt.changed) || !reflect.DeepEqual(deleted, tt.deleted) {
+			t.Errorf("%s: diffScans = %q, %q; want %q, %q", tt.name, changed, deleted, tt.changed, tt.deleted)
+		}
+	}
+}
+
+func TestKeepRemoteAndNeverSend(t *testing.T) {
+	tests := []struct {
+		rel                   string
+		keepRemote, neverSend bool
+	}{
+		{"src/net/http/server.go", false, false},
+		{"VERSION", true, false},
+		{"VERSION.cache", false, true},
+		{"src/go/build/zcgo.go", true, true},
+		{"src/cmd/go/internal/cfg/zdefaultcc.go", true, false},
+		{"src/runtime/internal/sys/zversion.go", true, true},
+	}
+	for _, tt := range tests {
+		if got := keepRemote(tt.rel); got != tt.keepRemote {
+			t.Errorf("keepRemote(%q) = %v; want %v", tt.rel, got, tt.keepRemote)
+		}
+		if got := neverSend(tt.rel); got != tt.neverSend {
+			t.Errorf("neverSend(%q) = %v; want %v", tt.rel, got, tt.neverSend)
+		}
+	}
+}
+
+func TestScanGoroot(t *testing.T) {
+	goroot, err := ioutil.TempDir("", "gomote-goroot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(goroot)
+	for _, rel := range []string{
+		"src/a.go",
+		"src/ignored/b.go",
+		"src/.a.go.swp",
+		"src/a.go~",
+		"pkg/linux_amd64/x.a",
+		".git/HEAD",
+		"VERSION.cache",
+		"README",
+	} {
+		path := filepath.Join(goroot, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(rel), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	isGitIgnored := func(path string) bool {
+		return path == filepath.Join(goroot, "src", "ignored")
+	}
+	files, err := scanGoroot(goroot, isGitIgnored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for rel, st := range files {
+		if st.size != int64(len(rel)) {
+			t.Errorf("%s: size %d; want %d", rel, st.size, len(rel))
+		}
+		got = append(got, rel)
+	}
+	sort.Strings(got)
+	if want := []string{"README", "src/a.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("scanned files = %q; want %q", got, want)
+	}
+}
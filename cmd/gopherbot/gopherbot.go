@@ -423,7 +423,7 @@ func (b *gopherbot) addGitHubComment(ctx context.Context, org, repo string, issu
 			since = c.Updated
 			// TODO: check for gopherbot as author? check for exact match?
 			// This seems fine for now.
-			if strings.Contains(c.Body, msg) {
+			if strings.Contains(c.GetBody(), msg) {
 				dup = true
 				return errStopIteration
 			}
@@ -892,7 +892,7 @@ func (b *gopherbot) cl2issue(ctx context.Context) error {
 				hasComment := false
 				substr := fmt.Sprintf("%d mentions this issue", cl.Number)
 				gi.ForeachComment(func(c *maintner.GitHubComment) error {
-					if strings.Contains(c.Body, substr) {
+					if strings.Contains(c.GetBody(), substr) {
 						hasComment = true
 						return errStopIteration
 					}
@@ -1073,7 +1073,7 @@ func (b *gopherbot) congratulateNewContributors(ctx context.Context) error {
 			// matches as well.
 			for j := range congratulatoryMessages {
 				// Message looks something like "Patch set X:\n\n(our text)"
-				if strings.Contains(cl.Messages[i].Message, congratulatoryMessages[j]) {
+				if strings.Contains(cl.Messages[i].GetMessage(), congratulatoryMessages[j]) {
 					foundMessage = true
 					break
 				}
@@ -1255,11 +1255,11 @@ func (b *gopherbot) openCherryPickIssues(ctx context.Context) error {
 		}
 		var backportComment *maintner.GitHubComment
 		if err := gi.ForeachComment(func(c *maintner.GitHubComment) error {
-			if strings.HasPrefix(c.Body, "Backport issue(s) opened") {
+			if strings.HasPrefix(c.GetBody(), "Backport issue(s) opened") {
 				backportComment = nil
 				return errStopIteration
 			}
-			body := strings.ToLower(c.Body)
+			body := strings.ToLower(c.GetBody())
 			if strings.Contains(body, "@gopherbot") &&
 				strings.Contains(body, "please") &&
 				strings.Contains(body, "backport") {
@@ -1278,7 +1278,7 @@ func (b *gopherbot) openCherryPickIssues(ctx context.Context) error {
 		}
 		var selectedReleases []string
 		for _, r := range majorReleases {
-			if strings.Contains(backportComment.Body, r) {
+			if strings.Contains(backportComment.GetBody(), r) {
 				selectedReleases = append(selectedReleases, r)
 			}
 		}
@@ -1293,7 +1293,7 @@ func (b *gopherbot) openCherryPickIssues(ctx context.Context) error {
 			id, err := b.createGitHubIssue(ctx,
 				fmt.Sprintf("%s [%s backport]", gi.Title, rel),
 				fmt.Sprintf("@%s requested issue #%d to be considered for backport to the next %s minor release.\n\n%s\n",
-					backportComment.User.Login, gi.Number, rel, blockqoute(backportComment.Body)),
+					backportComment.User.Login, gi.Number, rel, blockqoute(backportComment.GetBody())),
 				[]string{"CherryPickCandidate"}, backportComment.Created)
 			if err != nil {
 				return err
@@ -1438,9 +1438,9 @@ func (b *gopherbot) applyLabelsFromComments(ctx context.Context) error {
 
 		var cmds []labelCommand
 
-		cmds = append(cmds, labelCommandsFromBody(gi.Body, gi.Created)...)
+		cmds = append(cmds, labelCommandsFromBody(gi.GetBody(), gi.Created)...)
 		gi.ForeachComment(func(gc *maintner.GitHubComment) error {
-			cmds = append(cmds, labelCommandsFromBody(gc.Body, gc.Created)...)
+			cmds = append(cmds, labelCommandsFromBody(gc.GetBody(), gc.Created)...)
 			return nil
 		})
 
@@ -1641,7 +1641,7 @@ func (b *gopherbot) assignReviewersToCLs(ctx context.Context) error {
 			if b.deletedChanges[gc] {
 				return nil
 			}
-			if strutil.ContainsFold(cl.Commit.Msg, "do not submit") || strutil.ContainsFold(cl.Commit.Msg, "do not review") {
+			if strutil.ContainsFold(cl.Commit.GetMsg(), "do not submit") || strutil.ContainsFold(cl.Commit.GetMsg(), "do not review") {
 				return nil
 			}
 
@@ -1749,11 +1749,12 @@ func humanReviewersInMetas(metas []*maintner.GerritMeta) bool {
 		hasHuman bool
 	)
 	for _, m := range metas {
-		if !strings.Contains(m.Commit.Msg, "Reviewer:") && !strings.Contains(m.Commit.Msg, "CC:") {
+		msg := m.Commit.GetMsg()
+		if !strings.Contains(msg, "Reviewer:") && !strings.Contains(msg, "CC:") {
 			continue
 		}
 
-		err := maintner.ForeachLineStr(m.Commit.Msg, func(ln string) error {
+		err := maintner.ForeachLineStr(msg, func(ln string) error {
 			if !strings.HasPrefix(ln, "Reviewer:") && !strings.HasPrefix(ln, "CC:") {
 				return nil
 			}
@@ -892,7 +892,7 @@ func (sc *statsClient) workshopStats() {
 
 	// Get all the contributors from comments on the issue.
 	sc.corpus().GitHub().Repo("golang", "go").Issue(workshopIssue).ForeachComment(func(c *maintner.GitHubComment) error {
-		contributors[strings.TrimSpace(c.Body)] = c.User.Login
+		contributors[strings.TrimSpace(c.GetBody())] = c.User.Login
 		return nil
 	})
 	fmt.Printf("Number of registrations: %d\n", len(contributors))
@@ -1059,7 +1059,7 @@ func (sc *statsClient) rangeStats() {
 					if dirTouched[who] == nil {
 						dirTouched[who] = map[projectFile]bool{}
 					}
-					for _, diff := range cl.Commit.Files {
+					for _, diff := range cl.Commit.GetFiles() {
 						if strings.Contains(diff.File, "vendor/") {
 							continue
 						}
@@ -144,7 +144,7 @@ func main() {
 // clSubject returns the first line of the CL's commit message,
 // without the trailing newline.
 func clSubject(cl *maintner.GerritCL) string {
-	subj := cl.Commit.Msg
+	subj := cl.Commit.GetMsg()
 	if i := strings.Index(subj, "\n"); i != -1 {
 		return subj[:i]
 	}
@@ -171,13 +171,13 @@ func parseRelNote(s string) string {
 }
 
 func clRelNote(cl *maintner.GerritCL) string {
-	msg := cl.Commit.Msg
+	msg := cl.Commit.GetMsg()
 	if strings.Contains(msg, "RELNOTE") {
 		return parseRelNote(msg)
 	}
 	for _, comment := range cl.Messages {
-		if strings.Contains(comment.Message, "RELNOTE") {
-			return parseRelNote(comment.Message)
+		if strings.Contains(comment.GetMessage(), "RELNOTE") {
+			return parseRelNote(comment.GetMessage())
 		}
 	}
 	return ""
@@ -93,9 +93,9 @@ func (s *server) updateActivities() {
 		if !c.Created.After(latest) {
 			return nil
 		}
-		id, ok := intFromStr(c.Body)
+		id, ok := intFromStr(c.GetBody())
 		if !ok {
-			return fmt.Errorf("intFromStr(%q) = %v", c.Body, ok)
+			return fmt.Errorf("intFromStr(%q) = %v", c.GetBody(), ok)
 		}
 		s.userMapping[id] = c.User
 
@@ -172,7 +172,7 @@ func (s *server) updateReleaseData() {
 				if closed && closedVersion < m.Version {
 					closed = false
 				}
-				sm := annotationRE.FindStringSubmatch(m.Message)
+				sm := annotationRE.FindStringSubmatch(m.GetMessage())
 				if sm == nil {
 					continue
 				}
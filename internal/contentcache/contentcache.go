@@ -0,0 +1,381 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package contentcache implements a size-bounded, content-addressed
+// cache of blobs on local disk, such as source and snapshot tarballs.
+//
+// Blobs are looked up by a caller-chosen key (for instance
+// "source/go/<rev>") but stored by the SHA-256 of their contents, so
+// different keys naming identical content share one file. Concurrent
+// misses for the same key are coalesced into a single fetch, and the
+// least recently used keys are evicted to stay under the size limit.
+package contentcache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/build/internal/singleflight"
+)
+
+// Cache is a disk-backed content cache, safe for concurrent use.
+type Cache struct {
+	dir      string
+	maxBytes int64
+
+	group singleflight.Group
+
+	mu    sync.Mutex
+	ll    *list.List               // of *entry, most recently used first
+	keys  map[string]*list.Element // key => element in ll
+	blobs map[string]*blob         // digest => blob
+	size  int64                    // bytes of all blobs
+	stats Stats
+}
+
+// entry is a key and the blob it names.
+type entry struct {
+	key      string
+	blob     *blob
+	added    time.Time
+	lastUsed time.Time
+	hits     int64
+}
+
+// blob is a file in the cache directory, named by its digest.
+type blob struct {
+	digest string
+	size   int64
+	refs   int // number of keys naming it
+}
+
+// Stats are counters of a Cache's activity since it was created.
+type Stats struct {
+	Hits         int64
+	Misses       int64 // includes fetches that failed
+	FetchErrors  int64
+	Evictions    int64 // keys evicted
+	EvictedBytes int64 // bytes of blobs deleted by eviction
+	Uncacheable  int64 // fetches too big to cache
+	Entries      int   // current number of keys
+	Bytes        int64 // current bytes on disk
+}
+
+// Entry describes a cached key, for debugging.
+type Entry struct {
+	Key      string
+	Digest   string // hex SHA-256 of the content
+	Size     int64
+	Added    time.Time
+	LastUsed time.Time
+	Hits     int64
+}
+
+// New returns a cache storing at most maxBytes of content in dir,
+// which is created if needed. Files a cache left in dir earlier are
+// removed; any other files are left alone.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if maxBytes <= 0 {
+		return nil, errors.New("contentcache: non-positive size limit")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		if fi.Mode().IsRegular() && isCacheFile(fi.Name()) {
+			if err := os.Remove(filepath.Join(dir, fi.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		keys:     make(map[string]*list.Element),
+		blobs:    make(map[string]*blob),
+	}, nil
+}
+
+// tempPrefix is the prefix of the names of files being fetched.
+const tempPrefix = "fetch-"
+
+// isCacheFile reports whether name is the name of a file a Cache
+// creates: a blob, named by its digest, or a file being fetched.
+func isCacheFile(name string) bool {
+	if strings.HasPrefix(name, tempPrefix) {
+		return true
+	}
+	if len(name) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cache) blobPath(digest string) string {
+	return filepath.Join(c.dir, digest)
+}
+
+// Get returns a reader of the content for key. If key isn't cached,
+// Get calls fetch to write the content to w, caches it, and returns
+// it; concurrent Gets of the same missing key share one call to
+// fetch. The caller must close the returned reader.
+//
+// Content bigger than the whole cache is returned but not kept.
+func (c *Cache) Get(key string, fetch func(w io.Writer) error) (io.ReadCloser, error) {
+	if rc, ok := c.open(key, true); ok {
+		return rc, nil
+	}
+	var (
+		fetched bool          // whether this call ran fetch
+		big     io.ReadCloser // content too big to cache, if fetched
+	)
+	_, err, _ := c.group.Do(key, func() (interface{}, error) {
+		fetched = true
+		// Somebody may have finished fetching it while we
+		// were waiting to get here.
+		if c.contains(key) {
+			return nil, nil
+		}
+		c.mu.Lock()
+		c.stats.Misses++
+		c.mu.Unlock()
+		var err error
+		big, err = c.fetch(key, fetch)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if big != nil {
+		return big, nil
+	}
+	if rc, ok := c.open(key, !fetched); ok {
+		return rc, nil
+	}
+	// It was too big to cache, or was evicted already; we need
+	// our own copy.
+	tmp, _, err := c.fetchTemp(fetch)
+	if err != nil {
+		return nil, err
+	}
+	return &removeOnClose{tmp}, nil
+}
+
+// contains reports whether key is cached.
+func (c *Cache) contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.keys[key]
+	return ok
+}
+
+// open returns a reader of key's content if it's cached. If
+// countHit, it counts as a cache hit.
+func (c *Cache) open(key string, countHit bool) (io.ReadCloser, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.keys[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	f, err := os.Open(c.blobPath(e.blob.digest))
+	if err != nil {
+		// Somebody removed it from under us. Forget it.
+		c.removeElementLocked(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	e.lastUsed = time.Now()
+	if countHit {
+		e.hits++
+		c.stats.Hits++
+	}
+	return f, true
+}
+
+// fetchTemp calls fn to write to a new temporary file in the cache
+// directory and returns the file, positioned at its start, and the
+// SHA-256 of its contents.
+func (c *Cache) fetchTemp(fn func(w io.Writer) error) (f *os.File, digest string, err error) {
+	tmp, err := ioutil.TempFile(c.dir, tempPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+	h := sha256.New()
+	err = fn(io.MultiWriter(tmp, h))
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		c.mu.Lock()
+		c.stats.FetchErrors++
+		c.mu.Unlock()
+		return nil, "", err
+	}
+	return tmp, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fetch calls fn to fetch key and stores its content. If the content
+// is too big to cache, it returns a reader of it instead.
+func (c *Cache) fetch(key string, fn func(w io.Writer) error) (big io.ReadCloser, err error) {
+	tmp, digest, err := c.fetchTemp(fn)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := tmp.Stat()
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	size := fi.Size()
+	if size > c.maxBytes {
+		c.mu.Lock()
+		c.stats.Uncacheable++
+		c.mu.Unlock()
+		return &removeOnClose{tmp}, nil
+	}
+	tmp.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.blobs[digest]
+	if ok {
+		// Same content under another key; share it.
+		os.Remove(tmp.Name())
+	} else {
+		if err := os.Rename(tmp.Name(), c.blobPath(digest)); err != nil {
+			os.Remove(tmp.Name())
+			return nil, err
+		}
+		b = &blob{digest: digest, size: size}
+		c.blobs[digest] = b
+		c.size += size
+	}
+	b.refs++
+	now := time.Now()
+	c.keys[key] = c.ll.PushFront(&entry{key: key, blob: b, added: now, lastUsed: now})
+	c.evictLocked()
+	return nil, nil
+}
+
+// evictLocked removes least recently used keys until the cache fits
+// in its size limit. Readers of evicted blobs keep working, since
+// their files are already open.
+func (c *Cache) evictLocked() {
+	for c.size > c.maxBytes {
+		el := c.ll.Back()
+		if el == nil {
+			return
+		}
+		c.stats.Evictions++
+		if e := el.Value.(*entry); e.blob.refs == 1 {
+			c.stats.EvictedBytes += e.blob.size
+		}
+		c.removeElementLocked(el)
+	}
+}
+
+func (c *Cache) removeElementLocked(el *list.Element) {
+	e := el.Value.(*entry)
+	c.ll.Remove(el)
+	delete(c.keys, e.key)
+	e.blob.refs--
+	if e.blob.refs == 0 {
+		delete(c.blobs, e.blob.digest)
+		c.size -= e.blob.size
+		os.Remove(c.blobPath(e.blob.digest))
+	}
+}
+
+// Stats returns the cache's counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.Entries = len(c.keys)
+	st.Bytes = c.size
+	return st
+}
+
+// Entries returns the cached keys, most recently used first.
+func (c *Cache) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ents := make([]Entry, 0, c.ll.Len())
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*entry)
+		ents = append(ents, Entry{
+			Key:      e.key,
+			Digest:   e.blob.digest,
+			Size:     e.blob.size,
+			Added:    e.added,
+			LastUsed: e.lastUsed,
+			Hits:     e.hits,
+		})
+	}
+	return ents
+}
+
+// Remove removes key from the cache, if present.
+func (c *Cache) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.keys[key]; ok {
+		c.removeElementLocked(el)
+	}
+}
+
+// String returns a short summary of the cache, like
+// "12 entries, 345.6 MB of 20.0 GB".
+func (c *Cache) String() string {
+	st := c.Stats()
+	return fmt.Sprintf("%d entries, %s of %s", st.Entries, humanBytes(st.Bytes), humanBytes(c.maxBytes))
+}
+
+func humanBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
+
+// removeOnClose is a temporary file that's deleted when closed.
+type removeOnClose struct {
+	*os.File
+}
+
+func (f *removeOnClose) Close() error {
+	err := f.File.Close()
+	os.Remove(f.File.Name())
+	return err
+}
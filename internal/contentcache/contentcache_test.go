@@ -0,0 +1,206 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package contentcache
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func newTestCache(t *testing.T, maxBytes int64) (*Cache, func()) {
+	dir, err := ioutil.TempDir("", "contentcache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(filepath.Join(dir, "cache"), maxBytes)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return c, func() { os.RemoveAll(dir) }
+}
+
+func fetchString(s string, calls *int32) func(io.Writer) error {
+	return func(w io.Writer) error {
+		atomic.AddInt32(calls, 1)
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+func readAll(t *testing.T, rc io.ReadCloser, err error) string {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	b, err := ioutil.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestGetCachesAndDedups(t *testing.T) {
+	c, cleanup := newTestCache(t, 1<<20)
+	defer cleanup()
+
+	var calls int32
+	for i := 0; i < 3; i++ {
+		rc, err := c.Get("source/go/abc", fetchString("tarball", &calls))
+		if got := readAll(t, rc, err); got != "tarball" {
+			t.Fatalf("Get = %q; want tarball", got)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times; want 1", calls)
+	}
+
+	// The same content under another key shares the blob.
+	rc, err := c.Get("source/go/abc-again", fetchString("tarball", &calls))
+	readAll(t, rc, err)
+	st := c.Stats()
+	if st.Entries != 2 || st.Bytes != int64(len("tarball")) {
+		t.Errorf("Stats = %+v; want 2 entries sharing %d bytes", st, len("tarball"))
+	}
+	if st.Hits != 2 || st.Misses != 2 {
+		t.Errorf("hits, misses = %d, %d; want 2, 2", st.Hits, st.Misses)
+	}
+	ents := c.Entries()
+	if len(ents) != 2 || ents[0].Key != "source/go/abc-again" || ents[0].Digest != ents[1].Digest {
+		t.Errorf("Entries = %+v", ents)
+	}
+}
+
+func TestGetSingleflight(t *testing.T) {
+	c, cleanup := newTestCache(t, 1<<20)
+	defer cleanup()
+
+	var calls int32
+	release := make(chan bool)
+	fetch := func(w io.Writer) error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		_, err := io.WriteString(w, "snapshot")
+		return err
+	}
+	var wg sync.WaitGroup
+	errc := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rc, err := c.Get("snapshot/x", fetch)
+			if err != nil {
+				errc <- err
+				return
+			}
+			defer rc.Close()
+			b, err := ioutil.ReadAll(rc)
+			if err == nil && string(b) != "snapshot" {
+				err = errors.New("got " + string(b))
+			}
+			errc <- err
+		}()
+	}
+	close(release)
+	wg.Wait()
+	close(errc)
+	for err := range errc {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times; want 1", calls)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	c, cleanup := newTestCache(t, 10)
+	defer cleanup()
+
+	var calls int32
+	get := func(key, content string) string {
+		rc, err := c.Get(key, fetchString(content, &calls))
+		return readAll(t, rc, err)
+	}
+	get("a", "aaaa")
+	get("b", "bbbb")
+	get("a", "aaaa") // a is now the most recently used
+	get("c", "cccc") // evicts b
+
+	keys := make([]string, 0)
+	for _, e := range c.Entries() {
+		keys = append(keys, e.Key)
+	}
+	if got := strings.Join(keys, ","); got != "c,a" {
+		t.Errorf("keys = %s; want c,a", got)
+	}
+	st := c.Stats()
+	if st.Evictions != 1 || st.EvictedBytes != 4 || st.Bytes != 8 {
+		t.Errorf("Stats = %+v; want 1 eviction of 4 bytes, 8 bytes left", st)
+	}
+
+	// Too big to cache: returned, but not kept.
+	if got := get("huge", "0123456789abc"); got != "0123456789abc" {
+		t.Errorf("huge = %q", got)
+	}
+	if st := c.Stats(); st.Uncacheable != 1 || st.Entries != 2 {
+		t.Errorf("after huge fetch, Stats = %+v", st)
+	}
+}
+
+func TestFetchError(t *testing.T) {
+	c, cleanup := newTestCache(t, 1<<20)
+	defer cleanup()
+
+	boom := errors.New("boom")
+	if _, err := c.Get("k", func(io.Writer) error { return boom }); err != boom {
+		t.Fatalf("Get = %v; want boom", err)
+	}
+	if st := c.Stats(); st.FetchErrors != 1 || st.Entries != 0 {
+		t.Errorf("Stats = %+v", st)
+	}
+	files, _ := ioutil.ReadDir(c.dir)
+	if len(files) != 0 {
+		t.Errorf("cache dir has %d files after failed fetch; want 0", len(files))
+	}
+}
+
+func TestNewKeepsOtherFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "contentcache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	blob := strings.Repeat("ab", 32)
+	for _, name := range []string{blob, "fetch-123", "notes.txt", strings.Repeat("AB", 32)} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := New(dir, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		blob:                     false,
+		"fetch-123":              false,
+		"notes.txt":              true,
+		strings.Repeat("AB", 32): true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("after New, %s exists = %v; want %v", name, exists, want)
+		}
+	}
+}
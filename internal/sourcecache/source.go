@@ -20,6 +20,7 @@ import (
 	"time"
 
 	"golang.org/x/build/cmd/coordinator/spanlog"
+	"golang.org/x/build/internal/contentcache"
 	"golang.org/x/build/internal/lru"
 	"golang.org/x/build/internal/singleflight"
 )
@@ -42,22 +43,28 @@ func GetSourceTgz(sl spanlog.Logger, repo, rev string) (tgz io.Reader, err error
 		if tgzBytes, ok := sourceCache.Get(key); ok {
 			return tgzBytes, nil
 		}
-
-		if gitMirrorClient != nil {
-			sp := sl.CreateSpan("get_source_from_gitmirror")
-			tgzBytes, err := getSourceTgzFromGitMirror(repo, rev)
+		if diskCache == nil {
+			tgzBytes, err := fetchSourceTgz(sl, repo, rev)
 			if err == nil {
 				sourceCache.Add(key, tgzBytes)
-				sp.Done(nil)
-				return tgzBytes, nil
 			}
-			log.Printf("Error fetching source %s/%s from watcher (after %v uptime): %v",
-				repo, rev, time.Since(processStartTime), err)
-			sp.Done(errors.New("timeout"))
+			return tgzBytes, err
 		}
 
-		sp := sl.CreateSpan("get_source_from_gerrit", fmt.Sprintf("%v from gerrit", key))
-		tgzBytes, err := getSourceTgzFromGerrit(repo, rev)
+		sp := sl.CreateSpan("get_source_from_disk_cache", key)
+		rc, err := diskCache.Get("source/"+key, func(w io.Writer) error {
+			tgzBytes, err := fetchSourceTgz(sl, repo, rev)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(tgzBytes)
+			return err
+		})
+		if err != nil {
+			return nil, sp.Done(err)
+		}
+		defer rc.Close()
+		tgzBytes, err := ioutil.ReadAll(rc)
 		sp.Done(err)
 		if err == nil {
 			sourceCache.Add(key, tgzBytes)
@@ -70,6 +77,39 @@ func GetSourceTgz(sl spanlog.Logger, repo, rev string) (tgz io.Reader, err error
 	return bytes.NewReader(vi.([]byte)), nil
 }
 
+// fetchSourceTgz fetches a tgz of repo at rev from gitmirror, if
+// registered, or else Gerrit.
+func fetchSourceTgz(sl spanlog.Logger, repo, rev string) ([]byte, error) {
+	key := fmt.Sprintf("%v-%v", repo, rev)
+	if gitMirrorClient != nil {
+		sp := sl.CreateSpan("get_source_from_gitmirror")
+		tgzBytes, err := getSourceTgzFromGitMirror(repo, rev)
+		if err == nil {
+			sp.Done(nil)
+			return tgzBytes, nil
+		}
+		log.Printf("Error fetching source %s/%s from watcher (after %v uptime): %v",
+			repo, rev, time.Since(processStartTime), err)
+		sp.Done(errors.New("timeout"))
+	}
+
+	sp := sl.CreateSpan("get_source_from_gerrit", fmt.Sprintf("%v from gerrit", key))
+	tgzBytes, err := getSourceTgzFromGerrit(repo, rev)
+	sp.Done(err)
+	return tgzBytes, err
+}
+
+// diskCache, if non-nil, backs the in-memory sourceCache.
+var diskCache *contentcache.Cache
+
+// SetDiskCache makes GetSourceTgz keep source tarballs in c, in
+// addition to its small in-memory cache, so they survive eviction
+// from memory. If used, this function must be called before
+// GetSourceTgz.
+func SetDiskCache(c *contentcache.Cache) {
+	diskCache = c
+}
+
 var gitMirrorClient *http.Client
 
 // RegisterGitMirrorDial registers a dial function which will be used to reach gitmirror.
@@ -0,0 +1,203 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+// Point-in-time ("as of") views of issues and CLs.
+//
+// GitHub issue state is reconstructed by starting from the issue's
+// current state and undoing, newest first, the events that happened
+// after the requested time. Gerrit CL state is recomputed from the
+// NoteDb meta commits made up to the requested time.
+//
+// For the state of the whole corpus at a position in the mutation
+// log, see Corpus.InitializeAsOf.
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// GitHubIssueState is the state of a GitHub issue at a point in time.
+// See GitHubIssue.AsOf.
+type GitHubIssueState struct {
+	Issue *GitHubIssue
+	Time  time.Time
+
+	// Exists is whether the issue had been created by Time.
+	// If false, the rest of the fields should be ignored.
+	Exists bool
+
+	Title     string
+	Closed    bool
+	Milestone string        // title of the milestone, or "" for none
+	Labels    []string      // sorted label names
+	Assignees []*GitHubUser // sorted by ID
+}
+
+// HasLabel reports whether the issue was labeled with the given label.
+func (s *GitHubIssueState) HasLabel(label string) bool {
+	i := sort.SearchStrings(s.Labels, label)
+	return i < len(s.Labels) && s.Labels[i] == label
+}
+
+// AsOf returns the state of the issue's title, open/closed status,
+// milestone, labels and assignees at time t.
+//
+// The state is reconstructed from the issue's events, so it's only
+// accurate for changes whose events have been synced from GitHub.
+func (gi *GitHubIssue) AsOf(t time.Time) *GitHubIssueState {
+	s := &GitHubIssueState{
+		Issue:     gi,
+		Time:      t,
+		Exists:    !gi.NotExist && !gi.Created.After(t),
+		Title:     gi.Title,
+		Closed:    gi.Closed,
+		Assignees: append([]*GitHubUser(nil), gi.Assignees...),
+	}
+	if !s.Exists {
+		return s
+	}
+	if !gi.Milestone.IsNone() && !gi.Milestone.IsUnknown() {
+		s.Milestone = gi.Milestone.Title
+	}
+	labels := map[string]bool{}
+	for _, lb := range gi.Labels {
+		labels[lb.Name] = true
+	}
+
+	var later []*GitHubIssueEvent
+	gi.ForeachEvent(func(e *GitHubIssueEvent) error {
+		if e.Created.After(t) {
+			later = append(later, e)
+		}
+		return nil
+	})
+	for i := len(later) - 1; i >= 0; i-- {
+		switch e := later[i]; e.Type {
+		case "labeled":
+			delete(labels, e.Label)
+		case "unlabeled":
+			labels[e.Label] = true
+		case "milestoned":
+			if s.Milestone == e.Milestone {
+				s.Milestone = ""
+			}
+		case "demilestoned":
+			s.Milestone = e.Milestone
+		case "assigned":
+			s.Assignees = removeGitHubUser(s.Assignees, e.Assignee)
+		case "unassigned":
+			if e.Assignee != nil {
+				s.Assignees = append(removeGitHubUser(s.Assignees, e.Assignee), e.Assignee)
+			}
+		case "closed":
+			s.Closed = false
+		case "reopened":
+			s.Closed = true
+		case "renamed":
+			s.Title = e.From
+		}
+	}
+	// Even if its events haven't been synced, an issue
+	// wasn't closed before it was last closed.
+	if !gi.ClosedAt.IsZero() && gi.ClosedAt.After(t) && !gi.hasEventAfter("closed", t) {
+		s.Closed = false
+	}
+
+	for name := range labels {
+		s.Labels = append(s.Labels, name)
+	}
+	sort.Strings(s.Labels)
+	sort.Slice(s.Assignees, func(i, j int) bool { return s.Assignees[i].ID < s.Assignees[j].ID })
+	return s
+}
+
+// hasEventAfter reports whether gi has an event of the given type
+// after t.
+func (gi *GitHubIssue) hasEventAfter(eventType string, t time.Time) bool {
+	for _, e := range gi.events {
+		if e.Type == eventType && e.Created.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeGitHubUser returns users without u, compared by ID.
+func removeGitHubUser(users []*GitHubUser, u *GitHubUser) []*GitHubUser {
+	if u == nil {
+		return users
+	}
+	for i, v := range users {
+		if v.ID == u.ID {
+			return append(users[:i:i], users[i+1:]...)
+		}
+	}
+	return users
+}
+
+// GerritCLState is the state of a Gerrit CL at a point in time.
+// See GerritCL.AsOf.
+type GerritCLState struct {
+	CL   *GerritCL
+	Time time.Time
+
+	// Meta is the most recent meta commit made by Time,
+	// or nil if the CL hadn't been created by then.
+	Meta *GerritMeta
+
+	// Status is "merged", "abandoned", "new" or "draft",
+	// or "" if Meta is nil.
+	Status string
+
+	// Version is the latest patch set uploaded by Time,
+	// and Commit its git commit, if known.
+	Version int32
+	Commit  *GitCommit
+
+	Hashtags GerritHashtags
+
+	// Votes maps from label name to voter email to their vote,
+	// as returned by GerritMeta.LabelVotes.
+	Votes map[string]map[string]int8
+}
+
+// Exists reports whether the CL had been created by s.Time.
+func (s *GerritCLState) Exists() bool { return s.Meta != nil }
+
+// AsOf returns the state of the CL at time t, as recorded by the meta
+// commits Gerrit made up to then.
+func (cl *GerritCL) AsOf(t time.Time) *GerritCLState {
+	s := &GerritCLState{CL: cl, Time: t}
+	n := sort.Search(len(cl.Metas), func(i int) bool {
+		return cl.Metas[i].Commit.CommitTime.After(t)
+	})
+	if n == 0 {
+		return s
+	}
+	metas := cl.Metas[:n]
+	s.Meta = metas[n-1]
+	for i := n - 1; i >= 0 && s.Status == ""; i-- {
+		s.Status = getGerritStatus(metas[i].Commit)
+	}
+	if s.Status == "" {
+		s.Status = "new"
+	}
+	for _, m := range metas {
+		footer := m.Footer()
+		if commit, _ := lineValue(footer, "Commit: "); commit == "" {
+			continue
+		}
+		ps, _ := lineValue(footer, "Patch-set: ")
+		if v, err := strconv.ParseInt(ps, 10, 32); err == nil && int32(v) > s.Version {
+			s.Version = int32(v)
+		}
+	}
+	s.Commit = cl.CommitAtVersion(s.Version)
+	s.Hashtags = hashtagsAsOf(metas)
+	s.Votes = s.Meta.LabelVotes()
+	return s
+}
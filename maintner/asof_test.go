@@ -0,0 +1,226 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+func TestGitHubIssueAsOf(t *testing.T) {
+	c := new(Corpus)
+	c.processMutationLocked(&maintpb.Mutation{Github: &maintpb.GithubMutation{
+		Owner:      "golang",
+		Repo:       "go",
+		Labels:     []*maintpb.GithubLabel{{Id: 1, Name: "NeedsFix"}},
+		Milestones: []*maintpb.GithubMilestone{{Id: 50, Title: "Go1.12", Number: 3}},
+	}})
+	c.processMutationLocked(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+		Owner:       "golang",
+		Repo:        "go",
+		Number:      1,
+		User:        &maintpb.GithubUser{Id: 100, Login: "gopherbot"},
+		Assignees:   []*maintpb.GithubUser{{Id: 200, Login: "rsc"}},
+		Title:       "net/http: Transport leaks goroutines",
+		Created:     p3339("2018-01-01T00:00:00Z"),
+		Updated:     p3339("2018-01-06T00:00:00Z"),
+		ClosedAt:    p3339("2018-01-06T00:00:00Z"),
+		Closed:      &maintpb.BoolChange{Val: true},
+		AddLabel:    []*maintpb.GithubLabel{{Id: 1, Name: "NeedsFix"}},
+		MilestoneId: 50,
+		Event: []*maintpb.GithubIssueEvent{{
+			Id:        1,
+			EventType: "labeled",
+			Label:     &maintpb.GithubLabel{Name: "NeedsInvestigation"},
+			Created:   p3339("2018-01-02T00:00:00Z"),
+		}, {
+			Id:        2,
+			EventType: "unlabeled",
+			Label:     &maintpb.GithubLabel{Name: "NeedsInvestigation"},
+			Created:   p3339("2018-01-03T00:00:00Z"),
+		}, {
+			Id:        3,
+			EventType: "labeled",
+			Label:     &maintpb.GithubLabel{Name: "NeedsFix"},
+			Created:   p3339("2018-01-03T00:00:00Z"),
+		}, {
+			Id:        4,
+			EventType: "milestoned",
+			Milestone: &maintpb.GithubMilestone{Title: "Go1.12"},
+			Created:   p3339("2018-01-04T00:00:00Z"),
+		}, {
+			Id:         5,
+			EventType:  "assigned",
+			AssigneeId: 200,
+			Created:    p3339("2018-01-04T00:00:00Z"),
+		}, {
+			Id:         6,
+			EventType:  "renamed",
+			RenameFrom: "Transport leaks",
+			RenameTo:   "net/http: Transport leaks goroutines",
+			Created:    p3339("2018-01-05T00:00:00Z"),
+		}, {
+			Id:        7,
+			EventType: "closed",
+			Created:   p3339("2018-01-06T00:00:00Z"),
+		}},
+	}})
+	gi := c.GitHub().Repo("golang", "go").Issue(1)
+
+	type state struct {
+		Exists    bool
+		Title     string
+		Closed    bool
+		Milestone string
+		Labels    []string
+		Assignees int
+	}
+	tests := []struct {
+		at   string
+		want state
+	}{
+		{"2017-12-31T00:00:00Z", state{}},
+		{"2018-01-01T12:00:00Z", state{true, "Transport leaks", false, "", nil, 0}},
+		{"2018-01-02T12:00:00Z", state{true, "Transport leaks", false, "", []string{"NeedsInvestigation"}, 0}},
+		{"2018-01-03T12:00:00Z", state{true, "Transport leaks", false, "", []string{"NeedsFix"}, 0}},
+		{"2018-01-04T12:00:00Z", state{true, "Transport leaks", false, "Go1.12", []string{"NeedsFix"}, 1}},
+		{"2018-01-05T12:00:00Z", state{true, "net/http: Transport leaks goroutines", false, "Go1.12", []string{"NeedsFix"}, 1}},
+		{"2018-01-06T12:00:00Z", state{true, "net/http: Transport leaks goroutines", true, "Go1.12", []string{"NeedsFix"}, 1}},
+	}
+	for _, tt := range tests {
+		s := gi.AsOf(t3339(tt.at))
+		got := state{s.Exists, s.Title, s.Closed, s.Milestone, s.Labels, len(s.Assignees)}
+		if !s.Exists {
+			got = state{}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("AsOf(%s) = %+v; want %+v", tt.at, got, tt.want)
+		}
+	}
+	if s := gi.AsOf(t3339("2018-01-03T12:00:00Z")); !s.HasLabel("NeedsFix") || s.HasLabel("NeedsInvestigation") {
+		t.Errorf("AsOf(2018-01-03).Labels = %q; want NeedsFix only", s.Labels)
+	}
+}
+
+func TestGerritCLAsOf(t *testing.T) {
+	c := new(Corpus)
+	code1 := strings.Repeat("c1", 20)
+	code2 := strings.Repeat("c2", 20)
+	var metas []string
+	var commits []*maintpb.GitCommit
+	for i, msg := range []string{
+		"Create change\n\nUploaded patch set 1.\n\nPatch-set: 1\nBranch: refs/heads/master\nCommit: " + code1 + "\nStatus: new\n",
+		"Update patch set 1\n\nPatch Set 1: Code-Review+2\n\nPatch-set: 1\nLabel: Code-Review=+2\n",
+		"Update patch set 1\n\nHashtags added: wait-release\n\nPatch-set: 1\nHashtags: wait-release\nTag: autogenerated:gerrit:setHashtag\n",
+		"Update patch set 2\n\nUploaded patch set 2.\n\nPatch-set: 2\nCommit: " + code2 + "\nTag: autogenerated:gerrit:newPatchSet\n",
+		"Update patch set 2\n\nChange has been successfully merged.\n\nPatch-set: 2\nStatus: merged\nTag: autogenerated:gerrit:merged\n",
+	} {
+		parent := ""
+		if i > 0 {
+			parent = metas[i-1]
+		}
+		hash := strings.Repeat(string('a'+rune(i)), 40)
+		metas = append(metas, hash)
+		commits = append(commits, &maintpb.GitCommit{
+			Sha1: hash,
+			Raw:  testRawCommit(parent, "Morty Smith <5206@62eb7196>", 1514764800+int64(i)*86400, "+0000", msg),
+		})
+	}
+	for _, code := range []string{code1, code2} {
+		commits = append(commits, &maintpb.GitCommit{
+			Sha1: code,
+			Raw:  testRawCommit("", "Gopher <gopher@golang.org>", 1514764800, "+0000", "net/http: close idle connections\n"),
+		})
+	}
+	c.processMutationLocked(&maintpb.Mutation{Gerrit: &maintpb.GerritMutation{
+		Project: "go.googlesource.com/go",
+		Commits: commits,
+		Refs: []*maintpb.GitRef{
+			{Ref: "refs/changes/34/1234/1", Sha1: code1},
+			{Ref: "refs/changes/34/1234/2", Sha1: code2},
+			{Ref: "refs/changes/34/1234/meta", Sha1: metas[4]},
+		},
+	}})
+	c.finishProcessing()
+	cl := c.Gerrit().Project("go.googlesource.com", "go").CL(1234)
+	if cl == nil || len(cl.Metas) != 5 {
+		t.Fatalf("CL = %+v; want CL with 5 metas", cl)
+	}
+
+	if s := cl.AsOf(time.Unix(1514764800-1, 0)); s.Exists() {
+		t.Errorf("AsOf before creation = %+v; want nonexistent", s)
+	}
+	tests := []struct {
+		day      int64
+		status   string
+		version  int32
+		commit   string
+		votes    int
+		hashtags GerritHashtags
+	}{
+		{0, "new", 1, code1, 0, ""},
+		{1, "new", 1, code1, 1, ""},
+		{2, "new", 1, code1, 1, "wait-release"},
+		{3, "new", 2, code2, 1, "wait-release"},
+		{4, "merged", 2, code2, 1, "wait-release"},
+	}
+	for _, tt := range tests {
+		s := cl.AsOf(time.Unix(1514764800+tt.day*86400+3600, 0))
+		if s.Meta != cl.Metas[tt.day] || s.Status != tt.status || s.Version != tt.version ||
+			s.Commit == nil || s.Commit.Hash.String() != tt.commit || len(s.Votes["Code-Review"]) != tt.votes || s.Hashtags != tt.hashtags {
+			t.Errorf("day %d: AsOf = %+v", tt.day, s)
+		}
+	}
+	if s := cl.AsOf(time.Unix(1514764800+86400, 0)); s.Votes["Code-Review"]["5206@62eb7196"] != 2 {
+		t.Errorf("votes on day 1 = %v; want Code-Review+2", s.Votes)
+	}
+}
+
+func TestInitializeAsOf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-asof")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "maintner-2018-01-01.mutlog")
+	appendTestIssue(t, file, 1)
+	fi, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	off := fi.Size()
+	appendTestIssue(t, file, 2)
+	appendTestIssue(t, file, 3)
+
+	ctx := context.Background()
+	c := new(Corpus)
+	if err := c.Initialize(ctx, NewDiskMutationLogger(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(file); err != nil || c.LogOffset() != fi.Size() {
+		t.Errorf("LogOffset = %d; want size of log", c.LogOffset())
+	}
+
+	for _, o := range []int64{off, off + 1} {
+		c := new(Corpus)
+		if err := c.InitializeAsOf(ctx, NewDiskMutationLogger(dir), o); err != nil {
+			t.Fatal(err)
+		}
+		if nums := issueNumbers(t, c, "repo:golang/go"); nums != "go#1" {
+			t.Errorf("InitializeAsOf(%d) issues = %q; want go#1", o, nums)
+		}
+		if c.LogOffset() != off {
+			t.Errorf("InitializeAsOf(%d).LogOffset = %d; want %d", o, c.LogOffset(), off)
+		}
+	}
+}
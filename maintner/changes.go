@@ -0,0 +1,262 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// A ChangeEvent is a change to the corpus, derived from a mutation as
+// it's processed. See EnableChangeEvents and WatchChanges.
+type ChangeEvent struct {
+	// Seq is the sequence number of the mutation that caused the
+	// change: its index in the mutation log, counting from 0.
+	// Unlike LogOffset and InitializeAsOf's offsets, it counts
+	// mutations, not bytes. Several events may have the same Seq.
+	Seq int64
+
+	// Type is one of:
+	// * issue_opened, issue_closed, issue_reopened
+	// * issue_labeled, issue_unlabeled
+	// * issue_commented
+	// * cl_created, cl_patchset (a new patch set was uploaded)
+	// * cl_updated (the CL's meta ref moved: votes, messages or status)
+	// * ref_moved (a non-change ref of a Gerrit project)
+	Type string
+
+	// For issue_* events:
+	GitHubRepo GitHubRepoID
+	Issue      int32
+	Label      string // for issue_labeled and issue_unlabeled
+	CommentID  int64  // for issue_commented
+
+	// For cl_* and ref_moved events:
+	GerritProject string // "go.googlesource.com/go"
+	CL            int32
+	Version       int32   // for cl_created and cl_patchset
+	Ref           string  // for ref_moved
+	OldHash, Hash GitHash // for ref_moved; OldHash is empty for new refs
+}
+
+// changeLog holds the most recent change events.
+type changeLog struct {
+	max int
+
+	mu     sync.Mutex
+	events []*ChangeEvent
+	oldest int64         // Seq from which events is complete
+	next   int64         // Seq of the next mutation
+	wake   chan struct{} // closed when events are added
+}
+
+// EnableChangeEvents makes the corpus record the change events of at
+// least the last n mutations it processes, for WatchChanges. To
+// record events for the mutations in its log, it must be called
+// before Initialize.
+func (c *Corpus) EnableChangeEvents(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.changes = &changeLog{
+		max:    n,
+		oldest: c.numMutations,
+		next:   c.numMutations,
+		wake:   make(chan struct{}),
+	}
+}
+
+// noteMutation records the change events of the mutation m, which is
+// about to be processed.
+//
+// c.mu must be held.
+func (c *Corpus) noteMutation(m *maintpb.Mutation) {
+	cl := c.changes
+	var evs []*ChangeEvent
+	add := func(e *ChangeEvent) {
+		e.Seq = c.numMutations
+		evs = append(evs, e)
+	}
+	if im := m.GithubIssue; im != nil {
+		c.issueChanges(im, add)
+	}
+	if gm := m.Gerrit; gm != nil {
+		c.gerritChanges(gm, add)
+	}
+
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.next = c.numMutations + 1
+	if len(evs) == 0 {
+		return
+	}
+	cl.events = append(cl.events, evs...)
+	if len(cl.events) > 2*cl.max {
+		// Keep the last max events, and whole mutations' worth.
+		cut := len(cl.events) - cl.max
+		for cut > 0 && cl.events[cut].Seq == cl.events[cut-1].Seq {
+			cut--
+		}
+		cl.oldest = cl.events[cut].Seq
+		cl.events = append(cl.events[:0:0], cl.events[cut:]...)
+	}
+	close(cl.wake)
+	cl.wake = make(chan struct{})
+}
+
+// issueChanges calls add with the change events of m.
+//
+// c.mu must be held.
+func (c *Corpus) issueChanges(m *maintpb.GithubIssueMutation, add func(*ChangeEvent)) {
+	id := GitHubRepoID{m.Owner, m.Repo}
+	if !id.valid() || m.Number == 0 {
+		return
+	}
+	var gi *GitHubIssue
+	if c.github != nil {
+		if gr := c.github.repos[id]; gr != nil {
+			gi = gr.issues[m.Number]
+		}
+	}
+	issue := func(typ string) *ChangeEvent {
+		return &ChangeEvent{Type: typ, GitHubRepo: id, Issue: m.Number}
+	}
+	if (gi == nil || gi.Created.IsZero()) && m.Created != nil && !m.NotExist {
+		add(issue("issue_opened"))
+	}
+	if m.Closed != nil && (gi == nil || gi.Closed != m.Closed.Val) {
+		if m.Closed.Val {
+			add(issue("issue_closed"))
+		} else if gi != nil {
+			add(issue("issue_reopened"))
+		}
+	}
+	for _, lp := range m.AddLabel {
+		if gi == nil || !gi.HasLabelID(lp.Id) {
+			e := issue("issue_labeled")
+			e.Label = lp.Name
+			add(e)
+		}
+	}
+	if gi != nil {
+		for _, lid := range m.RemoveLabel {
+			if lb, ok := gi.Labels[lid]; ok {
+				e := issue("issue_unlabeled")
+				e.Label = lb.Name
+				add(e)
+			}
+		}
+	}
+	for _, cm := range m.Comment {
+		if gi == nil || gi.comments[cm.Id] == nil {
+			e := issue("issue_commented")
+			e.CommentID = cm.Id
+			add(e)
+		}
+	}
+}
+
+// gerritChanges calls add with the change events of gm.
+//
+// c.mu must be held.
+func (c *Corpus) gerritChanges(gm *maintpb.GerritMutation, add func(*ChangeEvent)) {
+	var gp *GerritProject
+	if c.gerrit != nil {
+		gp = c.gerrit.projects[gm.Project]
+	}
+	for _, refp := range gm.Refs {
+		if len(refp.Sha1) != 40 {
+			continue
+		}
+		hash := c.gitHashFromHexStr(refp.Sha1)
+		m := rxChangeRef.FindStringSubmatch(refp.Ref)
+		if m == nil {
+			if strings.HasPrefix(refp.Ref, "refs/meta/") {
+				continue
+			}
+			var old GitHash
+			if gp != nil {
+				old = gp.ref[refp.Ref]
+			}
+			if old != hash {
+				add(&ChangeEvent{Type: "ref_moved", GerritProject: gm.Project, Ref: refp.Ref, OldHash: old, Hash: hash})
+			}
+			continue
+		}
+		clNum, err := strconv.ParseInt(m[1], 10, 32)
+		version, ok := gerritVersionNumber(m[2])
+		if !ok || err != nil {
+			continue
+		}
+		clv := gerritCLVersion{int32(clNum), version}
+		if gp != nil && gp.remote[clv] == hash {
+			continue
+		}
+		e := &ChangeEvent{GerritProject: gm.Project, CL: clv.CLNumber, Version: version}
+		switch {
+		case version == 0:
+			e.Type = "cl_updated"
+		case gp == nil || gp.cls[clv.CLNumber] == nil || gp.cls[clv.CLNumber].Version == 0:
+			e.Type = "cl_created"
+		default:
+			e.Type = "cl_patchset"
+		}
+		add(e)
+	}
+}
+
+// WatchChanges calls fn serially with each change event from the
+// mutation with sequence number seq onwards, as they happen, until
+// ctx is done or fn returns an error. A negative seq means to start
+// with the next mutation processed. To resume watching, pass the Seq
+// of the last event seen plus one.
+//
+// EnableChangeEvents must have been called, and WatchChanges fails
+// if events from seq are no longer recorded.
+func (c *Corpus) WatchChanges(ctx context.Context, seq int64, fn func(*ChangeEvent) error) error {
+	c.mu.RLock()
+	cl := c.changes
+	c.mu.RUnlock()
+	if cl == nil {
+		return fmt.Errorf("maintner: change events not enabled")
+	}
+	cl.mu.Lock()
+	if seq < 0 {
+		seq = cl.next
+	}
+	cl.mu.Unlock()
+	for {
+		cl.mu.Lock()
+		if seq < cl.oldest {
+			oldest := cl.oldest
+			cl.mu.Unlock()
+			return fmt.Errorf("maintner: change events from mutation %d no longer available; oldest is %d", seq, oldest)
+		}
+		var evs []*ChangeEvent
+		for i := len(cl.events) - 1; i >= 0 && cl.events[i].Seq >= seq; i-- {
+			evs = append(evs, cl.events[i])
+		}
+		if seq < cl.next {
+			seq = cl.next
+		}
+		wake := cl.wake
+		cl.mu.Unlock()
+
+		for i := len(evs) - 1; i >= 0; i-- {
+			if err := fn(evs[i]); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wake:
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+func changeStrings(evs []*ChangeEvent) []string {
+	var ss []string
+	for _, e := range evs {
+		s := fmt.Sprintf("%d %s", e.Seq, e.Type)
+		switch {
+		case e.Label != "":
+			s += " " + e.Label
+		case e.Issue != 0:
+			s += fmt.Sprintf(" %v#%d", e.GitHubRepo, e.Issue)
+		case e.Ref != "":
+			s += " " + e.Ref
+		case e.CL != 0:
+			s += fmt.Sprintf(" %d/%d", e.CL, e.Version)
+		}
+		ss = append(ss, s)
+	}
+	return ss
+}
+
+func TestChangeEvents(t *testing.T) {
+	c := new(Corpus)
+	c.EnableChangeEvents(100)
+	issue := func(m *maintpb.GithubIssueMutation) {
+		m.Owner, m.Repo, m.Number = "golang", "go", 1
+		c.addMutation(&maintpb.Mutation{GithubIssue: m})
+	}
+	issue(&maintpb.GithubIssueMutation{
+		Title:    "fmt: Printf is slow",
+		Created:  p3339("2018-01-01T00:00:00Z"),
+		AddLabel: []*maintpb.GithubLabel{{Id: 1, Name: "Performance"}},
+	})
+	issue(&maintpb.GithubIssueMutation{
+		AddLabel: []*maintpb.GithubLabel{{Id: 1, Name: "Performance"}, {Id: 2, Name: "NeedsFix"}},
+		Comment:  []*maintpb.GithubIssueCommentMutation{{Id: 10, Body: "Me too."}},
+	})
+	issue(&maintpb.GithubIssueMutation{
+		RemoveLabel: []int64{1},
+		Closed:      &maintpb.BoolChange{Val: true},
+	})
+
+	code := strings.Repeat("1c", 20)
+	meta := strings.Repeat("a1", 20)
+	c.addMutation(&maintpb.Mutation{Gerrit: &maintpb.GerritMutation{
+		Project: "go.googlesource.com/go",
+		Commits: []*maintpb.GitCommit{{
+			Sha1: code,
+			Raw:  testRawCommit("", "Gopher <gopher@golang.org>", 1514764800, "+0000", "fmt: speed up Printf\n"),
+		}, {
+			Sha1: meta,
+			Raw:  testRawCommit("", "Gopher <1@62eb7196>", 1514764800, "+0000", "Create change\n\nPatch-set: 1\n"),
+		}},
+		Refs: []*maintpb.GitRef{
+			{Ref: "refs/heads/master", Sha1: code},
+			{Ref: "refs/changes/34/1234/1", Sha1: code},
+			{Ref: "refs/changes/34/1234/meta", Sha1: meta},
+		},
+	}})
+	// Repeated refs aren't changes.
+	c.addMutation(&maintpb.Mutation{Gerrit: &maintpb.GerritMutation{
+		Project: "go.googlesource.com/go",
+		Refs: []*maintpb.GitRef{
+			{Ref: "refs/heads/master", Sha1: code},
+			{Ref: "refs/changes/34/1234/2", Sha1: code},
+		},
+	}})
+
+	var got []*ChangeEvent
+	errStop := errors.New("stop")
+	err := c.WatchChanges(context.Background(), 0, func(e *ChangeEvent) error {
+		got = append(got, e)
+		if e.Seq == 4 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop {
+		t.Fatalf("WatchChanges = %v; want errStop", err)
+	}
+	want := []string{
+		"0 issue_opened golang/go#1",
+		"0 issue_labeled Performance",
+		"1 issue_labeled NeedsFix",
+		"1 issue_commented golang/go#1",
+		"2 issue_closed golang/go#1",
+		"2 issue_unlabeled Performance",
+		"3 ref_moved refs/heads/master",
+		"3 cl_created 1234/1",
+		"3 cl_updated 1234/0",
+		"4 cl_patchset 1234/2",
+	}
+	if g := changeStrings(got); !reflect.DeepEqual(g, want) {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(g, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Watching from the end waits for new changes.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errc := make(chan error, 1)
+	var later []*ChangeEvent
+	go func() {
+		errc <- c.WatchChanges(ctx, 5, func(e *ChangeEvent) error {
+			later = append(later, e)
+			return errStop
+		})
+	}()
+	issue(&maintpb.GithubIssueMutation{Closed: &maintpb.BoolChange{Val: false}})
+	if err := <-errc; err != errStop {
+		t.Fatalf("WatchChanges from end = %v; want errStop", err)
+	}
+	if g, want := changeStrings(later), []string{"5 issue_reopened golang/go#1"}; !reflect.DeepEqual(g, want) {
+		t.Errorf("later events = %q; want %q", g, want)
+	}
+}
+
+func TestChangeEventsTrimmed(t *testing.T) {
+	c := new(Corpus)
+	c.EnableChangeEvents(2)
+	for i := 1; i <= 10; i++ {
+		c.addMutation(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:   "golang",
+			Repo:    "go",
+			Number:  int32(i),
+			Created: p3339("2018-01-01T00:00:00Z"),
+		}})
+	}
+	if err := c.WatchChanges(context.Background(), 0, nil); err == nil {
+		t.Error("WatchChanges from trimmed seq succeeded; want error")
+	}
+	var got []int32
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.WatchChanges(ctx, 8, func(e *ChangeEvent) error {
+		got = append(got, e.Issue)
+		if e.Issue == 10 {
+			cancel()
+		}
+		return nil
+	})
+	if want := []int32{9, 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("issues from seq 8 = %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,470 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// maintexport exports the Go project's GitHub issues and Gerrit CLs
+// from x/build/maintner/godata into tables, so they can be analyzed
+// with SQL or a spreadsheet.
+//
+// With -format=sql (the default), it writes a SQL script for SQLite
+// to -out, or with -sqlite, runs it with the sqlite3 command to
+// update a database directly:
+//
+//	maintexport -sqlite=go.db -state=go.db.state
+//
+// -sqlite requires the sqlite3 command-line program to be in $PATH.
+//
+// With -state, the first run exports everything, and later runs only
+// re-export the issues and CLs whose rows changed since the last run,
+// and delete the rows of those that no longer exist or became
+// private. The state file records a checksum of each item's rows.
+//
+// With -format=csv, it writes one CSV file per table, with a header
+// row, to the directory -out. CSV exports are always complete.
+//
+// The tables, whose columns are listed in schema.go, are:
+//
+//	issues           GitHub issues and pull requests
+//	issue_labels     their current labels
+//	issue_assignees  their current assignees
+//	issue_comments   their comments
+//	issue_events     their events (labeled, closed, renamed, ...)
+//	cls              Gerrit CLs
+//	cl_patchsets     the git commit of each patch set of a CL
+//	cl_votes         current votes on CLs' labels
+//	cl_messages      review messages on CLs
+//	commits          the git commits of CL patch sets
+//
+// Times are in UTC, in RFC 3339 format. Repos are of the form
+// "golang/go" and Gerrit projects "go.googlesource.com/go".
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/build/maintner"
+	"golang.org/x/build/maintner/godata"
+)
+
+var (
+	formatFlag = flag.String("format", "sql", `Output format: "sql" or "csv".`)
+	outFlag    = flag.String("out", "", "For -format=sql, the file to write the SQL script to. For -format=csv, the directory to write CSV files to.")
+	sqliteFlag = flag.String("sqlite", "", "If non-empty, the SQLite database to update by running the SQL script with the sqlite3 command, which must be in $PATH.")
+	stateFlag  = flag.String("state", "", "If non-empty, the file recording how far previous exports got, for incremental exports with -format=sql.")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// exportState is the contents of the -state file.
+type exportState struct {
+	// LogOffset is the corpus's offset in the mutation log when
+	// it was exported.
+	LogOffset int64
+
+	// Issues and CLs map the items exported, by itemKey, to the
+	// checksum of their rows.
+	Issues map[string]string
+	CLs    map[string]string
+}
+
+func run() error {
+	var (
+		w   tableWriter
+		err error
+	)
+	switch *formatFlag {
+	case "sql":
+		if *outFlag == "" && *sqliteFlag == "" {
+			return fmt.Errorf("-format=sql requires -out or -sqlite")
+		}
+		w, err = newSQLWriter(*outFlag, *sqliteFlag)
+	case "csv":
+		if *outFlag == "" {
+			return fmt.Errorf("-format=csv requires -out")
+		}
+		if *stateFlag != "" {
+			return fmt.Errorf("-state requires -format=sql")
+		}
+		w, err = newCSVWriter(*outFlag)
+	default:
+		return fmt.Errorf("unknown -format %q", *formatFlag)
+	}
+	if err != nil {
+		return err
+	}
+
+	var st *exportState
+	if *stateFlag != "" {
+		st, err = readState(*stateFlag)
+		if err != nil {
+			return err
+		}
+	}
+
+	corpus, err := godata.Get(context.Background())
+	if err != nil {
+		return err
+	}
+	if st != nil && st.LogOffset == corpus.LogOffset() {
+		log.Printf("No changes since the last export.")
+		return w.close()
+	}
+	e := &exporter{w: w, prev: st}
+	if err := e.export(corpus); err != nil {
+		w.close()
+		return err
+	}
+	if err := w.close(); err != nil {
+		return err
+	}
+	log.Printf("Exported %d issues and %d CLs; deleted %d.", e.issues, e.cls, e.deleted)
+	if *stateFlag != "" {
+		e.state.LogOffset = corpus.LogOffset()
+		return writeState(*stateFlag, &e.state)
+	}
+	return nil
+}
+
+// readState reads the state file, returning nil if it doesn't exist
+// yet.
+func readState(file string) (*exportState, error) {
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	st := new(exportState)
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", file, err)
+	}
+	return st, nil
+}
+
+func writeState(file string, st *exportState) error {
+	data, err := json.MarshalIndent(st, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// An exporter writes the rows of a corpus's issues and CLs.
+type exporter struct {
+	w    tableWriter
+	prev *exportState // of the previous export, if incremental; or nil
+
+	state      exportState // of this export
+	issues     int
+	cls        int
+	deleted    int
+	seenCommit map[string]bool // hex hashes of the commits written
+}
+
+func (e *exporter) export(c *maintner.Corpus) error {
+	e.seenCommit = make(map[string]bool)
+	e.state.Issues = make(map[string]string)
+	e.state.CLs = make(map[string]string)
+	if err := e.w.begin(e.prev != nil); err != nil {
+		return err
+	}
+	var prev exportState
+	if e.prev != nil {
+		prev = *e.prev
+	}
+	err := c.GitHub().ForeachRepo(func(gr *maintner.GitHubRepo) error {
+		repo := gr.ID().String()
+		return gr.ForeachIssue(func(gi *maintner.GitHubIssue) error {
+			if gi.NotExist {
+				return nil
+			}
+			exported, err := e.exportItem(issueTables, prev.Issues, e.state.Issues, repo, gi.Number, func(w tableWriter) error {
+				return exportIssue(w, repo, gi)
+			})
+			if exported {
+				e.issues++
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	err = c.Gerrit().ForeachProjectUnsorted(func(gp *maintner.GerritProject) error {
+		proj := gp.ServerSlashProject()
+		return gp.ForeachCLUnsorted(func(cl *maintner.GerritCL) error {
+			if cl.Private || cl.Commit == nil || len(cl.Metas) == 0 {
+				return nil
+			}
+			exported, err := e.exportItem(clTables, prev.CLs, e.state.CLs, proj, cl.Number, func(w tableWriter) error {
+				return exportCL(w, proj, cl)
+			})
+			if exported {
+				e.cls++
+			}
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if e.prev == nil {
+		return nil
+	}
+	// Items exported before but not now no longer exist, or
+	// became private.
+	if err := e.deleteGone(issueTables, prev.Issues, e.state.Issues); err != nil {
+		return err
+	}
+	return e.deleteGone(clTables, prev.CLs, e.state.CLs)
+}
+
+// itemKey returns the key of an issue or CL in an exportState.
+func itemKey(repo string, number int32) string {
+	return repo + "#" + strconv.Itoa(int(number))
+}
+
+// parseItemKey parses an itemKey.
+func parseItemKey(key string) (repo string, number int32, ok bool) {
+	i := strings.LastIndex(key, "#")
+	if i < 0 {
+		return "", 0, false
+	}
+	n, err := strconv.ParseInt(key[i+1:], 10, 32)
+	if err != nil {
+		return "", 0, false
+	}
+	return key[:i], int32(n), true
+}
+
+// exportItem exports the issue or CL number of repo, whose rows
+// write writes, unless its rows are the same as in the previous
+// export, prev. It deletes the item's old rows in tables first and
+// records its rows' checksum in cur. It reports whether the item was
+// exported.
+func (e *exporter) exportItem(tables []*table, prev, cur map[string]string, repo string, number int32, write func(tableWriter) error) (bool, error) {
+	rec := new(rowRecorder)
+	if err := write(rec); err != nil {
+		return false, err
+	}
+	key, sum := itemKey(repo, number), rec.sum()
+	cur[key] = sum
+	if e.prev != nil {
+		if prev[key] == sum {
+			return false, nil
+		}
+		for _, t := range tables {
+			if err := e.w.delete(t, repo, number); err != nil {
+				return false, err
+			}
+		}
+	}
+	for _, r := range rec.rows {
+		if r.t == commitsTable {
+			hash := r.vals[0].(string)
+			if e.seenCommit[hash] {
+				continue
+			}
+			e.seenCommit[hash] = true
+		}
+		if err := e.w.row(r.t, r.vals...); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// deleteGone deletes the rows in tables of the items in prev that
+// aren't in cur.
+func (e *exporter) deleteGone(tables []*table, prev, cur map[string]string) error {
+	for key := range prev {
+		if _, ok := cur[key]; ok {
+			continue
+		}
+		repo, number, ok := parseItemKey(key)
+		if !ok {
+			return fmt.Errorf("bad item %q in state file", key)
+		}
+		for _, t := range tables {
+			if err := e.w.delete(t, repo, number); err != nil {
+				return err
+			}
+		}
+		e.deleted++
+	}
+	return nil
+}
+
+// A rowRecorder is a tableWriter that records the rows of an item.
+type rowRecorder struct {
+	rows []recordedRow
+}
+
+type recordedRow struct {
+	t    *table
+	vals []interface{}
+}
+
+func (r *rowRecorder) begin(incremental bool) error { panic("unused") }
+
+func (r *rowRecorder) delete(t *table, repo string, number int32) error { panic("unused") }
+
+func (r *rowRecorder) row(t *table, vals ...interface{}) error {
+	r.rows = append(r.rows, recordedRow{t, vals})
+	return nil
+}
+
+func (r *rowRecorder) close() error { panic("unused") }
+
+// sum returns a checksum of the recorded rows, except for those of
+// the commits table, which are shared between CLs and never change.
+func (r *rowRecorder) sum() string {
+	h := sha1.New()
+	for _, row := range r.rows {
+		if row.t == commitsTable {
+			continue
+		}
+		fmt.Fprintf(h, "%s", row.t.name)
+		for _, v := range row.vals {
+			fmt.Fprintf(h, ",%s", sqlValue(v))
+		}
+		h.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
+
+func login(u *maintner.GitHubUser) string {
+	if u == nil {
+		return ""
+	}
+	return u.Login
+}
+
+// exportIssue writes the rows of gi, an issue in repo, to w.
+func exportIssue(w tableWriter, repo string, gi *maintner.GitHubIssue) error {
+	var milestone string
+	if !gi.Milestone.IsNone() && !gi.Milestone.IsUnknown() {
+		milestone = gi.Milestone.Title
+	}
+	if err := w.row(issuesTable, repo, gi.Number, gi.Title, gi.GetBody(), login(gi.User), gi.PullRequest,
+		gi.Closed, gi.Created, gi.Updated, gi.ClosedAt, milestone); err != nil {
+		return err
+	}
+	// Write map-ordered rows in a fixed order, so that unchanged items
+	// keep the same checksum.
+	var labels []string
+	for _, lb := range gi.Labels {
+		labels = append(labels, lb.Name)
+	}
+	sort.Strings(labels)
+	for _, name := range labels {
+		if err := w.row(issueLabelsTable, repo, gi.Number, name); err != nil {
+			return err
+		}
+	}
+	for _, u := range gi.Assignees {
+		if err := w.row(issueAssigneesTable, repo, gi.Number, login(u)); err != nil {
+			return err
+		}
+	}
+	err := gi.ForeachComment(func(c *maintner.GitHubComment) error {
+		return w.row(issueCommentsTable, repo, gi.Number, c.ID, login(c.User), c.Created, c.Updated, c.GetBody())
+	})
+	if err != nil {
+		return err
+	}
+	return gi.ForeachEvent(func(ev *maintner.GitHubIssueEvent) error {
+		return w.row(issueEventsTable, repo, gi.Number, ev.ID, ev.Type, login(ev.Actor), ev.Created,
+			ev.Label, ev.Milestone, login(ev.Assignee), ev.From, ev.To)
+	})
+}
+
+// exportCL writes the rows of cl, a CL in the Gerrit project proj,
+// and of its patch sets' commits, to w.
+func exportCL(w tableWriter, proj string, cl *maintner.GerritCL) error {
+	meta := cl.Metas[len(cl.Metas)-1]
+	if err := w.row(clsTable, proj, cl.Number, cl.ChangeID(), cl.Subject(), cl.Branch(), cl.Status,
+		cl.OwnerID(), cl.Created, meta.Commit.CommitTime, cl.Version, cl.Commit.Hash.String()); err != nil {
+		return err
+	}
+	for v := int32(1); v <= cl.Version; v++ {
+		gc := cl.CommitAtVersion(v)
+		if gc == nil {
+			continue
+		}
+		if err := w.row(clPatchSetsTable, proj, cl.Number, v, gc.Hash.String()); err != nil {
+			return err
+		}
+		if err := exportCommit(w, gc); err != nil {
+			return err
+		}
+	}
+	labelVotes := meta.LabelVotes()
+	labels := make([]string, 0, len(labelVotes))
+	for label := range labelVotes {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		votes := labelVotes[label]
+		voters := make([]string, 0, len(votes))
+		for voter := range votes {
+			voters = append(voters, voter)
+		}
+		sort.Strings(voters)
+		for _, voter := range voters {
+			if err := w.row(clVotesTable, proj, cl.Number, label, voter, int(votes[voter])); err != nil {
+				return err
+			}
+		}
+	}
+	for i, m := range cl.Messages {
+		var author string
+		if m.Author != nil {
+			author = m.Author.Str
+		}
+		if err := w.row(clMessagesTable, proj, cl.Number, i, m.Version, author, m.Date, m.GetMessage()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportCommit writes the row of gc to w.
+func exportCommit(w tableWriter, gc *maintner.GitCommit) error {
+	var author, committer string
+	if gc.Author != nil {
+		author = gc.Author.Str
+	}
+	if gc.Committer != nil {
+		committer = gc.Committer.Str
+	}
+	var added, deleted int64
+	files := gc.GetFiles()
+	for _, f := range files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	return w.row(commitsTable, gc.Hash.String(), author, gc.AuthorTime, committer, gc.CommitTime,
+		gc.Summary(), gc.GetMsg(), len(files), added, deleted)
+}
@@ -0,0 +1,284 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/build/maintner"
+)
+
+func TestSQLValue(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{"", "''"},
+		{"it's", "'it''s'"},
+		{int32(42), "42"},
+		{int64(-1), "-1"},
+		{true, "1"},
+		{false, "0"},
+		{time.Time{}, "NULL"},
+		{time.Date(2018, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*3600)), "'2018-01-02T08:04:05Z'"},
+	}
+	for _, tt := range tests {
+		if got := sqlValue(tt.v); got != tt.want {
+			t.Errorf("sqlValue(%#v) = %s; want %s", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestSQLWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintexport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out.sql")
+	w, err := newSQLWriter(out, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.begin(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.delete(issueLabelsTable, "golang/go", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.row(issueLabelsTable, "golang/go", int32(1), "NeedsFix"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := string(data)
+	for _, want := range []string{
+		"BEGIN;\n",
+		"CREATE TABLE IF NOT EXISTS issue_labels (repo TEXT, number INTEGER, label TEXT, PRIMARY KEY (repo, number, label));\n",
+		"DELETE FROM issue_labels WHERE repo = 'golang/go' AND number = 1;\n",
+		"INSERT OR REPLACE INTO issue_labels VALUES ('golang/go', 1, 'NeedsFix');\n",
+		"COMMIT;\n",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script doesn't contain %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "DELETE FROM issue_labels;") {
+		t.Errorf("incremental script deletes all rows:\n%s", script)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintexport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	w, err := newCSVWriter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.begin(false); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.row(issueLabelsTable, "golang/go", int32(1), "help, wanted"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "issue_labels.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "repo,number,label\ngolang/go,1,\"help, wanted\"\n"; got != want {
+		t.Errorf("issue_labels.csv = %q; want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "commits.csv")); err != nil {
+		t.Errorf("commits.csv not written: %v", err)
+	}
+}
+
+// logWriter is a tableWriter that logs what it's asked to write.
+type logWriter struct {
+	log []string
+}
+
+func (w *logWriter) begin(incremental bool) error { return nil }
+
+func (w *logWriter) delete(t *table, repo string, number int32) error {
+	w.log = append(w.log, fmt.Sprintf("delete %s %s", t.name, itemKey(repo, number)))
+	return nil
+}
+
+func (w *logWriter) row(t *table, vals ...interface{}) error {
+	w.log = append(w.log, fmt.Sprintf("row %s %v", t.name, vals))
+	return nil
+}
+
+func (w *logWriter) close() error { return nil }
+
+func TestExportItem(t *testing.T) {
+	labels := func(number int32, names ...string) func(tableWriter) error {
+		return func(w tableWriter) error {
+			for _, name := range names {
+				if err := w.row(issueLabelsTable, "golang/go", number, name); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	sum := func(write func(tableWriter) error) string {
+		rec := new(rowRecorder)
+		write(rec)
+		return rec.sum()
+	}
+	w := new(logWriter)
+	e := &exporter{
+		w: w,
+		prev: &exportState{Issues: map[string]string{
+			"golang/go#1": sum(labels(1, "NeedsFix")),
+			"golang/go#2": sum(labels(2, "NeedsFix")),
+			"golang/go#3": sum(labels(3)),
+		}},
+		state:      exportState{Issues: make(map[string]string)},
+		seenCommit: make(map[string]bool),
+	}
+	tables := []*table{issuesTable, issueLabelsTable}
+	for _, tt := range []struct {
+		number int32
+		labels []string
+		want   bool
+	}{
+		{1, []string{"NeedsFix"}, false},                 // unchanged
+		{2, []string{"NeedsFix", "Documentation"}, true}, // changed
+		{4, []string{"Documentation"}, true},             // new
+	} {
+		got, err := e.exportItem(tables, e.prev.Issues, e.state.Issues, "golang/go", tt.number, labels(tt.number, tt.labels...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("exportItem of #%d = %v; want %v", tt.number, got, tt.want)
+		}
+	}
+	// #3 no longer exists.
+	if err := e.deleteGone(tables, e.prev.Issues, e.state.Issues); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"delete issues golang/go#2",
+		"delete issue_labels golang/go#2",
+		"row issue_labels [golang/go 2 NeedsFix]",
+		"row issue_labels [golang/go 2 Documentation]",
+		"delete issues golang/go#4",
+		"delete issue_labels golang/go#4",
+		"row issue_labels [golang/go 4 Documentation]",
+		"delete issues golang/go#3",
+		"delete issue_labels golang/go#3",
+	}
+	if !reflect.DeepEqual(w.log, want) {
+		t.Errorf("writes:\n%s\nwant:\n%s", strings.Join(w.log, "\n"), strings.Join(want, "\n"))
+	}
+	if len(e.state.Issues) != 3 || e.state.Issues["golang/go#1"] != e.prev.Issues["golang/go#1"] {
+		t.Errorf("new state = %v", e.state.Issues)
+	}
+}
+
+func TestExportUnchangedMultiValued(t *testing.T) {
+	gi := &maintner.GitHubIssue{
+		Number: 1,
+		Title:  "x/build: flaky",
+		Labels: map[int64]*maintner.GitHubLabel{
+			1: {ID: 1, Name: "NeedsFix"},
+			2: {ID: 2, Name: "Builders"},
+			3: {ID: 3, Name: "Soon"},
+			4: {ID: 4, Name: "Testing"},
+		},
+	}
+	cl := &maintner.GerritCL{
+		Number: 2,
+		Status: "new",
+		Commit: &maintner.GitCommit{Msg: "cmd/coordinator: fix flake\n"},
+	}
+	for _, footer := range []string{
+		"Label: Code-Review=+2\nLabel: Run-TryBot=+1\nLabel: Trust=+1",
+		"Label: Code-Review=+1\nLabel: TryBot-Result=+1",
+	} {
+		cl.Metas = append(cl.Metas, &maintner.GerritMeta{
+			Commit: &maintner.GitCommit{
+				Author: &maintner.GitPerson{Str: fmt.Sprintf("Gopher <%d@62eb7196>", len(cl.Metas))},
+				Msg:    "Update patch set 1\n\nPatch-set: 1\n" + footer + "\n",
+			},
+			CL: cl,
+		})
+	}
+	cl.Meta = cl.Metas[len(cl.Metas)-1]
+
+	export := func(prev *exportState) (*exportState, bool) {
+		e := &exporter{
+			w:          new(logWriter),
+			prev:       prev,
+			state:      exportState{Issues: make(map[string]string), CLs: make(map[string]string)},
+			seenCommit: make(map[string]bool),
+		}
+		issueChanged, err := e.exportItem(issueTables, prev.Issues, e.state.Issues, "golang/go", gi.Number, func(w tableWriter) error {
+			return exportIssue(w, "golang/go", gi)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		clChanged, err := e.exportItem(clTables, prev.CLs, e.state.CLs, "go.googlesource.com/build", cl.Number, func(w tableWriter) error {
+			return exportCL(w, "go.googlesource.com/build", cl)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &e.state, issueChanged || clChanged
+	}
+	state, _ := export(&exportState{})
+	// Map iteration order varies between runs, so try several.
+	for i := 0; i < 10; i++ {
+		if _, changed := export(state); changed {
+			t.Fatalf("run %d re-exported unchanged items", i+2)
+		}
+	}
+}
+
+func TestParseItemKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		repo   string
+		number int32
+		ok     bool
+	}{
+		{"golang/go#123", "golang/go", 123, true},
+		{"go.googlesource.com/go#4567", "go.googlesource.com/go", 4567, true},
+		{"golang/go", "", 0, false},
+		{"golang/go#x", "", 0, false},
+	}
+	for _, tt := range tests {
+		repo, number, ok := parseItemKey(tt.key)
+		if repo != tt.repo || number != tt.number || ok != tt.ok {
+			t.Errorf("parseItemKey(%q) = %q, %d, %v; want %q, %d, %v", tt.key, repo, number, ok, tt.repo, tt.number, tt.ok)
+		}
+	}
+	if key := itemKey("golang/go", 123); key != "golang/go#123" {
+		t.Errorf("itemKey = %q; want golang/go#123", key)
+	}
+}
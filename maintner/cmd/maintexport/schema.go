@@ -0,0 +1,162 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+// A table is an exported table. The first columns of issue and CL
+// tables identify the issue or CL a row belongs to.
+type table struct {
+	name string
+	cols []column
+	key  int // number of leading columns in the primary key
+}
+
+type column struct {
+	name string
+	typ  string // SQLite type: "TEXT" or "INTEGER"
+}
+
+// Times are TEXT, and bools INTEGER.
+var (
+	issuesTable = &table{
+		name: "issues",
+		key:  2,
+		cols: []column{
+			{"repo", "TEXT"},
+			{"number", "INTEGER"},
+			{"title", "TEXT"},
+			{"body", "TEXT"},
+			{"user", "TEXT"}, // login
+			{"pull_request", "INTEGER"},
+			{"closed", "INTEGER"},
+			{"created", "TEXT"},
+			{"updated", "TEXT"},
+			{"closed_at", "TEXT"},
+			{"milestone", "TEXT"}, // title, or "" for none
+		},
+	}
+	issueLabelsTable = &table{
+		name: "issue_labels",
+		key:  3,
+		cols: []column{
+			{"repo", "TEXT"},
+			{"number", "INTEGER"},
+			{"label", "TEXT"},
+		},
+	}
+	issueAssigneesTable = &table{
+		name: "issue_assignees",
+		key:  3,
+		cols: []column{
+			{"repo", "TEXT"},
+			{"number", "INTEGER"},
+			{"login", "TEXT"},
+		},
+	}
+	issueCommentsTable = &table{
+		name: "issue_comments",
+		key:  3,
+		cols: []column{
+			{"repo", "TEXT"},
+			{"number", "INTEGER"},
+			{"id", "INTEGER"},
+			{"user", "TEXT"},
+			{"created", "TEXT"},
+			{"updated", "TEXT"},
+			{"body", "TEXT"},
+		},
+	}
+	issueEventsTable = &table{
+		name: "issue_events",
+		key:  3,
+		cols: []column{
+			{"repo", "TEXT"},
+			{"number", "INTEGER"},
+			{"id", "INTEGER"},
+			{"type", "TEXT"}, // "labeled", "closed", "renamed", etc.
+			{"actor", "TEXT"},
+			{"created", "TEXT"},
+			{"label", "TEXT"},
+			{"milestone", "TEXT"},
+			{"assignee", "TEXT"},
+			{"rename_from", "TEXT"},
+			{"rename_to", "TEXT"},
+		},
+	}
+
+	clsTable = &table{
+		name: "cls",
+		key:  2,
+		cols: []column{
+			{"project", "TEXT"},
+			{"number", "INTEGER"},
+			{"change_id", "TEXT"},
+			{"subject", "TEXT"},
+			{"branch", "TEXT"},
+			{"status", "TEXT"},   // "new", "merged", "abandoned" or "draft"
+			{"owner", "INTEGER"}, // Gerrit account ID
+			{"created", "TEXT"},
+			{"updated", "TEXT"},    // time of the last meta commit
+			{"version", "INTEGER"}, // latest patch set
+			{"commit_hash", "TEXT"},
+		},
+	}
+	clPatchSetsTable = &table{
+		name: "cl_patchsets",
+		key:  3,
+		cols: []column{
+			{"project", "TEXT"},
+			{"number", "INTEGER"},
+			{"version", "INTEGER"},
+			{"commit_hash", "TEXT"},
+		},
+	}
+	clVotesTable = &table{
+		name: "cl_votes",
+		key:  4,
+		cols: []column{
+			{"project", "TEXT"},
+			{"number", "INTEGER"},
+			{"label", "TEXT"}, // "Code-Review"
+			{"voter", "TEXT"}, // Gerrit account email, "<id>@<server uuid>"
+			{"value", "INTEGER"},
+		},
+	}
+	clMessagesTable = &table{
+		name: "cl_messages",
+		key:  3,
+		cols: []column{
+			{"project", "TEXT"},
+			{"number", "INTEGER"},
+			{"seq", "INTEGER"}, // index in the CL's messages
+			{"version", "INTEGER"},
+			{"author", "TEXT"}, // "Name <email>"
+			{"date", "TEXT"},
+			{"message", "TEXT"},
+		},
+	}
+
+	commitsTable = &table{
+		name: "commits",
+		key:  1,
+		cols: []column{
+			{"hash", "TEXT"},
+			{"author", "TEXT"}, // "Name <email>"
+			{"author_time", "TEXT"},
+			{"committer", "TEXT"},
+			{"commit_time", "TEXT"},
+			{"subject", "TEXT"},
+			{"message", "TEXT"},
+			{"files", "INTEGER"}, // number of files changed
+			{"added", "INTEGER"}, // lines
+			{"deleted", "INTEGER"},
+		},
+	}
+)
+
+var (
+	issueTables = []*table{issuesTable, issueLabelsTable, issueAssigneesTable, issueCommentsTable, issueEventsTable}
+	clTables    = []*table{clsTable, clPatchSetsTable, clVotesTable, clMessagesTable}
+	allTables   = append(append(append([]*table(nil), issueTables...), clTables...), commitsTable)
+)
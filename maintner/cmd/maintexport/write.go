@@ -0,0 +1,249 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A tableWriter writes rows of tables.
+type tableWriter interface {
+	// begin starts the export. If incremental is false, the
+	// tables' old rows are replaced.
+	begin(incremental bool) error
+
+	// delete deletes the rows of t belonging to an issue or CL,
+	// identified by the values of t's first two columns.
+	delete(t *table, repo string, number int32) error
+
+	// row writes a row of t. Values are strings, integers,
+	// bools or time.Times.
+	row(t *table, vals ...interface{}) error
+
+	// close finishes the export.
+	close() error
+}
+
+// sqlWriter writes a SQL script for SQLite.
+type sqlWriter struct {
+	f   *os.File  // or nil
+	cmd *exec.Cmd // running sqlite3, or nil
+	in  io.WriteCloser
+	bw  *bufio.Writer
+}
+
+// newSQLWriter returns a writer of a SQL script to the file out
+// and/or the sqlite3 command updating the database db.
+func newSQLWriter(out, db string) (*sqlWriter, error) {
+	if db != "" {
+		// Check before anything is written.
+		if _, err := exec.LookPath("sqlite3"); err != nil {
+			return nil, fmt.Errorf("-sqlite requires the sqlite3 command: %v", err)
+		}
+	}
+	w := new(sqlWriter)
+	var ws []io.Writer
+	if out != "" {
+		f, err := os.Create(out)
+		if err != nil {
+			return nil, err
+		}
+		w.f = f
+		ws = append(ws, f)
+	}
+	if db != "" {
+		w.cmd = exec.Command("sqlite3", "-bail", db)
+		w.cmd.Stdout = os.Stdout
+		w.cmd.Stderr = os.Stderr
+		in, err := w.cmd.StdinPipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := w.cmd.Start(); err != nil {
+			return nil, fmt.Errorf("running sqlite3: %v", err)
+		}
+		w.in = in
+		ws = append(ws, in)
+	}
+	w.bw = bufio.NewWriterSize(io.MultiWriter(ws...), 256<<10)
+	return w, nil
+}
+
+func (w *sqlWriter) begin(incremental bool) error {
+	w.bw.WriteString("BEGIN;\n")
+	for _, t := range allTables {
+		fmt.Fprintf(w.bw, "CREATE TABLE IF NOT EXISTS %s (", t.name)
+		var key []string
+		for i, c := range t.cols {
+			if i > 0 {
+				w.bw.WriteString(", ")
+			}
+			fmt.Fprintf(w.bw, "%s %s", c.name, c.typ)
+			if i < t.key {
+				key = append(key, c.name)
+			}
+		}
+		fmt.Fprintf(w.bw, ", PRIMARY KEY (%s));\n", strings.Join(key, ", "))
+		if !incremental {
+			fmt.Fprintf(w.bw, "DELETE FROM %s;\n", t.name)
+		}
+	}
+	return nil
+}
+
+func (w *sqlWriter) delete(t *table, repo string, number int32) error {
+	_, err := fmt.Fprintf(w.bw, "DELETE FROM %s WHERE %s = %s AND %s = %d;\n",
+		t.name, t.cols[0].name, sqlQuote(repo), t.cols[1].name, number)
+	return err
+}
+
+func (w *sqlWriter) row(t *table, vals ...interface{}) error {
+	if len(vals) != len(t.cols) {
+		panic(fmt.Sprintf("%d values for table %s with %d columns", len(vals), t.name, len(t.cols)))
+	}
+	fmt.Fprintf(w.bw, "INSERT OR REPLACE INTO %s VALUES (", t.name)
+	for i, v := range vals {
+		if i > 0 {
+			w.bw.WriteString(", ")
+		}
+		w.bw.WriteString(sqlValue(v))
+	}
+	_, err := w.bw.WriteString(");\n")
+	return err
+}
+
+func (w *sqlWriter) close() error {
+	w.bw.WriteString("COMMIT;\n")
+	err := w.bw.Flush()
+	if w.f != nil {
+		if cerr := w.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if w.cmd != nil {
+		w.in.Close()
+		if werr := w.cmd.Wait(); err == nil && werr != nil {
+			err = fmt.Errorf("sqlite3: %v", werr)
+		}
+	}
+	return err
+}
+
+// sqlValue returns v as a SQL literal.
+func sqlValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return sqlQuote(v)
+	case int:
+		return strconv.Itoa(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		if v.IsZero() {
+			return "NULL"
+		}
+		return sqlQuote(v.UTC().Format(time.RFC3339))
+	}
+	panic(fmt.Sprintf("unexpected value type %T", v))
+}
+
+// sqlQuote returns s as a SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// csvWriter writes a CSV file per table.
+type csvWriter struct {
+	dir   string
+	files map[*table]*os.File
+	ws    map[*table]*csv.Writer
+}
+
+func newCSVWriter(dir string) (*csvWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &csvWriter{
+		dir:   dir,
+		files: make(map[*table]*os.File),
+		ws:    make(map[*table]*csv.Writer),
+	}, nil
+}
+
+func (w *csvWriter) begin(incremental bool) error {
+	if incremental {
+		return errors.New("CSV exports can't be incremental")
+	}
+	for _, t := range allTables {
+		f, err := os.Create(filepath.Join(w.dir, t.name+".csv"))
+		if err != nil {
+			return err
+		}
+		w.files[t] = f
+		cw := csv.NewWriter(f)
+		w.ws[t] = cw
+		var header []string
+		for _, c := range t.cols {
+			header = append(header, c.name)
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *csvWriter) delete(t *table, repo string, number int32) error {
+	return errors.New("CSV exports can't be incremental")
+}
+
+func (w *csvWriter) row(t *table, vals ...interface{}) error {
+	rec := make([]string, len(vals))
+	for i, v := range vals {
+		switch v := v.(type) {
+		case string:
+			rec[i] = v
+		case time.Time:
+			if !v.IsZero() {
+				rec[i] = v.UTC().Format(time.RFC3339)
+			}
+		default:
+			rec[i] = sqlValue(v)
+		}
+	}
+	return w.ws[t].Write(rec)
+}
+
+func (w *csvWriter) close() error {
+	var err error
+	for t, f := range w.files {
+		cw := w.ws[t]
+		cw.Flush()
+		if werr := cw.Error(); err == nil {
+			err = werr
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
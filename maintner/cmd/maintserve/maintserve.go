@@ -6,6 +6,9 @@
 // can be viewed in a browser. It uses x/build/maintner/godata as
 // its backing source of data.
 //
+// With -search, issues and Gerrit CLs can be searched by their text
+// at /search.
+//
 // It statically embeds all the resources it uses, so it's possible to use
 // it when offline. During that time, the corpus will not be able to update,
 // and GitHub user profile pictures won't load.
@@ -20,6 +23,7 @@ import (
 	"mime"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
@@ -33,7 +37,10 @@ import (
 	"golang.org/x/build/maintner/godata"
 )
 
-var httpFlag = flag.String("http", ":8080", "Listen for HTTP connections on this address.")
+var (
+	httpFlag   = flag.String("http", ":8080", "Listen for HTTP connections on this address.")
+	searchFlag = flag.Bool("search", false, "Enable full-text search of issues and CLs. The index is stored next to the cached mutation logs.")
+)
 
 func main() {
 	flag.Parse()
@@ -53,6 +60,11 @@ func run() error {
 	if err != nil {
 		return err
 	}
+	if *searchFlag {
+		if err := corpus.EnableSearch(filepath.Join(godata.Dir(), "search-index")); err != nil {
+			return err
+		}
+	}
 	issuesService := maintnerissues.NewService(corpus)
 	issuesApp := issuesapp.New(issuesService, nil, issuesapp.Options{
 		HeadPre: `<meta name="viewport" content="width=device-width">
@@ -116,6 +128,12 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Handle "/search".
+	if req.URL.Path == "/search" {
+		h.serveSearch(w, req)
+		return
+	}
+
 	// Handle "/assets/style.css".
 	if req.URL.Path == "/assets/style.css" {
 		http.ServeContent(w, req, "style.css", time.Time{}, strings.NewReader(styleCSS))
@@ -152,8 +170,9 @@ var indexHTML = template.Must(template.New("").Parse(`<html>
 	<body>
 		<div style="max-width: 800px; margin: 0 auto 100px auto;">
 			<h2>maintserve</h2>
+			{{if .Search}}<form action="/search"><input name="q" size="50"> <input type="submit" class="btn" value="Search"></form>{{end}}
 			<h3>Repos</h3>
-			<ul>{{range .}}
+			<ul>{{range .Repos}}
 				<li><a href="/{{.RepoID}}">{{.RepoID}}</a> ({{.Count}} issues)</li>
 				{{- end}}
 			</ul>
@@ -188,7 +207,81 @@ func (h *handler) serveIndex(w http.ResponseWriter, req *http.Request) {
 	})
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	err = indexHTML.Execute(w, repos)
+	err = indexHTML.Execute(w, struct {
+		Search bool
+		Repos  []repo
+	}{*searchFlag, repos})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+var searchHTML = template.Must(template.New("").Parse(`<html>
+	<head>
+		<title>{{.Query}} - maintserve</title>
+		<meta name="viewport" content="width=device-width">
+		<link href="/assets/fonts/fonts.css" rel="stylesheet" type="text/css">
+		<link href="/assets/style.css" rel="stylesheet" type="text/css">
+	</head>
+	<body>
+		<div style="max-width: 800px; margin: 0 auto 100px auto;">
+			<h2><a href="/">maintserve</a></h2>
+			<form action="/search"><input name="q" size="50" value="{{.Query}}"> <input type="submit" class="btn" value="Search"></form>
+			{{with .Err}}<p>{{.}}</p>{{end}}
+			<ul>{{range .Results}}
+				<li><a href="{{.URL}}">{{.Name}}</a>: {{.Title}}{{if .Closed}} (closed){{end}}</li>
+				{{- end}}
+			</ul>
+		</div>
+	<body>
+</html>`))
+
+// maxSearchResults is the number of results shown by serveSearch.
+const maxSearchResults = 100
+
+// serveSearch serves the results of a full-text search of issues and CLs.
+func (h *handler) serveSearch(w http.ResponseWriter, req *http.Request) {
+	if !*searchFlag {
+		http.Error(w, "404 Not Found\n\nsearch is disabled", http.StatusNotFound)
+		return
+	}
+	type result struct {
+		Name, URL, Title string
+		Closed           bool
+	}
+	data := struct {
+		Query   string
+		Err     error
+		Results []result
+	}{Query: req.FormValue("q")}
+	res, err := h.c.Search(data.Query, maxSearchResults)
+	data.Err = err
+	for _, r := range res {
+		if cl := r.CL; cl != nil {
+			reviewServer := strings.Replace(cl.Project.Server(), ".googlesource.com", "-review.googlesource.com", 1)
+			data.Results = append(data.Results, result{
+				Name:   fmt.Sprintf("CL %d", cl.Number),
+				URL:    fmt.Sprintf("https://%s/c/%s/+/%d", reviewServer, cl.Project.Project(), cl.Number),
+				Title:  cl.Subject(),
+				Closed: cl.Status == "merged" || cl.Status == "abandoned",
+			})
+			continue
+		}
+		rr := result{
+			Name:   fmt.Sprintf("%s#%d", r.Repo.ID(), r.Issue.Number),
+			URL:    fmt.Sprintf("/%s/%d", r.Repo.ID(), r.Issue.Number),
+			Title:  r.Issue.Title,
+			Closed: r.Issue.Closed,
+		}
+		if r.Issue.PullRequest {
+			// Pull requests aren't served by issuesapp.
+			rr.URL = fmt.Sprintf("https://github.com/%s/pull/%d", r.Repo.ID(), r.Issue.Number)
+		}
+		data.Results = append(data.Results, rr)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = searchHTML.Execute(w, data)
 	if err != nil {
 		log.Println(err)
 	}
@@ -0,0 +1,177 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// reclogrepair checks and repairs maintner mutation log files, in the
+// reclog format, that were damaged by a torn write or bit flips.
+//
+// Usage:
+//
+//	reclogrepair [-n] [-rewrite] [-checksum] file...
+//
+// For each file, it reports the damaged byte ranges it finds. When the
+// only damage is at the end of the file, as after a crash during a
+// write, it truncates the file before it. Damage elsewhere is only
+// repaired with -rewrite, which rewrites the file with its intact
+// records, at their new offsets, keeping the original as file.bak.
+// Records with checksums keep them, and with -checksum all records
+// get them; -rewrite -checksum rewrites even undamaged files.
+//
+// Rewriting a file changes where its records are, so maintner
+// followers that mirrored the file must discard their copy.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"golang.org/x/build/maintner/reclog"
+)
+
+var (
+	dryRun   = flag.Bool("n", false, "only report damage; don't change files")
+	rewrite  = flag.Bool("rewrite", false, "rewrite files with damage before their end, dropping the damaged records")
+	checksum = flag.Bool("checksum", false, "when rewriting, give all records checksums")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: reclogrepair [flags] file...\n")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+	}
+	ok := true
+	for _, file := range flag.Args() {
+		if err := repair(file); err != nil {
+			log.Printf("%s: %v", file, err)
+			ok = false
+		}
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// A damage is a damaged byte range of a file.
+type damage struct {
+	start, end int64
+	err        error
+}
+
+// scan returns the damaged ranges of the file and its size.
+func scan(file string) ([]damage, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	var bad []damage
+	err = reclog.ForeachRecordResync(f, 0, func(off int64, hdr, rec []byte) error {
+		return nil
+	}, func(start, end int64, err error) error {
+		bad = append(bad, damage{start, end, err})
+		return nil
+	})
+	return bad, fi.Size(), err
+}
+
+func repair(file string) error {
+	bad, size, err := scan(file)
+	if err != nil {
+		return err
+	}
+	for _, d := range bad {
+		log.Printf("%s: bytes %d-%d damaged: %v", file, d.start, d.end, d.err)
+	}
+	if *dryRun {
+		return nil
+	}
+	switch {
+	case len(bad) == 0 && !(*rewrite && *checksum):
+		return nil
+	case len(bad) == 1 && bad[0].end == size && !*rewrite:
+		log.Printf("%s: truncating to %d bytes", file, bad[0].start)
+		return os.Truncate(file, bad[0].start)
+	case !*rewrite:
+		return fmt.Errorf("damaged before its end; use -rewrite to drop the damaged records")
+	}
+	return rewriteFile(file)
+}
+
+// rewriteFile rewrites file with its intact records.
+func rewriteFile(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	tmp := file + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	bw := bufio.NewWriter(out)
+	cw := &countWriter{w: bw}
+
+	var n int
+	var prevEnd int64 // end of the previous record or damage
+	err = reclog.ForeachRecordResync(f, 0, func(off int64, hdr, rec []byte) error {
+		// A gap before a record that isn't damage is its
+		// checksum record.
+		hadChecksum := off != prevEnd
+		prevEnd = off + int64(len(hdr)+len(rec))
+		n++
+		if hadChecksum || *checksum {
+			return reclog.WriteChecksummedRecord(cw, cw.n, rec)
+		}
+		return reclog.WriteRecord(cw, cw.n, rec)
+	}, func(start, end int64, err error) error {
+		prevEnd = end
+		return nil
+	})
+	if err != nil {
+		out.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(file, file+".bak"); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return err
+	}
+	log.Printf("%s: rewrote %d records; original is %s.bak", file, n, file)
+	return nil
+}
+
+// countWriter counts the bytes written to w, so records can be given
+// their offsets.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
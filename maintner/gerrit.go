@@ -72,6 +72,9 @@ func (g *Gerrit) getOrCreateProject(gerritProj string) *GerritProject {
 		ref:    map[string]GitHash{},
 		commit: map[GitHash]*GitCommit{},
 		need:   map[GitHash]bool{},
+
+		clOfCommit:    map[GitHash]*GerritCL{},
+		clsByChangeID: map[string][]*GerritCL{},
 	}
 	g.projects[gerritProj] = proj
 	return proj
@@ -98,6 +101,15 @@ type GerritProject struct {
 	commit          map[GitHash]*GitCommit
 	numLabelChanges int // incremented (too many times) by meta commits with "Label:" updates
 	dirtyCL         map[*GerritCL]struct{}
+	index           termIndex // of CLs, for queries
+
+	// clOfCommit maps the commit of each patch set to its CL.
+	clOfCommit map[GitHash]*GerritCL
+
+	// clsByChangeID maps Change-Id values to the CLs with them,
+	// sorted by number. Usually there's one, or more for
+	// cherry-picks to other branches.
+	clsByChangeID map[string][]*GerritCL
 
 	// ref are the non-change refs with keys like "HEAD",
 	// "refs/heads/master", "refs/tags/v0.8.0", etc.
@@ -275,6 +287,14 @@ type GerritCL struct {
 	// "refs/heads/" prefix. It's usually "master".
 	branch string
 
+	// topic is a cache of the latest "Topic: " value seen from
+	// MetaCommits' commit message values. See Topic.
+	topic string
+
+	// changeID is the Change-Id Commit was indexed under in
+	// Project.clsByChangeID.
+	changeID string
+
 	// Meta is the head of the most recent Gerrit "meta" commit
 	// for this CL. This is guaranteed to be a linear history
 	// back to a CL-specific root commit for this meta branch.
@@ -303,6 +323,13 @@ type GerritCL struct {
 
 	// Messages contains all of the messages for this CL, in sorted order.
 	Messages []*GerritMessage
+
+	// comments are the published inline comments, by UUID.
+	// See ForeachComment and CommentThreads.
+	comments map[string]*GerritComment
+
+	// commentsMeta is the meta commit comments were last read from.
+	commentsMeta GitHash
 }
 
 // complete reports whether cl is complete.
@@ -328,7 +355,7 @@ type GerritMessage struct {
 
 	// Message is the raw message contents from Gerrit (a subset
 	// of the raw git commit message), starting with "Patch Set
-	// nnnn".
+	// nnnn". It's empty in compact mode; use GetMessage.
 	Message string
 
 	// Date is when this message was stored (the commit time of
@@ -345,8 +372,14 @@ type GerritMessage struct {
 	// (which use their real email) via the user ID, so they point to the same
 	// object.
 	Author *GitPerson
+
+	message textRef // in compact mode, part of Meta's msg
 }
 
+// GetMessage returns the message's contents. Unlike Message, it works
+// in compact mode.
+func (m *GerritMessage) GetMessage() string { return textOf(m.Message, m.message) }
+
 // References reports whether cl includes a commit message reference
 // to the provided Github issue ref.
 func (cl *GerritCL) References(ref GitHubIssueRef) bool {
@@ -364,9 +397,11 @@ func (cl *GerritCL) Branch() string { return cl.branch }
 func (cl *GerritCL) updateBranch() {
 	for i := len(cl.Metas) - 1; i >= 0; i-- {
 		mc := cl.Metas[i]
-		branch, _ := lineValue(mc.Commit.Msg, "Branch:")
+		branch, _ := lineValue(mc.Commit.GetMsg(), "Branch:")
 		if branch != "" {
-			cl.branch = strings.TrimPrefix(branch, "refs/heads/")
+			// Copy the branch, so a compact corpus doesn't keep
+			// the message in memory.
+			cl.branch = cl.Project.gerrit.c.strb([]byte(strings.TrimPrefix(branch, "refs/heads/")))
 			return
 		}
 	}
@@ -408,7 +443,7 @@ func lineValue(all, prefix string) (value, rest string) {
 func (cl *GerritCL) WorkInProgress() bool {
 	var wip bool
 	for _, m := range cl.Metas {
-		v, _ := lineValue(m.Commit.Msg, "Work-in-progress:")
+		v, _ := lineValue(m.Commit.GetMsg(), "Work-in-progress:")
 		switch v {
 		case "true":
 			wip = true
@@ -438,7 +473,7 @@ func (cl *GerritCL) Footer(key string) string {
 		panic("Footer key does not end in colon")
 	}
 	// TODO: git footers are treated as multimaps. Account for this.
-	v, _ := lineValue(cl.Commit.Msg, key)
+	v, _ := lineValue(cl.Commit.GetMsg(), key)
 	return v
 }
 
@@ -457,16 +492,7 @@ func (cl *GerritCL) OwnerID() int {
 	}
 	// Meta commits caused by the owner of a change have an email of the form
 	// <user id>@<uuid of gerrit server>.
-	email := cl.Metas[0].Commit.Author.Email()
-	idx := strings.Index(email, "@")
-	if idx == -1 {
-		return -1
-	}
-	id, err := strconv.Atoi(email[:idx])
-	if err != nil {
-		return -1
-	}
-	return id
+	return gerritAccountID(cl.Metas[0].Commit.Author)
 }
 
 // Owner returns the author of the first commit to the CL. It returns nil on error.
@@ -490,10 +516,11 @@ func (cl *GerritCL) Subject() string {
 	if cl.Commit == nil {
 		return ""
 	}
-	if i := strings.Index(cl.Commit.Msg, "\n"); i >= 0 {
-		return cl.Commit.Msg[:i]
+	msg := cl.Commit.GetMsg()
+	if i := strings.Index(msg, "\n"); i >= 0 {
+		return msg[:i]
 	}
-	return cl.Commit.Msg
+	return msg
 }
 
 // CommitAtVersion returns the git commit of the specifid version of this CL.
@@ -515,7 +542,7 @@ func (cl *GerritCL) updateGithubIssueRefs() {
 	gc := cl.Commit
 
 	oldRefs := cl.GitHubIssueRefs
-	newRefs := gerrit.c.parseGithubRefs(gp.proj, gc.Msg)
+	newRefs := gerrit.c.parseGithubRefs(gp.proj, gc.GetMsg())
 	cl.GitHubIssueRefs = newRefs
 	for _, ref := range newRefs {
 		if !clSliceContains(gerrit.clsReferencingGithubIssue[ref], cl) {
@@ -613,13 +640,14 @@ var statuses = []string{"merged", "abandoned", "draft", "new"}
 // "abandoned", "new", and "merged" statuses to some meta commits; you may have
 // to search the current meta commit's parents to find the last good commit.
 func getGerritStatus(commit *GitCommit) string {
-	idx := strings.Index(commit.Msg, statusIndicator)
+	msg := commit.GetMsg()
+	idx := strings.Index(msg, statusIndicator)
 	if idx == -1 {
 		return ""
 	}
 	off := idx + len(statusIndicator)
 	for _, status := range statuses {
-		if strings.HasPrefix(commit.Msg[off:], status) {
+		if strings.HasPrefix(msg[off:], status) {
 			return status
 		}
 	}
@@ -666,8 +694,9 @@ func (gp *GerritProject) getGerritMessage(commit *GitCommit) *GerritMessage {
 	const existVerPhrase = "\nPatch Set "
 	const newVerPhrase = "\nUploaded patch set "
 
-	startExist := strings.Index(commit.Msg, existVerPhrase)
-	startNew := strings.Index(commit.Msg, newVerPhrase)
+	msg := commit.GetMsg()
+	startExist := strings.Index(msg, existVerPhrase)
+	startNew := strings.Index(msg, newVerPhrase)
 	var start int
 	var phrase string
 	switch {
@@ -682,11 +711,11 @@ func (gp *GerritProject) getGerritMessage(commit *GitCommit) *GerritMessage {
 	}
 
 	numStart := start + len(phrase)
-	colon := strings.IndexByte(commit.Msg[numStart:], ':')
+	colon := strings.IndexByte(msg[numStart:], ':')
 	if colon == -1 {
 		return nil
 	}
-	num := commit.Msg[numStart : numStart+colon]
+	num := msg[numStart : numStart+colon]
 	if strings.Contains(num, "\n") || strings.Contains(num, ".") {
 		// Spanned lines. Didn't match expected comment form
 		// we care about (comments with vote changes), like:
@@ -699,11 +728,11 @@ func (gp *GerritProject) getGerritMessage(commit *GitCommit) *GerritMessage {
 	}
 	version, err := strconv.ParseInt(num, 10, 32)
 	if err != nil {
-		gp.logf("for phrase %q at %d, unexpected patch set number in %s; err: %v, message: %s", phrase, start, commit.Hash, err, commit.Msg)
+		gp.logf("for phrase %q at %d, unexpected patch set number in %s; err: %v, message: %s", phrase, start, commit.Hash, err, msg)
 		return nil
 	}
 	start++
-	v := commit.Msg[start:]
+	v := msg[start:]
 	l := 0
 	for {
 		i := strings.IndexByte(v, '\n')
@@ -712,19 +741,24 @@ func (gp *GerritProject) getGerritMessage(commit *GitCommit) *GerritMessage {
 		}
 		if strings.HasPrefix(v[:i], "Patch-set:") {
 			// two newlines before the Patch-set message
-			v = commit.Msg[start : start+l-2]
+			v = msg[start : start+l-2]
 			break
 		}
 		v = v[i+1:]
 		l = l + i + 1
 	}
-	return &GerritMessage{
+	gm := &GerritMessage{
 		Meta:    commit,
 		Author:  commit.Author,
 		Date:    commit.CommitTime,
-		Message: v,
 		Version: int32(version),
 	}
+	if commit.msg.ts != nil {
+		gm.message = commit.msg.slice(start, start+l-2)
+	} else {
+		gm.Message = v
+	}
+	return gm
 }
 
 func reverseGerritMessages(ss []*GerritMessage) {
@@ -789,8 +823,14 @@ func (gp *GerritProject) processMutation(gm *maintpb.GerritMutation) {
 			continue
 		}
 		clv := gerritCLVersion{int32(clNum64), version}
-		gp.remote[clv] = hash
 		cl := gp.getOrCreateCL(clv.CLNumber)
+		if clv.Version != 0 {
+			if old, ok := gp.remote[clv]; ok && gp.clOfCommit[old] == cl {
+				delete(gp.clOfCommit, old)
+			}
+			gp.clOfCommit[hash] = cl
+		}
+		gp.remote[clv] = hash
 
 		if clv.Version == 0 { // is a meta commit
 			cl.Meta = newGerritMeta(gc, cl)
@@ -799,11 +839,16 @@ func (gp *GerritProject) processMutation(gm *maintpb.GerritMutation) {
 			cl.Commit = gc
 			cl.Version = clv.Version
 			cl.updateGithubIssueRefs()
+			cl.updateChangeID()
 		}
 		if c.didInit {
 			gp.logf("Ref %+v => %v", clv, hash)
 		}
 	}
+
+	for _, cc := range gm.Comments {
+		gp.processCommentsMutation(cc)
+	}
 }
 
 // noteDirtyCL notes a CL that needs further processing before the corpus
@@ -853,10 +898,11 @@ func (gp *GerritProject) finishProcessingCL(cl *GerritCL) {
 	var backwardMetas []*GerritMeta
 
 	err := gp.foreachCommit(mostRecentMetaCommit, func(gc *GitCommit) error {
-		if strings.Contains(gc.Msg, "\nLabel: ") {
+		msg := gc.GetMsg()
+		if strings.Contains(msg, "\nLabel: ") {
 			gp.numLabelChanges++
 		}
-		if strings.Contains(gc.Msg, "\nPrivate: true\n") {
+		if strings.Contains(msg, "\nPrivate: true\n") {
 			cl.Private = true
 		}
 		if gc.GerritMeta == nil {
@@ -892,6 +938,10 @@ func (gp *GerritProject) finishProcessingCL(cl *GerritCL) {
 	cl.Created = cl.Metas[0].Commit.CommitTime
 
 	cl.updateBranch()
+	cl.updateTopic()
+	cl.updateCommentAuthors()
+	gp.updateCLIndex(cl)
+	gp.gerrit.c.markSearchDirty(cl)
 }
 
 // clSliceContains reports whether cls contains cl.
@@ -1092,7 +1142,7 @@ func (gp *GerritProject) syncOnce(ctx context.Context) error {
 		return err
 	}
 	if len(changedRefs) == 0 {
-		return nil
+		return gp.syncComments(ctx)
 	}
 	gp.logf("%d new refs", len(changedRefs))
 	const batchSize = 250
@@ -1125,7 +1175,7 @@ func (gp *GerritProject) syncOnce(ctx context.Context) error {
 			}})
 		changedRefs = changedRefs[len(batch):]
 	}
-	return nil
+	return gp.syncComments(ctx)
 }
 
 func (gp *GerritProject) syncCommits(ctx context.Context) (n int, err error) {
@@ -1317,7 +1367,7 @@ const (
 func newGerritMeta(gc *GitCommit, cl *GerritCL) *GerritMeta {
 	m := &GerritMeta{Commit: gc, CL: cl}
 
-	if msg := m.Commit.Msg; strings.Contains(msg, "autogenerated:gerrit:setHashtag") && m.ActionTag() == "autogenerated:gerrit:setHashtag" {
+	if msg := m.Commit.GetMsg(); strings.Contains(msg, "autogenerated:gerrit:setHashtag") && m.ActionTag() == "autogenerated:gerrit:setHashtag" {
 		m.flags |= metaFlagHashtagEdit
 	}
 	return m
@@ -1325,11 +1375,12 @@ func newGerritMeta(gc *GitCommit, cl *GerritCL) *GerritMeta {
 
 // Footer returns the "key: value" lines at the base of the commit.
 func (m *GerritMeta) Footer() string {
-	i := strings.LastIndex(m.Commit.Msg, "\n\n")
+	msg := m.Commit.GetMsg()
+	i := strings.LastIndex(msg, "\n\n")
 	if i == -1 {
 		return ""
 	}
-	return m.Commit.Msg[i+2:]
+	return msg[i+2:]
 }
 
 // Hashtags returns the current set of hashtags.
@@ -1353,7 +1404,7 @@ func (m *GerritMeta) HashtagEdits() (added, removed GerritHashtags, ok bool) {
 		return
 	}
 
-	msg := m.Commit.Msg
+	msg := m.Commit.GetMsg()
 
 	// Parse lines of form:
 	//
@@ -0,0 +1,486 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Logic to parse and sync the published inline comments that Gerrit
+// stores as notes in the tree of a CL's NoteDb meta commits.
+
+package maintner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// GerritComment is a published comment on a file or line of a patch
+// set of a CL.
+type GerritComment struct {
+	// UUID identifies the comment.
+	UUID string
+
+	// ParentUUID is the UUID of the comment this one replies to.
+	// It's empty for the first comment of a thread.
+	ParentUUID string
+
+	// Version is the patch set version the comment is on.
+	Version int32
+
+	// File is the path of the file the comment is on, or
+	// "/COMMIT_MSG" for the commit message.
+	File string
+
+	// Line is the line the comment is on, or 0 for comments on the
+	// file as a whole.
+	Line int
+
+	// Range is the range of text the comment is on, if any.
+	Range *GerritCommentRange
+
+	// Side is 1 for comments on the patch set itself, and 0 or
+	// negative for comments on (one of) its parents.
+	Side int
+
+	// AuthorID is the Gerrit account ID of the comment's author.
+	AuthorID int
+
+	// Author is the author of the comment, as found in the CL's meta
+	// commits. It's nil if the author never made a meta commit on the
+	// CL, which should never happen, as publishing comments makes one.
+	Author *GitPerson
+
+	// Written is when the comment was published.
+	Written time.Time
+
+	// Message is the comment's text. It's empty in compact mode;
+	// use GetMessage.
+	Message string
+
+	// Unresolved is whether the comment marked its thread as
+	// needing attention.
+	Unresolved bool
+
+	// Revision is the commit the comment is on.
+	Revision string
+
+	message textRef // in compact mode
+}
+
+// GetMessage returns the comment's text. Unlike Message, it works in
+// compact mode.
+func (c *GerritComment) GetMessage() string { return textOf(c.Message, c.message) }
+
+// GerritCommentRange is a range of text a GerritComment is on.
+// Lines are 1-based; characters are 0-based offsets in the line.
+type GerritCommentRange struct {
+	StartLine, StartChar int
+	EndLine, EndChar     int
+}
+
+// GerritCommentThread is a comment and its replies.
+type GerritCommentThread struct {
+	// Comments are the comments in the thread, oldest first.
+	// Comments[0] is the one that started the thread.
+	Comments []*GerritComment
+}
+
+// Root returns the comment that started the thread.
+func (t *GerritCommentThread) Root() *GerritComment { return t.Comments[0] }
+
+// Last returns the most recent comment in the thread.
+func (t *GerritCommentThread) Last() *GerritComment { return t.Comments[len(t.Comments)-1] }
+
+// Unresolved reports whether the thread needs attention, which is
+// decided by its most recent comment.
+func (t *GerritCommentThread) Unresolved() bool { return t.Last().Unresolved }
+
+// ForeachComment calls fn for each published inline comment on the
+// CL.
+//
+// If fn returns an error, iteration ends and ForeachComment returns
+// with that error.
+//
+// The fn function is called serially, in order of the comment's time.
+func (cl *GerritCL) ForeachComment(fn func(*GerritComment) error) error {
+	for _, c := range cl.sortedComments() {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cl *GerritCL) sortedComments() []*GerritComment {
+	s := make([]*GerritComment, 0, len(cl.comments))
+	for _, c := range cl.comments {
+		s = append(s, c)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		wi, wj := s[i].Written, s[j].Written
+		if wi.Before(wj) {
+			return true
+		}
+		return wi.Equal(wj) && s[i].UUID < s[j].UUID
+	})
+	return s
+}
+
+// CommentThreads returns the CL's inline comments grouped into
+// threads, in order of the time each thread was started.
+func (cl *GerritCL) CommentThreads() []*GerritCommentThread {
+	var threads []*GerritCommentThread
+	byRoot := make(map[string]*GerritCommentThread)
+	for _, c := range cl.sortedComments() {
+		root := cl.threadRoot(c)
+		t, ok := byRoot[root.UUID]
+		if !ok {
+			t = new(GerritCommentThread)
+			byRoot[root.UUID] = t
+			threads = append(threads, t)
+		}
+		t.Comments = append(t.Comments, c)
+	}
+	return threads
+}
+
+// threadRoot returns the first comment of c's thread. A comment
+// whose parent is unknown starts a thread of its own.
+func (cl *GerritCL) threadRoot(c *GerritComment) *GerritComment {
+	for i := 0; c.ParentUUID != "" && i < len(cl.comments); i++ {
+		parent, ok := cl.comments[c.ParentUUID]
+		if !ok {
+			break
+		}
+		c = parent
+	}
+	return c
+}
+
+// UnresolvedCommentThreads returns the number of the CL's comment
+// threads that are unresolved.
+func (cl *GerritCL) UnresolvedCommentThreads() int {
+	n := 0
+	for _, t := range cl.CommentThreads() {
+		if t.Unresolved() {
+			n++
+		}
+	}
+	return n
+}
+
+// gerritAccountID returns the Gerrit account ID of the author of a
+// meta commit, whose email is of the form <account ID>@<server UUID>,
+// or -1 if p isn't of that form.
+func gerritAccountID(p *GitPerson) int {
+	email := p.Email()
+	idx := strings.Index(email, "@")
+	if idx == -1 {
+		return -1
+	}
+	id, err := strconv.Atoi(email[:idx])
+	if err != nil {
+		return -1
+	}
+	return id
+}
+
+// updateCommentAuthors sets the Author of the CL's comments that
+// lack one, from the authors of its meta commits.
+//
+// called with Corpus.mu Locked
+func (cl *GerritCL) updateCommentAuthors() {
+	var people map[int]*GitPerson
+	for _, c := range cl.comments {
+		if c.Author != nil {
+			continue
+		}
+		if people == nil {
+			people = make(map[int]*GitPerson)
+			for _, m := range cl.Metas {
+				if a := m.Commit.Author; a != nil {
+					people[gerritAccountID(a)] = a
+				}
+			}
+		}
+		c.Author = people[c.AuthorID]
+	}
+}
+
+// processCommentsMutation records the comments in cc.
+//
+// called with Corpus.mu Locked
+func (gp *GerritProject) processCommentsMutation(cc *maintpb.GerritCLComments) {
+	if len(cc.MetaSha1) != 40 {
+		gp.logf("ignoring comments on CL %d with bogus meta hash %q", cc.ClNumber, cc.MetaSha1)
+		return
+	}
+	cl := gp.getOrCreateCL(cc.ClNumber)
+	for _, pc := range cc.Comments {
+		if pc.Uuid == "" {
+			gp.logf("ignoring comment on CL %d lacking UUID: %v", cc.ClNumber, pc)
+			continue
+		}
+		if cl.comments == nil {
+			cl.comments = make(map[string]*GerritComment)
+		}
+		cl.comments[pc.Uuid] = gp.gerrit.c.newGerritComment(pc)
+	}
+	cl.commentsMeta = gp.gerrit.c.gitHashFromHexStr(cc.MetaSha1)
+	if cl.Meta != nil {
+		gp.noteDirtyCL(cl) // to find the comments' authors
+	}
+}
+
+// c.mu must be held for writing.
+func (c *Corpus) newGerritComment(pc *maintpb.GerritComment) *GerritComment {
+	cm := &GerritComment{
+		UUID:       pc.Uuid,
+		ParentUUID: pc.ParentUuid,
+		Version:    pc.PatchSet,
+		File:       pc.File,
+		Line:       int(pc.Line),
+		Side:       int(pc.Side),
+		AuthorID:   int(pc.AuthorId),
+		Unresolved: pc.Unresolved,
+		Revision:   pc.RevSha1,
+	}
+	c.setText(&cm.Message, &cm.message, pc.Message)
+	if r := pc.Range; r != nil {
+		cm.Range = &GerritCommentRange{
+			StartLine: int(r.StartLine),
+			StartChar: int(r.StartChar),
+			EndLine:   int(r.EndLine),
+			EndChar:   int(r.EndChar),
+		}
+	}
+	if pc.Written != nil {
+		cm.Written, _ = ptypes.Timestamp(pc.Written)
+		cm.Written = cm.Written.UTC()
+	}
+	return cm
+}
+
+// maxCommentSyncsPerPass is the most CLs whose comments syncComments
+// reads from git per sync, so backfilling the comments of old CLs
+// doesn't hold up syncing new refs.
+const maxCommentSyncsPerPass = 500
+
+// clsWithStaleComments returns up to max CLs whose comments haven't
+// been read from their current meta commit, most recent CLs first,
+// along with those meta commits.
+func (gp *GerritProject) clsWithStaleComments(max int) (nums []int32, metas []GitHash) {
+	c := gp.gerrit.c
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for num, cl := range gp.cls {
+		if cl.Meta != nil && cl.commentsMeta != cl.Meta.Commit.Hash {
+			nums = append(nums, num)
+		}
+	}
+	sort.Slice(nums, func(i, j int) bool { return nums[i] > nums[j] })
+	if len(nums) > max {
+		nums = nums[:max]
+	}
+	for _, num := range nums {
+		metas = append(metas, gp.cls[num].Meta.Commit.Hash)
+	}
+	return nums, metas
+}
+
+// syncComments records the new and changed inline comments of CLs
+// whose meta commits changed since their comments were last read.
+func (gp *GerritProject) syncComments(ctx context.Context) error {
+	c := gp.gerrit.c
+	nums, metas := gp.clsWithStaleComments(maxCommentSyncsPerPass)
+	if len(nums) == 0 {
+		return nil
+	}
+	gp.logf("syncing comments of %d CLs", len(nums))
+
+	const batchSize = 50
+	var batch []*maintpb.GerritCLComments
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		c.addMutation(&maintpb.Mutation{
+			Gerrit: &maintpb.GerritMutation{
+				Project:  gp.proj,
+				Comments: batch,
+			},
+		})
+		batch = nil
+	}
+	for i, num := range nums {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		meta := metas[i]
+		comments, err := gp.readNoteDbComments(ctx, meta)
+		if err != nil {
+			// Perhaps this git directory predates the
+			// meta commit being fetched. Try once more.
+			if ferr := gp.fetchHashes(ctx, []GitHash{meta}); ferr == nil {
+				comments, err = gp.readNoteDbComments(ctx, meta)
+			}
+		}
+		if err != nil {
+			// Don't retry forever; the comments will be read
+			// again when the CL's meta ref next changes.
+			gp.logf("reading comments of CL %d at meta %v: %v", num, meta, err)
+		}
+		c.mu.RLock()
+		cl := gp.cls[num]
+		var changed []*maintpb.GerritComment
+		for _, pc := range comments {
+			if old, ok := cl.comments[pc.Uuid]; !ok || gerritCommentChanged(old, pc) {
+				changed = append(changed, pc)
+			}
+		}
+		c.mu.RUnlock()
+		batch = append(batch, &maintpb.GerritCLComments{
+			ClNumber: num,
+			MetaSha1: meta.String(),
+			Comments: changed,
+		})
+		if len(batch) == batchSize {
+			flush()
+		}
+	}
+	flush()
+	return nil
+}
+
+// gerritCommentChanged reports whether pc differs from old in any
+// way the author can change after publishing. Gerrit admins can
+// rewrite a comment's message to delete it.
+func gerritCommentChanged(old *GerritComment, pc *maintpb.GerritComment) bool {
+	return old.GetMessage() != pc.Message || old.Unresolved != pc.Unresolved
+}
+
+// readNoteDbComments returns the published inline comments stored in
+// the tree of the meta commit meta.
+func (gp *GerritProject) readNoteDbComments(ctx context.Context, meta GitHash) ([]*maintpb.GerritComment, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-tree", "-r", "-z", meta.String())
+	cmd.Dir = gp.gitDir()
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-tree %v: %v", meta, formatExecError(err))
+	}
+	var comments []*maintpb.GerritComment
+	for _, ent := range bytes.Split(out, []byte{0}) {
+		// "<mode> SP <type> SP <object> TAB <file>"
+		tab := bytes.IndexByte(ent, '\t')
+		if tab == -1 {
+			continue
+		}
+		f := strings.Fields(string(ent[:tab]))
+		if len(f) != 3 || f[1] != "blob" {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, "git", "cat-file", "blob", f[2])
+		cmd.Dir = gp.gitDir()
+		note, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file blob %s: %v", f[2], formatExecError(err))
+		}
+		cs, err := parseNoteDbComments(note)
+		if err != nil {
+			return nil, fmt.Errorf("parsing comments note %s in meta %v: %v", ent[tab+1:], meta, err)
+		}
+		comments = append(comments, cs...)
+	}
+	return comments, nil
+}
+
+// noteDbComments is the JSON format of a NoteDb revision note: the
+// published comments on one patch set.
+type noteDbComments struct {
+	Comments []struct {
+		Key struct {
+			UUID       string `json:"uuid"`
+			Filename   string `json:"filename"`
+			PatchSetID int32  `json:"patchSetId"`
+		} `json:"key"`
+		LineNbr int32 `json:"lineNbr"`
+		Author  struct {
+			ID int64 `json:"id"`
+		} `json:"author"`
+		WrittenOn  string `json:"writtenOn"`
+		Side       int32  `json:"side"`
+		Message    string `json:"message"`
+		ParentUUID string `json:"parentUuid"`
+		Range      *struct {
+			StartLine int32 `json:"startLine"`
+			StartChar int32 `json:"startChar"`
+			EndLine   int32 `json:"endLine"`
+			EndChar   int32 `json:"endChar"`
+		} `json:"range"`
+		RevID      string `json:"revId"`
+		Unresolved bool   `json:"unresolved"`
+	} `json:"comments"`
+}
+
+// noteDbTimeLayouts are the layouts Gerrit has used for writtenOn.
+var noteDbTimeLayouts = []string{
+	"Jan 2, 2006 3:04:05 PM",
+	"2006-01-02 15:04:05.999999999",
+	time.RFC3339Nano,
+}
+
+// parseNoteDbComments parses a NoteDb revision note. Notes in the
+// legacy, pre-JSON text format are ignored.
+func parseNoteDbComments(note []byte) ([]*maintpb.GerritComment, error) {
+	note = bytes.TrimSpace(note)
+	if len(note) == 0 || note[0] != '{' {
+		return nil, nil
+	}
+	var nc noteDbComments
+	if err := json.Unmarshal(note, &nc); err != nil {
+		return nil, err
+	}
+	var comments []*maintpb.GerritComment
+	for _, jc := range nc.Comments {
+		pc := &maintpb.GerritComment{
+			Uuid:       jc.Key.UUID,
+			ParentUuid: jc.ParentUUID,
+			PatchSet:   jc.Key.PatchSetID,
+			File:       jc.Key.Filename,
+			Line:       jc.LineNbr,
+			Side:       jc.Side,
+			AuthorId:   jc.Author.ID,
+			Message:    jc.Message,
+			Unresolved: jc.Unresolved,
+			RevSha1:    jc.RevID,
+		}
+		if r := jc.Range; r != nil {
+			pc.Range = &maintpb.GerritCommentRange{
+				StartLine: r.StartLine,
+				StartChar: r.StartChar,
+				EndLine:   r.EndLine,
+				EndChar:   r.EndChar,
+			}
+		}
+		for _, layout := range noteDbTimeLayouts {
+			if t, err := time.Parse(layout, jc.WrittenOn); err == nil {
+				pc.Written, _ = ptypes.TimestampProto(t)
+				break
+			}
+		}
+		if pc.Written == nil {
+			log.Printf("maintner: unknown time format %q in comment %s", jc.WrittenOn, jc.Key.UUID)
+		}
+		comments = append(comments, pc)
+	}
+	return comments, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+const testCommentsNote = `{
+  "comments": [
+    {
+      "key": {
+        "uuid": "9f8a1c4e_1d2b7a36",
+        "filename": "src/fmt/print.go",
+        "patchSetId": 2
+      },
+      "lineNbr": 37,
+      "author": {
+        "id": 5206
+      },
+      "writtenOn": "Mar 3, 2018 7:45:12 PM",
+      "side": 1,
+      "message": "Why not use a strings.Builder here?",
+      "range": {
+        "startLine": 37,
+        "startChar": 2,
+        "endLine": 37,
+        "endChar": 19
+      },
+      "revId": "7b9fb2a4b8f1c2e1f3c02d7f2c6e4a1a0f4d2c10",
+      "serverId": "62eb7196-b449-3ce5-99f1-c037f21e1705",
+      "unresolved": true
+    },
+    {
+      "key": {
+        "uuid": "3c6f0e1a_77b0d8e2",
+        "filename": "src/fmt/print.go",
+        "patchSetId": 2
+      },
+      "lineNbr": 37,
+      "author": {
+        "id": 137
+      },
+      "writtenOn": "Mar 4, 2018 9:02:00 AM",
+      "side": 1,
+      "message": "Done",
+      "parentUuid": "9f8a1c4e_1d2b7a36",
+      "revId": "7b9fb2a4b8f1c2e1f3c02d7f2c6e4a1a0f4d2c10",
+      "serverId": "62eb7196-b449-3ce5-99f1-c037f21e1705",
+      "unresolved": false
+    }
+  ]
+}`
+
+func TestParseNoteDbComments(t *testing.T) {
+	cs, err := parseNoteDbComments([]byte(testCommentsNote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cs) != 2 {
+		t.Fatalf("got %d comments; want 2", len(cs))
+	}
+	c := cs[0]
+	if c.Uuid != "9f8a1c4e_1d2b7a36" || c.File != "src/fmt/print.go" || c.PatchSet != 2 ||
+		c.Line != 37 || c.AuthorId != 5206 || !c.Unresolved || c.Side != 1 {
+		t.Errorf("first comment = %v", c)
+	}
+	if c.Range == nil || c.Range.StartChar != 2 || c.Range.EndChar != 19 {
+		t.Errorf("first comment range = %v", c.Range)
+	}
+	if c.Written == nil || c.Written.Seconds != t3339("2018-03-03T19:45:12Z").Unix() {
+		t.Errorf("first comment written = %v; want 2018-03-03T19:45:12Z", c.Written)
+	}
+	if cs[1].ParentUuid != c.Uuid || cs[1].Unresolved || cs[1].Range != nil {
+		t.Errorf("second comment = %v", cs[1])
+	}
+
+	// Legacy text notes are ignored.
+	cs, err = parseNoteDbComments([]byte("Revision: 7b9fb2a4b8f1c2e1f3c02d7f2c6e4a1a0f4d2c10\nPatch-set: 2\n"))
+	if err != nil || cs != nil {
+		t.Errorf("legacy note = %v, %v; want nil, nil", cs, err)
+	}
+}
+
+func TestGerritCommentThreads(t *testing.T) {
+	c := new(Corpus)
+	c.initGerrit()
+	gp := c.gerrit.getOrCreateProject("go.googlesource.com/go")
+	cl := gp.getOrCreateCL(1234)
+	for _, who := range []string{"Rick Sanchez <137@62eb7196>", "Morty Smith <5206@62eb7196>"} {
+		cl.Metas = append(cl.Metas, newGerritMeta(&GitCommit{Author: &GitPerson{Str: who}}, cl))
+	}
+	cl.Meta = cl.Metas[len(cl.Metas)-1]
+
+	pcs, err := parseNoteDbComments([]byte(testCommentsNote))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An unresolved comment in a thread of its own.
+	pcs = append(pcs, &maintpb.GerritComment{
+		Uuid:       "aa11bb22_0c0d0e0f",
+		PatchSet:   3,
+		File:       "/COMMIT_MSG",
+		AuthorId:   5206,
+		Written:    p3339("2018-03-05T00:00:00Z"),
+		Message:    "Please mention the issue.",
+		Unresolved: true,
+	})
+	c.mu.Lock()
+	gp.processMutation(&maintpb.GerritMutation{
+		Project: gp.proj,
+		Comments: []*maintpb.GerritCLComments{{
+			ClNumber: 1234,
+			MetaSha1: strings.Repeat("ab", 20),
+			Comments: pcs,
+		}},
+	})
+	c.mu.Unlock()
+	cl.updateCommentAuthors() // normally done by finishProcessingCL
+
+	if got, want := cl.commentsMeta.String(), strings.Repeat("ab", 20); got != want {
+		t.Errorf("commentsMeta = %v; want %v", got, want)
+	}
+	threads := cl.CommentThreads()
+	if len(threads) != 2 {
+		t.Fatalf("got %d threads; want 2", len(threads))
+	}
+	if th := threads[0]; len(th.Comments) != 2 || th.Root().UUID != "9f8a1c4e_1d2b7a36" || th.Unresolved() {
+		t.Errorf("first thread = %+v; want resolved thread of 2 comments", th.Comments)
+	}
+	if th := threads[1]; len(th.Comments) != 1 || !th.Unresolved() {
+		t.Errorf("second thread = %+v; want 1 unresolved comment", th.Comments)
+	}
+	if n := cl.UnresolvedCommentThreads(); n != 1 {
+		t.Errorf("UnresolvedCommentThreads = %d; want 1", n)
+	}
+	var authors []string
+	cl.ForeachComment(func(gc *GerritComment) error {
+		authors = append(authors, gc.Author.Name())
+		return nil
+	})
+	if got, want := strings.Join(authors, ","), "Morty Smith,Rick Sanchez,Morty Smith"; got != want {
+		t.Errorf("authors = %s; want %s", got, want)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Topic returns the CL's topic, or the empty string if it has none.
+func (cl *GerritCL) Topic() string { return cl.topic }
+
+func (cl *GerritCL) updateTopic() {
+	for i := len(cl.Metas) - 1; i >= 0; i-- {
+		// An empty value means the topic was removed.
+		if topic, ok := metaFooterValue(cl.Metas[i], "Topic:"); ok {
+			cl.topic = cl.Project.gerrit.c.strb([]byte(topic))
+			return
+		}
+	}
+	cl.topic = ""
+}
+
+// metaFooterValue returns the value of the footer line of m for key,
+// which must end in a colon, and whether m has one. Unlike lineValue,
+// it distinguishes an empty value from a missing line.
+func metaFooterValue(m *GerritMeta, key string) (value string, ok bool) {
+	for _, line := range strings.Split(m.Footer(), "\n") {
+		if strings.HasPrefix(line, key) {
+			return strings.TrimSpace(line[len(key):]), true
+		}
+	}
+	return "", false
+}
+
+// updateChangeID indexes cl under the Change-Id of its latest commit.
+//
+// called with Corpus.mu Locked
+func (cl *GerritCL) updateChangeID() {
+	gp := cl.Project
+	id := cl.ChangeID()
+	if id == cl.changeID {
+		return
+	}
+	if old := cl.changeID; old != "" {
+		cls := gp.clsByChangeID[old]
+		for i, v := range cls {
+			if v == cl {
+				cls = append(cls[:i:i], cls[i+1:]...)
+				break
+			}
+		}
+		if len(cls) == 0 {
+			delete(gp.clsByChangeID, old)
+		} else {
+			gp.clsByChangeID[old] = cls
+		}
+	}
+	cl.changeID = ""
+	if id == "" {
+		return
+	}
+	cl.changeID = gp.gerrit.c.strb([]byte(id))
+	cls := gp.clsByChangeID[cl.changeID]
+	i := sort.Search(len(cls), func(i int) bool { return cls[i].Number >= cl.Number })
+	cls = append(cls, nil)
+	copy(cls[i+1:], cls[i:])
+	cls[i] = cl
+	gp.clsByChangeID[cl.changeID] = cls
+}
+
+// CherryPicks returns the other CLs in cl's project with the same
+// Change-Id on other branches, usually cherry-picks of cl or of the
+// CL it's a cherry-pick of. They're sorted by number.
+func (cl *GerritCL) CherryPicks() []*GerritCL {
+	if cl.changeID == "" {
+		return nil
+	}
+	var cls []*GerritCL
+	for _, other := range cl.Project.clsByChangeID[cl.changeID] {
+		if other != cl && other.Branch() != cl.Branch() {
+			cls = append(cls, other)
+		}
+	}
+	return cls
+}
+
+// baseCL returns the CL whose patch set is the parent of gc, the
+// commit of a patch set of a CL in gp, along with that parent. It
+// returns nil if there's no such CL, or it's merged, in which case
+// gc is based on the branch rather than on another CL.
+func (gp *GerritProject) baseCL(gc *GitCommit) (*GerritCL, *GitCommit) {
+	if len(gc.Parents) == 0 {
+		return nil, nil
+	}
+	parent := gc.Parents[0]
+	cl := gp.clOfCommit[parent.Hash]
+	if cl == nil || cl.Status == "merged" {
+		return nil, nil
+	}
+	return cl, parent
+}
+
+// RelatedChanges returns the other CLs in cl's relation chain, as
+// Gerrit's "Related changes" do: the unmerged CLs that cl's latest
+// patch set is based on, directly or through each other, and the
+// open CLs whose latest patch sets are based on any patch set of cl.
+// They're ordered from the base of the chain to its tip. CLs based on
+// a merged CL are based on its branch, so aren't included.
+func (cl *GerritCL) RelatedChanges() []*GerritCL {
+	gp := cl.Project
+	if cl.Commit == nil {
+		return nil
+	}
+	inChain := map[*GerritCL]bool{cl: true}
+	var ancestors []*GerritCL
+	for base, gc := gp.baseCL(cl.Commit); base != nil && !inChain[base]; base, gc = gp.baseCL(gc) {
+		inChain[base] = true
+		ancestors = append(ancestors, base)
+	}
+
+	// Descendants are found from their end, so each open CL's
+	// chain is walked until it reaches cl or leaves the chain.
+	type descendant struct {
+		cl    *GerritCL
+		depth int // number of CLs from cl, counting itself
+	}
+	var descendants []descendant
+	for _, other := range gp.cls {
+		if other.Status != "new" || other.Commit == nil || inChain[other] {
+			continue
+		}
+		depth := 1
+		for base, gc := gp.baseCL(other.Commit); base != nil && base != other; base, gc = gp.baseCL(gc) {
+			if base == cl {
+				descendants = append(descendants, descendant{other, depth})
+				break
+			}
+			depth++
+		}
+	}
+	sort.Slice(descendants, func(i, j int) bool {
+		di, dj := descendants[i], descendants[j]
+		if di.depth != dj.depth {
+			return di.depth < dj.depth
+		}
+		return di.cl.Number < dj.cl.Number
+	})
+
+	related := make([]*GerritCL, 0, len(ancestors)+len(descendants))
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		related = append(related, ancestors[i])
+	}
+	for _, d := range descendants {
+		related = append(related, d.cl)
+	}
+	return related
+}
+
+var (
+	rxRevertsCL     = regexp.MustCompile(`(?m)^This reverts CL (\d+)`)
+	rxRevertsCommit = regexp.MustCompile(`(?m)^This reverts commit ([0-9a-f]{40})`)
+)
+
+// RevertOf returns the CL that cl reverts, or nil if it isn't a
+// revert of a known CL in its project. It uses the "Revert-of"
+// footer Gerrit adds to reverts it creates, and otherwise the
+// "This reverts CL nnn." or "This reverts commit xxx." line of the
+// commit message.
+func (cl *GerritCL) RevertOf() *GerritCL {
+	gp := cl.Project
+	for _, m := range cl.Metas {
+		if v, _ := metaFooterValue(m, "Revert-of:"); v != "" {
+			num, err := strconv.ParseInt(v, 10, 32)
+			if err != nil {
+				return nil
+			}
+			return gp.cls[int32(num)]
+		}
+	}
+	if cl.Commit == nil {
+		return nil
+	}
+	msg := cl.Commit.GetMsg()
+	if !strings.Contains(msg, "This reverts ") {
+		return nil
+	}
+	if m := rxRevertsCL.FindStringSubmatch(msg); m != nil {
+		num, err := strconv.ParseInt(m[1], 10, 32)
+		if err != nil {
+			return nil
+		}
+		return gp.cls[int32(num)]
+	}
+	if m := rxRevertsCommit.FindStringSubmatch(msg); m != nil {
+		// Not gitHashFromHexStr, which interns the hash, modifying
+		// the corpus.
+		h, err := hex.DecodeString(m[1])
+		if err != nil {
+			return nil
+		}
+		return gp.clOfCommit[GitHash(h)]
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// newRelationsTestCorpus returns a corpus with a Gerrit project
+// holding a stack of CLs, a cherry-pick and reverts.
+func newRelationsTestCorpus(t *testing.T) *Corpus {
+	gm := &maintpb.GerritMutation{Project: "go.googlesource.com/go"}
+	n := 0
+	commit := func(parent, msg string) string {
+		n++
+		sha := fmt.Sprintf("%040x", n)
+		gm.Commits = append(gm.Commits, &maintpb.GitCommit{
+			Sha1: sha,
+			Raw:  testRawCommit(parent, "Gopher <5206@62eb7196>", 1514764800+int64(n), "+0000", msg),
+		})
+		return sha
+	}
+	changeID := func(num int) string { return fmt.Sprintf("\nChange-Id: I%040d\n", num) }
+	// cl adds CL num with the patch set ps and a meta commit with
+	// each of the footers.
+	cl := func(num int, ps string, footers ...string) {
+		meta := ""
+		for _, f := range footers {
+			meta = commit(meta, "Update change\n\nPatch-set: 1\n"+f+"\n")
+		}
+		gm.Refs = append(gm.Refs,
+			&maintpb.GitRef{Ref: fmt.Sprintf("refs/changes/%02d/%d/1", num%100, num), Sha1: ps},
+			&maintpb.GitRef{Ref: fmt.Sprintf("refs/changes/%02d/%d/meta", num%100, num), Sha1: meta})
+	}
+	const open = "Branch: refs/heads/master\nStatus: new"
+
+	base := commit("", "initial commit\n")
+	ps5 := commit(base, "merged\n"+changeID(5))
+	cl(5, ps5, "Branch: refs/heads/master\nStatus: merged")
+
+	// 10 <- 11 <- 12, and 10 <- 13, all based on merged CL 5.
+	ps10 := commit(ps5, "first\n"+changeID(10))
+	cl(10, ps10, open+"\nTopic: stack")
+	ps11 := commit(ps10, "second\n"+changeID(11))
+	cl(11, ps11, open+"\nTopic: stack", "Topic:")
+	cl(12, commit(ps11, "third\n"+changeID(12)), open)
+	cl(13, commit(ps10, "sibling\n"+changeID(13)), open)
+
+	// A cherry-pick of CL 10.
+	cl(20, commit(base, "first\n"+changeID(10)), "Branch: refs/heads/release-branch.go1.11\nStatus: new")
+
+	cl(30, commit(base, "Revert \"first\"\n\nThis reverts CL 10.\n"+changeID(30)), open)
+	cl(31, commit(base, "Revert \"merged\"\n\nThis reverts commit "+ps5+".\n"+changeID(31)), open)
+	cl(32, commit(base, "Revert \"second\"\n\nReason for revert: broke the build.\n"+changeID(32)), open+"\nRevert-of: 11")
+
+	c := new(Corpus)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.processMutationLocked(&maintpb.Mutation{Gerrit: gm})
+	c.finishProcessing()
+	return c
+}
+
+// clNumbers returns the numbers of cls, separated by commas.
+func clNumbers(cls []*GerritCL) string {
+	var nums []string
+	for _, cl := range cls {
+		nums = append(nums, fmt.Sprint(cl.Number))
+	}
+	return strings.Join(nums, ",")
+}
+
+func TestGerritRelations(t *testing.T) {
+	c := newRelationsTestCorpus(t)
+	t.Run("processed", func(t *testing.T) { testGerritRelations(t, c) })
+
+	// The indexes are rebuilt from a snapshot.
+	var buf bytes.Buffer
+	if err := c.encodeSnapshot(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	dec := gob.NewDecoder(&buf)
+	var hdr snapshotHeader
+	if err := dec.Decode(&hdr); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := decodeSnapshot(dec, &hdr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("snapshot", func(t *testing.T) { testGerritRelations(t, sc) })
+}
+
+func testGerritRelations(t *testing.T, c *Corpus) {
+	gp := c.Gerrit().Project("go.googlesource.com", "go")
+	for _, tt := range []struct {
+		cl          int32
+		topic       string
+		related     string
+		cherryPicks string
+		revertOf    int32
+	}{
+		{cl: 5},
+		{cl: 10, topic: "stack", related: "11,13,12", cherryPicks: "20"},
+		{cl: 11, related: "10,12"},
+		{cl: 12, related: "10,11"},
+		{cl: 13, related: "10"},
+		{cl: 20, cherryPicks: "10"},
+		{cl: 30, revertOf: 10},
+		{cl: 31, revertOf: 5},
+		{cl: 32, revertOf: 11},
+	} {
+		cl := gp.CL(tt.cl)
+		if cl == nil {
+			t.Fatalf("CL %d not found", tt.cl)
+		}
+		if got := cl.Topic(); got != tt.topic {
+			t.Errorf("CL %d: Topic = %q; want %q", tt.cl, got, tt.topic)
+		}
+		if got := clNumbers(cl.RelatedChanges()); got != tt.related {
+			t.Errorf("CL %d: RelatedChanges = %q; want %q", tt.cl, got, tt.related)
+		}
+		if got := clNumbers(cl.CherryPicks()); got != tt.cherryPicks {
+			t.Errorf("CL %d: CherryPicks = %q; want %q", tt.cl, got, tt.cherryPicks)
+		}
+		var revertOf int32
+		if r := cl.RevertOf(); r != nil {
+			revertOf = r.Number
+		}
+		if revertOf != tt.revertOf {
+			t.Errorf("CL %d: RevertOf = %d; want %d", tt.cl, revertOf, tt.revertOf)
+		}
+	}
+}
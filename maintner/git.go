@@ -18,6 +18,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"golang.org/x/build/maintner/maintpb"
 )
 
@@ -71,9 +72,35 @@ type GitCommit struct {
 	Committer  *GitPerson
 	Reviewer   *GitPerson
 	CommitTime time.Time
-	Msg        string // Commit message subject and body
-	Files      []*maintpb.GitDiffTreeFile
-	GerritMeta *GerritMeta // non-nil if it's a Gerrit NoteDB meta commit
+	Msg        string                     // Commit message subject and body; empty in compact mode (use GetMsg)
+	Files      []*maintpb.GitDiffTreeFile // nil in compact mode (use GetFiles)
+	GerritMeta *GerritMeta                // non-nil if it's a Gerrit NoteDB meta commit
+
+	msg, files textRef // in compact mode
+}
+
+// GetMsg returns the commit message subject and body. Unlike Msg, it
+// works in compact mode.
+func (gc *GitCommit) GetMsg() string { return textOf(gc.Msg, gc.msg) }
+
+// GetFiles returns the files the commit changed, sorted by name. Unlike
+// Files, it works in compact mode.
+func (gc *GitCommit) GetFiles() []*maintpb.GitDiffTreeFile {
+	if gc.files.ts == nil {
+		return gc.Files
+	}
+	return diffTreeFiles(gc.Hash, gc.files)
+}
+
+// diffTreeFiles returns the files of the diff tree of commit h, kept
+// in a text store as ref.
+func diffTreeFiles(h GitHash, ref textRef) []*maintpb.GitDiffTreeFile {
+	dt := new(maintpb.GitDiffTree)
+	if err := proto.Unmarshal([]byte(ref.String()), dt); err != nil {
+		log.Printf("maintner: unmarshaling diff tree of %v: %v", h, err)
+		return nil
+	}
+	return dt.File
 }
 
 func (gc *GitCommit) String() string {
@@ -111,7 +138,7 @@ func (gc *GitCommit) hasAncestor(ancestor *GitCommit, checked map[*GitCommit]boo
 
 // Summary returns the first line of the commit message.
 func (gc *GitCommit) Summary() string {
-	s := gc.Msg
+	s := gc.GetMsg()
 	if i := strings.IndexByte(s, '\n'); i != -1 {
 		s = s[:i]
 	}
@@ -122,11 +149,12 @@ func (gc *GitCommit) Summary() string {
 // SameDiffStat reports whether gc has the same diff stat numbers as b.
 // If either is unknown, false is returned.
 func (gc *GitCommit) SameDiffStat(b *GitCommit) bool {
-	if len(gc.Files) != len(b.Files) {
+	aFiles, bFiles := gc.GetFiles(), b.GetFiles()
+	if len(aFiles) != len(bFiles) {
 		return false
 	}
-	for i, af := range gc.Files {
-		bf := b.Files[i]
+	for i, af := range aFiles {
+		bf := bFiles[i]
 		if af == nil || bf == nil {
 			return false
 		}
@@ -326,12 +354,12 @@ func (c *Corpus) indexCommit(conf polledGitCommits, hash GitHash) error {
 
 // c.mu is held for writing.
 func (c *Corpus) processGitMutation(m *maintpb.GitMutation) {
-	commit := m.Commit
-	if commit == nil {
-		return
+	if commit := m.Commit; commit != nil {
+		c.processGitCommit(commit)
+	}
+	if name := m.GetRepo().GetName(); name != "" {
+		c.getOrCreateGitRepo(name).processRefs(m.Refs)
 	}
-	// TODO: care about m.Repo?
-	c.processGitCommit(commit)
 }
 
 // c.mu is held for writing.
@@ -353,7 +381,14 @@ func (c *Corpus) processGitCommit(commit *maintpb.GitCommit) (*GitCommit, error)
 	gc := &GitCommit{
 		Hash:    hash,
 		Parents: make([]*GitCommit, 0, bytes.Count(hdr, parentSpace)),
-		Msg:     c.strb(msg),
+	}
+	var msgText string // not kept in compact mode
+	if c.text == nil {
+		gc.Msg = c.strb(msg)
+		msgText = gc.Msg
+	} else {
+		msgText = string(msg)
+		c.setText(&gc.Msg, &gc.msg, msgText)
 	}
 
 	// The commit message contains the reviewer email address. Sample commit message:
@@ -364,17 +399,15 @@ func (c *Corpus) processGitCommit(commit *maintpb.GitCommit) (*GitCommit, error)
 	// Patch-set: 1
 	// Reviewer: Ian Lance Taylor <5206@62eb7196-b449-3ce5-99f1-c037f21e1705>
 	// Label: Code-Review=+2
-	if reviewer, _ := lineValue(c.strb(msg), "Reviewer: "); reviewer != "" {
-		gc.Reviewer = &GitPerson{Str: reviewer}
+	if reviewer, _ := lineValue(msgText, "Reviewer: "); reviewer != "" {
+		gc.Reviewer = &GitPerson{Str: c.strb([]byte(reviewer))}
 	}
 
 	if commit.DiffTree != nil {
-		gc.Files = commit.DiffTree.File
-	}
-	for _, f := range gc.Files {
-		f.File = c.str(f.File) // intern the string
+		files := commit.DiffTree.File
+		sort.Slice(files, func(i, j int) bool { return files[i].File < files[j].File })
+		c.setFiles(gc, files)
 	}
-	sort.Slice(gc.Files, func(i, j int) bool { return gc.Files[i].File < gc.Files[j].File })
 	parents := 0
 	err := ForeachLine(hdr, func(ln []byte) error {
 		if bytes.HasPrefix(ln, parentSpace) {
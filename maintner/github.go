@@ -112,6 +112,7 @@ type GitHubRepo struct {
 	issues     map[int32]*GitHubIssue // num -> issue
 	milestones map[int64]*GitHubMilestone
 	labels     map[int64]*GitHubLabel
+	index      termIndex // of issues, for queries
 }
 
 func (gr *GitHubRepo) ID() GitHubRepoID { return gr.id }
@@ -242,18 +243,30 @@ type GitHubIssue struct {
 	ClosedAt    time.Time
 	ClosedBy    *GitHubUser
 	Title       string
-	Body        string
+	Body        string                 // empty in compact mode (use GetBody)
 	Milestone   *GitHubMilestone       // nil for unknown, noMilestone for none
 	Labels      map[int64]*GitHubLabel // label ID => label
 
+	body textRef // in compact mode
+
 	commentsUpdatedTil time.Time                   // max comment modtime seen
 	commentsSyncedAsOf time.Time                   // as of server's Date header
 	comments           map[int64]*GitHubComment    // by comment.ID
 	eventMaxTime       time.Time                   // latest time of any event in events map
 	eventsSyncedAsOf   time.Time                   // as of server's Date header
 	events             map[int64]*GitHubIssueEvent // by event.ID
+
+	// Pull requests only:
+	reviewsSyncedAsOf        time.Time                      // as of server's Date header
+	reviews                  map[int64]*GitHubReview        // by review.ID
+	reviewCommentsUpdatedTil time.Time                      // max review comment modtime seen
+	reviewComments           map[int64]*GitHubReviewComment // by comment.ID
 }
 
+// GetBody returns the issue's body. Unlike Body, it works in compact
+// mode.
+func (gi *GitHubIssue) GetBody() string { return textOf(gi.Body, gi.body) }
+
 // LastModified reports the most recent time that any known metadata was updated.
 // In contrast to the Updated field, LastModified includes comments and events.
 //
@@ -267,6 +280,9 @@ func (gi *GitHubIssue) LastModified() time.Time {
 	if gi.eventMaxTime.After(ret) {
 		ret = gi.eventMaxTime
 	}
+	if gi.reviewCommentsUpdatedTil.After(ret) {
+		ret = gi.reviewCommentsUpdatedTil
+	}
 	return ret
 }
 
@@ -335,6 +351,81 @@ func (gi *GitHubIssue) ForeachComment(fn func(*GitHubComment) error) error {
 	return nil
 }
 
+// ForeachReview calls fn for each review of the pull request. It
+// does nothing if the issue isn't a pull request.
+//
+// If fn returns an error, iteration ends and ForeachReview returns
+// with that error.
+//
+// The fn function is called serially, in order of the review's
+// submission time. Pending reviews, which have no submission time,
+// come first.
+func (gi *GitHubIssue) ForeachReview(fn func(*GitHubReview) error) error {
+	s := make([]*GitHubReview, 0, len(gi.reviews))
+	for _, r := range gi.reviews {
+		s = append(s, r)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		ti, tj := s[i].Submitted, s[j].Submitted
+		if ti.Before(tj) {
+			return true
+		}
+		return ti.Equal(tj) && s[i].ID < s[j].ID
+	})
+	for _, r := range s {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForeachReviewComment calls fn for each line-level review comment
+// on the pull request. It does nothing if the issue isn't a pull
+// request.
+//
+// If fn returns an error, iteration ends and ForeachReviewComment
+// returns with that error.
+//
+// The fn function is called serially, in order of the comment's time.
+func (gi *GitHubIssue) ForeachReviewComment(fn func(*GitHubReviewComment) error) error {
+	s := make([]*GitHubReviewComment, 0, len(gi.reviewComments))
+	for _, c := range gi.reviewComments {
+		s = append(s, c)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		ci, cj := s[i].Created, s[j].Created
+		if ci.Before(cj) {
+			return true
+		}
+		return ci.Equal(cj) && s[i].ID < s[j].ID
+	})
+	for _, c := range s {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LatestReviews returns each reviewer's most recently submitted
+// review of the pull request, ignoring pending reviews and reviews
+// that only commented. It's the approval state GitHub shows on the
+// pull request page.
+func (gi *GitHubIssue) LatestReviews() map[*GitHubUser]*GitHubReview {
+	m := make(map[*GitHubUser]*GitHubReview)
+	gi.ForeachReview(func(r *GitHubReview) error {
+		switch r.State {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			if r.User != nil {
+				m[r.User] = r
+			}
+		}
+		return nil
+	})
+	return m
+}
+
 // HasLabel reports whether the issue is labeled with the given label.
 func (gi *GitHubIssue) HasLabel(label string) bool {
 	for _, lb := range gi.Labels {
@@ -472,9 +563,58 @@ type GitHubComment struct {
 	User    *GitHubUser
 	Created time.Time
 	Updated time.Time
-	Body    string
+	Body    string // empty in compact mode (use GetBody)
+
+	body textRef // in compact mode
+}
+
+// GetBody returns the comment's body. Unlike Body, it works in compact
+// mode.
+func (c *GitHubComment) GetBody() string { return textOf(c.Body, c.body) }
+
+// GitHubReview is a review of a pull request.
+// See https://developer.github.com/v3/pulls/reviews/
+type GitHubReview struct {
+	ID        int64
+	User      *GitHubUser
+	Body      string    // empty in compact mode (use GetBody)
+	State     string    // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED or PENDING
+	CommitID  string    // head commit the review was made against
+	Submitted time.Time // zero for pending reviews
+
+	body textRef // in compact mode
+}
+
+// GetBody returns the review's body. Unlike Body, it works in compact
+// mode.
+func (rv *GitHubReview) GetBody() string { return textOf(rv.Body, rv.body) }
+
+// GitHubReviewComment is a comment on a line of a pull request's
+// diff, usually made as part of a review.
+// See https://developer.github.com/v3/pulls/comments/
+type GitHubReviewComment struct {
+	ID        int64
+	ReviewID  int64 // 0 if not part of a review
+	InReplyTo int64 // ID of the comment this replies to, or 0
+	User      *GitHubUser
+	Created   time.Time
+	Updated   time.Time
+	Body      string // empty in compact mode (use GetBody)
+
+	Path             string // file the comment is on
+	DiffHunk         string
+	Position         int // line index in the diff at CommitID; 0 if the comment is outdated
+	OriginalPosition int // line index in the diff at OriginalCommitID
+	CommitID         string
+	OriginalCommitID string
+
+	body textRef // in compact mode
 }
 
+// GetBody returns the comment's body. Unlike Body, it works in compact
+// mode.
+func (rc *GitHubReviewComment) GetBody() string { return textOf(rc.Body, rc.body) }
+
 // GitHubDismissedReview is the contents of a dismissed review event. For more
 // details, see https://developer.github.com/v3/issues/events/.
 type GitHubDismissedReviewEvent struct {
@@ -657,6 +797,15 @@ func (gi *GitHubIssue) eventsSynced() bool {
 	return gi.eventsSyncedAsOf.After(gi.Updated)
 }
 
+// (requires corpus be locked for reads)
+func (gi *GitHubIssue) reviewsSynced() bool {
+	if gi.NotExist || !gi.PullRequest {
+		// Only pull requests have reviews.
+		return true
+	}
+	return gi.reviewsSyncedAsOf.After(gi.Updated)
+}
+
 func (c *Corpus) initGithub() {
 	if c.github != nil {
 		return
@@ -974,7 +1123,7 @@ func (d githubIssueDiffer) diffClosedBy(m *maintpb.GithubIssueMutation) bool {
 }
 
 func (d githubIssueDiffer) diffBody(m *maintpb.GithubIssueMutation) bool {
-	if d.a != nil && d.a.Body == d.b.GetBody() {
+	if d.a != nil && d.a.GetBody() == d.b.GetBody() {
 		return false
 	}
 	m.Body = d.b.GetBody()
@@ -1186,6 +1335,9 @@ func (c *Corpus) processGithubIssueMutation(m *maintpb.GithubIssueMutation) {
 			panic(err)
 		}
 	}
+	defer gr.updateIssueIndex(gi)
+	defer c.markSearchDirty(GitHubIssueRef{gr, gi.Number})
+
 	if m.NotExist != gi.NotExist {
 		gi.NotExist = m.NotExist
 	}
@@ -1240,7 +1392,7 @@ func (c *Corpus) processGithubIssueMutation(m *maintpb.GithubIssueMutation) {
 	gi.Assignees = c.github.setAssigneesFromProto(gi.Assignees, m.Assignees, m.DeletedAssignees)
 
 	if m.Body != "" {
-		gi.Body = m.Body
+		c.setText(&gi.Body, &gi.body, m.Body)
 	}
 	if m.Title != "" {
 		gi.Title = m.Title
@@ -1284,7 +1436,7 @@ func (c *Corpus) processGithubIssueMutation(m *maintpb.GithubIssueMutation) {
 			gc.Updated = gc.Updated.UTC()
 		}
 		if cmut.Body != "" {
-			gc.Body = cmut.Body
+			c.setText(&gc.Body, &gc.body, cmut.Body)
 		}
 	}
 	if m.CommentStatus != nil && m.CommentStatus.ServerDate != nil {
@@ -1312,6 +1464,87 @@ func (c *Corpus) processGithubIssueMutation(m *maintpb.GithubIssueMutation) {
 			gi.eventsSyncedAsOf = serverDate.UTC()
 		}
 	}
+
+	for _, rmut := range m.Review {
+		if rmut.Id == 0 {
+			log.Printf("Ignoring bogus review mutation lacking Id: %v", rmut)
+			continue
+		}
+		rv, ok := gi.reviews[rmut.Id]
+		if !ok {
+			if gi.reviews == nil {
+				gi.reviews = make(map[int64]*GitHubReview)
+			}
+			rv = &GitHubReview{ID: rmut.Id}
+			gi.reviews[rv.ID] = rv
+		}
+		if rmut.User != nil {
+			rv.User = c.github.getUser(rmut.User)
+		}
+		if rmut.Body != "" {
+			c.setText(&rv.Body, &rv.body, rmut.Body)
+		}
+		if rmut.State != "" {
+			rv.State = rmut.State
+		}
+		if rmut.CommitId != "" {
+			rv.CommitID = rmut.CommitId
+		}
+		if rmut.Submitted != nil {
+			rv.Submitted, _ = ptypes.Timestamp(rmut.Submitted)
+			rv.Submitted = rv.Submitted.UTC()
+		}
+	}
+	for _, cmut := range m.ReviewComment {
+		if cmut.Id == 0 {
+			log.Printf("Ignoring bogus review comment mutation lacking Id: %v", cmut)
+			continue
+		}
+		rc, ok := gi.reviewComments[cmut.Id]
+		if !ok {
+			if gi.reviewComments == nil {
+				gi.reviewComments = make(map[int64]*GitHubReviewComment)
+			}
+			rc = &GitHubReviewComment{
+				ID:               cmut.Id,
+				ReviewID:         cmut.ReviewId,
+				InReplyTo:        cmut.InReplyTo,
+				Path:             cmut.Path,
+				OriginalPosition: int(cmut.OriginalPosition),
+				OriginalCommitID: cmut.OriginalCommitId,
+				DiffHunk:         cmut.DiffHunk,
+			}
+			gi.reviewComments[rc.ID] = rc
+		}
+		if cmut.User != nil {
+			rc.User = c.github.getUser(cmut.User)
+		}
+		if cmut.Created != nil {
+			rc.Created, _ = ptypes.Timestamp(cmut.Created)
+			rc.Created = rc.Created.UTC()
+		}
+		if cmut.Updated != nil {
+			rc.Updated, _ = ptypes.Timestamp(cmut.Updated)
+			rc.Updated = rc.Updated.UTC()
+			if rc.Updated.After(gi.reviewCommentsUpdatedTil) {
+				gi.reviewCommentsUpdatedTil = rc.Updated
+			}
+		}
+		if cmut.Body != "" {
+			c.setText(&rc.Body, &rc.body, cmut.Body)
+		}
+		// Position and CommitID move as the pull request is
+		// updated; Position goes to 0 when the line is gone.
+		rc.Position = int(cmut.Position)
+		if cmut.CommitId != "" {
+			rc.CommitID = cmut.CommitId
+		}
+	}
+	if m.ReviewStatus != nil && m.ReviewStatus.ServerDate != nil {
+		if serverDate, err := ptypes.Timestamp(m.ReviewStatus.ServerDate); err == nil {
+			gi.reviewsSyncedAsOf = serverDate.UTC()
+		}
+	}
 }
 
 // githubCache is an httpcache.Cache wrapper that only
@@ -1454,6 +1687,9 @@ func (p *githubRepoPoller) sync(ctx context.Context, expectChanges bool) error {
 	if err := p.syncEvents(ctx); err != nil {
 		return err
 	}
+	if err := p.syncReviews(ctx); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -1836,14 +2072,14 @@ func (p *githubRepoPoller) syncCommentsOnIssue(ctx context.Context, issueNum int
 					Created: created,
 					Updated: updated,
 				}
-			} else if !cur.Updated.Equal(*ic.UpdatedAt) || cur.Body != *ic.Body {
+			} else if !cur.Updated.Equal(*ic.UpdatedAt) || cur.GetBody() != *ic.Body {
 				cmut = &maintpb.GithubIssueCommentMutation{
 					Id: id,
 				}
 				if !cur.Updated.Equal(*ic.UpdatedAt) {
 					cmut.Updated = updated
 				}
-				if cur.Body != *ic.Body {
+				if cur.GetBody() != *ic.Body {
 					cmut.Body = *ic.Body
 				}
 			}
@@ -1979,6 +2215,233 @@ func (p *githubRepoPoller) syncEventsOnIssue(ctx context.Context, issueNum int32
 	return nil
 }
 
+func (p *githubRepoPoller) issueNumbersWithStaleReviewSync() (issueNums []int32) {
+	p.c.mu.RLock()
+	defer p.c.mu.RUnlock()
+
+	for n, gi := range p.gr.issues {
+		if !gi.reviewsSynced() {
+			issueNums = append(issueNums, n)
+		}
+	}
+	sort.Slice(issueNums, func(i, j int) bool {
+		return issueNums[i] < issueNums[j]
+	})
+	return issueNums
+}
+
+func (p *githubRepoPoller) syncReviews(ctx context.Context) error {
+	for {
+		nums := p.issueNumbersWithStaleReviewSync()
+		if len(nums) == 0 {
+			return nil
+		}
+		remain := len(nums)
+		for _, num := range nums {
+			p.logf("review sync: %d pull requests remaining; syncing %v", remain, num)
+			if err := p.syncReviewsOnIssue(ctx, num); err != nil {
+				p.logf("review sync on pull request %d: %v", num, err)
+				return err
+			}
+			remain--
+		}
+	}
+}
+
+// syncReviewsOnIssue syncs the reviews and review comments of pull
+// request issueNum.
+func (p *githubRepoPoller) syncReviewsOnIssue(ctx context.Context, issueNum int32) error {
+	p.c.mu.RLock()
+	issue := p.gr.issues[issueNum]
+	if issue == nil {
+		p.c.mu.RUnlock()
+		return fmt.Errorf("unknown issue number %v", issueNum)
+	}
+	since := issue.reviewCommentsUpdatedTil
+	if !issue.Closed {
+		// A push to an open PR moves or outdates its review
+		// comments without changing their update times, so list
+		// them all. That's usually a single page, as a list since
+		// the last update is, and only changed comments make
+		// mutations.
+		since = time.Time{}
+	}
+	p.c.mu.RUnlock()
+
+	owner, repo := p.gr.id.Owner, p.gr.id.Repo
+	mut := &maintpb.Mutation{
+		GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:  owner,
+			Repo:   repo,
+			Number: issueNum,
+		},
+	}
+
+	// Reviews can't be listed by modification time, so fetch them
+	// all; PRs rarely have more than a page. The server date of the
+	// first response is what we're synced as of, as anything
+	// changed after it might have been missed.
+	var serverDate time.Time
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		rs, res, err := p.githubDirect.PullRequests.ListReviews(ctx, owner, repo, int(issueNum), opt)
+		if err != nil {
+			return err
+		}
+		if serverDate.IsZero() {
+			serverDate, err = http.ParseTime(res.Header.Get("Date"))
+			if err != nil {
+				return fmt.Errorf("invalid server Date response: %v", err)
+			}
+		}
+		p.c.mu.RLock()
+		for _, r := range rs {
+			if r.ID == nil || r.User == nil {
+				p.logf("bogus review: %v", r)
+				continue
+			}
+			if rmut := newGithubReviewProto(issue.reviews[*r.ID], r); rmut != nil {
+				mut.GithubIssue.Review = append(mut.GithubIssue.Review, rmut)
+			}
+		}
+		p.c.mu.RUnlock()
+		if res.NextPage == 0 {
+			break
+		}
+		opt.Page = res.NextPage
+	}
+
+	copt := &github.PullRequestListCommentsOptions{
+		Sort:        "updated",
+		Direction:   "asc",
+		Since:       since,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	for {
+		rcs, res, err := p.githubDirect.PullRequests.ListComments(ctx, owner, repo, int(issueNum), copt)
+		if err != nil {
+			return err
+		}
+		if since.IsZero() {
+			p.logf("Number of review comments on pull request %d: %v", issueNum, len(rcs))
+		} else {
+			p.logf("Number of review comments on pull request %d since %v: %v", issueNum, since, len(rcs))
+		}
+		p.c.mu.RLock()
+		for _, rc := range rcs {
+			if rc.ID == nil || rc.Body == nil || rc.User == nil || rc.CreatedAt == nil || rc.UpdatedAt == nil {
+				p.logf("bogus review comment: %v", rc)
+				continue
+			}
+			if cmut := newGithubReviewCommentProto(issue.reviewComments[*rc.ID], rc); cmut != nil {
+				mut.GithubIssue.ReviewComment = append(mut.GithubIssue.ReviewComment, cmut)
+			}
+		}
+		p.c.mu.RUnlock()
+		if res.NextPage == 0 {
+			break
+		}
+		copt.Page = res.NextPage
+	}
+
+	sdp, _ := ptypes.TimestampProto(serverDate.UTC())
+	mut.GithubIssue.ReviewStatus = &maintpb.GithubIssueSyncStatus{ServerDate: sdp}
+	p.c.addMutation(mut)
+	return nil
+}
+
+// newGithubReviewProto returns the mutation to update cur, which may
+// be nil, to r, or nil if nothing changed.
+// (requires corpus be locked for reads)
+func newGithubReviewProto(cur *GitHubReview, r *github.PullRequestReview) *maintpb.GithubReview {
+	var submitted time.Time
+	if r.SubmittedAt != nil {
+		submitted = r.SubmittedAt.UTC()
+	}
+	if cur == nil {
+		m := &maintpb.GithubReview{
+			Id:       *r.ID,
+			User:     newGithubUserProto(nil, r.User),
+			Body:     r.GetBody(),
+			State:    r.GetState(),
+			CommitId: r.GetCommitID(),
+		}
+		if !submitted.IsZero() {
+			m.Submitted, _ = ptypes.TimestampProto(submitted)
+		}
+		return m
+	}
+	m := &maintpb.GithubReview{Id: cur.ID}
+	changed := false
+	if body := r.GetBody(); body != cur.GetBody() {
+		m.Body = body
+		changed = true
+	}
+	if state := r.GetState(); state != cur.State {
+		m.State = state
+		changed = true
+	}
+	if commit := r.GetCommitID(); commit != cur.CommitID {
+		m.CommitId = commit
+		changed = true
+	}
+	if !submitted.IsZero() && !submitted.Equal(cur.Submitted) {
+		m.Submitted, _ = ptypes.TimestampProto(submitted)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return m
+}
+
+// newGithubReviewCommentProto returns the mutation to update cur,
+// which may be nil, to rc, or nil if nothing changed.
+// (requires corpus be locked for reads)
+func newGithubReviewCommentProto(cur *GitHubReviewComment, rc *github.PullRequestComment) *maintpb.GithubReviewCommentMutation {
+	updated, err := ptypes.TimestampProto(*rc.UpdatedAt)
+	if err != nil {
+		return nil
+	}
+	if cur == nil {
+		created, err := ptypes.TimestampProto(*rc.CreatedAt)
+		if err != nil {
+			return nil
+		}
+		return &maintpb.GithubReviewCommentMutation{
+			Id:               *rc.ID,
+			ReviewId:         rc.GetPullRequestReviewID(),
+			InReplyTo:        rc.GetInReplyTo(),
+			User:             newGithubUserProto(nil, rc.User),
+			Body:             *rc.Body,
+			Path:             rc.GetPath(),
+			Position:         int32(rc.GetPosition()),
+			OriginalPosition: int32(rc.GetOriginalPosition()),
+			CommitId:         rc.GetCommitID(),
+			OriginalCommitId: rc.GetOriginalCommitID(),
+			DiffHunk:         rc.GetDiffHunk(),
+			Created:          created,
+			Updated:          updated,
+		}
+	}
+	if cur.Updated.Equal(*rc.UpdatedAt) && cur.GetBody() == *rc.Body &&
+		cur.Position == rc.GetPosition() && cur.CommitID == rc.GetCommitID() {
+		return nil
+	}
+	m := &maintpb.GithubReviewCommentMutation{
+		Id:       cur.ID,
+		Position: int32(rc.GetPosition()),
+		CommitId: rc.GetCommitID(),
+	}
+	if !cur.Updated.Equal(*rc.UpdatedAt) {
+		m.Updated = updated
+	}
+	if cur.GetBody() != *rc.Body {
+		m.Body = *rc.Body
+	}
+	return m
+}
+
 // parseGithubEvents parses the JSON array of GitHub events in r.  It
 // does this the very manual way (using map[string]interface{})
 // instead of using nice types because https://golang.org/issue/15314
@@ -12,6 +12,7 @@ import (
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/google/go-github/github"
 
 	"golang.org/x/build/maintner/maintpb"
 )
@@ -589,3 +590,108 @@ func TestParseGithubRefs(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessGithubReviewMutations(t *testing.T) {
+	c := new(Corpus)
+	alice := &maintpb.GithubUser{Id: 1, Login: "alice"}
+	bob := &maintpb.GithubUser{Id: 2, Login: "bob"}
+	c.processMutationLocked(&maintpb.Mutation{
+		GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:       "golang",
+			Repo:        "go",
+			Number:      7,
+			Created:     p3339("2018-01-01T00:00:00Z"),
+			PullRequest: true,
+			Review: []*maintpb.GithubReview{
+				{Id: 20, User: bob, State: "COMMENTED", Submitted: p3339("2018-01-03T00:00:00Z")},
+				{Id: 10, User: alice, State: "CHANGES_REQUESTED", Submitted: p3339("2018-01-02T00:00:00Z")},
+				{Id: 30, User: alice, State: "APPROVED", CommitId: "abc", Submitted: p3339("2018-01-04T00:00:00Z")},
+			},
+			ReviewComment: []*maintpb.GithubReviewCommentMutation{
+				{
+					Id:       100,
+					ReviewId: 10,
+					User:     alice,
+					Body:     "typo",
+					Path:     "src/fmt/print.go",
+					Position: 5,
+					CommitId: "abc",
+					Created:  p3339("2018-01-02T00:00:00Z"),
+					Updated:  p3339("2018-01-02T00:00:00Z"),
+				},
+			},
+		},
+	})
+	// An edit, after which the comment's line is gone from the diff.
+	c.processMutationLocked(&maintpb.Mutation{
+		GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:  "golang",
+			Repo:   "go",
+			Number: 7,
+			ReviewComment: []*maintpb.GithubReviewCommentMutation{
+				{Id: 100, Body: "typo here", CommitId: "def", Updated: p3339("2018-01-05T00:00:00Z")},
+			},
+			ReviewStatus: &maintpb.GithubIssueSyncStatus{ServerDate: p3339("2018-01-06T00:00:00Z")},
+		},
+	})
+
+	gi := c.GitHub().Repo("golang", "go").Issue(7)
+	var ids []int64
+	gi.ForeachReview(func(r *GitHubReview) error {
+		ids = append(ids, r.ID)
+		return nil
+	})
+	if want := []int64{10, 20, 30}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("ForeachReview IDs = %v; want %v", ids, want)
+	}
+
+	latest := gi.LatestReviews()
+	if len(latest) != 1 {
+		t.Fatalf("LatestReviews = %v; want just alice's", latest)
+	}
+	for u, r := range latest {
+		if u.Login != "alice" || r.ID != 30 || r.State != "APPROVED" {
+			t.Errorf("LatestReviews has %s => %+v; want alice => approval 30", u.Login, r)
+		}
+	}
+
+	var rc *GitHubReviewComment
+	gi.ForeachReviewComment(func(c *GitHubReviewComment) error {
+		rc = c
+		return nil
+	})
+	if rc == nil {
+		t.Fatal("no review comments")
+	}
+	if rc.Body != "typo here" || rc.Path != "src/fmt/print.go" || rc.Position != 0 || rc.CommitID != "def" || rc.User.Login != "alice" {
+		t.Errorf("review comment = %+v", rc)
+	}
+	if !gi.reviewsSynced() {
+		t.Error("reviewsSynced = false; want true")
+	}
+	if got, want := gi.LastModified(), t3339("2018-01-05T00:00:00Z"); !got.Equal(want) {
+		t.Errorf("LastModified = %v; want %v", got, want)
+	}
+}
+
+func TestNewGithubReviewCommentProto(t *testing.T) {
+	created, updated := t3339("2018-01-02T00:00:00Z"), t3339("2018-01-02T00:00:00Z")
+	cur := &GitHubReviewComment{ID: 100, Body: "typo", Position: 5, CommitID: "abc", Created: created, Updated: updated}
+	rc := &github.PullRequestComment{
+		ID:        github.Int64(100),
+		Body:      github.String("typo"),
+		Position:  github.Int(5),
+		CommitID:  github.String("abc"),
+		CreatedAt: &created,
+		UpdatedAt: &updated,
+	}
+	if m := newGithubReviewCommentProto(cur, rc); m != nil {
+		t.Errorf("unchanged comment gave mutation %v", m)
+	}
+	rc.CommitID = github.String("def")
+	rc.Position = github.Int(7)
+	m := newGithubReviewCommentProto(cur, rc)
+	if m == nil || m.Position != 7 || m.CommitId != "def" || m.Body != "" || m.Updated != nil {
+		t.Errorf("after push, mutation = %v; want new position and commit only", m)
+	}
+}
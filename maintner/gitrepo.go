@@ -0,0 +1,352 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// A GitRepo is a git repository tracked with TrackGitRepo, which may
+// be hosted anywhere. Its commits are in the corpus alongside those
+// of Gerrit projects and Go repos.
+type GitRepo struct {
+	c    *Corpus
+	name string
+	url  string // what to fetch from; only set in leader mode
+
+	// ref are the branch and tag refs, with keys like
+	// "refs/heads/master" and "refs/tags/v1.0". Annotated tags
+	// map to the commit they tag.
+	ref map[string]GitHash
+}
+
+// gitRepoNameRx is the pattern describing the name of a repo tracked
+// with TrackGitRepo.
+var gitRepoNameRx = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._\-/]*$`)
+
+// TrackGitRepo registers the git repo at remote, which is anything
+// "git fetch" accepts, such as an HTTPS URL or a local path, to have
+// its branches, tags and commits slurped into the corpus under the
+// given name. Only valid in leader mode.
+func (c *Corpus) TrackGitRepo(remote, name string) {
+	if c.mutationLogger == nil {
+		panic("can't TrackGitRepo in non-leader mode")
+	}
+	if !gitRepoNameRx.MatchString(name) {
+		panic(fmt.Sprintf("bogus git repo name %q", name))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range c.watchedGitRepos {
+		if r.name == name {
+			panic("duplicated watched git repo " + name)
+		}
+	}
+	r := c.getOrCreateGitRepo(name)
+	r.url = remote
+	c.watchedGitRepos = append(c.watchedGitRepos, r)
+}
+
+// GitRepo returns the git repo tracked with the given name, or nil if
+// it's unknown.
+func (c *Corpus) GitRepo(name string) *GitRepo {
+	return c.gitRepos[name]
+}
+
+// ForeachGitRepo calls fn for each git repo tracked with
+// TrackGitRepo, in order of name. Iteration ends if fn returns a
+// non-nil value.
+func (c *Corpus) ForeachGitRepo(fn func(*GitRepo) error) error {
+	var names []string
+	for name := range c.gitRepos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := fn(c.gitRepos[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// c.mu must be held
+func (c *Corpus) getOrCreateGitRepo(name string) *GitRepo {
+	if r, ok := c.gitRepos[name]; ok {
+		return r
+	}
+	if c.gitRepos == nil {
+		c.gitRepos = map[string]*GitRepo{}
+	}
+	r := &GitRepo{
+		c:    c,
+		name: name,
+		ref:  map[string]GitHash{},
+	}
+	c.gitRepos[name] = r
+	return r
+}
+
+// Name returns the name the repo was tracked with.
+func (r *GitRepo) Name() string { return r.name }
+
+// Ref returns the commit of a branch or tag ref, such as
+// "refs/heads/master" or "refs/tags/v1.0". The returned hash is the
+// zero value (an empty string) if the ref does not exist.
+func (r *GitRepo) Ref(ref string) GitHash {
+	return r.ref[ref]
+}
+
+// ForeachRef calls fn for each branch and tag ref of the repo, in
+// order of name. Iteration ends if fn returns a non-nil value.
+func (r *GitRepo) ForeachRef(fn func(ref string, hash GitHash) error) error {
+	var refs []string
+	for ref := range r.ref {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if err := fn(ref, r.ref[ref]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Commit returns the commit of a branch or tag ref, or nil if the ref
+// does not exist.
+func (r *GitRepo) Commit(ref string) *GitCommit {
+	h, ok := r.ref[ref]
+	if !ok {
+		return nil
+	}
+	return r.c.gitCommit[h]
+}
+
+// ForeachCommit calls fn once for each commit reachable from the
+// repo's refs, in no particular order. Iteration ends if fn returns
+// a non-nil value.
+func (r *GitRepo) ForeachCommit(fn func(*GitCommit) error) error {
+	seen := map[*GitCommit]bool{}
+	var stack []*GitCommit
+	for _, h := range r.ref {
+		if gc := r.c.gitCommit[h]; gc != nil {
+			stack = append(stack, gc)
+		}
+	}
+	for len(stack) > 0 {
+		gc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[gc] || gc.Committer == placeholderCommitter {
+			continue
+		}
+		seen[gc] = true
+		if err := fn(gc); err != nil {
+			return err
+		}
+		stack = append(stack, gc.Parents...)
+	}
+	return nil
+}
+
+// c.mu must be held.
+func (r *GitRepo) processRefs(refs []*maintpb.GitRef) {
+	for _, ref := range refs {
+		if ref.Sha1 == "" {
+			delete(r.ref, ref.Ref)
+			continue
+		}
+		if len(ref.Sha1) != 40 {
+			log.Printf("git repo %s: bogus sha1 %q for ref %q", r.name, ref.Sha1, ref.Ref)
+			continue
+		}
+		r.ref[r.c.str(ref.Ref)] = r.c.gitHashFromHexStr(ref.Sha1)
+	}
+}
+
+func (r *GitRepo) logf(format string, args ...interface{}) {
+	log.Printf("git repo "+r.name+": "+format, args...)
+}
+
+func (r *GitRepo) gitDir() string {
+	return filepath.Join(r.c.getDataDir(), "git", url.PathEscape(r.name))
+}
+
+func (r *GitRepo) sync(ctx context.Context, loop bool) error {
+	if err := r.init(ctx); err != nil {
+		r.logf("init: %v", err)
+		return err
+	}
+	for {
+		if err := r.syncOnce(ctx); err != nil {
+			r.logf("sync: %v", err)
+			return err
+		}
+		if !loop {
+			return nil
+		}
+		timer := time.NewTimer(5 * time.Minute)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// init creates the bare git repo that r is mirrored into, or points
+// an existing one at r.url.
+func (r *GitRepo) init(ctx context.Context) error {
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("looking for git binary: %v", err)
+	}
+	gitDir := r.gitDir()
+	if _, err := os.Stat(filepath.Join(gitDir, "config")); err == nil {
+		cmd := exec.CommandContext(ctx, "git", "remote", "set-url", "origin", r.url)
+		cmd.Dir = gitDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git remote set-url in %s: %v, %s", gitDir, err, out)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(gitDir, 0755); err != nil {
+		return err
+	}
+	for _, args := range [][]string{
+		{"init", "--bare", "--quiet"},
+		{"remote", "add", "origin", r.url},
+	} {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = gitDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s in %s: %v, %s", args[0], gitDir, err, out)
+		}
+	}
+	return nil
+}
+
+// syncOnce fetches the repo's branches and tags, then adds mutations
+// for their new commits, parents first, followed by one for the refs
+// that changed.
+func (r *GitRepo) syncOnce(ctx context.Context) error {
+	c := r.c
+	gitDir := r.gitDir()
+
+	fetchCtx, cancel := context.WithTimeout(ctx, 30*time.Minute)
+	cmd := exec.CommandContext(fetchCtx, "git", "fetch", "--quiet", "--prune", "origin",
+		"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	cmd.Dir = gitDir
+	out, err := cmd.CombinedOutput()
+	cancel()
+	if err != nil {
+		return fmt.Errorf("git fetch origin: %v, %s", err, out)
+	}
+
+	cmd = exec.CommandContext(ctx, "git", "for-each-ref",
+		"--format=%(objecttype) %(objectname) %(*objecttype) %(*objectname) %(refname)",
+		"refs/heads", "refs/tags")
+	cmd.Dir = gitDir
+	out, err = cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git for-each-ref in %s: %v", gitDir, formatExecError(err))
+	}
+	refs := map[string]string{} // ref name -> hex commit hash
+	bs := bufio.NewScanner(bytes.NewReader(out))
+	for bs.Scan() {
+		f := strings.Fields(bs.Text())
+		switch {
+		case len(f) == 3 && f[0] == "commit":
+			refs[f[2]] = f[1]
+		case len(f) == 5 && f[2] == "commit":
+			// An annotated tag of a commit.
+			refs[f[4]] = f[3]
+		}
+	}
+	if err := bs.Err(); err != nil {
+		return err
+	}
+
+	var changedRefs []*maintpb.GitRef
+	var revs bytes.Buffer // rev-list input: new commits and ^old ones
+	c.mu.RLock()
+	for name, sha1 := range refs {
+		if old := r.ref[name]; old.String() != sha1 {
+			changedRefs = append(changedRefs, &maintpb.GitRef{Ref: name, Sha1: sha1})
+			fmt.Fprintf(&revs, "%s\n", sha1)
+		}
+	}
+	for name, h := range r.ref {
+		if _, ok := refs[name]; !ok {
+			changedRefs = append(changedRefs, &maintpb.GitRef{Ref: name})
+		}
+		fmt.Fprintf(&revs, "^%s\n", h)
+	}
+	c.mu.RUnlock()
+	if len(changedRefs) == 0 {
+		return nil
+	}
+	sort.Slice(changedRefs, func(i, j int) bool { return changedRefs[i].Ref < changedRefs[j].Ref })
+	r.logf("%d changed refs", len(changedRefs))
+
+	cmd = exec.CommandContext(ctx, "git", "rev-list", "--reverse", "--topo-order", "--ignore-missing", "--stdin")
+	cmd.Dir = gitDir
+	cmd.Stdin = &revs
+	out, err = cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git rev-list in %s: %v", gitDir, formatExecError(err))
+	}
+	var toIndex []GitHash
+	c.mu.RLock()
+	for _, sha1 := range strings.Fields(string(out)) {
+		// Not gitHashFromHexStr, which interns the hash, modifying
+		// the corpus under only a read lock.
+		h, err := hex.DecodeString(sha1)
+		if err != nil || len(h) != 20 {
+			continue
+		}
+		hash := GitHash(h)
+		if gc := c.gitCommit[hash]; gc == nil || gc.Committer == placeholderCommitter {
+			toIndex = append(toIndex, hash)
+		}
+	}
+	c.mu.RUnlock()
+
+	repo := &maintpb.GitRepo{Name: r.name}
+	lastLog := time.Now()
+	for i, hash := range toIndex {
+		if now := time.Now(); now.Sub(lastLog) > time.Second {
+			lastLog = now
+			r.logf("parsing commits (%v of %v done)", i, len(toIndex))
+		}
+		commit, err := parseCommitFromGit(gitDir, hash)
+		if err != nil {
+			return err
+		}
+		c.addMutation(&maintpb.Mutation{
+			Git: &maintpb.GitMutation{Repo: repo, Commit: commit},
+		})
+	}
+	c.addMutation(&maintpb.Mutation{
+		Git: &maintpb.GitMutation{Repo: repo, Refs: changedRefs},
+	})
+	r.logf("indexed %d commits", len(toIndex))
+	return nil
+}
@@ -0,0 +1,197 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// runGit runs git in dir with a fixed author, committer and date.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Gopher", "GIT_AUTHOR_EMAIL=gopher@golang.org",
+		"GIT_COMMITTER_NAME=Gopher", "GIT_COMMITTER_EMAIL=gopher@golang.org",
+		"GIT_AUTHOR_DATE=2018-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2018-01-01T00:00:00Z")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v, %s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestTrackGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "maintner-gitrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	logDir := filepath.Join(dir, "log")
+	for _, d := range []string{src, logDir} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git := func(args ...string) string {
+		t.Helper()
+		return runGit(t, src, args...)
+	}
+	git("init", "--quiet")
+	git("symbolic-ref", "HEAD", "refs/heads/master")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	git("tag", "-a", "-m", "version 1", "v1")
+	git("commit", "--quiet", "--allow-empty", "-m", "second")
+	second := git("rev-parse", "HEAD")
+
+	ctx := context.Background()
+	logger := NewDiskMutationLogger(logDir)
+	c := new(Corpus)
+	c.EnableLeaderMode(logger, filepath.Join(dir, "data"))
+	if err := c.Initialize(ctx, logger); err != nil {
+		t.Fatal(err)
+	}
+	c.TrackGitRepo(src, "local")
+	if err := c.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	refs := func(c *Corpus) map[string]string {
+		r := c.GitRepo("local")
+		if r == nil {
+			t.Fatal(`GitRepo("local") = nil`)
+		}
+		m := map[string]string{}
+		r.ForeachRef(func(ref string, h GitHash) error {
+			m[ref] = h.String()
+			return nil
+		})
+		return m
+	}
+	want := map[string]string{"refs/heads/master": second, "refs/tags/v1": first}
+	if got := refs(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("refs = %v; want %v", got, want)
+	}
+	r := c.GitRepo("local")
+	if gc := r.Commit("refs/heads/master"); gc == nil || gc.Summary() != "second" || len(gc.Parents) != 1 || gc.Parents[0].Hash.String() != first {
+		t.Errorf("master commit = %v; want second, with parent %s", gc, first)
+	}
+	n := 0
+	r.ForeachCommit(func(*GitCommit) error { n++; return nil })
+	if n != 2 {
+		t.Errorf("ForeachCommit saw %d commits; want 2", n)
+	}
+
+	// Move and delete refs.
+	git("checkout", "--quiet", "-b", "dev")
+	git("commit", "--quiet", "--allow-empty", "-m", "third")
+	third := git("rev-parse", "HEAD")
+	git("tag", "-d", "v1")
+	if err := c.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want = map[string]string{"refs/heads/master": second, "refs/heads/dev": third}
+	if got := refs(c); !reflect.DeepEqual(got, want) {
+		t.Errorf("after changes, refs = %v; want %v", got, want)
+	}
+
+	// The refs and commits are in the log.
+	c2 := new(Corpus)
+	if err := c2.Initialize(ctx, NewDiskMutationLogger(logDir)); err != nil {
+		t.Fatal(err)
+	}
+	if got := refs(c2); !reflect.DeepEqual(got, want) {
+		t.Errorf("from log, refs = %v; want %v", got, want)
+	}
+	if gc := c2.GitRepo("local").Commit("refs/heads/dev"); gc == nil || gc.Summary() != "third" {
+		t.Errorf("from log, dev commit = %v; want third", gc)
+	}
+}
+
+// Tests that git repos can sync concurrently with each other and
+// with readers holding the corpus's read lock. Run with -race.
+func TestTrackGitRepoConcurrentSync(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir, err := ioutil.TempDir("", "maintner-gitrepo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logDir := filepath.Join(dir, "log")
+	if err := os.Mkdir(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"a", "b"}
+	for _, name := range names {
+		src := filepath.Join(dir, name)
+		if err := os.Mkdir(src, 0755); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, src, "init", "--quiet")
+		runGit(t, src, "symbolic-ref", "HEAD", "refs/heads/master")
+		for i := 0; i < 20; i++ {
+			runGit(t, src, "commit", "--quiet", "--allow-empty", "-m", name+" commit")
+		}
+	}
+
+	ctx := context.Background()
+	logger := NewDiskMutationLogger(logDir)
+	c := new(Corpus)
+	c.EnableLeaderMode(logger, filepath.Join(dir, "data"))
+	if err := c.Initialize(ctx, logger); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		c.TrackGitRepo(filepath.Join(dir, name), name)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			c.mu.RLock()
+			for _, name := range names {
+				c.GitRepo(name).ForeachCommit(func(*GitCommit) error { return nil })
+			}
+			c.mu.RUnlock()
+		}
+	}()
+	err = c.Sync(ctx)
+	close(done)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		n := 0
+		c.GitRepo(name).ForeachCommit(func(*GitCommit) error { n++; return nil })
+		if n != 20 {
+			t.Errorf("repo %s has %d commits; want 20", name, n)
+		}
+	}
+}
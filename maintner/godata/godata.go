@@ -15,6 +15,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"golang.org/x/build/maintner"
 )
@@ -28,8 +29,8 @@ import (
 //
 // The initial call to Get will download approximately 350-400 MB of
 // data into a directory "golang-maintner" under your operating
-// system's user cache directory. Subsequent calls will only download
-// what's changed since the previous call.
+// system's user cache directory, logging its progress. Subsequent
+// calls will only download what's changed since the previous call.
 //
 // Even with all the data already cached on local disk, a call to Get
 // takes approximately 5 seconds to read the mutation log into memory.
@@ -46,7 +47,8 @@ func Get(ctx context.Context) (*maintner.Corpus, error) {
 	if err := os.MkdirAll(targetDir, 0700); err != nil {
 		return nil, err
 	}
-	mutSrc := maintner.NewNetworkMutationSource("https://maintner.golang.org/logs", targetDir)
+	mutSrc := maintner.NewNetworkMutationSource("https://maintner.golang.org/logs", targetDir,
+		maintner.WithDownloadProgress(LogDownloadProgress()))
 	corpus := new(maintner.Corpus)
 	if err := corpus.Initialize(ctx, mutSrc); err != nil {
 		return nil, err
@@ -54,6 +56,23 @@ func Get(ctx context.Context) (*maintner.Corpus, error) {
 	return corpus, nil
 }
 
+// LogDownloadProgress returns a function for
+// maintner.WithDownloadProgress that logs the progress of downloads
+// that take more than a few seconds, such as the first one, every few
+// seconds.
+func LogDownloadProgress() func(maintner.DownloadProgress) {
+	const interval = 5 * time.Second
+	last := time.Now()
+	return func(p maintner.DownloadProgress) {
+		if p.BytesDone == p.Bytes || time.Since(last) < interval {
+			return
+		}
+		last = time.Now()
+		log.Printf("Downloaded %d of %d MB of mutation logs (%d of %d segments)...",
+			p.BytesDone>>20, p.Bytes>>20, p.SegmentsDone, p.Segments)
+	}
+}
+
 // Dir returns the directory containing the cached mutation logs.
 func Dir() string {
 	return filepath.Join(XdgCacheDir(), "golang-maintner")
@@ -33,7 +33,7 @@ type FileCount struct {
 func topModifiedFiles(gp *maintner.GerritProject, topN int) []FileCount {
 	n := map[string]int{} // file -> count
 	gp.ForeachCLUnsorted(func(gcl *maintner.GerritCL) error {
-		for _, f := range gcl.Commit.Files {
+		for _, f := range gcl.Commit.GetFiles() {
 			n[modernizeFilename(f.File)]++
 		}
 		return nil
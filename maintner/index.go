@@ -0,0 +1,161 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Secondary indexes over the corpus, used by queries.
+
+package maintner
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A termIndex is an inverted index from search terms, like
+// "label:150880243", to the items (*GitHubIssue or *GerritCL) having
+// them. It's updated as mutations are processed.
+type termIndex struct {
+	items map[string]map[interface{}]struct{} // term => items
+	terms map[interface{}][]string            // item => its terms
+}
+
+// update sets the terms that item is indexed under.
+func (x *termIndex) update(item interface{}, terms []string) {
+	if x.items == nil {
+		x.items = make(map[string]map[interface{}]struct{})
+		x.terms = make(map[interface{}][]string)
+	}
+	old := x.terms[item]
+	if stringsEqual(old, terms) {
+		return
+	}
+	for _, t := range old {
+		s := x.items[t]
+		delete(s, item)
+		if len(s) == 0 {
+			delete(x.items, t)
+		}
+	}
+	for _, t := range terms {
+		s, ok := x.items[t]
+		if !ok {
+			s = make(map[interface{}]struct{})
+			x.items[t] = s
+		}
+		s[item] = struct{}{}
+	}
+	if len(terms) == 0 {
+		delete(x.terms, item)
+	} else {
+		x.terms[item] = terms
+	}
+}
+
+// lookup returns the items indexed under term. The caller must not
+// modify it.
+func (x *termIndex) lookup(term string) map[interface{}]struct{} {
+	return x.items[term]
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// issueTerms returns the index terms of gi. Labels, milestones and
+// users are indexed by ID, as their names can change.
+func issueTerms(gi *GitHubIssue) []string {
+	if gi.NotExist {
+		return nil
+	}
+	terms := make([]string, 0, 4+len(gi.Labels)+len(gi.Assignees))
+	if gi.Closed {
+		terms = append(terms, "is:closed")
+	} else {
+		terms = append(terms, "is:open")
+	}
+	if gi.PullRequest {
+		terms = append(terms, "is:pr")
+	} else {
+		terms = append(terms, "is:issue")
+	}
+	if gi.Milestone.IsNone() {
+		terms = append(terms, "no:milestone")
+	} else if !gi.Milestone.IsUnknown() {
+		terms = append(terms, "milestone:"+strconv.FormatInt(gi.Milestone.ID, 10))
+	}
+	for id := range gi.Labels {
+		terms = append(terms, "label:"+strconv.FormatInt(id, 10))
+	}
+	if gi.User != nil {
+		terms = append(terms, "author:"+strconv.FormatInt(gi.User.ID, 10))
+	}
+	for _, u := range gi.Assignees {
+		terms = append(terms, "assignee:"+strconv.FormatInt(u.ID, 10))
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// updateIssueIndex re-indexes gi after a mutation.
+//
+// called with Corpus.mu Locked
+func (gr *GitHubRepo) updateIssueIndex(gi *GitHubIssue) {
+	gr.index.update(gi, issueTerms(gi))
+}
+
+// Hashtags returns the CL's current hashtags: those set by the
+// latest meta commit that edited them. Unlike the Hashtags of
+// cl.Meta, it doesn't depend on whether the latest meta commit
+// happens to record them.
+func (cl *GerritCL) Hashtags() GerritHashtags {
+	return hashtagsAsOf(cl.Metas)
+}
+
+// hashtagsAsOf returns the hashtags set by the last of metas that
+// edits them.
+func hashtagsAsOf(metas []*GerritMeta) GerritHashtags {
+	for i := len(metas) - 1; i >= 0; i-- {
+		if m := metas[i]; m.flags&metaFlagHashtagEdit != 0 {
+			return m.Hashtags()
+		}
+	}
+	return ""
+}
+
+// clTerms returns the index terms of cl.
+func clTerms(cl *GerritCL) []string {
+	if !cl.complete() {
+		return nil
+	}
+	terms := []string{"status:" + cl.Status}
+	if b := cl.Branch(); b != "" {
+		terms = append(terms, "branch:"+strings.ToLower(b))
+	}
+	if t := cl.Topic(); t != "" {
+		terms = append(terms, "topic:"+strings.ToLower(t))
+	}
+	if id := cl.OwnerID(); id != -1 {
+		terms = append(terms, "owner:"+strconv.Itoa(id))
+	}
+	cl.Hashtags().Foreach(func(t string) {
+		terms = append(terms, "hashtag:"+strings.ToLower(t))
+	})
+	sort.Strings(terms)
+	return terms
+}
+
+// updateCLIndex re-indexes cl after its meta commits are processed.
+//
+// called with Corpus.mu Locked
+func (gp *GerritProject) updateCLIndex(cl *GerritCL) {
+	gp.index.update(cl, clTerms(cl))
+}
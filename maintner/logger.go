@@ -7,6 +7,7 @@ package maintner
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,8 +28,10 @@ type MutationLogger interface {
 type DiskMutationLogger struct {
 	directory string
 
-	mu   sync.Mutex
-	done bool // true after first GetMutations
+	mu        sync.Mutex
+	checksums bool             // write checksummed records
+	done      bool             // true after first GetMutations
+	skip      map[string]int64 // log file name => bytes to skip, from resumeAfter
 }
 
 // NewDiskMutationLogger creates a new DiskMutationLogger, which will create
@@ -40,6 +43,15 @@ func NewDiskMutationLogger(directory string) *DiskMutationLogger {
 	return &DiskMutationLogger{directory: directory}
 }
 
+// SetChecksums sets whether Log writes records with checksums, which
+// readers verify. Readers that predate checksums see a checksum
+// record as an empty mutation.
+func (d *DiskMutationLogger) SetChecksums(v bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.checksums = v
+}
+
 // filename returns the filename to write to. The oldest filename must come
 // first in lexical order.
 func (d *DiskMutationLogger) filename() string {
@@ -56,6 +68,9 @@ func (d *DiskMutationLogger) Log(m *maintpb.Mutation) error {
 	}
 	d.mu.Lock()
 	defer d.mu.Unlock()
+	if d.checksums {
+		return reclog.AppendChecksummedRecordToFile(d.filename(), data)
+	}
 	return reclog.AppendRecordToFile(d.filename(), data)
 }
 
@@ -101,13 +116,23 @@ func (d *DiskMutationLogger) GetMutations(ctx context.Context) <-chan MutationSt
 
 	go func() {
 		err := d.ForeachFile(func(fullPath string, fi os.FileInfo) error {
-			return reclog.ForeachFileRecord(fullPath, func(off int64, hdr, rec []byte) error {
+			name := fi.Name()
+			skip := d.skip[name] // d.mu is held by ForeachFile
+			if skip > 0 && skip >= fi.Size() {
+				return nil
+			}
+			prev := skip
+			return foreachFileRecordFrom(fullPath, skip, func(off int64, hdr, rec []byte) error {
 				m := new(maintpb.Mutation)
 				if err := proto.Unmarshal(rec, m); err != nil {
 					return err
 				}
+				// n includes any checksum record before this one.
+				end := off + int64(len(hdr)+len(rec))
+				n := end - prev
+				prev = end
 				select {
-				case ch <- MutationStreamEvent{Mutation: m}:
+				case ch <- MutationStreamEvent{Mutation: m, pos: logPos{name, end}, n: n}:
 					return nil
 				case <-ctx.Done():
 					return ctx.Err()
@@ -125,3 +150,79 @@ func (d *DiskMutationLogger) GetMutations(ctx context.Context) <-chan MutationSt
 	}()
 	return ch
 }
+
+// foreachFileRecordFrom is like reclog.ForeachFileRecord, but starts
+// at offset skip, which must be the start of a record.
+func foreachFileRecordFrom(path string, skip int64, fn reclog.RecordCallback) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Seek(skip, io.SeekStart); err != nil {
+		return err
+	}
+	if err := reclog.ForeachRecord(f, skip, fn); err != nil {
+		return fmt.Errorf("error in %s: %v", path, err)
+	}
+	return nil
+}
+
+// snapshotDir implements snapshotSource. Snapshots are kept with the
+// log files.
+func (d *DiskMutationLogger) snapshotDir() string { return d.directory }
+
+// logPrefix implements snapshotSource. The prefix records only the
+// sizes of the log files, as they're only ever appended to.
+func (d *DiskMutationLogger) logPrefix(pos logPos) ([]logSeg, error) {
+	var prefix []logSeg
+	found := false
+	err := d.ForeachFile(func(fullPath string, fi os.FileInfo) error {
+		switch {
+		case found:
+		case fi.Name() == pos.seg:
+			prefix = append(prefix, logSeg{Name: pos.seg, Size: pos.off})
+			found = true
+		default:
+			prefix = append(prefix, logSeg{Name: fi.Name(), Size: fi.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("log file %s not found", pos.seg)
+	}
+	return prefix, nil
+}
+
+// resumeAfter implements snapshotSource.
+func (d *DiskMutationLogger) resumeAfter(ctx context.Context, prefix []logSeg) error {
+	var files []logSeg
+	err := d.ForeachFile(func(fullPath string, fi os.FileInfo) error {
+		files = append(files, logSeg{Name: fi.Name(), Size: fi.Size()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(files) < len(prefix) {
+		return fmt.Errorf("log has %d files; snapshot read %d", len(files), len(prefix))
+	}
+	skip := make(map[string]int64)
+	for i, p := range prefix {
+		f := files[i]
+		if f.Name != p.Name {
+			return fmt.Errorf("log file %d is %s; snapshot read %s", i, f.Name, p.Name)
+		}
+		if f.Size < p.Size || (f.Size != p.Size && i < len(prefix)-1) {
+			return fmt.Errorf("log file %s is %d bytes; snapshot read %d", f.Name, f.Size, p.Size)
+		}
+		skip[p.Name] = p.Size
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.skip = skip
+	return nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+func TestDiskMutationLoggerChecksums(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logger := NewDiskMutationLogger(dir)
+	for i, checksums := range []bool{true, false, true} {
+		logger.SetChecksums(checksums)
+		err := logger.Log(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:   "golang",
+			Repo:    "go",
+			Number:  int32(i + 1),
+			Created: p3339("2018-01-01T00:00:00Z"),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := new(Corpus)
+	if err := c.Initialize(context.Background(), NewDiskMutationLogger(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if nums := issueNumbers(t, c, "repo:golang/go"); nums != "go#1,go#2,go#3" {
+		t.Errorf("issues = %q; want go#1,go#2,go#3", nums)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.mutlog"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("log files = %v, %v; want one", files, err)
+	}
+	if fi, err := os.Stat(files[0]); err != nil || c.LogOffset() != fi.Size() {
+		t.Errorf("LogOffset = %d; want size of log", c.LogOffset())
+	}
+}
@@ -61,6 +61,23 @@ type Corpus struct {
 	gitCommitTodo map[GitHash]bool          // -> true
 	gitOfHg       map[string]GitHash        // hg hex hash -> git hash
 	zoneCache     map[string]*time.Location // "+0530" => location
+
+	gitRepos        map[string]*GitRepo // keyed by the name given to TrackGitRepo
+	watchedGitRepos []*GitRepo
+
+	search *searchIndex // nil unless EnableSearch was called
+	text   *textStore   // nil unless EnableCompactMode was called
+
+	// snapshots:
+	logPos  logPos // in mutationSource's log, just past the last mutation processed
+	logTail int64  // bytes of log processed since the last snapshot, or since the start
+
+	// log offsets:
+	logOff int64 // bytes of mutationSource's log processed; see LogOffset
+	stopAt int64 // if positive, the log offset InitializeAsOf stops at
+
+	numMutations int64      // mutations processed, from the start of the log
+	changes      *changeLog // nil unless EnableChangeEvents was called
 }
 
 // RLock grabs the corpus's read lock. Grabbing the read lock prevents
@@ -223,6 +240,12 @@ type MutationStreamEvent struct {
 	// have occurred yet). The End event is not a terminal state
 	// like Err. There may be multiple Ends.
 	End bool
+
+	// pos is the position in the log just past Mutation, for
+	// sources that support snapshots (see snapshotSource).
+	pos logPos
+	// n is the size of Mutation's log record, if pos is set.
+	n int64
 }
 
 // Initialize populates the Corpus using the data from the
@@ -233,10 +256,52 @@ func (c *Corpus) Initialize(ctx context.Context, src MutationSource) error {
 		panic("duplicate call to Initialize")
 	}
 	c.mutationSource = src
+	if ss, ok := src.(snapshotSource); ok {
+		c.loadSnapshot(ctx, ss)
+	}
 	log.Printf("Loading data from log %T ...", src)
+	if err := c.update(ctx, nil); err != nil {
+		return err
+	}
+	c.maybeWriteSnapshot()
+	return nil
+}
+
+// InitializeAsOf is like Initialize, but populates the Corpus with
+// only the mutations in the first off bytes of the MutationSource's
+// log, as reported by LogOffset. The resulting Corpus reflects the
+// state of the world as maintner saw it at that point. It can't be
+// updated later.
+//
+// Snapshots aren't used, so the whole log prefix is replayed.
+// Only sources that read a mutation log, like those returned by
+// NewDiskMutationLogger and NewNetworkMutationSource, report log
+// offsets; for others, InitializeAsOf behaves like Initialize.
+func (c *Corpus) InitializeAsOf(ctx context.Context, src MutationSource, off int64) error {
+	if c.mutationSource != nil {
+		panic("duplicate call to Initialize")
+	}
+	if off <= 0 {
+		return errors.New("maintner: InitializeAsOf offset must be positive")
+	}
+	c.mutationSource = src
+	c.stopAt = off
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	log.Printf("Loading data from log %T up to offset %d ...", src, off)
 	return c.update(ctx, nil)
 }
 
+// LogOffset reports how many bytes of its MutationSource's log the
+// corpus has processed. Offsets can later be passed to
+// InitializeAsOf. LogOffset doesn't count mutations the corpus
+// generated itself in leader mode.
+func (c *Corpus) LogOffset() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logOff
+}
+
 // ErrSplit is returned when the the client notices the leader's
 // mutation log has changed. This can happen if the leader restarts
 // with uncommitted transactions. (The leader only commits mutations
@@ -259,11 +324,17 @@ func (c *Corpus) Update(ctx context.Context) error {
 	if c.sawErrSplit {
 		panic("Update called after previous call returned ErrSplit")
 	}
+	if c.stopAt > 0 {
+		panic("Update called on corpus loaded with InitializeAsOf")
+	}
 	log.Printf("Updating data from log %T ...", c.mutationSource)
 	err := c.update(ctx, nil)
 	if err == ErrSplit {
 		c.sawErrSplit = true
 	}
+	if err == nil {
+		c.maybeWriteSnapshot()
+	}
 	return err
 }
 
@@ -276,11 +347,17 @@ func (c *Corpus) UpdateWithLocker(ctx context.Context, lk sync.Locker) error {
 	if c.sawErrSplit {
 		panic("UpdateWithLocker called after previous call returned ErrSplit")
 	}
+	if c.stopAt > 0 {
+		panic("UpdateWithLocker called on corpus loaded with InitializeAsOf")
+	}
 	log.Printf("Updating data from log %T ...", c.mutationSource)
 	err := c.update(ctx, lk)
 	if err == ErrSplit {
 		c.sawErrSplit = true
 	}
+	if err == nil {
+		c.maybeWriteSnapshot()
+	}
 	return err
 }
 
@@ -310,7 +387,7 @@ func (c *Corpus) update(ctx context.Context, lk sync.Locker) error {
 				log.Printf("Corpus GetMutations: %v", e.Err)
 				return e.Err
 			}
-			if e.End {
+			if e.End || (c.stopAt > 0 && c.logOff+e.n > c.stopAt) {
 				c.didInit = true
 				lk.Lock()
 				c.finishProcessing()
@@ -320,6 +397,9 @@ func (c *Corpus) update(ctx context.Context, lk sync.Locker) error {
 			}
 			lk.Lock()
 			c.processMutationLocked(e.Mutation)
+			c.logPos = e.pos
+			c.logTail += e.n
+			c.logOff += e.n
 			lk.Unlock()
 		}
 	}
@@ -333,6 +413,7 @@ func (c *Corpus) addMutation(m *maintpb.Mutation) {
 	c.mu.Lock()
 	c.processMutationLocked(m)
 	c.finishProcessing()
+	c.logPos = logPos{} // the corpus is now ahead of its source's log
 	c.mu.Unlock()
 
 	if c.mutationLogger == nil {
@@ -347,6 +428,10 @@ func (c *Corpus) addMutation(m *maintpb.Mutation) {
 
 // c.mu must be held.
 func (c *Corpus) processMutationLocked(m *maintpb.Mutation) {
+	if c.changes != nil {
+		c.noteMutation(m)
+	}
+	c.numMutations++
 	if im := m.GithubIssue; im != nil {
 		c.processGithubIssueMutation(im)
 	}
@@ -367,6 +452,7 @@ func (c *Corpus) processMutationLocked(m *maintpb.Mutation) {
 // c.mu must be held.
 func (c *Corpus) finishProcessing() {
 	c.gerrit.finishProcessing()
+	c.finishSearch()
 }
 
 // SyncLoop runs forever (until an error or context expiration) and
@@ -417,6 +503,22 @@ func (c *Corpus) sync(ctx context.Context, loop bool) error {
 			}
 		})
 	}
+	for _, r := range c.watchedGitRepos {
+		r := r
+		group.Go(func() error {
+			log.Printf("Polling git repo %v ...", r.name)
+			for {
+				err := r.sync(ctx, loop)
+				if loop && isTempErr(err) {
+					log.Printf("Temporary error from git repo %v: %v", r.name, err)
+					time.Sleep(30 * time.Second)
+					continue
+				}
+				log.Printf("git sync ending for %v: %v", r.name, err)
+				return err
+			}
+		})
+	}
 	for _, w := range c.watchedGerritRepos {
 		gp := w.project
 		group.Go(func() error {
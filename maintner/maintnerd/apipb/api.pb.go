@@ -15,12 +15,37 @@ It has these top-level messages:
 	GoFindTryWorkRequest
 	GoFindTryWorkResponse
 	GerritTryWorkItem
+	SearchRequest
+	SearchResponse
+	SearchResult
+	WatchChangesRequest
+	ChangeEvent
+	ListGerritProjectsRequest
+	ListGerritProjectsResponse
+	GerritProject
+	ListGitHubReposRequest
+	ListGitHubReposResponse
+	ListRefsRequest
+	ListRefsResponse
+	GitRef
+	GetCLRequest
+	GerritCL
+	GerritPatchSet
+	GerritVote
+	GerritMessage
+	GetIssueRequest
+	GitHubIssue
+	GitHubComment
+	GitHubIssueEvent
+	QueryRequest
+	QueryResponse
 */
 package apipb
 
 import proto "github.com/golang/protobuf/proto"
 import fmt "fmt"
 import math "math"
+import google_protobuf "github.com/golang/protobuf/ptypes/timestamp"
 
 import (
 	context "context"
@@ -234,6 +259,1062 @@ func (m *GerritTryWorkItem) GetGoBranch() []string {
 	return nil
 }
 
+type SearchRequest struct {
+	// query is words to search for in the titles, descriptions and
+	// comments of GitHub issues, and the commit messages and review
+	// messages of Gerrit CLs.
+	Query string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+	// max_results is the maximum number of results to return.
+	// Zero means 50.
+	MaxResults int32 `protobuf:"varint,2,opt,name=max_results,json=maxResults" json:"max_results,omitempty"`
+}
+
+func (m *SearchRequest) Reset()                    { *m = SearchRequest{} }
+func (m *SearchRequest) String() string            { return proto.CompactTextString(m) }
+func (*SearchRequest) ProtoMessage()               {}
+func (*SearchRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *SearchRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *SearchRequest) GetMaxResults() int32 {
+	if m != nil {
+		return m.MaxResults
+	}
+	return 0
+}
+
+type SearchResponse struct {
+	// results are the matches, most relevant first.
+	Results []*SearchResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+}
+
+func (m *SearchResponse) Reset()                    { *m = SearchResponse{} }
+func (m *SearchResponse) String() string            { return proto.CompactTextString(m) }
+func (*SearchResponse) ProtoMessage()               {}
+func (*SearchResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *SearchResponse) GetResults() []*SearchResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+type SearchResult struct {
+	// score is the relevance of the result, comparable only to
+	// other results of the same query.
+	Score float64 `protobuf:"fixed64,1,opt,name=score" json:"score,omitempty"`
+	// title is the issue title or the CL subject.
+	Title         string `protobuf:"bytes,2,opt,name=title" json:"title,omitempty"`
+	GithubRepo    string `protobuf:"bytes,3,opt,name=github_repo,json=githubRepo" json:"github_repo,omitempty"`
+	GithubIssue   int32  `protobuf:"varint,4,opt,name=github_issue,json=githubIssue" json:"github_issue,omitempty"`
+	PullRequest   bool   `protobuf:"varint,5,opt,name=pull_request,json=pullRequest" json:"pull_request,omitempty"`
+	GerritServer  string `protobuf:"bytes,6,opt,name=gerrit_server,json=gerritServer" json:"gerrit_server,omitempty"`
+	GerritProject string `protobuf:"bytes,7,opt,name=gerrit_project,json=gerritProject" json:"gerrit_project,omitempty"`
+	GerritCl      int32  `protobuf:"varint,8,opt,name=gerrit_cl,json=gerritCl" json:"gerrit_cl,omitempty"`
+	Closed        bool   `protobuf:"varint,9,opt,name=closed" json:"closed,omitempty"`
+}
+
+func (m *SearchResult) Reset()                    { *m = SearchResult{} }
+func (m *SearchResult) String() string            { return proto.CompactTextString(m) }
+func (*SearchResult) ProtoMessage()               {}
+func (*SearchResult) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *SearchResult) GetScore() float64 {
+	if m != nil {
+		return m.Score
+	}
+	return 0
+}
+
+func (m *SearchResult) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *SearchResult) GetGithubRepo() string {
+	if m != nil {
+		return m.GithubRepo
+	}
+	return ""
+}
+
+func (m *SearchResult) GetGithubIssue() int32 {
+	if m != nil {
+		return m.GithubIssue
+	}
+	return 0
+}
+
+func (m *SearchResult) GetPullRequest() bool {
+	if m != nil {
+		return m.PullRequest
+	}
+	return false
+}
+
+func (m *SearchResult) GetGerritServer() string {
+	if m != nil {
+		return m.GerritServer
+	}
+	return ""
+}
+
+func (m *SearchResult) GetGerritProject() string {
+	if m != nil {
+		return m.GerritProject
+	}
+	return ""
+}
+
+func (m *SearchResult) GetGerritCl() int32 {
+	if m != nil {
+		return m.GerritCl
+	}
+	return 0
+}
+
+func (m *SearchResult) GetClosed() bool {
+	if m != nil {
+		return m.Closed
+	}
+	return false
+}
+
+type WatchChangesRequest struct {
+	// seq is the sequence number of the mutation to stream changes
+	// from. To resume a stream, use the seq of the last event
+	// received plus one. A negative seq means to stream only changes
+	// that haven't happened yet.
+	Seq int64 `protobuf:"varint,1,opt,name=seq" json:"seq,omitempty"`
+	// types are the event types to return, such as "issue_labeled"
+	// or "cl_patchset". See ChangeEvent.type.
+	Types []string `protobuf:"bytes,2,rep,name=types" json:"types,omitempty"`
+	// github_repos are the GitHub repos ("golang/go") whose events
+	// to return.
+	GithubRepos []string `protobuf:"bytes,3,rep,name=github_repos,json=githubRepos" json:"github_repos,omitempty"`
+	// gerrit_projects are the Gerrit projects
+	// ("go.googlesource.com/go") whose events to return.
+	GerritProjects []string `protobuf:"bytes,4,rep,name=gerrit_projects,json=gerritProjects" json:"gerrit_projects,omitempty"`
+}
+
+func (m *WatchChangesRequest) Reset()                    { *m = WatchChangesRequest{} }
+func (m *WatchChangesRequest) String() string            { return proto.CompactTextString(m) }
+func (*WatchChangesRequest) ProtoMessage()               {}
+func (*WatchChangesRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *WatchChangesRequest) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *WatchChangesRequest) GetTypes() []string {
+	if m != nil {
+		return m.Types
+	}
+	return nil
+}
+
+func (m *WatchChangesRequest) GetGithubRepos() []string {
+	if m != nil {
+		return m.GithubRepos
+	}
+	return nil
+}
+
+func (m *WatchChangesRequest) GetGerritProjects() []string {
+	if m != nil {
+		return m.GerritProjects
+	}
+	return nil
+}
+
+type ChangeEvent struct {
+	// seq is the sequence number of the mutation that caused the
+	// change: its index in the mutation log. It counts mutations,
+	// not bytes like log offsets do. Several events may have the
+	// same seq.
+	Seq int64 `protobuf:"varint,1,opt,name=seq" json:"seq,omitempty"`
+	// type is one of "issue_opened", "issue_closed",
+	// "issue_reopened", "issue_labeled", "issue_unlabeled",
+	// "issue_commented", "cl_created", "cl_patchset", "cl_updated"
+	// or "ref_moved".
+	Type string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	// For issue events:
+	GithubRepo  string `protobuf:"bytes,3,opt,name=github_repo,json=githubRepo" json:"github_repo,omitempty"`
+	GithubIssue int32  `protobuf:"varint,4,opt,name=github_issue,json=githubIssue" json:"github_issue,omitempty"`
+	Label       string `protobuf:"bytes,5,opt,name=label" json:"label,omitempty"`
+	CommentId   int64  `protobuf:"varint,6,opt,name=comment_id,json=commentId" json:"comment_id,omitempty"`
+	// For CL and ref events:
+	GerritServer  string `protobuf:"bytes,7,opt,name=gerrit_server,json=gerritServer" json:"gerrit_server,omitempty"`
+	GerritProject string `protobuf:"bytes,8,opt,name=gerrit_project,json=gerritProject" json:"gerrit_project,omitempty"`
+	GerritCl      int32  `protobuf:"varint,9,opt,name=gerrit_cl,json=gerritCl" json:"gerrit_cl,omitempty"`
+	Version       int32  `protobuf:"varint,10,opt,name=version" json:"version,omitempty"`
+	Ref           string `protobuf:"bytes,11,opt,name=ref" json:"ref,omitempty"`
+	OldCommit     string `protobuf:"bytes,12,opt,name=old_commit,json=oldCommit" json:"old_commit,omitempty"`
+	Commit        string `protobuf:"bytes,13,opt,name=commit" json:"commit,omitempty"`
+}
+
+func (m *ChangeEvent) Reset()                    { *m = ChangeEvent{} }
+func (m *ChangeEvent) String() string            { return proto.CompactTextString(m) }
+func (*ChangeEvent) ProtoMessage()               {}
+func (*ChangeEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *ChangeEvent) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *ChangeEvent) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetGithubRepo() string {
+	if m != nil {
+		return m.GithubRepo
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetGithubIssue() int32 {
+	if m != nil {
+		return m.GithubIssue
+	}
+	return 0
+}
+
+func (m *ChangeEvent) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetCommentId() int64 {
+	if m != nil {
+		return m.CommentId
+	}
+	return 0
+}
+
+func (m *ChangeEvent) GetGerritServer() string {
+	if m != nil {
+		return m.GerritServer
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetGerritProject() string {
+	if m != nil {
+		return m.GerritProject
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetGerritCl() int32 {
+	if m != nil {
+		return m.GerritCl
+	}
+	return 0
+}
+
+func (m *ChangeEvent) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *ChangeEvent) GetRef() string {
+	if m != nil {
+		return m.Ref
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetOldCommit() string {
+	if m != nil {
+		return m.OldCommit
+	}
+	return ""
+}
+
+func (m *ChangeEvent) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+type ListGerritProjectsRequest struct {
+}
+
+func (m *ListGerritProjectsRequest) Reset()                    { *m = ListGerritProjectsRequest{} }
+func (m *ListGerritProjectsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListGerritProjectsRequest) ProtoMessage()               {}
+func (*ListGerritProjectsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+type ListGerritProjectsResponse struct {
+	Projects []*GerritProject `protobuf:"bytes,1,rep,name=projects" json:"projects,omitempty"`
+}
+
+func (m *ListGerritProjectsResponse) Reset()                    { *m = ListGerritProjectsResponse{} }
+func (m *ListGerritProjectsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListGerritProjectsResponse) ProtoMessage()               {}
+func (*ListGerritProjectsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *ListGerritProjectsResponse) GetProjects() []*GerritProject {
+	if m != nil {
+		return m.Projects
+	}
+	return nil
+}
+
+type GerritProject struct {
+	Server  string `protobuf:"bytes,1,opt,name=server" json:"server,omitempty"`
+	Project string `protobuf:"bytes,2,opt,name=project" json:"project,omitempty"`
+}
+
+func (m *GerritProject) Reset()                    { *m = GerritProject{} }
+func (m *GerritProject) String() string            { return proto.CompactTextString(m) }
+func (*GerritProject) ProtoMessage()               {}
+func (*GerritProject) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *GerritProject) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *GerritProject) GetProject() string {
+	if m != nil {
+		return m.Project
+	}
+	return ""
+}
+
+type ListGitHubReposRequest struct {
+}
+
+func (m *ListGitHubReposRequest) Reset()                    { *m = ListGitHubReposRequest{} }
+func (m *ListGitHubReposRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListGitHubReposRequest) ProtoMessage()               {}
+func (*ListGitHubReposRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+type ListGitHubReposResponse struct {
+	Repos []string `protobuf:"bytes,1,rep,name=repos" json:"repos,omitempty"`
+}
+
+func (m *ListGitHubReposResponse) Reset()                    { *m = ListGitHubReposResponse{} }
+func (m *ListGitHubReposResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListGitHubReposResponse) ProtoMessage()               {}
+func (*ListGitHubReposResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *ListGitHubReposResponse) GetRepos() []string {
+	if m != nil {
+		return m.Repos
+	}
+	return nil
+}
+
+type ListRefsRequest struct {
+	GerritServer  string `protobuf:"bytes,1,opt,name=gerrit_server,json=gerritServer" json:"gerrit_server,omitempty"`
+	GerritProject string `protobuf:"bytes,2,opt,name=gerrit_project,json=gerritProject" json:"gerrit_project,omitempty"`
+}
+
+func (m *ListRefsRequest) Reset()                    { *m = ListRefsRequest{} }
+func (m *ListRefsRequest) String() string            { return proto.CompactTextString(m) }
+func (*ListRefsRequest) ProtoMessage()               {}
+func (*ListRefsRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *ListRefsRequest) GetGerritServer() string {
+	if m != nil {
+		return m.GerritServer
+	}
+	return ""
+}
+
+func (m *ListRefsRequest) GetGerritProject() string {
+	if m != nil {
+		return m.GerritProject
+	}
+	return ""
+}
+
+type ListRefsResponse struct {
+	// refs are the project's refs, other than those of CLs,
+	// sorted by name.
+	Refs []*GitRef `protobuf:"bytes,1,rep,name=refs" json:"refs,omitempty"`
+}
+
+func (m *ListRefsResponse) Reset()                    { *m = ListRefsResponse{} }
+func (m *ListRefsResponse) String() string            { return proto.CompactTextString(m) }
+func (*ListRefsResponse) ProtoMessage()               {}
+func (*ListRefsResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *ListRefsResponse) GetRefs() []*GitRef {
+	if m != nil {
+		return m.Refs
+	}
+	return nil
+}
+
+type GitRef struct {
+	Ref    string `protobuf:"bytes,1,opt,name=ref" json:"ref,omitempty"`
+	Commit string `protobuf:"bytes,2,opt,name=commit" json:"commit,omitempty"`
+}
+
+func (m *GitRef) Reset()                    { *m = GitRef{} }
+func (m *GitRef) String() string            { return proto.CompactTextString(m) }
+func (*GitRef) ProtoMessage()               {}
+func (*GitRef) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *GitRef) GetRef() string {
+	if m != nil {
+		return m.Ref
+	}
+	return ""
+}
+
+func (m *GitRef) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+type GetCLRequest struct {
+	GerritServer  string `protobuf:"bytes,1,opt,name=gerrit_server,json=gerritServer" json:"gerrit_server,omitempty"`
+	GerritProject string `protobuf:"bytes,2,opt,name=gerrit_project,json=gerritProject" json:"gerrit_project,omitempty"`
+	Number        int32  `protobuf:"varint,3,opt,name=number" json:"number,omitempty"`
+}
+
+func (m *GetCLRequest) Reset()                    { *m = GetCLRequest{} }
+func (m *GetCLRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetCLRequest) ProtoMessage()               {}
+func (*GetCLRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *GetCLRequest) GetGerritServer() string {
+	if m != nil {
+		return m.GerritServer
+	}
+	return ""
+}
+
+func (m *GetCLRequest) GetGerritProject() string {
+	if m != nil {
+		return m.GerritProject
+	}
+	return ""
+}
+
+func (m *GetCLRequest) GetNumber() int32 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+type GerritCL struct {
+	GerritServer   string                     `protobuf:"bytes,1,opt,name=gerrit_server,json=gerritServer" json:"gerrit_server,omitempty"`
+	GerritProject  string                     `protobuf:"bytes,2,opt,name=gerrit_project,json=gerritProject" json:"gerrit_project,omitempty"`
+	Number         int32                      `protobuf:"varint,3,opt,name=number" json:"number,omitempty"`
+	ChangeId       string                     `protobuf:"bytes,4,opt,name=change_id,json=changeId" json:"change_id,omitempty"`
+	Subject        string                     `protobuf:"bytes,5,opt,name=subject" json:"subject,omitempty"`
+	Branch         string                     `protobuf:"bytes,6,opt,name=branch" json:"branch,omitempty"`
+	Status         string                     `protobuf:"bytes,7,opt,name=status" json:"status,omitempty"`
+	WorkInProgress bool                       `protobuf:"varint,8,opt,name=work_in_progress,json=workInProgress" json:"work_in_progress,omitempty"`
+	OwnerId        int64                      `protobuf:"varint,9,opt,name=owner_id,json=ownerId" json:"owner_id,omitempty"`
+	OwnerName      string                     `protobuf:"bytes,10,opt,name=owner_name,json=ownerName" json:"owner_name,omitempty"`
+	Created        *google_protobuf.Timestamp `protobuf:"bytes,11,opt,name=created" json:"created,omitempty"`
+	Hashtags       []string                   `protobuf:"bytes,12,rep,name=hashtags" json:"hashtags,omitempty"`
+	GithubIssues   []string                   `protobuf:"bytes,13,rep,name=github_issues,json=githubIssues" json:"github_issues,omitempty"`
+	// patch_sets are the CL's patch sets, oldest first.
+	PatchSets []*GerritPatchSet `protobuf:"bytes,14,rep,name=patch_sets,json=patchSets" json:"patch_sets,omitempty"`
+	// votes are the current votes on the CL's labels.
+	Votes []*GerritVote `protobuf:"bytes,15,rep,name=votes" json:"votes,omitempty"`
+	// messages are the CL's review messages, oldest first.
+	Messages []*GerritMessage `protobuf:"bytes,16,rep,name=messages" json:"messages,omitempty"`
+}
+
+func (m *GerritCL) Reset()                    { *m = GerritCL{} }
+func (m *GerritCL) String() string            { return proto.CompactTextString(m) }
+func (*GerritCL) ProtoMessage()               {}
+func (*GerritCL) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *GerritCL) GetGerritServer() string {
+	if m != nil {
+		return m.GerritServer
+	}
+	return ""
+}
+
+func (m *GerritCL) GetGerritProject() string {
+	if m != nil {
+		return m.GerritProject
+	}
+	return ""
+}
+
+func (m *GerritCL) GetNumber() int32 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+func (m *GerritCL) GetChangeId() string {
+	if m != nil {
+		return m.ChangeId
+	}
+	return ""
+}
+
+func (m *GerritCL) GetSubject() string {
+	if m != nil {
+		return m.Subject
+	}
+	return ""
+}
+
+func (m *GerritCL) GetBranch() string {
+	if m != nil {
+		return m.Branch
+	}
+	return ""
+}
+
+func (m *GerritCL) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *GerritCL) GetWorkInProgress() bool {
+	if m != nil {
+		return m.WorkInProgress
+	}
+	return false
+}
+
+func (m *GerritCL) GetOwnerId() int64 {
+	if m != nil {
+		return m.OwnerId
+	}
+	return 0
+}
+
+func (m *GerritCL) GetOwnerName() string {
+	if m != nil {
+		return m.OwnerName
+	}
+	return ""
+}
+
+func (m *GerritCL) GetCreated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *GerritCL) GetHashtags() []string {
+	if m != nil {
+		return m.Hashtags
+	}
+	return nil
+}
+
+func (m *GerritCL) GetGithubIssues() []string {
+	if m != nil {
+		return m.GithubIssues
+	}
+	return nil
+}
+
+func (m *GerritCL) GetPatchSets() []*GerritPatchSet {
+	if m != nil {
+		return m.PatchSets
+	}
+	return nil
+}
+
+func (m *GerritCL) GetVotes() []*GerritVote {
+	if m != nil {
+		return m.Votes
+	}
+	return nil
+}
+
+func (m *GerritCL) GetMessages() []*GerritMessage {
+	if m != nil {
+		return m.Messages
+	}
+	return nil
+}
+
+type GerritPatchSet struct {
+	Version int32  `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Commit  string `protobuf:"bytes,2,opt,name=commit" json:"commit,omitempty"`
+}
+
+func (m *GerritPatchSet) Reset()                    { *m = GerritPatchSet{} }
+func (m *GerritPatchSet) String() string            { return proto.CompactTextString(m) }
+func (*GerritPatchSet) ProtoMessage()               {}
+func (*GerritPatchSet) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *GerritPatchSet) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *GerritPatchSet) GetCommit() string {
+	if m != nil {
+		return m.Commit
+	}
+	return ""
+}
+
+type GerritVote struct {
+	Label string `protobuf:"bytes,1,opt,name=label" json:"label,omitempty"`
+	Voter string `protobuf:"bytes,2,opt,name=voter" json:"voter,omitempty"`
+	Value int32  `protobuf:"varint,3,opt,name=value" json:"value,omitempty"`
+}
+
+func (m *GerritVote) Reset()                    { *m = GerritVote{} }
+func (m *GerritVote) String() string            { return proto.CompactTextString(m) }
+func (*GerritVote) ProtoMessage()               {}
+func (*GerritVote) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *GerritVote) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *GerritVote) GetVoter() string {
+	if m != nil {
+		return m.Voter
+	}
+	return ""
+}
+
+func (m *GerritVote) GetValue() int32 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+type GerritMessage struct {
+	Version int32                      `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Author  string                     `protobuf:"bytes,2,opt,name=author" json:"author,omitempty"`
+	Date    *google_protobuf.Timestamp `protobuf:"bytes,3,opt,name=date" json:"date,omitempty"`
+	Message string                     `protobuf:"bytes,4,opt,name=message" json:"message,omitempty"`
+}
+
+func (m *GerritMessage) Reset()                    { *m = GerritMessage{} }
+func (m *GerritMessage) String() string            { return proto.CompactTextString(m) }
+func (*GerritMessage) ProtoMessage()               {}
+func (*GerritMessage) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
+
+func (m *GerritMessage) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *GerritMessage) GetAuthor() string {
+	if m != nil {
+		return m.Author
+	}
+	return ""
+}
+
+func (m *GerritMessage) GetDate() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Date
+	}
+	return nil
+}
+
+func (m *GerritMessage) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+type GetIssueRequest struct {
+	GithubRepo string `protobuf:"bytes,1,opt,name=github_repo,json=githubRepo" json:"github_repo,omitempty"`
+	Number     int32  `protobuf:"varint,2,opt,name=number" json:"number,omitempty"`
+}
+
+func (m *GetIssueRequest) Reset()                    { *m = GetIssueRequest{} }
+func (m *GetIssueRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetIssueRequest) ProtoMessage()               {}
+func (*GetIssueRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{25} }
+
+func (m *GetIssueRequest) GetGithubRepo() string {
+	if m != nil {
+		return m.GithubRepo
+	}
+	return ""
+}
+
+func (m *GetIssueRequest) GetNumber() int32 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+type GitHubIssue struct {
+	GithubRepo  string                     `protobuf:"bytes,1,opt,name=github_repo,json=githubRepo" json:"github_repo,omitempty"`
+	Number      int32                      `protobuf:"varint,2,opt,name=number" json:"number,omitempty"`
+	Title       string                     `protobuf:"bytes,3,opt,name=title" json:"title,omitempty"`
+	Body        string                     `protobuf:"bytes,4,opt,name=body" json:"body,omitempty"`
+	User        string                     `protobuf:"bytes,5,opt,name=user" json:"user,omitempty"`
+	PullRequest bool                       `protobuf:"varint,6,opt,name=pull_request,json=pullRequest" json:"pull_request,omitempty"`
+	Closed      bool                       `protobuf:"varint,7,opt,name=closed" json:"closed,omitempty"`
+	Created     *google_protobuf.Timestamp `protobuf:"bytes,8,opt,name=created" json:"created,omitempty"`
+	Updated     *google_protobuf.Timestamp `protobuf:"bytes,9,opt,name=updated" json:"updated,omitempty"`
+	ClosedAt    *google_protobuf.Timestamp `protobuf:"bytes,10,opt,name=closed_at,json=closedAt" json:"closed_at,omitempty"`
+	Milestone   string                     `protobuf:"bytes,11,opt,name=milestone" json:"milestone,omitempty"`
+	Labels      []string                   `protobuf:"bytes,12,rep,name=labels" json:"labels,omitempty"`
+	Assignees   []string                   `protobuf:"bytes,13,rep,name=assignees" json:"assignees,omitempty"`
+	// comments are the issue's comments, oldest first.
+	Comments []*GitHubComment `protobuf:"bytes,14,rep,name=comments" json:"comments,omitempty"`
+	// events are the issue's events, oldest first.
+	Events []*GitHubIssueEvent `protobuf:"bytes,15,rep,name=events" json:"events,omitempty"`
+}
+
+func (m *GitHubIssue) Reset()                    { *m = GitHubIssue{} }
+func (m *GitHubIssue) String() string            { return proto.CompactTextString(m) }
+func (*GitHubIssue) ProtoMessage()               {}
+func (*GitHubIssue) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{26} }
+
+func (m *GitHubIssue) GetGithubRepo() string {
+	if m != nil {
+		return m.GithubRepo
+	}
+	return ""
+}
+
+func (m *GitHubIssue) GetNumber() int32 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+func (m *GitHubIssue) GetTitle() string {
+	if m != nil {
+		return m.Title
+	}
+	return ""
+}
+
+func (m *GitHubIssue) GetBody() string {
+	if m != nil {
+		return m.Body
+	}
+	return ""
+}
+
+func (m *GitHubIssue) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *GitHubIssue) GetPullRequest() bool {
+	if m != nil {
+		return m.PullRequest
+	}
+	return false
+}
+
+func (m *GitHubIssue) GetClosed() bool {
+	if m != nil {
+		return m.Closed
+	}
+	return false
+}
+
+func (m *GitHubIssue) GetCreated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *GitHubIssue) GetUpdated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
+func (m *GitHubIssue) GetClosedAt() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.ClosedAt
+	}
+	return nil
+}
+
+func (m *GitHubIssue) GetMilestone() string {
+	if m != nil {
+		return m.Milestone
+	}
+	return ""
+}
+
+func (m *GitHubIssue) GetLabels() []string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *GitHubIssue) GetAssignees() []string {
+	if m != nil {
+		return m.Assignees
+	}
+	return nil
+}
+
+func (m *GitHubIssue) GetComments() []*GitHubComment {
+	if m != nil {
+		return m.Comments
+	}
+	return nil
+}
+
+func (m *GitHubIssue) GetEvents() []*GitHubIssueEvent {
+	if m != nil {
+		return m.Events
+	}
+	return nil
+}
+
+type GitHubComment struct {
+	Id      int64                      `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	User    string                     `protobuf:"bytes,2,opt,name=user" json:"user,omitempty"`
+	Created *google_protobuf.Timestamp `protobuf:"bytes,3,opt,name=created" json:"created,omitempty"`
+	Updated *google_protobuf.Timestamp `protobuf:"bytes,4,opt,name=updated" json:"updated,omitempty"`
+	Body    string                     `protobuf:"bytes,5,opt,name=body" json:"body,omitempty"`
+}
+
+func (m *GitHubComment) Reset()                    { *m = GitHubComment{} }
+func (m *GitHubComment) String() string            { return proto.CompactTextString(m) }
+func (*GitHubComment) ProtoMessage()               {}
+func (*GitHubComment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{27} }
+
+func (m *GitHubComment) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *GitHubComment) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *GitHubComment) GetCreated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *GitHubComment) GetUpdated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
+func (m *GitHubComment) GetBody() string {
+	if m != nil {
+		return m.Body
+	}
+	return ""
+}
+
+type GitHubIssueEvent struct {
+	Id         int64                      `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Type       string                     `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	Actor      string                     `protobuf:"bytes,3,opt,name=actor" json:"actor,omitempty"`
+	Created    *google_protobuf.Timestamp `protobuf:"bytes,4,opt,name=created" json:"created,omitempty"`
+	Label      string                     `protobuf:"bytes,5,opt,name=label" json:"label,omitempty"`
+	Milestone  string                     `protobuf:"bytes,6,opt,name=milestone" json:"milestone,omitempty"`
+	Assignee   string                     `protobuf:"bytes,7,opt,name=assignee" json:"assignee,omitempty"`
+	RenameFrom string                     `protobuf:"bytes,8,opt,name=rename_from,json=renameFrom" json:"rename_from,omitempty"`
+	RenameTo   string                     `protobuf:"bytes,9,opt,name=rename_to,json=renameTo" json:"rename_to,omitempty"`
+}
+
+func (m *GitHubIssueEvent) Reset()                    { *m = GitHubIssueEvent{} }
+func (m *GitHubIssueEvent) String() string            { return proto.CompactTextString(m) }
+func (*GitHubIssueEvent) ProtoMessage()               {}
+func (*GitHubIssueEvent) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{28} }
+
+func (m *GitHubIssueEvent) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *GitHubIssueEvent) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *GitHubIssueEvent) GetActor() string {
+	if m != nil {
+		return m.Actor
+	}
+	return ""
+}
+
+func (m *GitHubIssueEvent) GetCreated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *GitHubIssueEvent) GetLabel() string {
+	if m != nil {
+		return m.Label
+	}
+	return ""
+}
+
+func (m *GitHubIssueEvent) GetMilestone() string {
+	if m != nil {
+		return m.Milestone
+	}
+	return ""
+}
+
+func (m *GitHubIssueEvent) GetAssignee() string {
+	if m != nil {
+		return m.Assignee
+	}
+	return ""
+}
+
+func (m *GitHubIssueEvent) GetRenameFrom() string {
+	if m != nil {
+		return m.RenameFrom
+	}
+	return ""
+}
+
+func (m *GitHubIssueEvent) GetRenameTo() string {
+	if m != nil {
+		return m.RenameTo
+	}
+	return ""
+}
+
+type QueryRequest struct {
+	// query is in maintner's query language, such as
+	// "repo:golang/go label:NeedsFix is:open" for issues, or
+	// "project:go owner:1234 status:new" for CLs. See
+	// https://godoc.org/golang.org/x/build/maintner#Query.
+	Query string `protobuf:"bytes,1,opt,name=query" json:"query,omitempty"`
+	// gerrit is whether to query Gerrit CLs instead of GitHub
+	// issues.
+	Gerrit bool `protobuf:"varint,2,opt,name=gerrit" json:"gerrit,omitempty"`
+	// max_results is the maximum number of results to return.
+	// Zero means 100.
+	MaxResults int32 `protobuf:"varint,3,opt,name=max_results,json=maxResults" json:"max_results,omitempty"`
+}
+
+func (m *QueryRequest) Reset()                    { *m = QueryRequest{} }
+func (m *QueryRequest) String() string            { return proto.CompactTextString(m) }
+func (*QueryRequest) ProtoMessage()               {}
+func (*QueryRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{29} }
+
+func (m *QueryRequest) GetQuery() string {
+	if m != nil {
+		return m.Query
+	}
+	return ""
+}
+
+func (m *QueryRequest) GetGerrit() bool {
+	if m != nil {
+		return m.Gerrit
+	}
+	return false
+}
+
+func (m *QueryRequest) GetMaxResults() int32 {
+	if m != nil {
+		return m.MaxResults
+	}
+	return 0
+}
+
+type QueryResponse struct {
+	// results are the matches, ordered by repo or project and
+	// then by number. Their scores are zero.
+	Results []*SearchResult `protobuf:"bytes,1,rep,name=results" json:"results,omitempty"`
+	// truncated is whether there were more than max_results
+	// matches.
+	Truncated bool `protobuf:"varint,2,opt,name=truncated" json:"truncated,omitempty"`
+}
+
+func (m *QueryResponse) Reset()                    { *m = QueryResponse{} }
+func (m *QueryResponse) String() string            { return proto.CompactTextString(m) }
+func (*QueryResponse) ProtoMessage()               {}
+func (*QueryResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{30} }
+
+func (m *QueryResponse) GetResults() []*SearchResult {
+	if m != nil {
+		return m.Results
+	}
+	return nil
+}
+
+func (m *QueryResponse) GetTruncated() bool {
+	if m != nil {
+		return m.Truncated
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*HasAncestorRequest)(nil), "apipb.HasAncestorRequest")
 	proto.RegisterType((*HasAncestorResponse)(nil), "apipb.HasAncestorResponse")
@@ -242,6 +1323,30 @@ func init() {
 	proto.RegisterType((*GoFindTryWorkRequest)(nil), "apipb.GoFindTryWorkRequest")
 	proto.RegisterType((*GoFindTryWorkResponse)(nil), "apipb.GoFindTryWorkResponse")
 	proto.RegisterType((*GerritTryWorkItem)(nil), "apipb.GerritTryWorkItem")
+	proto.RegisterType((*SearchRequest)(nil), "apipb.SearchRequest")
+	proto.RegisterType((*SearchResponse)(nil), "apipb.SearchResponse")
+	proto.RegisterType((*SearchResult)(nil), "apipb.SearchResult")
+	proto.RegisterType((*WatchChangesRequest)(nil), "apipb.WatchChangesRequest")
+	proto.RegisterType((*ChangeEvent)(nil), "apipb.ChangeEvent")
+	proto.RegisterType((*ListGerritProjectsRequest)(nil), "apipb.ListGerritProjectsRequest")
+	proto.RegisterType((*ListGerritProjectsResponse)(nil), "apipb.ListGerritProjectsResponse")
+	proto.RegisterType((*GerritProject)(nil), "apipb.GerritProject")
+	proto.RegisterType((*ListGitHubReposRequest)(nil), "apipb.ListGitHubReposRequest")
+	proto.RegisterType((*ListGitHubReposResponse)(nil), "apipb.ListGitHubReposResponse")
+	proto.RegisterType((*ListRefsRequest)(nil), "apipb.ListRefsRequest")
+	proto.RegisterType((*ListRefsResponse)(nil), "apipb.ListRefsResponse")
+	proto.RegisterType((*GitRef)(nil), "apipb.GitRef")
+	proto.RegisterType((*GetCLRequest)(nil), "apipb.GetCLRequest")
+	proto.RegisterType((*GerritCL)(nil), "apipb.GerritCL")
+	proto.RegisterType((*GerritPatchSet)(nil), "apipb.GerritPatchSet")
+	proto.RegisterType((*GerritVote)(nil), "apipb.GerritVote")
+	proto.RegisterType((*GerritMessage)(nil), "apipb.GerritMessage")
+	proto.RegisterType((*GetIssueRequest)(nil), "apipb.GetIssueRequest")
+	proto.RegisterType((*GitHubIssue)(nil), "apipb.GitHubIssue")
+	proto.RegisterType((*GitHubComment)(nil), "apipb.GitHubComment")
+	proto.RegisterType((*GitHubIssueEvent)(nil), "apipb.GitHubIssueEvent")
+	proto.RegisterType((*QueryRequest)(nil), "apipb.QueryRequest")
+	proto.RegisterType((*QueryResponse)(nil), "apipb.QueryResponse")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -260,6 +1365,28 @@ type MaintnerServiceClient interface {
 	HasAncestor(ctx context.Context, in *HasAncestorRequest, opts ...grpc.CallOption) (*HasAncestorResponse, error)
 	// GetRef returns information about a git ref.
 	GetRef(ctx context.Context, in *GetRefRequest, opts ...grpc.CallOption) (*GetRefResponse, error)
+	// Search does a full-text search of GitHub issues and Gerrit CLs.
+	// It fails if the server wasn't started with search enabled.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// WatchChanges streams changes to issues, CLs and refs as the
+	// server sees them, starting at a given mutation sequence number.
+	// It fails if the server wasn't started with change events
+	// enabled, or no longer has the events from that mutation.
+	WatchChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (MaintnerService_WatchChangesClient, error)
+	// ListGerritProjects lists the Gerrit projects the server tracks.
+	ListGerritProjects(ctx context.Context, in *ListGerritProjectsRequest, opts ...grpc.CallOption) (*ListGerritProjectsResponse, error)
+	// ListGitHubRepos lists the GitHub repos the server tracks.
+	ListGitHubRepos(ctx context.Context, in *ListGitHubReposRequest, opts ...grpc.CallOption) (*ListGitHubReposResponse, error)
+	// ListRefs lists the refs of a Gerrit project.
+	ListRefs(ctx context.Context, in *ListRefsRequest, opts ...grpc.CallOption) (*ListRefsResponse, error)
+	// GetCL returns a Gerrit CL with its patch sets, votes and
+	// messages.
+	GetCL(ctx context.Context, in *GetCLRequest, opts ...grpc.CallOption) (*GerritCL, error)
+	// GetIssue returns a GitHub issue with its comments and events.
+	GetIssue(ctx context.Context, in *GetIssueRequest, opts ...grpc.CallOption) (*GitHubIssue, error)
+	// Query returns the GitHub issues or Gerrit CLs matching a
+	// structured query.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error)
 	// GoFindTryWork finds trybot work for the coordinator to build & test.
 	GoFindTryWork(ctx context.Context, in *GoFindTryWorkRequest, opts ...grpc.CallOption) (*GoFindTryWorkResponse, error)
 }
@@ -290,6 +1417,101 @@ func (c *maintnerServiceClient) GetRef(ctx context.Context, in *GetRefRequest, o
 	return out, nil
 }
 
+func (c *maintnerServiceClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/Search", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintnerServiceClient) WatchChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (MaintnerService_WatchChangesClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_MaintnerService_serviceDesc.Streams[0], c.cc, "/apipb.MaintnerService/WatchChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &maintnerServiceWatchChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MaintnerService_WatchChangesClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type maintnerServiceWatchChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *maintnerServiceWatchChangesClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *maintnerServiceClient) ListGerritProjects(ctx context.Context, in *ListGerritProjectsRequest, opts ...grpc.CallOption) (*ListGerritProjectsResponse, error) {
+	out := new(ListGerritProjectsResponse)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/ListGerritProjects", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintnerServiceClient) ListGitHubRepos(ctx context.Context, in *ListGitHubReposRequest, opts ...grpc.CallOption) (*ListGitHubReposResponse, error) {
+	out := new(ListGitHubReposResponse)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/ListGitHubRepos", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintnerServiceClient) ListRefs(ctx context.Context, in *ListRefsRequest, opts ...grpc.CallOption) (*ListRefsResponse, error) {
+	out := new(ListRefsResponse)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/ListRefs", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintnerServiceClient) GetCL(ctx context.Context, in *GetCLRequest, opts ...grpc.CallOption) (*GerritCL, error) {
+	out := new(GerritCL)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/GetCL", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintnerServiceClient) GetIssue(ctx context.Context, in *GetIssueRequest, opts ...grpc.CallOption) (*GitHubIssue, error) {
+	out := new(GitHubIssue)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/GetIssue", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *maintnerServiceClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*QueryResponse, error) {
+	out := new(QueryResponse)
+	err := grpc.Invoke(ctx, "/apipb.MaintnerService/Query", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *maintnerServiceClient) GoFindTryWork(ctx context.Context, in *GoFindTryWorkRequest, opts ...grpc.CallOption) (*GoFindTryWorkResponse, error) {
 	out := new(GoFindTryWorkResponse)
 	err := grpc.Invoke(ctx, "/apipb.MaintnerService/GoFindTryWork", in, out, c.cc, opts...)
@@ -307,6 +1529,28 @@ type MaintnerServiceServer interface {
 	HasAncestor(context.Context, *HasAncestorRequest) (*HasAncestorResponse, error)
 	// GetRef returns information about a git ref.
 	GetRef(context.Context, *GetRefRequest) (*GetRefResponse, error)
+	// Search does a full-text search of GitHub issues and Gerrit CLs.
+	// It fails if the server wasn't started with search enabled.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// WatchChanges streams changes to issues, CLs and refs as the
+	// server sees them, starting at a given mutation sequence number.
+	// It fails if the server wasn't started with change events
+	// enabled, or no longer has the events from that mutation.
+	WatchChanges(*WatchChangesRequest, MaintnerService_WatchChangesServer) error
+	// ListGerritProjects lists the Gerrit projects the server tracks.
+	ListGerritProjects(context.Context, *ListGerritProjectsRequest) (*ListGerritProjectsResponse, error)
+	// ListGitHubRepos lists the GitHub repos the server tracks.
+	ListGitHubRepos(context.Context, *ListGitHubReposRequest) (*ListGitHubReposResponse, error)
+	// ListRefs lists the refs of a Gerrit project.
+	ListRefs(context.Context, *ListRefsRequest) (*ListRefsResponse, error)
+	// GetCL returns a Gerrit CL with its patch sets, votes and
+	// messages.
+	GetCL(context.Context, *GetCLRequest) (*GerritCL, error)
+	// GetIssue returns a GitHub issue with its comments and events.
+	GetIssue(context.Context, *GetIssueRequest) (*GitHubIssue, error)
+	// Query returns the GitHub issues or Gerrit CLs matching a
+	// structured query.
+	Query(context.Context, *QueryRequest) (*QueryResponse, error)
 	// GoFindTryWork finds trybot work for the coordinator to build & test.
 	GoFindTryWork(context.Context, *GoFindTryWorkRequest) (*GoFindTryWorkResponse, error)
 }
@@ -351,6 +1595,153 @@ func _MaintnerService_GetRef_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MaintnerService_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/Search",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintnerService_WatchChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MaintnerServiceServer).WatchChanges(m, &maintnerServiceWatchChangesServer{stream})
+}
+
+type MaintnerService_WatchChangesServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type maintnerServiceWatchChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *maintnerServiceWatchChangesServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MaintnerService_ListGerritProjects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGerritProjectsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).ListGerritProjects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/ListGerritProjects",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).ListGerritProjects(ctx, req.(*ListGerritProjectsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintnerService_ListGitHubRepos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGitHubReposRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).ListGitHubRepos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/ListGitHubRepos",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).ListGitHubRepos(ctx, req.(*ListGitHubReposRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintnerService_ListRefs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRefsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).ListRefs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/ListRefs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).ListRefs(ctx, req.(*ListRefsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintnerService_GetCL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).GetCL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/GetCL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).GetCL(ctx, req.(*GetCLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintnerService_GetIssue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetIssueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).GetIssue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/GetIssue",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).GetIssue(ctx, req.(*GetIssueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MaintnerService_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MaintnerServiceServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/apipb.MaintnerService/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MaintnerServiceServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _MaintnerService_GoFindTryWork_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GoFindTryWorkRequest)
 	if err := dec(in); err != nil {
@@ -381,45 +1772,159 @@ var _MaintnerService_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetRef",
 			Handler:    _MaintnerService_GetRef_Handler,
 		},
+		{
+			MethodName: "Search",
+			Handler:    _MaintnerService_Search_Handler,
+		},
+		{
+			MethodName: "ListGerritProjects",
+			Handler:    _MaintnerService_ListGerritProjects_Handler,
+		},
+		{
+			MethodName: "ListGitHubRepos",
+			Handler:    _MaintnerService_ListGitHubRepos_Handler,
+		},
+		{
+			MethodName: "ListRefs",
+			Handler:    _MaintnerService_ListRefs_Handler,
+		},
+		{
+			MethodName: "GetCL",
+			Handler:    _MaintnerService_GetCL_Handler,
+		},
+		{
+			MethodName: "GetIssue",
+			Handler:    _MaintnerService_GetIssue_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _MaintnerService_Query_Handler,
+		},
 		{
 			MethodName: "GoFindTryWork",
 			Handler:    _MaintnerService_GoFindTryWork_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchChanges",
+			Handler:       _MaintnerService_WatchChanges_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "api.proto",
 }
 
 func init() { proto.RegisterFile("api.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 445 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x53, 0x51, 0x6f, 0xd3, 0x30,
-	0x10, 0x56, 0x57, 0xda, 0xb5, 0xd7, 0x75, 0x80, 0xe9, 0x50, 0x48, 0x91, 0x18, 0x41, 0xa0, 0x3d,
-	0xf5, 0xa1, 0x08, 0xf1, 0x0c, 0x43, 0x74, 0x03, 0x21, 0xa1, 0x0c, 0x89, 0xc7, 0xc8, 0x4d, 0xaf,
-	0xa9, 0x19, 0xb1, 0x83, 0xed, 0x6e, 0xe2, 0x7f, 0xf1, 0x6b, 0xf8, 0x35, 0x28, 0xf6, 0xb9, 0xa4,
-	0x6b, 0xdf, 0x72, 0xdf, 0x7d, 0xe7, 0xfb, 0xee, 0xbe, 0x0b, 0xf4, 0x79, 0x25, 0x26, 0x95, 0x56,
-	0x56, 0xb1, 0x0e, 0xaf, 0x44, 0x35, 0x4f, 0x2e, 0x80, 0x5d, 0x70, 0xf3, 0x4e, 0xe6, 0x68, 0xac,
-	0xd2, 0x29, 0xfe, 0x5a, 0xa3, 0xb1, 0xec, 0x31, 0x74, 0x73, 0x55, 0x96, 0xc2, 0x46, 0xad, 0xd3,
-	0xd6, 0x59, 0x3f, 0xa5, 0x88, 0xc5, 0xd0, 0xe3, 0x44, 0x8d, 0x0e, 0x5c, 0x66, 0x13, 0x27, 0x19,
-	0x3c, 0xda, 0x7a, 0xc9, 0x54, 0x4a, 0x1a, 0x64, 0xcf, 0xe1, 0x68, 0xc5, 0x4d, 0xb6, 0x29, 0xab,
-	0x1f, 0xec, 0xa5, 0x83, 0xd5, 0x7f, 0x2a, 0x7b, 0x09, 0xc7, 0x6b, 0x79, 0x2d, 0xd5, 0xad, 0xcc,
-	0xa8, 0xeb, 0x81, 0x23, 0x0d, 0x09, 0x3d, 0x77, 0x60, 0x52, 0xc2, 0x70, 0x86, 0x36, 0xc5, 0x65,
-	0x50, 0xf9, 0x00, 0xda, 0x1a, 0x97, 0x24, 0xb1, 0xfe, 0x64, 0x2f, 0x60, 0x58, 0xa0, 0xd6, 0xc2,
-	0x66, 0x06, 0xf5, 0x0d, 0x06, 0x91, 0x47, 0x1e, 0xbc, 0x72, 0x58, 0xdd, 0x8e, 0x48, 0x95, 0x56,
-	0x3f, 0x30, 0xb7, 0x51, 0xdb, 0xb1, 0xa8, 0xf4, 0xab, 0x07, 0x93, 0x57, 0x70, 0x1c, 0xda, 0xd1,
-	0x28, 0x23, 0xe8, 0xdc, 0xf0, 0x9f, 0x6b, 0xa4, 0x8e, 0x3e, 0x48, 0xde, 0xc2, 0x68, 0xa6, 0x3e,
-	0x0a, 0xb9, 0xf8, 0xa6, 0x7f, 0x7f, 0x57, 0xfa, 0x3a, 0xa8, 0x7b, 0x06, 0x83, 0xa5, 0xd2, 0x99,
-	0xb1, 0xbc, 0x10, 0xb2, 0xa0, 0xb9, 0x61, 0xa9, 0xf4, 0x95, 0x47, 0x92, 0xcf, 0x70, 0x72, 0xa7,
-	0x90, 0xfa, 0x4c, 0xe1, 0xf0, 0x96, 0x0b, 0xeb, 0xab, 0xda, 0x67, 0x83, 0x69, 0x34, 0x71, 0x66,
-	0x4d, 0x66, 0x4e, 0x20, 0xd1, 0x2f, 0x2d, 0x96, 0x69, 0x20, 0x26, 0x7f, 0x5a, 0xf0, 0x70, 0x27,
-	0xcd, 0x22, 0x38, 0x0c, 0x33, 0x7a, 0xcd, 0x21, 0xac, 0x1d, 0x9e, 0x6b, 0x2e, 0xf3, 0x15, 0xad,
-	0x88, 0x22, 0x36, 0x86, 0x7e, 0xbe, 0xe2, 0xb2, 0xc0, 0x4c, 0x2c, 0x68, 0x2f, 0x3d, 0x0f, 0x5c,
-	0x2e, 0x1a, 0x67, 0x71, 0x6f, 0xeb, 0x2c, 0xc6, 0xd0, 0x2f, 0x54, 0xf0, 0xae, 0x73, 0xda, 0xae,
-	0x8b, 0x0a, 0x75, 0xde, 0x4c, 0x52, 0xb3, 0x6e, 0x48, 0xbe, 0x77, 0xf1, 0xf4, 0x6f, 0x0b, 0xee,
-	0x7f, 0xe1, 0x42, 0x5a, 0x89, 0xba, 0xb6, 0x47, 0xe4, 0xc8, 0x3e, 0xc0, 0xa0, 0x71, 0x48, 0xec,
-	0x09, 0x0d, 0xbf, 0x7b, 0xa6, 0x71, 0xbc, 0x2f, 0x45, 0x4b, 0x7c, 0x03, 0x5d, 0x6f, 0x1f, 0x1b,
-	0x6d, 0xb6, 0xd7, 0x38, 0x9e, 0xf8, 0xe4, 0x0e, 0x4a, 0x65, 0x9f, 0x60, 0xb8, 0x65, 0x0a, 0x1b,
-	0x07, 0xde, 0x1e, 0x8f, 0xe3, 0xa7, 0xfb, 0x93, 0xfe, 0xad, 0x79, 0xd7, 0xfd, 0x69, 0xaf, 0xff,
-	0x05, 0x00, 0x00, 0xff, 0xff, 0xbb, 0x60, 0x62, 0xc0, 0x76, 0x03, 0x00, 0x00,
+	// 1717 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xdd, 0x72, 0x1b, 0x4b,
+	0x11, 0x2e, 0xfd, 0x4b, 0xad, 0x1f, 0xfb, 0x8c, 0x65, 0x67, 0x23, 0x9f, 0x40, 0xce, 0x52, 0x40,
+	0x6e, 0x90, 0x4f, 0x99, 0x1c, 0x72, 0x91, 0x0b, 0x48, 0x04, 0xb1, 0x1d, 0x9c, 0x54, 0x58, 0xa7,
+	0x48, 0x51, 0x45, 0x4a, 0xb5, 0x92, 0x46, 0xd2, 0x92, 0xdd, 0x1d, 0x65, 0x66, 0xd6, 0x8e, 0x1f,
+	0x81, 0xe2, 0x86, 0x7b, 0xee, 0x78, 0x05, 0x78, 0x01, 0xde, 0x82, 0x47, 0xe1, 0x92, 0x9a, 0x99,
+	0x9e, 0xfd, 0x91, 0x64, 0xc7, 0x95, 0x82, 0x73, 0xb7, 0xfd, 0x33, 0x33, 0x3d, 0xdd, 0xfd, 0x75,
+	0xf7, 0x2c, 0xb4, 0xfc, 0x55, 0x30, 0x5c, 0x71, 0x26, 0x19, 0xa9, 0xf9, 0xab, 0x60, 0x35, 0x19,
+	0x3c, 0x5d, 0x04, 0x72, 0x99, 0x4c, 0x86, 0x53, 0x16, 0x1d, 0x2d, 0x58, 0xe8, 0xc7, 0x8b, 0x23,
+	0x2d, 0x9f, 0x24, 0xf3, 0xa3, 0x95, 0xbc, 0x5e, 0x51, 0x71, 0x24, 0x83, 0x88, 0x0a, 0xe9, 0x47,
+	0xab, 0xec, 0xcb, 0xec, 0xe1, 0x9e, 0x02, 0x39, 0xf5, 0xc5, 0xb3, 0x78, 0x4a, 0x85, 0x64, 0xdc,
+	0xa3, 0x1f, 0x13, 0x2a, 0x24, 0x39, 0x80, 0xfa, 0x94, 0x45, 0x51, 0x20, 0x9d, 0xd2, 0xc3, 0xd2,
+	0xa3, 0x96, 0x87, 0x14, 0x19, 0x40, 0xd3, 0x47, 0x55, 0xa7, 0xac, 0x25, 0x29, 0xed, 0x8e, 0x61,
+	0xaf, 0xb0, 0x93, 0x58, 0xb1, 0x58, 0x50, 0xf2, 0x0d, 0x74, 0x96, 0xbe, 0x18, 0xa7, 0xcb, 0xd4,
+	0x86, 0x4d, 0xaf, 0xbd, 0xcc, 0x54, 0xc9, 0x8f, 0xa1, 0x97, 0xc4, 0x1f, 0x62, 0x76, 0x15, 0x8f,
+	0xf1, 0xd4, 0xb2, 0x56, 0xea, 0x22, 0x77, 0xa4, 0x99, 0x6e, 0x04, 0xdd, 0x13, 0x2a, 0x3d, 0x3a,
+	0xb7, 0x56, 0xee, 0x42, 0x85, 0xd3, 0x39, 0x9a, 0xa8, 0x3e, 0xc9, 0x8f, 0xa0, 0xbb, 0xa0, 0x9c,
+	0x07, 0x72, 0x2c, 0x28, 0xbf, 0xa4, 0xd6, 0xc8, 0x8e, 0x61, 0x5e, 0x68, 0x9e, 0x3a, 0x0e, 0x95,
+	0x56, 0x9c, 0xfd, 0x89, 0x4e, 0xa5, 0x53, 0xd1, 0x5a, 0xb8, 0xf4, 0x8d, 0x61, 0xba, 0x3f, 0x81,
+	0x9e, 0x3d, 0x0e, 0xaf, 0xd2, 0x87, 0xda, 0xa5, 0x1f, 0x26, 0x14, 0x4f, 0x34, 0x84, 0xfb, 0x04,
+	0xfa, 0x27, 0xec, 0x45, 0x10, 0xcf, 0xde, 0xf2, 0xeb, 0x77, 0x8c, 0x7f, 0xb0, 0xd6, 0xfd, 0x10,
+	0xda, 0x73, 0xc6, 0xc7, 0x42, 0xfa, 0x8b, 0x20, 0x5e, 0xe0, 0xbd, 0x61, 0xce, 0xf8, 0x85, 0xe1,
+	0xb8, 0xbf, 0x85, 0xfd, 0xb5, 0x85, 0x78, 0xce, 0x31, 0x34, 0xae, 0xfc, 0x40, 0x9a, 0x55, 0x95,
+	0x47, 0xed, 0x63, 0x67, 0xa8, 0x23, 0x3d, 0x3c, 0xd1, 0x06, 0xa2, 0xfa, 0x99, 0xa4, 0x91, 0x67,
+	0x15, 0xdd, 0x7f, 0x96, 0xe0, 0xab, 0x0d, 0x31, 0x71, 0xa0, 0x61, 0xef, 0x68, 0x6c, 0xb6, 0xa4,
+	0x8a, 0xf0, 0x84, 0xfb, 0xf1, 0x74, 0x89, 0x2e, 0x42, 0x8a, 0x1c, 0x42, 0x6b, 0xba, 0xf4, 0xe3,
+	0x05, 0x1d, 0x07, 0x33, 0xf4, 0x4b, 0xd3, 0x30, 0xce, 0x66, 0xb9, 0xb4, 0xa8, 0x16, 0xd2, 0xe2,
+	0x10, 0x5a, 0x0b, 0x66, 0x63, 0x57, 0x7b, 0x58, 0x51, 0x8b, 0x16, 0x6c, 0x94, 0x17, 0xe2, 0x61,
+	0x75, 0x2b, 0x7c, 0xae, 0x69, 0xf7, 0x05, 0x74, 0x2f, 0xa8, 0xcf, 0xa7, 0x4b, 0xeb, 0xb5, 0x3e,
+	0xd4, 0x3e, 0x26, 0x94, 0x5f, 0x5b, 0x1f, 0x6b, 0x42, 0xf9, 0x32, 0xf2, 0x3f, 0x8d, 0x39, 0x15,
+	0x49, 0x28, 0x85, 0x36, 0xb9, 0xe6, 0x41, 0xe4, 0x7f, 0xf2, 0x0c, 0xc7, 0xfd, 0x25, 0xf4, 0xec,
+	0x3e, 0xe8, 0xc4, 0x9f, 0x41, 0xc3, 0xaa, 0x1b, 0x27, 0xee, 0xa1, 0x13, 0x53, 0xbd, 0x24, 0x94,
+	0x9e, 0xd5, 0x71, 0xff, 0x5e, 0x86, 0x4e, 0x5e, 0xa2, 0x0c, 0x11, 0x53, 0xc6, 0x4d, 0xb0, 0x4b,
+	0x9e, 0x21, 0x14, 0x57, 0x06, 0x32, 0xa4, 0xe8, 0x35, 0x43, 0x28, 0xf3, 0x0c, 0x06, 0xc7, 0x9c,
+	0xae, 0x18, 0xba, 0x0d, 0x0c, 0xcb, 0xa3, 0x2b, 0xa6, 0x40, 0x80, 0x0a, 0x81, 0x10, 0x09, 0xd5,
+	0xee, 0xab, 0x79, 0xb8, 0xe8, 0x4c, 0xb1, 0x94, 0xca, 0x2a, 0x09, 0xc3, 0x31, 0x37, 0x8e, 0x70,
+	0x6a, 0x06, 0x27, 0x8a, 0x67, 0x7d, 0xb3, 0x91, 0xdd, 0xf5, 0x3b, 0x65, 0x77, 0x63, 0x4b, 0x76,
+	0xeb, 0xa8, 0x18, 0xb5, 0x69, 0xe8, 0x34, 0xb5, 0x39, 0x4d, 0xc3, 0x18, 0x85, 0x3a, 0xce, 0x21,
+	0x13, 0x74, 0xe6, 0xb4, 0xb4, 0x15, 0x48, 0xb9, 0x7f, 0x2e, 0xc1, 0xde, 0x3b, 0x5f, 0x4e, 0x97,
+	0x23, 0x9d, 0x11, 0x22, 0x07, 0x44, 0x41, 0x3f, 0x6a, 0x4f, 0x55, 0x3c, 0xf5, 0xa9, 0xfd, 0xa4,
+	0x2a, 0x8f, 0x53, 0xd6, 0x01, 0x37, 0x44, 0xce, 0x0d, 0xca, 0x4f, 0xc2, 0xa9, 0x68, 0x61, 0x3b,
+	0x73, 0x94, 0x20, 0x3f, 0x85, 0x9d, 0xa2, 0xf9, 0xc2, 0xa9, 0x6a, 0xad, 0x5e, 0xc1, 0x7e, 0xe1,
+	0xfe, 0xa7, 0x0c, 0x6d, 0x63, 0xc6, 0x6f, 0x2e, 0x69, 0xbc, 0xcd, 0x06, 0x02, 0x55, 0x75, 0x2c,
+	0x86, 0x4a, 0x7f, 0xff, 0x4f, 0x22, 0xd5, 0x87, 0x5a, 0xe8, 0x4f, 0x68, 0xa8, 0x43, 0xd4, 0xf2,
+	0x0c, 0x41, 0x1e, 0x00, 0x28, 0x00, 0xd0, 0x58, 0x2a, 0xe4, 0xd4, 0xb5, 0x19, 0x2d, 0xe4, 0x9c,
+	0xcd, 0x36, 0x63, 0xd7, 0xb8, 0x53, 0xec, 0x9a, 0x9f, 0x8d, 0x5d, 0x6b, 0x2d, 0x76, 0x0e, 0x34,
+	0x2e, 0x29, 0x17, 0x01, 0x8b, 0x1d, 0xd0, 0x22, 0x4b, 0xda, 0x72, 0xd9, 0xce, 0xca, 0xe5, 0x03,
+	0x00, 0x16, 0xce, 0x2c, 0x70, 0x3b, 0x5a, 0xd0, 0x62, 0xe1, 0x0c, 0x91, 0x9b, 0xc1, 0xbd, 0x9b,
+	0x87, 0xbb, 0x7b, 0x08, 0xf7, 0xcf, 0x03, 0x21, 0x4f, 0x0a, 0x01, 0xc1, 0x5c, 0x70, 0x5f, 0xc3,
+	0x60, 0x9b, 0x10, 0x51, 0xf9, 0x2d, 0x34, 0xd3, 0xb8, 0x1a, 0x58, 0xf6, 0x0b, 0xb5, 0x0d, 0x17,
+	0x78, 0xa9, 0x96, 0xfb, 0x0c, 0xba, 0x05, 0x91, 0xb2, 0x0a, 0x5d, 0x88, 0xbd, 0xc9, 0x50, 0xf9,
+	0x5a, 0x57, 0x2e, 0xd4, 0x3a, 0xd7, 0x81, 0x03, 0x6d, 0x52, 0x20, 0x4f, 0x31, 0xcd, 0xac, 0xb1,
+	0x47, 0x70, 0x6f, 0x43, 0x92, 0x15, 0x7b, 0x93, 0xa4, 0x25, 0x93, 0xc1, 0x9a, 0x70, 0xdf, 0xc3,
+	0x8e, 0x5a, 0xe0, 0xd1, 0xb9, 0xb8, 0x11, 0x95, 0xa5, 0x3b, 0x45, 0xb6, 0xbc, 0xad, 0xe7, 0x7c,
+	0x07, 0xbb, 0xd9, 0xf6, 0x69, 0x03, 0xad, 0x72, 0x3a, 0xb7, 0xee, 0xea, 0x5a, 0x77, 0x05, 0x4a,
+	0xcb, 0xd3, 0x22, 0xf7, 0x18, 0xea, 0x86, 0xde, 0xd2, 0x12, 0xb3, 0x20, 0x96, 0x0b, 0x41, 0xe4,
+	0xd0, 0x39, 0xa1, 0x72, 0x74, 0xfe, 0x7f, 0xb8, 0x86, 0x3a, 0x33, 0x4e, 0xa2, 0x09, 0xe5, 0x1a,
+	0x60, 0x35, 0x0f, 0x29, 0xf7, 0x5f, 0x55, 0x68, 0x9a, 0x60, 0x8e, 0xce, 0xbf, 0x8f, 0x03, 0x8b,
+	0xdd, 0xac, 0xba, 0xd6, 0xcd, 0x1c, 0x68, 0x88, 0x64, 0xa2, 0x37, 0x35, 0x48, 0xb6, 0x64, 0xae,
+	0x39, 0xd6, 0x0b, 0xcd, 0x51, 0xa5, 0x9e, 0xf4, 0x65, 0x22, 0x10, 0xbd, 0x48, 0x91, 0x47, 0xb0,
+	0x7b, 0xc5, 0xf8, 0x87, 0x71, 0x10, 0x2b, 0x33, 0x17, 0x9c, 0x0a, 0xa1, 0x91, 0xdb, 0xf4, 0x7a,
+	0x8a, 0x7f, 0x16, 0xbf, 0x41, 0x2e, 0xb9, 0x0f, 0x4d, 0x76, 0x15, 0x53, 0xae, 0xec, 0x69, 0xe9,
+	0x1a, 0xd1, 0xd0, 0xf4, 0xd9, 0x4c, 0x83, 0x51, 0x8b, 0x62, 0x3f, 0xa2, 0x0e, 0x20, 0x18, 0x15,
+	0xe7, 0xb5, 0x1f, 0x51, 0xf2, 0x18, 0x1a, 0x53, 0x4e, 0x7d, 0x49, 0x67, 0x1a, 0xc1, 0xed, 0xe3,
+	0xc1, 0x70, 0xc1, 0xd8, 0x22, 0xa4, 0x43, 0x3b, 0xec, 0x0d, 0xdf, 0xda, 0xd9, 0xce, 0xb3, 0xaa,
+	0x6a, 0x60, 0x5b, 0xfa, 0x62, 0x29, 0xfd, 0x85, 0x70, 0x3a, 0xa6, 0xf7, 0x5a, 0x5a, 0x07, 0x20,
+	0x57, 0xea, 0x84, 0xd3, 0xd5, 0x0a, 0x9d, 0x5c, 0xad, 0x13, 0xe4, 0x31, 0xc0, 0x4a, 0x55, 0xfc,
+	0xb1, 0xa0, 0x52, 0x38, 0x3d, 0x9d, 0x83, 0xfb, 0x45, 0xc8, 0x2a, 0xf1, 0x05, 0x95, 0x5e, 0x6b,
+	0x85, 0x5f, 0xaa, 0x8a, 0xd7, 0x2e, 0x99, 0xa4, 0xc2, 0xd9, 0xd1, 0x0b, 0xbe, 0x2a, 0x2c, 0xf8,
+	0x3d, 0x93, 0xd4, 0x33, 0x72, 0x55, 0x0f, 0x22, 0x2a, 0x84, 0xbf, 0xa0, 0xc2, 0xd9, 0xdd, 0x52,
+	0x0f, 0x5e, 0x19, 0xa1, 0x97, 0x6a, 0xb9, 0xcf, 0xa1, 0x67, 0x44, 0xf6, 0xdc, 0x7c, 0xc5, 0x2b,
+	0x15, 0x2b, 0xde, 0x4d, 0xb9, 0xff, 0x1a, 0x20, 0x33, 0x25, 0xab, 0xe7, 0xa5, 0x7c, 0x3d, 0xef,
+	0x9b, 0x2b, 0xd8, 0x11, 0xd2, 0x10, 0xd9, 0x08, 0x68, 0xf2, 0xcc, 0x10, 0xee, 0x5f, 0x4a, 0xd0,
+	0x2d, 0xd8, 0x7b, 0xbb, 0x4d, 0x7e, 0x22, 0x97, 0xe9, 0x00, 0x8d, 0x14, 0x19, 0x42, 0x75, 0xe6,
+	0x4b, 0xb3, 0xf1, 0xed, 0xc1, 0xd5, 0x7a, 0xea, 0x04, 0xf4, 0x09, 0x26, 0xb6, 0x25, 0xdd, 0x97,
+	0xb0, 0x73, 0x42, 0xa5, 0x8e, 0x5f, 0x6e, 0x16, 0xcd, 0xb7, 0xbd, 0xd2, 0x46, 0xdb, 0xcb, 0x00,
+	0x54, 0x2e, 0x20, 0xf6, 0x6f, 0x55, 0x68, 0x9b, 0xea, 0x68, 0x7a, 0xdf, 0x97, 0x6e, 0x94, 0x0d,
+	0x4e, 0x95, 0xfc, 0xe0, 0x44, 0xa0, 0x3a, 0x61, 0xb3, 0x6b, 0xbc, 0x81, 0xfe, 0x56, 0xbc, 0x44,
+	0x50, 0x8e, 0x98, 0xd4, 0xdf, 0x1b, 0xc3, 0x51, 0x7d, 0x73, 0x38, 0xca, 0x66, 0x96, 0x46, 0x7e,
+	0x66, 0xc9, 0xe3, 0xa6, 0x79, 0x77, 0xdc, 0x3c, 0x86, 0x46, 0xb2, 0x9a, 0xe9, 0x55, 0xad, 0xcf,
+	0xaf, 0x42, 0x55, 0xf2, 0x04, 0x5a, 0xe6, 0xd4, 0xb1, 0x2f, 0x1d, 0xf8, 0xec, 0xba, 0xa6, 0x51,
+	0x7e, 0x26, 0xc9, 0xd7, 0xd0, 0x8a, 0x82, 0x90, 0x0a, 0xc9, 0x62, 0x8a, 0x0d, 0x3a, 0x63, 0xa8,
+	0xab, 0xe9, 0x9c, 0xb4, 0x10, 0x46, 0x4a, 0xad, 0xf2, 0x85, 0x08, 0x16, 0x31, 0x4d, 0xc1, 0x9b,
+	0x31, 0x14, 0xb4, 0x70, 0xfc, 0xb0, 0xb8, 0xed, 0x67, 0xbd, 0xe3, 0x34, 0x99, 0x8c, 0x8c, 0xd0,
+	0x4b, 0xb5, 0xc8, 0x11, 0xd4, 0xe9, 0xa5, 0xd6, 0x37, 0xb0, 0xbd, 0x57, 0xd0, 0xd7, 0x09, 0xa0,
+	0x67, 0x2d, 0x0f, 0xd5, 0xdc, 0x7f, 0xa8, 0xbc, 0xcf, 0x6f, 0x46, 0x7a, 0x50, 0x0e, 0x66, 0x38,
+	0x84, 0x95, 0x83, 0x59, 0x1a, 0xcc, 0x72, 0x2e, 0x98, 0xb9, 0x88, 0x54, 0xbe, 0x28, 0x22, 0xd5,
+	0xbb, 0x47, 0xc4, 0x26, 0x58, 0x2d, 0x4b, 0x30, 0xf7, 0xaf, 0x65, 0xd8, 0x5d, 0xbf, 0xd2, 0x36,
+	0xc3, 0x37, 0x86, 0xc7, 0x3e, 0xd4, 0xfc, 0xa9, 0x7a, 0xc3, 0x62, 0x0e, 0x6b, 0x22, 0x7f, 0x9d,
+	0xea, 0xdd, 0xaf, 0xb3, 0x7d, 0x88, 0x2c, 0xe4, 0x41, 0x7d, 0x3d, 0x0f, 0xd4, 0xeb, 0x1b, 0xc3,
+	0x8b, 0x0d, 0x28, 0xa5, 0x15, 0x30, 0x39, 0x55, 0x9d, 0x63, 0x3c, 0xe7, 0x2c, 0xc2, 0xb9, 0x11,
+	0x0c, 0xeb, 0x05, 0x67, 0x91, 0x6a, 0x85, 0xa8, 0x20, 0x99, 0xce, 0xe9, 0x96, 0xd7, 0x34, 0x8c,
+	0xb7, 0xcc, 0x7d, 0x0f, 0x9d, 0xdf, 0xa9, 0x87, 0xd6, 0xed, 0xaf, 0xb0, 0x03, 0xa8, 0x9b, 0xb6,
+	0x8b, 0xef, 0x73, 0xa4, 0xd6, 0x5f, 0x67, 0x95, 0x8d, 0xd7, 0xd9, 0x1f, 0xa1, 0x8b, 0xdb, 0x7f,
+	0xd1, 0xe3, 0x4c, 0xb9, 0x45, 0xf2, 0x24, 0x9e, 0x6a, 0x27, 0x9b, 0xb3, 0x33, 0xc6, 0xf1, 0xbf,
+	0x6b, 0xb0, 0xf3, 0xca, 0x0f, 0x62, 0x19, 0x53, 0xae, 0xc6, 0x86, 0x60, 0x4a, 0xc9, 0xaf, 0xa1,
+	0x9d, 0xfb, 0x19, 0x41, 0xee, 0xe3, 0xf6, 0x9b, 0xbf, 0x3a, 0x06, 0x83, 0x6d, 0x22, 0x34, 0xf3,
+	0x3b, 0xa8, 0x9b, 0x5f, 0x00, 0x24, 0xeb, 0x4a, 0xb9, 0x1f, 0x10, 0x83, 0xfd, 0x35, 0x6e, 0xb6,
+	0xcc, 0xdc, 0x23, 0x5d, 0x56, 0x78, 0xe3, 0x0e, 0xf6, 0xd7, 0xb8, 0xb8, 0xec, 0x57, 0xd0, 0xc9,
+	0x3f, 0xae, 0x88, 0xb5, 0x6c, 0xcb, 0x8b, 0x6b, 0x40, 0x50, 0x96, 0x7b, 0x01, 0x7d, 0x5b, 0x22,
+	0x7f, 0x00, 0xb2, 0x39, 0x7b, 0x93, 0x87, 0xa8, 0x7b, 0xe3, 0xcc, 0x3e, 0xf8, 0xe6, 0x16, 0x0d,
+	0x34, 0xee, 0x8d, 0x19, 0x7c, 0x73, 0x93, 0x32, 0x79, 0x90, 0x5f, 0xb5, 0x31, 0x5b, 0x0f, 0x7e,
+	0x70, 0x93, 0x18, 0x77, 0x7c, 0x0a, 0x4d, 0x3b, 0xeb, 0x92, 0x83, 0x9c, 0x6e, 0x6e, 0xb6, 0x1e,
+	0xdc, 0xdb, 0xe0, 0xa7, 0x09, 0x54, 0xd3, 0xd3, 0x2b, 0xd9, 0xcb, 0x42, 0x90, 0xce, 0xb2, 0x83,
+	0x9d, 0xc2, 0x0c, 0x31, 0x3a, 0x27, 0xbf, 0x80, 0xa6, 0x6d, 0x89, 0xe9, 0x59, 0x6b, 0x3d, 0x32,
+	0x75, 0x69, 0xbe, 0xdd, 0x1d, 0x43, 0x4d, 0x27, 0x6e, 0x7a, 0x4c, 0x1e, 0x25, 0x83, 0x7e, 0x91,
+	0x89, 0xa6, 0xbd, 0x84, 0x6e, 0xe1, 0xb7, 0x0e, 0x39, 0xb4, 0x1b, 0x6f, 0xf9, 0x4b, 0x34, 0xf8,
+	0x7a, 0xbb, 0xd0, 0xec, 0x35, 0xa9, 0xeb, 0x12, 0xf2, 0xf3, 0xff, 0x0e, 0x00, 0xc2, 0x5c, 0xf8,
+	0xa4, 0xf5, 0x13, 0x00, 0x00,
 }
@@ -2,7 +2,10 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Package gcslog is an implementation of maintner.MutationSource and Logger for Google Cloud Storage.
+// Package gcslog is an implementation of maintner.MutationSource and
+// Logger that stores the log's segments in a Store, such as a Google
+// Cloud Storage bucket, a local directory or an S3-compatible bucket,
+// and serves them to followers over HTTP.
 package gcslog
 
 import (
@@ -13,6 +16,7 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"regexp"
@@ -27,11 +31,10 @@ import (
 	"golang.org/x/build/maintner"
 	"golang.org/x/build/maintner/maintpb"
 	"golang.org/x/build/maintner/reclog"
-	"google.golang.org/api/iterator"
 )
 
-// targetObjectSize is the goal maximum size for each log segment on
-// GCS. In the unlikely case that a single record is larger than this
+// targetObjectSize is the goal maximum size for each log segment in
+// the store. In the unlikely case that a single record is larger than this
 // then a segment would be bigger than this (records are never split
 // between log segments).  But otherwise this is the max size.
 const targetObjectSize = 16 << 20
@@ -42,11 +45,10 @@ const flushInterval = 10 * time.Minute
 var _ maintner.MutationLogger = &GCSLog{}
 var _ maintner.MutationSource = &GCSLog{}
 
-// GCSLog logs mutations to GCS.
+// GCSLog logs mutations to segments in a Store. Despite its name, the
+// Store need not be Google Cloud Storage.
 type GCSLog struct {
-	sc         *storage.Client
-	bucketName string
-	bucket     *storage.BucketHandle
+	store Store
 
 	mu         sync.Mutex // guards the following
 	cond       *sync.Cond
@@ -73,7 +75,7 @@ func (s gcsLogSegment) String() string {
 }
 
 // newGCSLogBase returns a new gcsLog instance without any association
-// with Google Cloud Storage.
+// with a Store.
 func newGCSLogBase() *GCSLog {
 	gl := &GCSLog{
 		seg: map[int]gcsLogSegment{},
@@ -88,10 +90,14 @@ func NewGCSLog(ctx context.Context, bucketName string) (*GCSLog, error) {
 	if err != nil {
 		return nil, fmt.Errorf("storage.NewClient: %v", err)
 	}
+	return NewLog(ctx, NewGCSStore(sc, bucketName))
+}
+
+// NewLog creates a GCSLog that logs mutations to segments in store,
+// resuming the log already there, if any.
+func NewLog(ctx context.Context, store Store) (*GCSLog, error) {
 	gl := newGCSLogBase()
-	gl.sc = sc
-	gl.bucketName = bucketName
-	gl.bucket = sc.Bucket(bucketName)
+	gl.store = store
 	if err := gl.initLoad(ctx); err != nil {
 		return nil, err
 	}
@@ -101,19 +107,15 @@ func NewGCSLog(ctx context.Context, bucketName string) (*GCSLog, error) {
 var objnameRx = regexp.MustCompile(`^(\d{4})\.([0-9a-f]{56})\.mutlog$`)
 
 func (gl *GCSLog) initLoad(ctx context.Context) error {
-	it := gl.bucket.Objects(ctx, nil)
+	objs, err := gl.store.List(ctx)
+	if err != nil {
+		return err
+	}
 	maxNum := 0
-	for {
-		objAttrs, err := it.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("iterating over %s bucket: %v", gl.bucketName, err)
-		}
+	for _, objAttrs := range objs {
 		m := objnameRx.FindStringSubmatch(objAttrs.Name)
 		if m == nil {
-			log.Printf("Ignoring unrecognized GCS object %q", objAttrs.Name)
+			log.Printf("Ignoring unrecognized object %q", objAttrs.Name)
 			continue
 		}
 		n, _ := strconv.ParseInt(m[1], 10, 32)
@@ -155,7 +157,7 @@ func (gl *GCSLog) initLoad(ctx context.Context) error {
 		return nil
 	}
 
-	r, err := gl.bucket.Object(gl.seg[maxNum].ObjectName()).NewReader(ctx)
+	r, err := gl.store.NewReader(ctx, gl.seg[maxNum].ObjectName())
 	if err != nil {
 		return err
 	}
@@ -173,7 +175,12 @@ func (gl *GCSLog) serveLogFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	num, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/logs/"))
+	name := strings.TrimPrefix(r.URL.Path, "/logs/")
+	if objnameRx.MatchString(name) {
+		gl.serveObject(w, r, name)
+		return
+	}
+	num, err := strconv.Atoi(name)
 	if err != nil {
 		http.Error(w, "bad path", http.StatusBadRequest)
 		return
@@ -186,9 +193,9 @@ func (gl *GCSLog) serveLogFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if num != gl.curNum {
-		obj := gl.seg[num].ObjectName()
+		seg := gl.seg[num]
 		gl.mu.Unlock()
-		http.Redirect(w, r, "https://storage.googleapis.com/"+gl.bucketName+"/"+obj, http.StatusFound)
+		http.Redirect(w, r, gl.segmentURL(seg), http.StatusFound)
 		return
 	}
 	content := gl.logBuf.String()
@@ -198,6 +205,54 @@ func (gl *GCSLog) serveLogFile(w http.ResponseWriter, r *http.Request) {
 	http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
 }
 
+// serveObject serves the frozen segment object with the given name
+// from the store, for stores without their own URLs.
+func (gl *GCSLog) serveObject(w http.ResponseWriter, r *http.Request, name string) {
+	gl.mu.Lock()
+	known := false
+	for n, seg := range gl.seg {
+		if n != gl.curNum && seg.ObjectName() == name {
+			known = true
+			break
+		}
+	}
+	gl.mu.Unlock()
+	if !known {
+		http.NotFound(w, r)
+		return
+	}
+	rc, err := gl.store.NewReader(r.Context(), name)
+	if err != nil {
+		log.Printf("opening %s: %v", name, err)
+		http.Error(w, "error opening segment", http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+	rs, ok := rc.(io.ReadSeeker)
+	if !ok {
+		data, err := ioutil.ReadAll(rc)
+		if err != nil {
+			log.Printf("reading %s: %v", name, err)
+			http.Error(w, "error reading segment", http.StatusInternalServerError)
+			return
+		}
+		rs = bytes.NewReader(data)
+	}
+	// Frozen segments never change; their names contain their hash.
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, "", time.Time{}, rs)
+}
+
+// segmentURL returns the URL that followers fetch the frozen segment
+// seg from.
+func (gl *GCSLog) segmentURL(seg gcsLogSegment) string {
+	if u := gl.store.URL(seg.ObjectName()); u != "" {
+		return u
+	}
+	return "/logs/" + seg.ObjectName()
+}
+
 func (gl *GCSLog) serveJSONLogsIndex(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" && r.Method != "HEAD" {
 		http.Error(w, "bad method", http.StatusBadRequest)
@@ -308,7 +363,7 @@ func (gl *GCSLog) getJSONLogs(startSeg int) (segs []maintner.LogSegmentJSON) {
 			Number: i,
 			Size:   seg.size,
 			SHA224: seg.sha224,
-			URL:    fmt.Sprintf("https://storage.googleapis.com/%s/%s", gl.bucketName, seg.ObjectName()),
+			URL:    gl.segmentURL(seg),
 		})
 	}
 	if gl.logBuf.Len() > 0 {
@@ -342,7 +397,7 @@ func (w gcsLogWriter) Write(p []byte) (n int, err error) {
 	return len(p), nil
 }
 
-// Log writes m to GCS after the buffer is full or after a periodic flush.
+// Log writes m to the store after the buffer is full or after a periodic flush.
 func (gl *GCSLog) Log(m *maintpb.Mutation) error {
 	data, err := proto.Marshal(m)
 	if err != nil {
@@ -416,17 +471,11 @@ func (gl *GCSLog) flushLocked(ctx context.Context) error {
 	objName := seg.ObjectName()
 	log.Printf("flushing %s (%d bytes)", objName, len(buf))
 	err := try(4, time.Second, func() error {
-		w := gl.bucket.Object(objName).NewWriter(ctx)
-		w.ContentType = "application/octet-stream"
-		w.ACL = append(w.ACL, storage.ACLRule{Entity: storage.AllUsers, Role: storage.RoleReader})
-		if _, err := w.Write(buf); err != nil {
-			return err
-		}
-		if err := w.Close(); err != nil {
+		created, err := gl.store.Put(ctx, objName, buf)
+		if err != nil {
 			return err
 		}
-		attrs := w.Attrs()
-		seg.created = attrs.Created
+		seg.created = created
 		return nil
 	})
 	if err != nil {
@@ -450,7 +499,7 @@ func (gl *GCSLog) flushLocked(ctx context.Context) error {
 }
 
 func (gl *GCSLog) deleteOldSegment(ctx context.Context, objName string) {
-	err := gl.bucket.Object(objName).Delete(ctx)
+	err := gl.store.Delete(ctx, objName)
 	if err != nil {
 		// Can ignore, though. Probably emphemeral, and not critical.
 		// It'll be deleted by new versions or next start-up anyway.
@@ -474,7 +523,7 @@ func (gl *GCSLog) foreachSegmentReader(ctx context.Context, fn func(r io.Reader)
 	objs := gl.objectNames()
 	for i, obj := range objs {
 		log.Printf("Reading %d/%d: %s ...", i+1, len(objs), obj)
-		rd, err := gl.bucket.Object(obj).NewReader(ctx)
+		rd, err := gl.store.NewReader(ctx, obj)
 		if err != nil {
 			return fmt.Errorf("failed to open %v: %v", obj, err)
 		}
@@ -533,7 +582,7 @@ func try(tries int, firstDelay time.Duration, fn func() error) error {
 	return err
 }
 
-// CopyFrom is only used for the one-time migrate from disk-to-GCS code path.
+// CopyFrom is only used for the one-time migrate from disk-to-store code path.
 func (gl *GCSLog) CopyFrom(src maintner.MutationSource) error {
 	gl.curNum = 0
 	ctx := context.Background()
@@ -0,0 +1,271 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcslog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Config configures a Store in a bucket of Amazon S3 or another
+// service with an S3-compatible API, such as MinIO or Ceph.
+type S3Config struct {
+	// Endpoint is the base URL of the service's API, such as
+	// "https://s3.us-west-2.amazonaws.com" or "http://localhost:9000".
+	// Buckets are addressed by path.
+	Endpoint string
+
+	// Region is the bucket's region, such as "us-west-2". If empty,
+	// "us-east-1" is used.
+	Region string
+
+	Bucket string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// PublicURL optionally is the base URL from which followers
+	// can download the bucket's objects, such as
+	// "https://mybucket.s3.amazonaws.com". If set, objects are
+	// written with the public-read canned ACL. If empty, the log's
+	// HTTP handlers serve them.
+	PublicURL string
+
+	// Client is the HTTP client to use. If nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// s3Store is a Store in an S3 bucket.
+type s3Store struct {
+	conf S3Config
+}
+
+// NewS3Store returns a Store in the S3 bucket described by conf.
+func NewS3Store(conf S3Config) Store {
+	conf.Endpoint = strings.TrimSuffix(conf.Endpoint, "/")
+	conf.PublicURL = strings.TrimSuffix(conf.PublicURL, "/")
+	if conf.Region == "" {
+		conf.Region = "us-east-1"
+	}
+	if conf.Client == nil {
+		conf.Client = http.DefaultClient
+	}
+	return &s3Store{conf: conf}
+}
+
+// do sends a signed request for the named object, or for the bucket
+// if name is empty, and returns the response if its status is 2xx.
+func (s *s3Store) do(ctx context.Context, method, name string, query url.Values, hdr http.Header, body []byte) (*http.Response, error) {
+	u := s.conf.Endpoint + "/" + s3Escape(s.conf.Bucket, false)
+	if name != "" {
+		u += "/" + s3Escape(name, false)
+	}
+	if len(query) > 0 {
+		u += "?" + s3Query(query)
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, vv := range hdr {
+		req.Header[k] = vv
+	}
+	signS3(req, body, s.conf.AccessKeyID, s.conf.SecretAccessKey, s.conf.Region, time.Now())
+	res, err := s.conf.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1<<10))
+		res.Body.Close()
+		return nil, fmt.Errorf("S3 %s %s: %s: %s", method, u, res.Status, bytes.TrimSpace(msg))
+	}
+	return res, nil
+}
+
+// s3ListResult is the response to an S3 ListObjectsV2 request.
+type s3ListResult struct {
+	Contents []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+func (s *s3Store) List(ctx context.Context) ([]ObjectAttrs, error) {
+	var objs []ObjectAttrs
+	query := url.Values{"list-type": {"2"}}
+	for {
+		res, err := s.do(ctx, "GET", "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var lr s3ListResult
+		err = xml.NewDecoder(res.Body).Decode(&lr)
+		res.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decoding S3 object list: %v", err)
+		}
+		for _, c := range lr.Contents {
+			objs = append(objs, ObjectAttrs{Name: c.Key, Size: c.Size, Created: c.LastModified})
+		}
+		if !lr.IsTruncated || lr.NextContinuationToken == "" {
+			return objs, nil
+		}
+		query.Set("continuation-token", lr.NextContinuationToken)
+	}
+}
+
+func (s *s3Store) NewReader(ctx context.Context, name string) (io.ReadCloser, error) {
+	res, err := s.do(ctx, "GET", name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *s3Store) Put(ctx context.Context, name string, data []byte) (time.Time, error) {
+	hdr := http.Header{"Content-Type": {"application/octet-stream"}}
+	if s.conf.PublicURL != "" {
+		hdr.Set("X-Amz-Acl", "public-read")
+	}
+	res, err := s.do(ctx, "PUT", name, nil, hdr, data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	res.Body.Close()
+	// S3 doesn't report when it created the object, so use the
+	// server's clock as the next best thing.
+	if t, err := http.ParseTime(res.Header.Get("Date")); err == nil {
+		return t, nil
+	}
+	return time.Now(), nil
+}
+
+func (s *s3Store) Delete(ctx context.Context, name string) error {
+	res, err := s.do(ctx, "DELETE", name, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
+
+func (s *s3Store) URL(name string) string {
+	if s.conf.PublicURL == "" {
+		return ""
+	}
+	return s.conf.PublicURL + "/" + s3Escape(name, false)
+}
+
+// signS3 signs req, whose body is body, with AWS Signature Version 4
+// for the S3 service, as of time t. It signs the Host header and all
+// the headers already in req.
+func signS3(req *http.Request, body []byte, accessKeyID, secretAccessKey, region string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	hdrs := map[string]string{"host": req.URL.Host}
+	for k, vv := range req.Header {
+		hdrs[strings.ToLower(k)] = strings.TrimSpace(strings.Join(vv, ","))
+	}
+	var names []string
+	for k := range hdrs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonHdrs bytes.Buffer
+	for _, k := range names {
+		fmt.Fprintf(&canonHdrs, "%s:%s\n", k, hdrs[k])
+	}
+	signedHdrs := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	canonReq := strings.Join([]string{
+		req.Method,
+		path,
+		s3Query(req.URL.Query()),
+		canonHdrs.String(),
+		signedHdrs,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonReqHash := sha256.Sum256([]byte(canonReq))
+	scope := date + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonReqHash[:])
+
+	key := []byte("AWS4" + secretAccessKey)
+	for _, s := range []string{date, region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	sig := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHdrs+", Signature="+sig)
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// s3Query returns the canonical encoding of query for S3, which is
+// also a valid query string.
+func s3Query(query url.Values) string {
+	var keys []string
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		vv := append([]string(nil), query[k]...)
+		sort.Strings(vv)
+		for _, v := range vv {
+			params = append(params, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// s3Escape escapes s as S3 requires for object keys and query
+// parameters: everything but unreserved characters, and slashes
+// unless escapeSlash is set, is percent-encoded.
+func s3Escape(s string, escapeSlash bool) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' && !escapeSlash {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,162 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcslog
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+// A Store holds the objects of a log's segments. Its methods may be
+// called concurrently.
+type Store interface {
+	// List returns the attributes of the objects in the store.
+	List(ctx context.Context) ([]ObjectAttrs, error)
+
+	// NewReader returns a reader of the named object's contents.
+	NewReader(ctx context.Context, name string) (io.ReadCloser, error)
+
+	// Put creates or replaces the named object and returns its
+	// creation time.
+	Put(ctx context.Context, name string, data []byte) (created time.Time, err error)
+
+	// Delete deletes the named object.
+	Delete(ctx context.Context, name string) error
+
+	// URL returns the URL that followers can download the named
+	// object from, or the empty string if the log's HTTP handlers
+	// should serve it.
+	URL(name string) string
+}
+
+// ObjectAttrs are the attributes of an object in a Store.
+type ObjectAttrs struct {
+	Name    string
+	Size    int64
+	Created time.Time
+}
+
+// gcsStore is a Store in a Google Cloud Storage bucket, whose objects
+// are public.
+type gcsStore struct {
+	bucketName string
+	bucket     *storage.BucketHandle
+}
+
+// NewGCSStore returns a Store in the named Google Cloud Storage
+// bucket. The objects it writes are readable by anyone.
+func NewGCSStore(sc *storage.Client, bucketName string) Store {
+	return &gcsStore{bucketName: bucketName, bucket: sc.Bucket(bucketName)}
+}
+
+func (s *gcsStore) List(ctx context.Context) ([]ObjectAttrs, error) {
+	var objs []ObjectAttrs
+	it := s.bucket.Objects(ctx, nil)
+	for {
+		objAttrs, err := it.Next()
+		if err == iterator.Done {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("iterating over %s bucket: %v", s.bucketName, err)
+		}
+		objs = append(objs, ObjectAttrs{Name: objAttrs.Name, Size: objAttrs.Size, Created: objAttrs.Created})
+	}
+}
+
+func (s *gcsStore) NewReader(ctx context.Context, name string) (io.ReadCloser, error) {
+	return s.bucket.Object(name).NewReader(ctx)
+}
+
+func (s *gcsStore) Put(ctx context.Context, name string, data []byte) (time.Time, error) {
+	w := s.bucket.Object(name).NewWriter(ctx)
+	w.ContentType = "application/octet-stream"
+	w.ACL = append(w.ACL, storage.ACLRule{Entity: storage.AllUsers, Role: storage.RoleReader})
+	if _, err := w.Write(data); err != nil {
+		return time.Time{}, err
+	}
+	if err := w.Close(); err != nil {
+		return time.Time{}, err
+	}
+	return w.Attrs().Created, nil
+}
+
+func (s *gcsStore) Delete(ctx context.Context, name string) error {
+	return s.bucket.Object(name).Delete(ctx)
+}
+
+func (s *gcsStore) URL(name string) string {
+	return "https://storage.googleapis.com/" + s.bucketName + "/" + name
+}
+
+// dirStore is a Store in a local directory.
+type dirStore struct {
+	dir string
+}
+
+// NewDirStore returns a Store in the directory dir, creating it if
+// necessary. The log's HTTP handlers serve its objects.
+func NewDirStore(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &dirStore{dir: dir}, nil
+}
+
+func (s *dirStore) List(ctx context.Context) ([]ObjectAttrs, error) {
+	fis, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var objs []ObjectAttrs
+	for _, fi := range fis {
+		if fi.Mode().IsRegular() {
+			objs = append(objs, ObjectAttrs{Name: fi.Name(), Size: fi.Size(), Created: fi.ModTime()})
+		}
+	}
+	return objs, nil
+}
+
+func (s *dirStore) NewReader(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.dir, name))
+}
+
+func (s *dirStore) Put(ctx context.Context, name string, data []byte) (time.Time, error) {
+	tf, err := ioutil.TempFile(s.dir, ".tmp-")
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer os.Remove(tf.Name())
+	if _, err := tf.Write(data); err != nil {
+		tf.Close()
+		return time.Time{}, err
+	}
+	if err := tf.Close(); err != nil {
+		return time.Time{}, err
+	}
+	file := filepath.Join(s.dir, name)
+	if err := os.Rename(tf.Name(), file); err != nil {
+		return time.Time{}, err
+	}
+	fi, err := os.Stat(file)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+func (s *dirStore) Delete(ctx context.Context, name string) error {
+	return os.Remove(filepath.Join(s.dir, name))
+}
+
+func (s *dirStore) URL(name string) string { return "" }
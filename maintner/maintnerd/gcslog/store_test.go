@@ -0,0 +1,122 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcslog
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/build/maintner"
+	"golang.org/x/build/maintner/maintpb"
+)
+
+func issueMutation(num int32, body string) *maintpb.Mutation {
+	return &maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+		Owner: "golang", Repo: "go", Number: num, Body: body,
+	}}
+}
+
+// issueNumbers returns the issue numbers of the mutations from src.
+func issueNumbers(t *testing.T, src maintner.MutationSource) []int32 {
+	t.Helper()
+	var nums []int32
+	for e := range src.GetMutations(context.Background()) {
+		if e.Err != nil {
+			t.Fatal(e.Err)
+		}
+		if e.End {
+			return nums
+		}
+		nums = append(nums, e.Mutation.GithubIssue.Number)
+	}
+	panic("unexpected channel close")
+}
+
+func TestDirStoreLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gcslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	store, err := NewDirStore(filepath.Join(dir, "segs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gl, err := NewLog(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second record doesn't fit in the first segment, so
+	// segment 0 is frozen.
+	big := strings.Repeat("x", targetObjectSize/2+1)
+	for _, m := range []*maintpb.Mutation{
+		issueMutation(1, big),
+		issueMutation(2, big),
+		issueMutation(3, "small"),
+	} {
+		if err := gl.Log(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := gl.flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	segs := gl.getJSONLogs(0)
+	if len(segs) != 2 {
+		t.Fatalf("got %d segments; want 2", len(segs))
+	}
+	if want := "/logs/0000." + segs[0].SHA224 + ".mutlog"; segs[0].URL != want {
+		t.Errorf("segment 0 URL = %q; want %q", segs[0].URL, want)
+	}
+	if want := "/logs/1"; segs[1].URL != want {
+		t.Errorf("segment 1 URL = %q; want %q", segs[1].URL, want)
+	}
+
+	mux := http.NewServeMux()
+	gl.RegisterHandlers(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/logs/0001." + segs[0].SHA224 + ".mutlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("unknown object: status %v; want 404", res.Status)
+	}
+
+	want := []int32{1, 2, 3}
+	cacheDir := filepath.Join(dir, "cache")
+	if err := os.Mkdir(cacheDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	ns := maintner.NewNetworkMutationSource(srv.URL+"/logs", cacheDir)
+	if got := issueNumbers(t, ns); !reflect.DeepEqual(got, want) {
+		t.Errorf("from network, issues = %v; want %v", got, want)
+	}
+
+	// A new log resumes the one in the store.
+	gl2, err := NewLog(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gl2.curNum != 1 {
+		t.Errorf("resumed log's curNum = %d; want 1", gl2.curNum)
+	}
+	if got := issueNumbers(t, gl2); !reflect.DeepEqual(got, want) {
+		t.Errorf("from store, issues = %v; want %v", got, want)
+	}
+}
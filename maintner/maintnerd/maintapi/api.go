@@ -14,6 +14,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"golang.org/x/build/gerrit"
 	"golang.org/x/build/maintner"
 	"golang.org/x/build/maintner/maintnerd/apipb"
@@ -57,9 +59,11 @@ func (s apiService) HasAncestor(ctx context.Context, req *apipb.HasAncestorReque
 }
 
 func isStagingCommit(cl *maintner.GerritCL) bool {
-	return cl.Commit != nil &&
-		strings.Contains(cl.Commit.Msg, "DO NOT SUBMIT") &&
-		strings.Contains(cl.Commit.Msg, "STAGING")
+	if cl.Commit == nil {
+		return false
+	}
+	msg := cl.Commit.GetMsg()
+	return strings.Contains(msg, "DO NOT SUBMIT") && strings.Contains(msg, "STAGING")
 }
 
 func tryBotStatus(cl *maintner.GerritCL, forStaging bool) (try, done bool) {
@@ -73,7 +77,7 @@ func tryBotStatus(cl *maintner.GerritCL, forStaging bool) (try, done bool) {
 		if msg.Version != cl.Version {
 			continue
 		}
-		firstLine := msg.Message
+		firstLine := msg.GetMessage()
 		if nl := strings.IndexByte(firstLine, '\n'); nl != -1 {
 			firstLine = firstLine[:nl]
 		}
@@ -117,6 +121,353 @@ func (s apiService) GetRef(ctx context.Context, req *apipb.GetRefRequest) (*apip
 	return res, nil
 }
 
+func (s apiService) Search(ctx context.Context, req *apipb.SearchRequest) (*apipb.SearchResponse, error) {
+	max := int(req.MaxResults)
+	if max <= 0 {
+		max = 50
+	}
+	s.c.RLock()
+	defer s.c.RUnlock()
+	results, err := s.c.Search(req.Query, max)
+	if err != nil {
+		return nil, err
+	}
+	res := new(apipb.SearchResponse)
+	for _, r := range results {
+		res.Results = append(res.Results, searchResult(r))
+	}
+	return res, nil
+}
+
+func searchResult(r *maintner.SearchResult) *apipb.SearchResult {
+	var res *apipb.SearchResult
+	if r.CL != nil {
+		res = clResult(r.CL)
+	} else {
+		res = issueResult(r.Repo, r.Issue)
+	}
+	res.Score = r.Score
+	return res
+}
+
+func clResult(cl *maintner.GerritCL) *apipb.SearchResult {
+	return &apipb.SearchResult{
+		Title:         cl.Subject(),
+		GerritServer:  cl.Project.Server(),
+		GerritProject: cl.Project.Project(),
+		GerritCl:      cl.Number,
+		Closed:        cl.Status == "merged" || cl.Status == "abandoned",
+	}
+}
+
+func issueResult(gr *maintner.GitHubRepo, gi *maintner.GitHubIssue) *apipb.SearchResult {
+	return &apipb.SearchResult{
+		Title:       gi.Title,
+		GithubRepo:  gr.ID().String(),
+		GithubIssue: gi.Number,
+		PullRequest: gi.PullRequest,
+		Closed:      gi.Closed,
+	}
+}
+
+var errStopQuery = errors.New("stop query")
+
+func (s apiService) Query(ctx context.Context, req *apipb.QueryRequest) (*apipb.QueryResponse, error) {
+	max := int(req.MaxResults)
+	if max <= 0 {
+		max = 100
+	}
+	q, err := maintner.ParseQuery(req.Query)
+	if err != nil {
+		return nil, err
+	}
+	s.c.RLock()
+	defer s.c.RUnlock()
+	res := new(apipb.QueryResponse)
+	add := func(r *apipb.SearchResult) error {
+		if len(res.Results) == max {
+			res.Truncated = true
+			return errStopQuery
+		}
+		res.Results = append(res.Results, r)
+		return nil
+	}
+	if req.Gerrit {
+		err = s.c.ForeachCLMatching(q, func(cl *maintner.GerritCL) error {
+			if cl.Private {
+				return nil
+			}
+			return add(clResult(cl))
+		})
+	} else {
+		err = s.c.ForeachIssueMatching(q, func(gr *maintner.GitHubRepo, gi *maintner.GitHubIssue) error {
+			return add(issueResult(gr, gi))
+		})
+	}
+	if err != nil && err != errStopQuery {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s apiService) ListGerritProjects(ctx context.Context, req *apipb.ListGerritProjectsRequest) (*apipb.ListGerritProjectsResponse, error) {
+	s.c.RLock()
+	defer s.c.RUnlock()
+	res := new(apipb.ListGerritProjectsResponse)
+	s.c.Gerrit().ForeachProjectUnsorted(func(gp *maintner.GerritProject) error {
+		res.Projects = append(res.Projects, &apipb.GerritProject{
+			Server:  gp.Server(),
+			Project: gp.Project(),
+		})
+		return nil
+	})
+	sort.Slice(res.Projects, func(i, j int) bool {
+		pi, pj := res.Projects[i], res.Projects[j]
+		if pi.Server != pj.Server {
+			return pi.Server < pj.Server
+		}
+		return pi.Project < pj.Project
+	})
+	return res, nil
+}
+
+func (s apiService) ListGitHubRepos(ctx context.Context, req *apipb.ListGitHubReposRequest) (*apipb.ListGitHubReposResponse, error) {
+	s.c.RLock()
+	defer s.c.RUnlock()
+	res := new(apipb.ListGitHubReposResponse)
+	s.c.GitHub().ForeachRepo(func(gr *maintner.GitHubRepo) error {
+		res.Repos = append(res.Repos, gr.ID().String())
+		return nil
+	})
+	return res, nil
+}
+
+func (s apiService) ListRefs(ctx context.Context, req *apipb.ListRefsRequest) (*apipb.ListRefsResponse, error) {
+	s.c.RLock()
+	defer s.c.RUnlock()
+	gp := s.c.Gerrit().Project(req.GerritServer, req.GerritProject)
+	if gp == nil {
+		return nil, errors.New("unknown gerrit project")
+	}
+	res := new(apipb.ListRefsResponse)
+	gp.ForeachNonChangeRef(func(ref string, hash maintner.GitHash) error {
+		res.Refs = append(res.Refs, &apipb.GitRef{Ref: ref, Commit: hash.String()})
+		return nil
+	})
+	return res, nil
+}
+
+func (s apiService) GetCL(ctx context.Context, req *apipb.GetCLRequest) (*apipb.GerritCL, error) {
+	s.c.RLock()
+	defer s.c.RUnlock()
+	gp := s.c.Gerrit().Project(req.GerritServer, req.GerritProject)
+	if gp == nil {
+		return nil, errors.New("unknown gerrit project")
+	}
+	cl := gp.CL(req.Number)
+	if cl == nil || cl.Commit == nil || len(cl.Metas) == 0 || cl.Private {
+		return nil, errors.New("unknown CL")
+	}
+	meta := cl.Metas[len(cl.Metas)-1]
+	res := &apipb.GerritCL{
+		GerritServer:   gp.Server(),
+		GerritProject:  gp.Project(),
+		Number:         cl.Number,
+		ChangeId:       cl.ChangeID(),
+		Subject:        cl.Subject(),
+		Branch:         cl.Branch(),
+		Status:         cl.Status,
+		WorkInProgress: cl.WorkInProgress(),
+		OwnerId:        int64(cl.OwnerID()),
+		OwnerName:      cl.OwnerName(),
+		Created:        timestampProto(cl.Created),
+	}
+	cl.Hashtags().Foreach(func(tag string) {
+		res.Hashtags = append(res.Hashtags, tag)
+	})
+	for _, ref := range cl.GitHubIssueRefs {
+		res.GithubIssues = append(res.GithubIssues, ref.String())
+	}
+	for v := int32(1); v <= cl.Version; v++ {
+		if c := cl.CommitAtVersion(v); c != nil {
+			res.PatchSets = append(res.PatchSets, &apipb.GerritPatchSet{Version: v, Commit: c.Hash.String()})
+		}
+	}
+	for label, byVoter := range meta.LabelVotes() {
+		for voter, value := range byVoter {
+			res.Votes = append(res.Votes, &apipb.GerritVote{Label: label, Voter: voter, Value: int32(value)})
+		}
+	}
+	sort.Slice(res.Votes, func(i, j int) bool {
+		vi, vj := res.Votes[i], res.Votes[j]
+		if vi.Label != vj.Label {
+			return vi.Label < vj.Label
+		}
+		return vi.Voter < vj.Voter
+	})
+	for _, m := range cl.Messages {
+		pm := &apipb.GerritMessage{
+			Version: m.Version,
+			Date:    timestampProto(m.Date),
+			Message: m.GetMessage(),
+		}
+		if m.Author != nil {
+			pm.Author = m.Author.Str
+		}
+		res.Messages = append(res.Messages, pm)
+	}
+	return res, nil
+}
+
+func (s apiService) GetIssue(ctx context.Context, req *apipb.GetIssueRequest) (*apipb.GitHubIssue, error) {
+	s.c.RLock()
+	defer s.c.RUnlock()
+	f := strings.SplitN(req.GithubRepo, "/", 2)
+	if len(f) != 2 {
+		return nil, errors.New("invalid GithubRepo")
+	}
+	gr := s.c.GitHub().Repo(f[0], f[1])
+	if gr == nil {
+		return nil, errors.New("unknown github repo")
+	}
+	gi := gr.Issue(req.Number)
+	if gi == nil || gi.NotExist {
+		return nil, errors.New("unknown issue")
+	}
+	res := &apipb.GitHubIssue{
+		GithubRepo:  gr.ID().String(),
+		Number:      gi.Number,
+		Title:       gi.Title,
+		Body:        gi.GetBody(),
+		User:        login(gi.User),
+		PullRequest: gi.PullRequest,
+		Closed:      gi.Closed,
+		Created:     timestampProto(gi.Created),
+		Updated:     timestampProto(gi.Updated),
+		ClosedAt:    timestampProto(gi.ClosedAt),
+	}
+	if !gi.Milestone.IsNone() && !gi.Milestone.IsUnknown() {
+		res.Milestone = gi.Milestone.Title
+	}
+	for _, lb := range gi.Labels {
+		res.Labels = append(res.Labels, lb.Name)
+	}
+	sort.Strings(res.Labels)
+	for _, u := range gi.Assignees {
+		res.Assignees = append(res.Assignees, login(u))
+	}
+	gi.ForeachComment(func(c *maintner.GitHubComment) error {
+		res.Comments = append(res.Comments, &apipb.GitHubComment{
+			Id:      c.ID,
+			User:    login(c.User),
+			Created: timestampProto(c.Created),
+			Updated: timestampProto(c.Updated),
+			Body:    c.GetBody(),
+		})
+		return nil
+	})
+	gi.ForeachEvent(func(e *maintner.GitHubIssueEvent) error {
+		res.Events = append(res.Events, &apipb.GitHubIssueEvent{
+			Id:         e.ID,
+			Type:       e.Type,
+			Actor:      login(e.Actor),
+			Created:    timestampProto(e.Created),
+			Label:      e.Label,
+			Milestone:  e.Milestone,
+			Assignee:   login(e.Assignee),
+			RenameFrom: e.From,
+			RenameTo:   e.To,
+		})
+		return nil
+	})
+	return res, nil
+}
+
+// login returns u's login, or the empty string if u is nil.
+func login(u *maintner.GitHubUser) string {
+	if u == nil {
+		return ""
+	}
+	return u.Login
+}
+
+// timestampProto returns t as a protobuf timestamp, or nil if t is
+// zero or out of range.
+func timestampProto(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	tp, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return tp
+}
+
+func (s apiService) WatchChanges(req *apipb.WatchChangesRequest, stream apipb.MaintnerService_WatchChangesServer) error {
+	match := changeFilter(req)
+	return s.c.WatchChanges(stream.Context(), req.Seq, func(e *maintner.ChangeEvent) error {
+		if !match(e) {
+			return nil
+		}
+		return stream.Send(changeEvent(e))
+	})
+}
+
+// changeFilter returns a func reporting whether a change event
+// matches the filters in req.
+func changeFilter(req *apipb.WatchChangesRequest) func(*maintner.ChangeEvent) bool {
+	set := func(ss []string) map[string]bool {
+		if len(ss) == 0 {
+			return nil
+		}
+		m := make(map[string]bool)
+		for _, s := range ss {
+			m[s] = true
+		}
+		return m
+	}
+	types, repos, projects := set(req.Types), set(req.GithubRepos), set(req.GerritProjects)
+	return func(e *maintner.ChangeEvent) bool {
+		if types != nil && !types[e.Type] {
+			return false
+		}
+		if repos != nil && (e.Issue == 0 || !repos[e.GitHubRepo.String()]) {
+			return false
+		}
+		if projects != nil && (e.GerritProject == "" || !projects[e.GerritProject]) {
+			return false
+		}
+		return true
+	}
+}
+
+func changeEvent(e *maintner.ChangeEvent) *apipb.ChangeEvent {
+	pe := &apipb.ChangeEvent{
+		Seq:       e.Seq,
+		Type:      e.Type,
+		Label:     e.Label,
+		CommentId: e.CommentID,
+		GerritCl:  e.CL,
+		Version:   e.Version,
+		Ref:       e.Ref,
+	}
+	if e.Issue != 0 {
+		pe.GithubRepo = e.GitHubRepo.String()
+		pe.GithubIssue = e.Issue
+	}
+	if i := strings.IndexByte(e.GerritProject, '/'); i != -1 {
+		pe.GerritServer, pe.GerritProject = e.GerritProject[:i], e.GerritProject[i+1:]
+	}
+	if e.Hash != "" {
+		pe.Commit = e.Hash.String()
+	}
+	if e.OldHash != "" {
+		pe.OldCommit = e.OldHash.String()
+	}
+	return pe
+}
+
 var tryCache struct {
 	sync.Mutex
 	forNumChanges int       // number of label changes in project val is valid for
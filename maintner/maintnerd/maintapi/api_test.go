@@ -5,16 +5,26 @@
 package maintapi
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"golang.org/x/build/maintner"
 	"golang.org/x/build/maintner/godata"
 	"golang.org/x/build/maintner/maintnerd/apipb"
+	"golang.org/x/build/maintner/maintpb"
+	"golang.org/x/build/maintner/reclog"
 )
 
 func TestGetRef(t *testing.T) {
@@ -145,6 +155,230 @@ func TestTryWorkItem(t *testing.T) {
 	}
 }
 
+// newTestCorpus returns a corpus loaded from a log of a few
+// mutations.
+func newTestCorpus(t *testing.T) *maintner.Corpus {
+	dir, err := ioutil.TempDir("", "maintapi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts := func(s string) *timestamp.Timestamp {
+		tm, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tp, _ := ptypes.TimestampProto(tm)
+		return tp
+	}
+	raw := func(parent, msg string) []byte {
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "tree %s\n", strings.Repeat("7", 40))
+		if parent != "" {
+			fmt.Fprintf(&buf, "parent %s\n", parent)
+		}
+		buf.WriteString("author Gopher <1234@62eb7196> 1514764800 +0000\n")
+		buf.WriteString("committer Gerrit Code Review <noreply-gerritcodereview@google.com> 1514764800 +0000\n")
+		fmt.Fprintf(&buf, "\n%s", msg)
+		return buf.Bytes()
+	}
+	code := strings.Repeat("1c", 20)
+	meta1 := strings.Repeat("a1", 20)
+	meta2 := strings.Repeat("a2", 20)
+	meta3 := strings.Repeat("a3", 20)
+	privateCode := strings.Repeat("2c", 20)
+	privateMeta := strings.Repeat("b1", 20)
+	muts := []*maintpb.Mutation{
+		{Github: &maintpb.GithubMutation{
+			Owner:  "golang",
+			Repo:   "go",
+			Labels: []*maintpb.GithubLabel{{Id: 1, Name: "NeedsFix"}},
+		}},
+		{GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:    "golang",
+			Repo:     "go",
+			Number:   1,
+			User:     &maintpb.GithubUser{Id: 100, Login: "gopherbot"},
+			Title:    "net/http: Transport leaks goroutines",
+			Created:  ts("2018-01-01T00:00:00Z"),
+			Updated:  ts("2018-01-02T00:00:00Z"),
+			AddLabel: []*maintpb.GithubLabel{{Id: 1, Name: "NeedsFix"}},
+			Comment: []*maintpb.GithubIssueCommentMutation{{
+				Id:      5,
+				User:    &maintpb.GithubUser{Id: 101, Login: "kevinburke"},
+				Body:    "I see this too.",
+				Created: ts("2018-01-02T00:00:00Z"),
+			}},
+			Event: []*maintpb.GithubIssueEvent{{
+				Id:        6,
+				EventType: "labeled",
+				ActorId:   100,
+				Label:     &maintpb.GithubLabel{Name: "NeedsFix"},
+				Created:   ts("2018-01-02T00:00:00Z"),
+			}},
+		}},
+		{Gerrit: &maintpb.GerritMutation{
+			Project: "go.googlesource.com/go",
+			Commits: []*maintpb.GitCommit{
+				{Sha1: code, Raw: raw("", "net/http: close idle connections\n\nFixes #1\n")},
+				{Sha1: meta1, Raw: raw("", "Create change\n\nUploaded patch set 1.\n\nPatch-set: 1\nBranch: refs/heads/master\nStatus: new\n")},
+				{Sha1: meta2, Raw: raw(meta1, "Update patch set 1\n\nHashtags added: wait-release\n\nPatch-set: 1\nHashtags: wait-release\nTag: autogenerated:gerrit:setHashtag\n")},
+				{Sha1: meta3, Raw: raw(meta2, "Update patch set 1\n\nPatch Set 1: Code-Review+2\n\nLooks good.\n\nPatch-set: 1\nLabel: Code-Review=+2\n")},
+				{Sha1: privateCode, Raw: raw("", "crypto/tls: fix embargoed vulnerability\n")},
+				{Sha1: privateMeta, Raw: raw("", "Create change\n\nUploaded patch set 1.\n\nPatch-set: 1\nBranch: refs/heads/master\nStatus: new\nPrivate: true\n")},
+			},
+			Refs: []*maintpb.GitRef{
+				{Ref: "refs/heads/master", Sha1: code},
+				{Ref: "refs/changes/34/1234/1", Sha1: code},
+				{Ref: "refs/changes/34/1234/meta", Sha1: meta3},
+				{Ref: "refs/changes/35/1235/1", Sha1: privateCode},
+				{Ref: "refs/changes/35/1235/meta", Sha1: privateMeta},
+			},
+		}},
+	}
+	file := filepath.Join(dir, "maintner-2018-01-01.mutlog")
+	for _, m := range muts {
+		data, err := proto.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reclog.AppendRecordToFile(file, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c := new(maintner.Corpus)
+	if err := c.Initialize(context.Background(), maintner.NewDiskMutationLogger(dir)); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestListAndGet(t *testing.T) {
+	s := apiService{newTestCorpus(t)}
+	ctx := context.Background()
+
+	projects, err := s.ListGerritProjects(ctx, &apipb.ListGerritProjectsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(projects.Projects), `[server:"go.googlesource.com" project:"go" ]`; got != want {
+		t.Errorf("ListGerritProjects = %s; want %s", got, want)
+	}
+	repos, err := s.ListGitHubRepos(ctx, &apipb.ListGitHubReposRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(repos.Repos), "[golang/go]"; got != want {
+		t.Errorf("ListGitHubRepos = %s; want %s", got, want)
+	}
+	refs, err := s.ListRefs(ctx, &apipb.ListRefsRequest{GerritServer: "go.googlesource.com", GerritProject: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs.Refs) != 1 || refs.Refs[0].Ref != "refs/heads/master" || refs.Refs[0].Commit != strings.Repeat("1c", 20) {
+		t.Errorf("ListRefs = %v; want just master", refs.Refs)
+	}
+
+	cl, err := s.GetCL(ctx, &apipb.GetCLRequest{GerritServer: "go.googlesource.com", GerritProject: "go", Number: 1234})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.Subject != "net/http: close idle connections" || cl.Status != "new" || cl.Branch != "master" ||
+		len(cl.PatchSets) != 1 || len(cl.Messages) != 1 || fmt.Sprint(cl.GithubIssues) != "[golang/go#1]" {
+		t.Errorf("GetCL = %v", cl)
+	}
+	if len(cl.Votes) != 1 || cl.Votes[0].Label != "Code-Review" || cl.Votes[0].Value != 2 {
+		t.Errorf("GetCL votes = %v; want Code-Review+2", cl.Votes)
+	}
+	if fmt.Sprint(cl.Hashtags) != "[wait-release]" {
+		t.Errorf("GetCL hashtags = %v; want [wait-release]", cl.Hashtags)
+	}
+	if _, err := s.GetCL(ctx, &apipb.GetCLRequest{GerritServer: "go.googlesource.com", GerritProject: "go", Number: 1}); err == nil {
+		t.Error("GetCL of unknown CL succeeded")
+	}
+	if _, err := s.GetCL(ctx, &apipb.GetCLRequest{GerritServer: "go.googlesource.com", GerritProject: "go", Number: 1235}); err == nil {
+		t.Error("GetCL of private CL succeeded")
+	}
+
+	issue, err := s.GetIssue(ctx, &apipb.GetIssueRequest{GithubRepo: "golang/go", Number: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issue.User != "gopherbot" || fmt.Sprint(issue.Labels) != "[NeedsFix]" ||
+		len(issue.Comments) != 1 || issue.Comments[0].User != "kevinburke" ||
+		len(issue.Events) != 1 || issue.Events[0].Label != "NeedsFix" {
+		t.Errorf("GetIssue = %v", issue)
+	}
+	if _, err := s.GetIssue(ctx, &apipb.GetIssueRequest{GithubRepo: "golang/go", Number: 2}); err == nil {
+		t.Error("GetIssue of unknown issue succeeded")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s := apiService{newTestCorpus(t)}
+	ctx := context.Background()
+	res, err := s.Query(ctx, &apipb.QueryRequest{Query: "label:NeedsFix is:open"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 1 || res.Results[0].GithubIssue != 1 || res.Truncated {
+		t.Errorf("issue Query = %v", res)
+	}
+	res, err = s.Query(ctx, &apipb.QueryRequest{Query: "project:go status:new", Gerrit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 1 || res.Results[0].GerritCl != 1234 {
+		t.Errorf("CL Query = %v", res)
+	}
+	res, err = s.Query(ctx, &apipb.QueryRequest{Query: "is:private", Gerrit: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 0 {
+		t.Errorf("CL Query for private CLs = %v; want none", res)
+	}
+	res, err = s.Query(ctx, &apipb.QueryRequest{Query: "is:open", MaxResults: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Results) != 1 || res.Truncated {
+		t.Errorf("Query with max 1 = %v", res)
+	}
+	if _, err := s.Query(ctx, &apipb.QueryRequest{Query: "status:new"}); err == nil {
+		t.Error("issue Query with CL term succeeded")
+	}
+}
+
+func TestChangeFilter(t *testing.T) {
+	issue := &maintner.ChangeEvent{Type: "issue_labeled", GitHubRepo: maintner.GitHubRepoID{Owner: "golang", Repo: "go"}, Issue: 1, Label: "NeedsFix"}
+	ref := &maintner.ChangeEvent{Type: "ref_moved", GerritProject: "go.googlesource.com/go", Ref: "refs/heads/master"}
+	tests := []struct {
+		req        apipb.WatchChangesRequest
+		issue, ref bool
+	}{
+		{apipb.WatchChangesRequest{}, true, true},
+		{apipb.WatchChangesRequest{Types: []string{"ref_moved", "cl_patchset"}}, false, true},
+		{apipb.WatchChangesRequest{GithubRepos: []string{"golang/go"}}, true, false},
+		{apipb.WatchChangesRequest{GithubRepos: []string{"golang/net"}}, false, false},
+		{apipb.WatchChangesRequest{GerritProjects: []string{"go.googlesource.com/go"}, Types: []string{"ref_moved"}}, false, true},
+	}
+	for i, tt := range tests {
+		match := changeFilter(&tt.req)
+		if got := match(issue); got != tt.issue {
+			t.Errorf("%d. issue match = %v; want %v", i, got, tt.issue)
+		}
+		if got := match(ref); got != tt.ref {
+			t.Errorf("%d. ref match = %v; want %v", i, got, tt.ref)
+		}
+	}
+
+	pe := changeEvent(ref)
+	if pe.GerritServer != "go.googlesource.com" || pe.GerritProject != "go" || pe.GithubRepo != "" {
+		t.Errorf("changeEvent = %+v", pe)
+	}
+}
+
 var (
 	corpusMu    sync.Mutex
 	corpusCache *maintner.Corpus
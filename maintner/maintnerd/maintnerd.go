@@ -52,14 +52,23 @@ var (
 	genMut          = flag.Bool("generate-mutations", true, "whether this instance should read from upstream git/gerrit/github and generate new mutations to the end of the log. This requires network access and only one instance can be generating mutation")
 	watchGithub     = flag.String("watch-github", "", "Comma-separated list of owner/repo pairs to slurp")
 	watchGerrit     = flag.String("watch-gerrit", "", `Comma-separated list of Gerrit projects to watch, each of form "hostname/project" (e.g. "go.googlesource.com/go")`)
+	watchGit        = flag.String("watch-git", "", `Comma-separated list of other git repos to mirror, each of form "name=url" (e.g. "tools=https://gitlab.com/example/tools.git")`)
 	pubsub          = flag.String("pubsub", "", "If non-empty, the golang.org/x/build/cmd/pubsubhelper URL scheme and hostname, without path")
 	config          = flag.String("config", "", "If non-empty, the name of a pre-defined config. Valid options are 'go' to be the primary Go server; 'godata' to run the server locally using the godata package, and 'devgo' to act like 'go', but mirror from godata at start-up.")
 	dataDir         = flag.String("data-dir", "", "Local directory to write protobuf files to (default $HOME/var/maintnerd)")
 	debug           = flag.Bool("debug", false, "Print debug logging information")
 	githubRateLimit = flag.Int("github-rate", 10, "Rate to limit GitHub requests (in queries per second, 0 is treated as unlimited)")
+	changeEvents    = flag.Int("change-events", 100000, "Number of recent change events to keep for the WatchChanges RPC. Zero disables it.")
+	logChecksums    = flag.Bool("log-checksums", false, "Write mutations to the disk log with checksums, which are verified when it's read. Followers built before checksums were added see them as empty mutations.")
+	searchFlag      = flag.Bool("search", false, "Enable full-text search of issues and CLs with the Search RPC. The index is kept in --data-dir.")
 
 	bucket         = flag.String("bucket", "", "if non-empty, Google Cloud Storage bucket to use for log storage")
-	migrateGCSFlag = flag.Bool("migrate-disk-to-gcs", false, "[dev] If true, migrate from disk-based logs to GCS logs on start-up, then quit.")
+	segmentDir     = flag.String("segment-dir", "", "if non-empty, local directory to use for log storage in segments, which maintnerd serves to followers like a bucket's")
+	s3Bucket       = flag.String("s3-bucket", "", "if non-empty, S3-compatible bucket to use for log storage. Credentials are read from $AWS_ACCESS_KEY_ID and $AWS_SECRET_ACCESS_KEY.")
+	s3Endpoint     = flag.String("s3-endpoint", "https://s3.amazonaws.com", "base URL of the S3-compatible API for --s3-bucket")
+	s3Region       = flag.String("s3-region", "us-east-1", "region of --s3-bucket")
+	s3PublicURL    = flag.String("s3-public-url", "", "if non-empty, base URL from which followers can download --s3-bucket's objects, which are then made public-read; otherwise maintnerd serves them")
+	migrateGCSFlag = flag.Bool("migrate-disk-to-gcs", false, "[dev] If true, migrate from disk-based logs to segmented logs (in --bucket, --segment-dir or --s3-bucket) on start-up, then quit.")
 )
 
 func init() {
@@ -99,15 +108,19 @@ func main() {
 
 	if *dataDir == "" {
 		*dataDir = filepath.Join(os.Getenv("HOME"), "var", "maintnerd")
-		if *bucket == "" {
+		if !segmentedLog() {
 			if err := os.MkdirAll(*dataDir, 0755); err != nil {
 				log.Fatal(err)
 			}
 			log.Printf("Storing data in implicit directory %s", *dataDir)
 		}
 	}
-	if *migrateGCSFlag && *bucket == "" {
-		log.Fatalf("--bucket flag required with --migrate-disk-to-gcs")
+	if *migrateGCSFlag && !segmentedLog() {
+		log.Fatalf("--bucket, --segment-dir or --s3-bucket flag required with --migrate-disk-to-gcs")
+	}
+	if *searchFlag && !*genMut && *config != "godata" {
+		// Only then is the corpus loaded to index.
+		log.Fatalf("--search requires --generate-mutations or --config=godata")
 	}
 
 	type storage interface {
@@ -126,7 +139,8 @@ func main() {
 			log.Fatal(err)
 		}
 		log.Printf("Syncing from https://maintner.golang.org/logs to %s", dir)
-		mutSrc := maintner.NewNetworkMutationSource("https://maintner.golang.org/logs", dir)
+		mutSrc := maintner.NewNetworkMutationSource("https://maintner.golang.org/logs", dir,
+			maintner.WithDownloadProgress(godata.LogDownloadProgress()))
 		for evt := range mutSrc.GetMutations(context.Background()) {
 			if evt.Err != nil {
 				log.Fatal(evt.Err)
@@ -155,11 +169,15 @@ func main() {
 		log.Fatalf("unknown --copnfig=%s", *config)
 	}
 	if *genMut {
-		if *bucket != "" {
+		if segmentedLog() {
 			ctx := context.Background()
-			gl, err := gcslog.NewGCSLog(ctx, *bucket)
+			store, err := logStore(ctx)
+			if err != nil {
+				log.Fatalf("log storage: %v", err)
+			}
+			gl, err := gcslog.NewLog(ctx, store)
 			if err != nil {
-				log.Fatalf("newGCSLog: %v", err)
+				log.Fatalf("gcslog.NewLog: %v", err)
 			}
 			gl.RegisterHandlers(http.DefaultServeMux)
 			if *migrateGCSFlag {
@@ -172,7 +190,9 @@ func main() {
 			}
 			logger = gl
 		} else {
-			logger = maintner.NewDiskMutationLogger(*dataDir)
+			diskLog := maintner.NewDiskMutationLogger(*dataDir)
+			diskLog.SetChecksums(*logChecksums)
+			logger = diskLog
 		}
 		corpus.EnableLeaderMode(logger, *dataDir)
 	}
@@ -180,6 +200,9 @@ func main() {
 		corpus.SetDebug()
 	}
 	corpus.SetVerbose(*verbose)
+	if *changeEvents > 0 {
+		corpus.EnableChangeEvents(*changeEvents)
+	}
 
 	if *watchGithub != "" {
 		if *githubRateLimit > 0 {
@@ -204,6 +227,15 @@ func main() {
 			corpus.TrackGerrit(project)
 		}
 	}
+	if *watchGit != "" {
+		for _, pair := range strings.Split(*watchGit, ",") {
+			i := strings.Index(pair, "=")
+			if i <= 0 || i == len(pair)-1 {
+				log.Fatalf("Invalid git repo: %s. Should be 'name=url,name2=url2'", pair)
+			}
+			corpus.TrackGitRepo(pair[i+1:], pair[:i])
+		}
+	}
 
 	var ln net.Listener
 	var err error
@@ -248,6 +280,15 @@ func main() {
 		return
 	}
 
+	if *searchFlag {
+		if err := os.MkdirAll(*dataDir, 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := corpus.EnableSearch(filepath.Join(*dataDir, "search-index")); err != nil {
+			log.Fatalf("EnableSearch: %v", err)
+		}
+	}
+
 	if *pubsub != "" {
 		corpus.StartPubSubHelperSubscribe(*pubsub)
 	}
@@ -337,6 +378,44 @@ var goGitHubProjects = []string{
 	"golang/vgo",
 }
 
+// segmentedLog reports whether the flags select storage for the
+// mutation log in segments, rather than in --data-dir.
+func segmentedLog() bool {
+	return *bucket != "" || *segmentDir != "" || *s3Bucket != ""
+}
+
+// logStore returns the store for the mutation log's segments selected
+// by the --bucket, --segment-dir or --s3-bucket flag.
+func logStore(ctx context.Context) (gcslog.Store, error) {
+	n := 0
+	for _, v := range []string{*bucket, *segmentDir, *s3Bucket} {
+		if v != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return nil, fmt.Errorf("only one of --bucket, --segment-dir and --s3-bucket may be set")
+	}
+	switch {
+	case *segmentDir != "":
+		return gcslog.NewDirStore(*segmentDir)
+	case *s3Bucket != "":
+		return gcslog.NewS3Store(gcslog.S3Config{
+			Endpoint:        *s3Endpoint,
+			Region:          *s3Region,
+			Bucket:          *s3Bucket,
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			PublicURL:       *s3PublicURL,
+		}), nil
+	}
+	sc, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("storage.NewClient: %v", err)
+	}
+	return gcslog.NewGCSStore(sc, *bucket), nil
+}
+
 func setGoConfig() {
 	if *watchGithub != "" {
 		log.Fatalf("can't set both --config and --watch-github")
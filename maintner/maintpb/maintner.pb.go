@@ -19,6 +19,8 @@ It has these top-level messages:
 	GithubCommit
 	GithubIssueSyncStatus
 	GithubIssueCommentMutation
+	GithubReview
+	GithubReviewCommentMutation
 	GithubUser
 	GithubTeam
 	GitMutation
@@ -27,6 +29,9 @@ It has these top-level messages:
 	GitDiffTree
 	GitDiffTreeFile
 	GerritMutation
+	GerritCLComments
+	GerritComment
+	GerritCommentRange
 	GitRef
 */
 package maintpb
@@ -52,6 +57,10 @@ type Mutation struct {
 	Github      *GithubMutation      `protobuf:"bytes,3,opt,name=github" json:"github,omitempty"`
 	Git         *GitMutation         `protobuf:"bytes,2,opt,name=git" json:"git,omitempty"`
 	Gerrit      *GerritMutation      `protobuf:"bytes,4,opt,name=gerrit" json:"gerrit,omitempty"`
+	// checksum is only set in the checksum records of the reclog
+	// format, which are encodings of just this field. They're
+	// skipped when reading logs, so mutations never have it set.
+	Checksum uint32 `protobuf:"fixed32,5,opt,name=checksum" json:"checksum,omitempty"`
 }
 
 func (m *Mutation) Reset()                    { *m = Mutation{} }
@@ -87,6 +96,13 @@ func (m *Mutation) GetGerrit() *GerritMutation {
 	return nil
 }
 
+func (m *Mutation) GetChecksum() uint32 {
+	if m != nil {
+		return m.Checksum
+	}
+	return 0
+}
+
 type GithubMutation struct {
 	Owner string `protobuf:"bytes,1,opt,name=owner" json:"owner,omitempty"`
 	Repo  string `protobuf:"bytes,2,opt,name=repo" json:"repo,omitempty"`
@@ -164,6 +180,11 @@ type GithubIssueMutation struct {
 	CommentStatus  *GithubIssueSyncStatus        `protobuf:"bytes,14,opt,name=comment_status,json=commentStatus" json:"comment_status,omitempty"`
 	Event          []*GithubIssueEvent           `protobuf:"bytes,26,rep,name=event" json:"event,omitempty"`
 	EventStatus    *GithubIssueSyncStatus        `protobuf:"bytes,27,opt,name=event_status,json=eventStatus" json:"event_status,omitempty"`
+	// Pull request reviews and their line-level comments. Only
+	// present for pull requests.
+	Review        []*GithubReview                `protobuf:"bytes,29,rep,name=review" json:"review,omitempty"`
+	ReviewComment []*GithubReviewCommentMutation `protobuf:"bytes,30,rep,name=review_comment,json=reviewComment" json:"review_comment,omitempty"`
+	ReviewStatus  *GithubIssueSyncStatus         `protobuf:"bytes,31,opt,name=review_status,json=reviewStatus" json:"review_status,omitempty"`
 }
 
 func (m *GithubIssueMutation) Reset()                    { *m = GithubIssueMutation{} }
@@ -360,6 +381,27 @@ func (m *GithubIssueMutation) GetEventStatus() *GithubIssueSyncStatus {
 	return nil
 }
 
+func (m *GithubIssueMutation) GetReview() []*GithubReview {
+	if m != nil {
+		return m.Review
+	}
+	return nil
+}
+
+func (m *GithubIssueMutation) GetReviewComment() []*GithubReviewCommentMutation {
+	if m != nil {
+		return m.ReviewComment
+	}
+	return nil
+}
+
+func (m *GithubIssueMutation) GetReviewStatus() *GithubIssueSyncStatus {
+	if m != nil {
+		return m.ReviewStatus
+	}
+	return nil
+}
+
 // BoolChange represents a change to a boolean value.
 type BoolChange struct {
 	Val bool `protobuf:"varint,1,opt,name=val" json:"val,omitempty"`
@@ -736,6 +778,181 @@ func (m *GithubIssueCommentMutation) GetUpdated() *google_protobuf.Timestamp {
 	return nil
 }
 
+// GithubReview is a pull request review. A review mutation for an ID
+// already seen replaces the previous fields that are non-zero.
+type GithubReview struct {
+	Id   int64       `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	User *GithubUser `protobuf:"bytes,2,opt,name=user" json:"user,omitempty"`
+	Body string      `protobuf:"bytes,3,opt,name=body" json:"body,omitempty"`
+	// state is one of "APPROVED", "CHANGES_REQUESTED", "COMMENTED",
+	// "DISMISSED" or "PENDING".
+	State     string                     `protobuf:"bytes,4,opt,name=state" json:"state,omitempty"`
+	CommitId  string                     `protobuf:"bytes,5,opt,name=commit_id,json=commitId" json:"commit_id,omitempty"`
+	Submitted *google_protobuf.Timestamp `protobuf:"bytes,6,opt,name=submitted" json:"submitted,omitempty"`
+}
+
+func (m *GithubReview) Reset()                    { *m = GithubReview{} }
+func (m *GithubReview) String() string            { return proto.CompactTextString(m) }
+func (*GithubReview) ProtoMessage()               {}
+func (*GithubReview) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *GithubReview) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *GithubReview) GetUser() *GithubUser {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *GithubReview) GetBody() string {
+	if m != nil {
+		return m.Body
+	}
+	return ""
+}
+
+func (m *GithubReview) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *GithubReview) GetCommitId() string {
+	if m != nil {
+		return m.CommitId
+	}
+	return ""
+}
+
+func (m *GithubReview) GetSubmitted() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Submitted
+	}
+	return nil
+}
+
+// GithubReviewCommentMutation is a new or edited comment on a line
+// of a pull request's diff.
+// See https://developer.github.com/v3/pulls/comments/
+type GithubReviewCommentMutation struct {
+	Id               int64                      `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	ReviewId         int64                      `protobuf:"varint,2,opt,name=review_id,json=reviewId" json:"review_id,omitempty"`
+	InReplyTo        int64                      `protobuf:"varint,3,opt,name=in_reply_to,json=inReplyTo" json:"in_reply_to,omitempty"`
+	User             *GithubUser                `protobuf:"bytes,4,opt,name=user" json:"user,omitempty"`
+	Body             string                     `protobuf:"bytes,5,opt,name=body" json:"body,omitempty"`
+	Path             string                     `protobuf:"bytes,6,opt,name=path" json:"path,omitempty"`
+	Position         int32                      `protobuf:"varint,7,opt,name=position" json:"position,omitempty"`
+	OriginalPosition int32                      `protobuf:"varint,8,opt,name=original_position,json=originalPosition" json:"original_position,omitempty"`
+	CommitId         string                     `protobuf:"bytes,9,opt,name=commit_id,json=commitId" json:"commit_id,omitempty"`
+	OriginalCommitId string                     `protobuf:"bytes,10,opt,name=original_commit_id,json=originalCommitId" json:"original_commit_id,omitempty"`
+	DiffHunk         string                     `protobuf:"bytes,11,opt,name=diff_hunk,json=diffHunk" json:"diff_hunk,omitempty"`
+	Created          *google_protobuf.Timestamp `protobuf:"bytes,12,opt,name=created" json:"created,omitempty"`
+	Updated          *google_protobuf.Timestamp `protobuf:"bytes,13,opt,name=updated" json:"updated,omitempty"`
+}
+
+func (m *GithubReviewCommentMutation) Reset()                    { *m = GithubReviewCommentMutation{} }
+func (m *GithubReviewCommentMutation) String() string            { return proto.CompactTextString(m) }
+func (*GithubReviewCommentMutation) ProtoMessage()               {}
+func (*GithubReviewCommentMutation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *GithubReviewCommentMutation) GetId() int64 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *GithubReviewCommentMutation) GetReviewId() int64 {
+	if m != nil {
+		return m.ReviewId
+	}
+	return 0
+}
+
+func (m *GithubReviewCommentMutation) GetInReplyTo() int64 {
+	if m != nil {
+		return m.InReplyTo
+	}
+	return 0
+}
+
+func (m *GithubReviewCommentMutation) GetUser() *GithubUser {
+	if m != nil {
+		return m.User
+	}
+	return nil
+}
+
+func (m *GithubReviewCommentMutation) GetBody() string {
+	if m != nil {
+		return m.Body
+	}
+	return ""
+}
+
+func (m *GithubReviewCommentMutation) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *GithubReviewCommentMutation) GetPosition() int32 {
+	if m != nil {
+		return m.Position
+	}
+	return 0
+}
+
+func (m *GithubReviewCommentMutation) GetOriginalPosition() int32 {
+	if m != nil {
+		return m.OriginalPosition
+	}
+	return 0
+}
+
+func (m *GithubReviewCommentMutation) GetCommitId() string {
+	if m != nil {
+		return m.CommitId
+	}
+	return ""
+}
+
+func (m *GithubReviewCommentMutation) GetOriginalCommitId() string {
+	if m != nil {
+		return m.OriginalCommitId
+	}
+	return ""
+}
+
+func (m *GithubReviewCommentMutation) GetDiffHunk() string {
+	if m != nil {
+		return m.DiffHunk
+	}
+	return ""
+}
+
+func (m *GithubReviewCommentMutation) GetCreated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+func (m *GithubReviewCommentMutation) GetUpdated() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Updated
+	}
+	return nil
+}
+
 type GithubUser struct {
 	Id    int64  `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
 	Login string `protobuf:"bytes,2,opt,name=login" json:"login,omitempty"`
@@ -744,7 +961,7 @@ type GithubUser struct {
 func (m *GithubUser) Reset()                    { *m = GithubUser{} }
 func (m *GithubUser) String() string            { return proto.CompactTextString(m) }
 func (*GithubUser) ProtoMessage()               {}
-func (*GithubUser) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+func (*GithubUser) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
 
 func (m *GithubUser) GetId() int64 {
 	if m != nil {
@@ -768,7 +985,7 @@ type GithubTeam struct {
 func (m *GithubTeam) Reset()                    { *m = GithubTeam{} }
 func (m *GithubTeam) String() string            { return proto.CompactTextString(m) }
 func (*GithubTeam) ProtoMessage()               {}
-func (*GithubTeam) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+func (*GithubTeam) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
 
 func (m *GithubTeam) GetId() int64 {
 	if m != nil {
@@ -789,12 +1006,16 @@ type GitMutation struct {
 	// commit adds a commit, or adds new information to a commit if fields
 	// are added in the future.
 	Commit *GitCommit `protobuf:"bytes,2,opt,name=commit" json:"commit,omitempty"`
+	// refs are the repo's branch and tag refs that changed, for repos
+	// with a name. A ref with an empty sha1 was deleted. Their
+	// commits are added by earlier mutations.
+	Refs []*GitRef `protobuf:"bytes,3,rep,name=refs" json:"refs,omitempty"`
 }
 
 func (m *GitMutation) Reset()                    { *m = GitMutation{} }
 func (m *GitMutation) String() string            { return proto.CompactTextString(m) }
 func (*GitMutation) ProtoMessage()               {}
-func (*GitMutation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+func (*GitMutation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
 
 func (m *GitMutation) GetRepo() *GitRepo {
 	if m != nil {
@@ -810,16 +1031,26 @@ func (m *GitMutation) GetCommit() *GitCommit {
 	return nil
 }
 
+func (m *GitMutation) GetRefs() []*GitRef {
+	if m != nil {
+		return m.Refs
+	}
+	return nil
+}
+
 // GitRepo identifies a git repo being mutated.
 type GitRepo struct {
 	// If go_repo is set, it identifies a go.googlesource.com/<go_repo> repo.
 	GoRepo string `protobuf:"bytes,1,opt,name=go_repo,json=goRepo" json:"go_repo,omitempty"`
+	// If name is set, it identifies a repo tracked with
+	// Corpus.TrackGitRepo, by the name it was given.
+	Name string `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
 }
 
 func (m *GitRepo) Reset()                    { *m = GitRepo{} }
 func (m *GitRepo) String() string            { return proto.CompactTextString(m) }
 func (*GitRepo) ProtoMessage()               {}
-func (*GitRepo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+func (*GitRepo) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
 
 func (m *GitRepo) GetGoRepo() string {
 	if m != nil {
@@ -828,6 +1059,13 @@ func (m *GitRepo) GetGoRepo() string {
 	return ""
 }
 
+func (m *GitRepo) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
 type GitCommit struct {
 	Sha1 string `protobuf:"bytes,1,opt,name=sha1" json:"sha1,omitempty"`
 	// raw is the "git cat-file commit $sha1" output.
@@ -838,7 +1076,7 @@ type GitCommit struct {
 func (m *GitCommit) Reset()                    { *m = GitCommit{} }
 func (m *GitCommit) String() string            { return proto.CompactTextString(m) }
 func (*GitCommit) ProtoMessage()               {}
-func (*GitCommit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+func (*GitCommit) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
 
 func (m *GitCommit) GetSha1() string {
 	if m != nil {
@@ -869,7 +1107,7 @@ type GitDiffTree struct {
 func (m *GitDiffTree) Reset()                    { *m = GitDiffTree{} }
 func (m *GitDiffTree) String() string            { return proto.CompactTextString(m) }
 func (*GitDiffTree) ProtoMessage()               {}
-func (*GitDiffTree) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+func (*GitDiffTree) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
 
 func (m *GitDiffTree) GetFile() []*GitDiffTreeFile {
 	if m != nil {
@@ -889,7 +1127,7 @@ type GitDiffTreeFile struct {
 func (m *GitDiffTreeFile) Reset()                    { *m = GitDiffTreeFile{} }
 func (m *GitDiffTreeFile) String() string            { return proto.CompactTextString(m) }
 func (*GitDiffTreeFile) ProtoMessage()               {}
-func (*GitDiffTreeFile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+func (*GitDiffTreeFile) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
 
 func (m *GitDiffTreeFile) GetFile() string {
 	if m != nil {
@@ -927,12 +1165,15 @@ type GerritMutation struct {
 	Commits []*GitCommit `protobuf:"bytes,2,rep,name=commits" json:"commits,omitempty"`
 	// git refs to update.
 	Refs []*GitRef `protobuf:"bytes,3,rep,name=refs" json:"refs,omitempty"`
+	// Published inline comments, read from the NoteDb notes in the
+	// tree of CLs' meta commits.
+	Comments []*GerritCLComments `protobuf:"bytes,4,rep,name=comments" json:"comments,omitempty"`
 }
 
 func (m *GerritMutation) Reset()                    { *m = GerritMutation{} }
 func (m *GerritMutation) String() string            { return proto.CompactTextString(m) }
 func (*GerritMutation) ProtoMessage()               {}
-func (*GerritMutation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+func (*GerritMutation) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
 
 func (m *GerritMutation) GetProject() string {
 	if m != nil {
@@ -955,6 +1196,197 @@ func (m *GerritMutation) GetRefs() []*GitRef {
 	return nil
 }
 
+func (m *GerritMutation) GetComments() []*GerritCLComments {
+	if m != nil {
+		return m.Comments
+	}
+	return nil
+}
+
+// GerritCLComments are the new or changed published inline comments
+// on a CL.
+type GerritCLComments struct {
+	ClNumber int32 `protobuf:"varint,1,opt,name=cl_number,json=clNumber" json:"cl_number,omitempty"`
+	// meta_sha1 is the meta commit the comments were read from. All
+	// comments published as of it are recorded once this is processed.
+	MetaSha1 string           `protobuf:"bytes,2,opt,name=meta_sha1,json=metaSha1" json:"meta_sha1,omitempty"`
+	Comments []*GerritComment `protobuf:"bytes,3,rep,name=comments" json:"comments,omitempty"`
+}
+
+func (m *GerritCLComments) Reset()                    { *m = GerritCLComments{} }
+func (m *GerritCLComments) String() string            { return proto.CompactTextString(m) }
+func (*GerritCLComments) ProtoMessage()               {}
+func (*GerritCLComments) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *GerritCLComments) GetClNumber() int32 {
+	if m != nil {
+		return m.ClNumber
+	}
+	return 0
+}
+
+func (m *GerritCLComments) GetMetaSha1() string {
+	if m != nil {
+		return m.MetaSha1
+	}
+	return ""
+}
+
+func (m *GerritCLComments) GetComments() []*GerritComment {
+	if m != nil {
+		return m.Comments
+	}
+	return nil
+}
+
+// GerritComment is a published comment on a file or line of a patch
+// set. A comment for a UUID already seen replaces the old one.
+type GerritComment struct {
+	Uuid       string              `protobuf:"bytes,1,opt,name=uuid" json:"uuid,omitempty"`
+	ParentUuid string              `protobuf:"bytes,2,opt,name=parent_uuid,json=parentUuid" json:"parent_uuid,omitempty"`
+	PatchSet   int32               `protobuf:"varint,3,opt,name=patch_set,json=patchSet" json:"patch_set,omitempty"`
+	File       string              `protobuf:"bytes,4,opt,name=file" json:"file,omitempty"`
+	Line       int32               `protobuf:"varint,5,opt,name=line" json:"line,omitempty"`
+	Range      *GerritCommentRange `protobuf:"bytes,6,opt,name=range" json:"range,omitempty"`
+	// side is 1 for comments on the patch set itself, and 0 or negative
+	// for comments on (one of) its parent(s).
+	Side       int32                      `protobuf:"varint,7,opt,name=side" json:"side,omitempty"`
+	AuthorId   int64                      `protobuf:"varint,8,opt,name=author_id,json=authorId" json:"author_id,omitempty"`
+	Written    *google_protobuf.Timestamp `protobuf:"bytes,9,opt,name=written" json:"written,omitempty"`
+	Message    string                     `protobuf:"bytes,10,opt,name=message" json:"message,omitempty"`
+	Unresolved bool                       `protobuf:"varint,11,opt,name=unresolved" json:"unresolved,omitempty"`
+	RevSha1    string                     `protobuf:"bytes,12,opt,name=rev_sha1,json=revSha1" json:"rev_sha1,omitempty"`
+}
+
+func (m *GerritComment) Reset()                    { *m = GerritComment{} }
+func (m *GerritComment) String() string            { return proto.CompactTextString(m) }
+func (*GerritComment) ProtoMessage()               {}
+func (*GerritComment) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *GerritComment) GetUuid() string {
+	if m != nil {
+		return m.Uuid
+	}
+	return ""
+}
+
+func (m *GerritComment) GetParentUuid() string {
+	if m != nil {
+		return m.ParentUuid
+	}
+	return ""
+}
+
+func (m *GerritComment) GetPatchSet() int32 {
+	if m != nil {
+		return m.PatchSet
+	}
+	return 0
+}
+
+func (m *GerritComment) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *GerritComment) GetLine() int32 {
+	if m != nil {
+		return m.Line
+	}
+	return 0
+}
+
+func (m *GerritComment) GetRange() *GerritCommentRange {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
+func (m *GerritComment) GetSide() int32 {
+	if m != nil {
+		return m.Side
+	}
+	return 0
+}
+
+func (m *GerritComment) GetAuthorId() int64 {
+	if m != nil {
+		return m.AuthorId
+	}
+	return 0
+}
+
+func (m *GerritComment) GetWritten() *google_protobuf.Timestamp {
+	if m != nil {
+		return m.Written
+	}
+	return nil
+}
+
+func (m *GerritComment) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
+func (m *GerritComment) GetUnresolved() bool {
+	if m != nil {
+		return m.Unresolved
+	}
+	return false
+}
+
+func (m *GerritComment) GetRevSha1() string {
+	if m != nil {
+		return m.RevSha1
+	}
+	return ""
+}
+
+type GerritCommentRange struct {
+	StartLine int32 `protobuf:"varint,1,opt,name=start_line,json=startLine" json:"start_line,omitempty"`
+	StartChar int32 `protobuf:"varint,2,opt,name=start_char,json=startChar" json:"start_char,omitempty"`
+	EndLine   int32 `protobuf:"varint,3,opt,name=end_line,json=endLine" json:"end_line,omitempty"`
+	EndChar   int32 `protobuf:"varint,4,opt,name=end_char,json=endChar" json:"end_char,omitempty"`
+}
+
+func (m *GerritCommentRange) Reset()                    { *m = GerritCommentRange{} }
+func (m *GerritCommentRange) String() string            { return proto.CompactTextString(m) }
+func (*GerritCommentRange) ProtoMessage()               {}
+func (*GerritCommentRange) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{23} }
+
+func (m *GerritCommentRange) GetStartLine() int32 {
+	if m != nil {
+		return m.StartLine
+	}
+	return 0
+}
+
+func (m *GerritCommentRange) GetStartChar() int32 {
+	if m != nil {
+		return m.StartChar
+	}
+	return 0
+}
+
+func (m *GerritCommentRange) GetEndLine() int32 {
+	if m != nil {
+		return m.EndLine
+	}
+	return 0
+}
+
+func (m *GerritCommentRange) GetEndChar() int32 {
+	if m != nil {
+		return m.EndChar
+	}
+	return 0
+}
+
 type GitRef struct {
 	// ref is the git ref name, such as:
 	//    HEAD
@@ -970,7 +1402,7 @@ type GitRef struct {
 func (m *GitRef) Reset()                    { *m = GitRef{} }
 func (m *GitRef) String() string            { return proto.CompactTextString(m) }
 func (*GitRef) ProtoMessage()               {}
-func (*GitRef) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+func (*GitRef) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{24} }
 
 func (m *GitRef) GetRef() string {
 	if m != nil {
@@ -998,6 +1430,8 @@ func init() {
 	proto.RegisterType((*GithubCommit)(nil), "maintpb.GithubCommit")
 	proto.RegisterType((*GithubIssueSyncStatus)(nil), "maintpb.GithubIssueSyncStatus")
 	proto.RegisterType((*GithubIssueCommentMutation)(nil), "maintpb.GithubIssueCommentMutation")
+	proto.RegisterType((*GithubReview)(nil), "maintpb.GithubReview")
+	proto.RegisterType((*GithubReviewCommentMutation)(nil), "maintpb.GithubReviewCommentMutation")
 	proto.RegisterType((*GithubUser)(nil), "maintpb.GithubUser")
 	proto.RegisterType((*GithubTeam)(nil), "maintpb.GithubTeam")
 	proto.RegisterType((*GitMutation)(nil), "maintpb.GitMutation")
@@ -1006,99 +1440,134 @@ func init() {
 	proto.RegisterType((*GitDiffTree)(nil), "maintpb.GitDiffTree")
 	proto.RegisterType((*GitDiffTreeFile)(nil), "maintpb.GitDiffTreeFile")
 	proto.RegisterType((*GerritMutation)(nil), "maintpb.GerritMutation")
+	proto.RegisterType((*GerritCLComments)(nil), "maintpb.GerritCLComments")
+	proto.RegisterType((*GerritComment)(nil), "maintpb.GerritComment")
+	proto.RegisterType((*GerritCommentRange)(nil), "maintpb.GerritCommentRange")
 	proto.RegisterType((*GitRef)(nil), "maintpb.GitRef")
 }
 
 func init() { proto.RegisterFile("maintner.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1401 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x6d, 0x6f, 0xdb, 0x36,
-	0x10, 0x86, 0xdf, 0xa5, 0xb3, 0xe3, 0xb8, 0x4c, 0x5f, 0xd8, 0xb4, 0xeb, 0x32, 0x75, 0x58, 0x83,
-	0xb6, 0x73, 0xda, 0x6e, 0xd8, 0x0a, 0x14, 0xc3, 0xd0, 0xd7, 0xc1, 0xc5, 0xda, 0x0f, 0x6c, 0xfa,
-	0x59, 0x90, 0x4d, 0x5a, 0x51, 0x27, 0x89, 0x9e, 0x44, 0xa5, 0x0b, 0xb0, 0x7d, 0xd9, 0x1f, 0x19,
-	0xb0, 0xff, 0xb4, 0xdf, 0xb1, 0xbf, 0x30, 0xf0, 0x48, 0x49, 0x8e, 0xed, 0x34, 0xed, 0xbe, 0xf1,
-	0x8e, 0xcf, 0x9d, 0x8e, 0x77, 0xc7, 0xe7, 0x28, 0x18, 0x26, 0x41, 0x94, 0xaa, 0x54, 0x64, 0xe3,
-	0x45, 0x26, 0x95, 0x24, 0x3d, 0x94, 0x17, 0xd3, 0xdd, 0x47, 0x61, 0xa4, 0x8e, 0x8a, 0xe9, 0x78,
-	0x26, 0x93, 0x83, 0x50, 0xc6, 0x41, 0x1a, 0x1e, 0x20, 0x62, 0x5a, 0xcc, 0x0f, 0x16, 0xea, 0x64,
-	0x21, 0xf2, 0x03, 0x15, 0x25, 0x22, 0x57, 0x41, 0xb2, 0xa8, 0x57, 0xc6, 0x8b, 0xf7, 0x4f, 0x03,
-	0x9c, 0x57, 0x85, 0x0a, 0x54, 0x24, 0x53, 0xf2, 0x23, 0x0c, 0x8c, 0x2f, 0x3f, 0xca, 0xf3, 0x42,
-	0xd0, 0xc6, 0x5e, 0x63, 0xbf, 0xff, 0xe0, 0xfa, 0xd8, 0x7e, 0x69, 0xfc, 0x13, 0x6e, 0x4e, 0xf4,
-	0x5e, 0x69, 0xc3, 0xfa, 0x61, 0xad, 0x24, 0x07, 0xd0, 0x35, 0x22, 0x6d, 0xa1, 0xe9, 0x95, 0x15,
-	0xd3, 0xca, 0xca, 0xc2, 0xc8, 0x57, 0xd0, 0x0a, 0x23, 0x45, 0x9b, 0x88, 0xbe, 0xb8, 0x8c, 0xae,
-	0xa0, 0x1a, 0x80, 0x8e, 0x45, 0x96, 0x45, 0x8a, 0xb6, 0x57, 0x1d, 0xa3, 0x7a, 0xc9, 0x31, 0xca,
-	0xde, 0xdf, 0x0d, 0x18, 0x9e, 0xfe, 0x26, 0xb9, 0x08, 0x1d, 0xf9, 0x3e, 0x15, 0x19, 0x1e, 0xcb,
-	0x65, 0x46, 0x20, 0x04, 0xda, 0x99, 0x58, 0x48, 0x0c, 0xc1, 0x65, 0xb8, 0x26, 0x77, 0xa1, 0x1b,
-	0x07, 0x53, 0x11, 0xe7, 0xb4, 0xb5, 0xd7, 0x5a, 0x0d, 0xec, 0xa8, 0x98, 0xfe, 0xac, 0x37, 0x99,
-	0xc5, 0x90, 0x87, 0x00, 0x49, 0x14, 0x8b, 0x5c, 0xc9, 0x54, 0xe4, 0xb4, 0x8d, 0x16, 0x74, 0xf5,
-	0xe0, 0x25, 0x80, 0x2d, 0x61, 0xbd, 0x7f, 0x1d, 0xd8, 0xd9, 0x90, 0xd3, 0x4f, 0x88, 0xf4, 0x32,
-	0x74, 0xd3, 0x22, 0x99, 0x8a, 0x0c, 0x13, 0xde, 0x61, 0x56, 0x22, 0xd7, 0xc0, 0x4d, 0xa5, 0xf2,
-	0xc5, 0x6f, 0x51, 0xae, 0xe8, 0xd6, 0x5e, 0x63, 0xdf, 0x61, 0x4e, 0x2a, 0xd5, 0x73, 0x2d, 0x93,
-	0x21, 0x34, 0x23, 0x4e, 0x07, 0x7b, 0x8d, 0xfd, 0x16, 0x6b, 0x46, 0x9c, 0xdc, 0x82, 0x76, 0x91,
-	0x8b, 0xcc, 0xa6, 0x76, 0x67, 0x25, 0xf4, 0xb7, 0xb9, 0xc8, 0x18, 0x02, 0xc8, 0x7d, 0x70, 0x83,
-	0x3c, 0x8f, 0xc2, 0x54, 0x88, 0x9c, 0x02, 0x1e, 0x74, 0x23, 0xba, 0x46, 0x91, 0x3b, 0x70, 0x81,
-	0x8b, 0x58, 0x28, 0xc1, 0xfd, 0xda, 0xb4, 0xbf, 0xd7, 0xda, 0x6f, 0xb1, 0x91, 0xdd, 0x78, 0x5c,
-	0x81, 0xbf, 0x85, 0xde, 0x2c, 0x13, 0x81, 0x12, 0x9c, 0x76, 0x30, 0x96, 0xdd, 0x71, 0x28, 0x65,
-	0x18, 0x8b, 0x71, 0xd9, 0xd0, 0xe3, 0xc3, 0xb2, 0x7f, 0x59, 0x09, 0xd5, 0x56, 0xc5, 0x82, 0xa3,
-	0x55, 0xf7, 0x7c, 0x2b, 0x0b, 0xd5, 0xd9, 0x9c, 0x4a, 0x7e, 0x42, 0x7b, 0x26, 0x9b, 0x7a, 0xad,
-	0xf3, 0xae, 0x22, 0x15, 0x0b, 0xea, 0x9a, 0xbc, 0xa3, 0x40, 0xbe, 0x80, 0x41, 0x2a, 0xfd, 0xaa,
-	0x6c, 0x74, 0x1b, 0xd3, 0xd9, 0x4f, 0x65, 0x55, 0x54, 0x0d, 0xa9, 0xf6, 0xfd, 0x88, 0xd3, 0x11,
-	0xe6, 0xb6, 0x5f, 0xe9, 0x26, 0x9c, 0xdc, 0x84, 0xad, 0x1a, 0x92, 0x16, 0x09, 0xbd, 0x80, 0x98,
-	0xda, 0xee, 0x75, 0x91, 0x90, 0x5b, 0xb0, 0x5d, 0x83, 0x4c, 0x28, 0x04, 0x43, 0x19, 0x56, 0xea,
-	0x43, 0x8c, 0xe9, 0x0e, 0x74, 0x67, 0xb1, 0xcc, 0x05, 0xa7, 0x3b, 0x2b, 0x45, 0x7b, 0x22, 0x65,
-	0xfc, 0xf4, 0x28, 0x48, 0x43, 0xc1, 0x2c, 0x44, 0x83, 0x63, 0x39, 0xfb, 0x45, 0x70, 0x7a, 0xf5,
-	0x03, 0x60, 0x03, 0xd1, 0x47, 0x59, 0x14, 0x71, 0xec, 0x67, 0xe2, 0xd7, 0x42, 0xe4, 0x8a, 0x5e,
-	0x37, 0xa7, 0xd5, 0x3a, 0x66, 0x54, 0xe4, 0x7b, 0x70, 0x8d, 0x67, 0x3f, 0x50, 0xf4, 0xd2, 0xb9,
-	0x29, 0x77, 0x0c, 0xf8, 0xb1, 0x22, 0xf7, 0x2a, 0xc3, 0xe9, 0x09, 0xbd, 0x7c, 0x76, 0xb7, 0x59,
-	0x8b, 0x27, 0x27, 0x3a, 0x9a, 0x4c, 0x24, 0xf2, 0x58, 0xf8, 0x78, 0xd9, 0xe8, 0x15, 0xec, 0x9c,
-	0xbe, 0xd1, 0xe1, 0x35, 0xc4, 0xa6, 0xe4, 0xdc, 0xee, 0xd3, 0x0f, 0xdc, 0x57, 0x27, 0xe0, 0xdc,
-	0x98, 0xfc, 0x00, 0xbd, 0x99, 0x4c, 0x12, 0x91, 0x2a, 0xea, 0xa0, 0xc1, 0xcd, 0x4d, 0x14, 0xf7,
-	0xd4, 0x40, 0x2a, 0x6a, 0x29, 0x6d, 0xc8, 0x73, 0x18, 0xda, 0xa5, 0x9f, 0xab, 0x40, 0x15, 0x39,
-	0x1d, 0xe2, 0x59, 0x6e, 0x6c, 0xf2, 0xf2, 0xe6, 0x24, 0x9d, 0xbd, 0x41, 0x14, 0xdb, 0xb2, 0x56,
-	0x46, 0x24, 0x07, 0xd0, 0x11, 0xc7, 0x3a, 0x86, 0x5d, 0x8c, 0xe1, 0xea, 0x26, 0xeb, 0xe7, 0x1a,
-	0xc0, 0x0c, 0x8e, 0x3c, 0x86, 0x01, 0x2e, 0xca, 0xaf, 0x5e, 0xfb, 0xa8, 0xaf, 0xf6, 0xd1, 0xc6,
-	0x08, 0xde, 0x0d, 0x80, 0xba, 0xe6, 0x64, 0x04, 0xad, 0xe3, 0x20, 0x46, 0x96, 0x71, 0x98, 0x5e,
-	0x7a, 0xf7, 0xa1, 0xbf, 0x94, 0x32, 0xcb, 0x14, 0x8d, 0x8a, 0x29, 0x08, 0xb4, 0xd3, 0x20, 0x11,
-	0x25, 0x05, 0xe9, 0xb5, 0xf7, 0x3b, 0x6c, 0xaf, 0x70, 0xdc, 0x9a, 0x59, 0x75, 0xaf, 0x9a, 0xcb,
-	0xf7, 0xaa, 0xee, 0xe1, 0xd6, 0xf9, 0x3d, 0x5c, 0x13, 0x5d, 0x1b, 0xdd, 0x5a, 0xc9, 0xfb, 0xab,
-	0x03, 0xa3, 0xd5, 0x7c, 0xad, 0x7d, 0xff, 0x33, 0x00, 0x93, 0x38, 0x3d, 0x0d, 0x6d, 0x10, 0x2e,
-	0x6a, 0x0e, 0x4f, 0x16, 0x82, 0x5c, 0x05, 0x27, 0x98, 0x29, 0x99, 0xe9, 0x9b, 0xdb, 0x42, 0xa3,
-	0x1e, 0xca, 0x13, 0xbe, 0xcc, 0x48, 0xed, 0x8f, 0x67, 0xa4, 0xdb, 0xd0, 0x31, 0xed, 0xd8, 0x59,
-	0x9f, 0x6b, 0x55, 0x3b, 0x1a, 0x08, 0xf9, 0x0e, 0xdc, 0x9a, 0x5a, 0x0c, 0x7f, 0x9d, 0x3d, 0x3c,
-	0x6a, 0x28, 0xf9, 0x1c, 0xfa, 0x25, 0xa1, 0xea, 0xb8, 0x7b, 0x18, 0x37, 0x94, 0xaa, 0x09, 0x5f,
-	0x02, 0xe0, 0xc1, 0x9c, 0x53, 0x00, 0x7d, 0xb6, 0xaf, 0xa1, 0xab, 0x1b, 0x32, 0x52, 0x48, 0x77,
-	0xfd, 0x07, 0x97, 0x56, 0x3e, 0xfb, 0x14, 0x37, 0x99, 0x05, 0x69, 0x7f, 0x99, 0xd0, 0x15, 0xf7,
-	0xe7, 0x99, 0x4c, 0x68, 0x1f, 0xb3, 0x08, 0x46, 0xf5, 0x22, 0x93, 0x89, 0x9e, 0x39, 0x16, 0xa0,
-	0x24, 0x4e, 0x17, 0x97, 0x39, 0x46, 0x71, 0x28, 0x8d, 0xf5, 0x71, 0x24, 0xde, 0x9b, 0x68, 0xb6,
-	0x4c, 0x34, 0xa5, 0x6a, 0xc2, 0xc9, 0x18, 0x76, 0x8c, 0x54, 0x32, 0x8f, 0x01, 0x0e, 0x11, 0x78,
-	0xc1, 0x6c, 0xb1, 0x72, 0x67, 0xc2, 0xc9, 0x43, 0xd8, 0x52, 0x22, 0x48, 0xfc, 0xd2, 0x05, 0x72,
-	0xee, 0x3a, 0x9f, 0x1c, 0x8a, 0x20, 0x61, 0x03, 0x8d, 0x64, 0x16, 0x48, 0x5e, 0xc3, 0x88, 0x47,
-	0x79, 0x12, 0xe5, 0x9a, 0x88, 0x8c, 0x39, 0x72, 0xfa, 0x3a, 0x0d, 0x3c, 0x2b, 0x61, 0xc6, 0xd6,
-	0x5c, 0xc6, 0x6d, 0x7e, 0x5a, 0xab, 0xbb, 0x4b, 0xaa, 0x23, 0x91, 0xf9, 0xef, 0x72, 0x99, 0x52,
-	0xd8, 0x6b, 0xec, 0x0f, 0x98, 0x8b, 0x9a, 0x97, 0xb9, 0x4c, 0xbd, 0x3f, 0x1b, 0xb0, 0x7b, 0xb6,
-	0x3b, 0x93, 0x35, 0x3c, 0x77, 0xd5, 0xb2, 0x8e, 0x51, 0x4c, 0x38, 0x4e, 0x4f, 0x63, 0x14, 0xc4,
-	0x7e, 0x22, 0xf2, 0x3c, 0x08, 0x05, 0xb6, 0xa8, 0xcb, 0x46, 0xd5, 0xc6, 0x2b, 0xa3, 0xd7, 0xb7,
-	0x4c, 0x13, 0x83, 0xc0, 0x4e, 0x75, 0x99, 0x11, 0x5e, 0xb6, 0x9d, 0xe6, 0xa8, 0xe5, 0xbd, 0x85,
-	0xc1, 0x72, 0x51, 0x3f, 0xe1, 0x85, 0x71, 0x0d, 0x5c, 0xd3, 0x00, 0xe5, 0xed, 0x70, 0x99, 0x63,
-	0x14, 0x13, 0xee, 0x1d, 0xc2, 0xa5, 0x8d, 0xa4, 0x43, 0x1e, 0x41, 0x3f, 0x17, 0xd9, 0xb1, 0xc8,
-	0x7c, 0x3d, 0x6d, 0xed, 0x43, 0xf2, 0x43, 0x77, 0x07, 0x0c, 0xfc, 0x59, 0xa0, 0x84, 0x7e, 0x93,
-	0xee, 0x9e, 0xcd, 0xc3, 0x6b, 0xb7, 0xbb, 0x7c, 0xbe, 0x34, 0xcf, 0x7b, 0xbe, 0x94, 0x23, 0xbf,
-	0xb5, 0x34, 0xf2, 0xff, 0xdf, 0x05, 0x5f, 0x7a, 0x72, 0x74, 0x3e, 0xfa, 0xc9, 0xe1, 0x3d, 0x00,
-	0xa8, 0x63, 0xda, 0x44, 0x92, 0xb1, 0x0c, 0xa3, 0xb4, 0x24, 0x49, 0x14, 0xbc, 0x7b, 0xa5, 0x8d,
-	0x6e, 0xe4, 0x4d, 0x7c, 0x9c, 0xc7, 0x45, 0x58, 0x16, 0x4c, 0xaf, 0x3d, 0x1f, 0x29, 0xbc, 0xca,
-	0xd6, 0x97, 0xb6, 0xa6, 0xa6, 0x04, 0xa3, 0xe5, 0xec, 0x30, 0xb1, 0x90, 0xb6, 0xca, 0xb7, 0x2b,
-	0x2e, 0x30, 0x59, 0x24, 0xcb, 0xb8, 0xd3, 0x44, 0xe0, 0x79, 0xd0, 0xb3, 0xc6, 0xe4, 0x0a, 0xf4,
-	0x42, 0xe9, 0x57, 0xfe, 0x5d, 0xd6, 0x0d, 0xa5, 0xde, 0xf0, 0x38, 0xb8, 0x95, 0x21, 0x46, 0x79,
-	0x14, 0xdc, 0xb7, 0x10, 0x5c, 0xeb, 0xd1, 0x93, 0x05, 0xef, 0xf1, 0x6b, 0x03, 0xa6, 0x97, 0x7a,
-	0x8e, 0xf3, 0x68, 0x3e, 0xf7, 0x55, 0x26, 0x84, 0x9d, 0x08, 0xa7, 0x88, 0xf3, 0x59, 0x34, 0x9f,
-	0x1f, 0x66, 0x42, 0x30, 0x87, 0xdb, 0x95, 0xf7, 0x08, 0x8f, 0x5a, 0x6e, 0x90, 0xbb, 0xd0, 0x9e,
-	0x47, 0xb1, 0xee, 0xb6, 0xb5, 0x27, 0x78, 0x89, 0x79, 0x11, 0xc5, 0x82, 0x21, 0xca, 0x4b, 0x70,
-	0x6e, 0x2d, 0x6f, 0xe8, 0x40, 0xad, 0x03, 0x0c, 0x54, 0xaf, 0x75, 0x59, 0x02, 0xce, 0x05, 0xc7,
-	0x50, 0x5b, 0xcc, 0x08, 0x84, 0x42, 0xcf, 0xbe, 0x5e, 0xcb, 0x89, 0x61, 0x45, 0x3d, 0xa8, 0xa6,
-	0x51, 0x1a, 0x64, 0x27, 0xd8, 0x4f, 0x0e, 0xb3, 0x92, 0xf7, 0x07, 0x0c, 0x4f, 0xff, 0xaa, 0x68,
-	0x1f, 0x8b, 0x4c, 0xbe, 0x13, 0x33, 0x65, 0x3f, 0x58, 0x8a, 0xe4, 0xae, 0x79, 0x9f, 0x44, 0x2a,
-	0xa7, 0x4d, 0x3c, 0xcb, 0xa6, 0x72, 0x94, 0x10, 0x72, 0x53, 0x57, 0x78, 0x5e, 0xfe, 0xab, 0x6c,
-	0x9f, 0xae, 0xf0, 0x9c, 0xe1, 0xa6, 0x37, 0x86, 0xae, 0x91, 0x31, 0xf3, 0x62, 0x6e, 0x3f, 0xa9,
-	0x97, 0x55, 0x7d, 0x9a, 0x75, 0x7d, 0xa6, 0x5d, 0x6c, 0xe4, 0x6f, 0xfe, 0x0b, 0x00, 0x00, 0xff,
-	0xff, 0xec, 0x37, 0x4a, 0xa0, 0x76, 0x0e, 0x00, 0x00,
+	// 1916 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0x5f, 0x6f, 0x1c, 0xb7,
+	0x11, 0xc7, 0xfd, 0xdf, 0x9d, 0x3b, 0x49, 0x17, 0x3a, 0xb6, 0xd7, 0x52, 0xa2, 0x5c, 0xd7, 0x41,
+	0x23, 0x24, 0x8e, 0x14, 0xbb, 0x6d, 0x6a, 0xc0, 0x28, 0x0a, 0x47, 0x76, 0x5a, 0xa5, 0x8e, 0x51,
+	0xd0, 0xf2, 0xf3, 0x62, 0xef, 0x96, 0x77, 0xc7, 0x78, 0x77, 0x79, 0xe5, 0x72, 0xe5, 0x0a, 0xe8,
+	0x43, 0x51, 0xf4, 0xa1, 0xdf, 0xa2, 0x40, 0x3f, 0x41, 0x1f, 0xfa, 0x21, 0xfa, 0x05, 0x0a, 0xf4,
+	0x6b, 0xf4, 0xad, 0x8f, 0x05, 0x87, 0xe4, 0xde, 0xde, 0x1f, 0x59, 0x4a, 0xd0, 0x37, 0x72, 0xe6,
+	0x37, 0xe4, 0x70, 0x66, 0xf8, 0xe3, 0xec, 0xc2, 0x6e, 0x16, 0xf3, 0x5c, 0xe5, 0x4c, 0x1e, 0x2f,
+	0xa4, 0x50, 0x82, 0xf4, 0x70, 0xbe, 0x18, 0xef, 0x3f, 0x99, 0x71, 0x35, 0x2f, 0xc7, 0xc7, 0x13,
+	0x91, 0x9d, 0xcc, 0x44, 0x1a, 0xe7, 0xb3, 0x13, 0x44, 0x8c, 0xcb, 0xe9, 0xc9, 0x42, 0x5d, 0x2e,
+	0x58, 0x71, 0xa2, 0x78, 0xc6, 0x0a, 0x15, 0x67, 0x8b, 0xe5, 0xc8, 0xac, 0x12, 0xfe, 0xa7, 0x01,
+	0xde, 0xb7, 0xa5, 0x8a, 0x15, 0x17, 0x39, 0xf9, 0x25, 0x0c, 0xcc, 0x5a, 0x11, 0x2f, 0x8a, 0x92,
+	0x05, 0x8d, 0x51, 0xe3, 0xa8, 0xff, 0xe8, 0x83, 0x63, 0xbb, 0xd3, 0xf1, 0xaf, 0x50, 0x79, 0xa6,
+	0x75, 0xce, 0x86, 0xf6, 0x67, 0x4b, 0x21, 0x39, 0x81, 0xae, 0x99, 0x06, 0x2d, 0x34, 0xbd, 0xbb,
+	0x66, 0x5a, 0x59, 0x59, 0x18, 0xf9, 0x31, 0xb4, 0x66, 0x5c, 0x05, 0x4d, 0x44, 0xbf, 0x5f, 0x47,
+	0x57, 0x50, 0x0d, 0xc0, 0x85, 0x99, 0x94, 0x5c, 0x05, 0xed, 0xf5, 0x85, 0x51, 0x5c, 0x5b, 0x18,
+	0xe7, 0x64, 0x1f, 0xbc, 0xc9, 0x9c, 0x4d, 0xde, 0x14, 0x65, 0x16, 0x74, 0x46, 0x8d, 0xa3, 0x1e,
+	0xad, 0xe6, 0xe1, 0xdf, 0x1a, 0xb0, 0xbb, 0xea, 0x0f, 0x79, 0x1f, 0x3a, 0xe2, 0x6d, 0xce, 0x24,
+	0x1e, 0xd9, 0xa7, 0x66, 0x42, 0x08, 0xb4, 0x25, 0x5b, 0x08, 0x74, 0xcf, 0xa7, 0x38, 0x26, 0x0f,
+	0xa0, 0x9b, 0xc6, 0x63, 0x96, 0x16, 0x41, 0x6b, 0xd4, 0x5a, 0x77, 0x7a, 0x5e, 0x8e, 0x5f, 0x68,
+	0x25, 0xb5, 0x18, 0xf2, 0x18, 0x20, 0xe3, 0x29, 0x2b, 0x94, 0xc8, 0x59, 0x11, 0xb4, 0xd1, 0x22,
+	0x58, 0x0f, 0x8a, 0x03, 0xd0, 0x1a, 0x36, 0xfc, 0x3b, 0xc0, 0xad, 0x2d, 0xf1, 0xfe, 0x1e, 0x9e,
+	0xde, 0x81, 0x6e, 0x5e, 0x66, 0x63, 0x26, 0x31, 0x19, 0x1d, 0x6a, 0x67, 0xe4, 0x00, 0xfc, 0x5c,
+	0xa8, 0x88, 0xfd, 0x9e, 0x17, 0x2a, 0xd8, 0x19, 0x35, 0x8e, 0x3c, 0xea, 0xe5, 0x42, 0x3d, 0xd7,
+	0x73, 0xb2, 0x0b, 0x4d, 0x9e, 0x04, 0x83, 0x51, 0xe3, 0xa8, 0x45, 0x9b, 0x3c, 0x21, 0x9f, 0x40,
+	0xbb, 0x2c, 0x98, 0xb4, 0x61, 0xbf, 0xb5, 0xe6, 0xfa, 0xeb, 0x82, 0x49, 0x8a, 0x00, 0xf2, 0x10,
+	0xfc, 0xb8, 0x28, 0xf8, 0x2c, 0x67, 0xac, 0x08, 0x60, 0xd4, 0xba, 0x0a, 0xbd, 0x44, 0x91, 0xcf,
+	0xe0, 0xbd, 0x84, 0xa5, 0x4c, 0xb1, 0x24, 0x5a, 0x9a, 0xf6, 0x47, 0xad, 0xa3, 0x16, 0x1d, 0x5a,
+	0xc5, 0xd3, 0x0a, 0xfc, 0x53, 0xe8, 0x4d, 0x24, 0x8b, 0x15, 0x4b, 0x30, 0x9f, 0xfd, 0x47, 0xfb,
+	0xc7, 0x33, 0x21, 0x66, 0x29, 0x3b, 0x76, 0xc5, 0x7e, 0x7c, 0xee, 0x6a, 0x9b, 0x3a, 0xa8, 0xb6,
+	0x2a, 0x17, 0x09, 0x5a, 0x75, 0xaf, 0xb7, 0xb2, 0x50, 0x1d, 0xcd, 0xb1, 0x48, 0x2e, 0x83, 0x9e,
+	0x89, 0xa6, 0x1e, 0xeb, 0xb8, 0x2b, 0xae, 0x52, 0x16, 0xf8, 0x26, 0xee, 0x38, 0x21, 0x3f, 0x82,
+	0x41, 0x2e, 0xa2, 0x2a, 0x6d, 0xc1, 0x1e, 0x86, 0xb3, 0x9f, 0x8b, 0x2a, 0xa9, 0x1a, 0x52, 0xe9,
+	0x23, 0x9e, 0x04, 0x43, 0x8c, 0x6d, 0xbf, 0x92, 0x9d, 0x25, 0xe4, 0x3e, 0xec, 0x2c, 0x21, 0x79,
+	0x99, 0x05, 0xef, 0x21, 0x66, 0x69, 0xf7, 0xb2, 0xcc, 0xc8, 0x27, 0xb0, 0xb7, 0x04, 0x19, 0x57,
+	0x08, 0xba, 0xb2, 0x5b, 0x89, 0xcf, 0xd1, 0xa7, 0xcf, 0xa0, 0x3b, 0x49, 0x45, 0xc1, 0x92, 0xe0,
+	0xd6, 0x5a, 0xd2, 0xbe, 0x12, 0x22, 0x3d, 0x9d, 0xc7, 0xf9, 0x8c, 0x51, 0x0b, 0xd1, 0xe0, 0x54,
+	0x4c, 0xde, 0xb0, 0x24, 0xb8, 0xf7, 0x0e, 0xb0, 0x81, 0xe8, 0xa3, 0x2c, 0xca, 0x34, 0x8d, 0x24,
+	0xfb, 0x5d, 0xc9, 0x0a, 0x15, 0x7c, 0x60, 0x4e, 0xab, 0x65, 0xd4, 0x88, 0xc8, 0xcf, 0xc1, 0x37,
+	0x2b, 0x47, 0xb1, 0x0a, 0x6e, 0x5f, 0x1b, 0x72, 0xcf, 0x80, 0x9f, 0x2a, 0xf2, 0x45, 0x65, 0x38,
+	0xbe, 0x0c, 0xee, 0x5c, 0x5d, 0x6d, 0xd6, 0xe2, 0xab, 0x4b, 0xed, 0x8d, 0x64, 0x99, 0xb8, 0x60,
+	0x11, 0x5e, 0xb6, 0xe0, 0x2e, 0x56, 0x4e, 0xdf, 0xc8, 0xf0, 0x1a, 0x62, 0x51, 0x26, 0x89, 0xd5,
+	0x07, 0xef, 0xb8, 0xaf, 0x5e, 0x9c, 0x24, 0xc6, 0xe4, 0x17, 0xd0, 0x9b, 0x88, 0x2c, 0x63, 0xb9,
+	0x0a, 0x3c, 0x34, 0xb8, 0xbf, 0x8d, 0xfe, 0x4e, 0x0d, 0xa4, 0xa2, 0x1d, 0x67, 0x43, 0x9e, 0xc3,
+	0xae, 0x1d, 0x46, 0x85, 0x8a, 0x55, 0x59, 0x04, 0xbb, 0x78, 0x96, 0xc3, 0x6d, 0xab, 0xbc, 0xba,
+	0xcc, 0x27, 0xaf, 0x10, 0x45, 0x77, 0xac, 0x95, 0x99, 0x92, 0x13, 0xe8, 0xb0, 0x0b, 0xed, 0xc3,
+	0x3e, 0xfa, 0x70, 0x6f, 0x9b, 0xf5, 0x73, 0x0d, 0xa0, 0x06, 0x47, 0x9e, 0xc2, 0x80, 0x5d, 0xd4,
+	0x76, 0x3d, 0xb8, 0xd1, 0xae, 0x7d, 0xb4, 0xb1, 0x7b, 0x7e, 0x0e, 0x5d, 0xc9, 0x2e, 0x38, 0x7b,
+	0x1b, 0x7c, 0x88, 0x9b, 0xde, 0x5e, 0x33, 0xa6, 0xa8, 0xa4, 0x16, 0x44, 0x7e, 0x03, 0xbb, 0x66,
+	0x14, 0xb9, 0x78, 0x1d, 0xa2, 0xd9, 0xc7, 0x5b, 0xcd, 0xd6, 0x03, 0xb6, 0x23, 0xeb, 0x62, 0x72,
+	0x0a, 0x56, 0xe0, 0xfc, 0xff, 0xe8, 0x46, 0xfe, 0x0f, 0x8c, 0x91, 0x99, 0x85, 0x87, 0x00, 0xcb,
+	0xa2, 0x25, 0x43, 0x68, 0x5d, 0xc4, 0x29, 0xd2, 0xa4, 0x47, 0xf5, 0x30, 0x7c, 0x08, 0xfd, 0x5a,
+	0xce, 0x2d, 0xd5, 0x35, 0x2a, 0xaa, 0x23, 0xd0, 0xce, 0xe3, 0x8c, 0x39, 0x0e, 0xd5, 0xe3, 0xf0,
+	0x0f, 0xb0, 0xb7, 0x46, 0xd2, 0x1b, 0x66, 0x15, 0x31, 0x34, 0xeb, 0xc4, 0xb0, 0xbc, 0x84, 0xad,
+	0xeb, 0x2f, 0xe1, 0x92, 0xa9, 0xdb, 0xb8, 0xac, 0x9d, 0x85, 0x7f, 0xed, 0xc0, 0x70, 0x3d, 0xe1,
+	0x1b, 0xfb, 0x7f, 0x08, 0x60, 0x32, 0xaf, 0x9f, 0x7a, 0xeb, 0x84, 0x8f, 0x92, 0xf3, 0xcb, 0x05,
+	0x23, 0xf7, 0xc0, 0x8b, 0x27, 0x4a, 0xc8, 0x88, 0x1b, 0x57, 0x5a, 0xb4, 0x87, 0xf3, 0xb3, 0xa4,
+	0x4e, 0xa9, 0xed, 0x9b, 0x53, 0xea, 0xa7, 0xd0, 0x31, 0xf7, 0xa9, 0xb3, 0xf9, 0x68, 0x57, 0xf7,
+	0xc9, 0x40, 0xc8, 0x97, 0xe0, 0x2f, 0xb9, 0xd1, 0x10, 0xf0, 0xd5, 0xaf, 0xdf, 0x12, 0x4a, 0x3e,
+	0x82, 0xbe, 0x7b, 0x11, 0xb4, 0xdf, 0x3d, 0xf4, 0x1b, 0x9c, 0xe8, 0x2c, 0xa9, 0x01, 0xf0, 0x60,
+	0xde, 0x0a, 0x40, 0x9f, 0xed, 0x73, 0xe8, 0xea, 0xb2, 0xe4, 0x0a, 0xf9, 0x7a, 0xb3, 0x98, 0x4f,
+	0x51, 0x49, 0x2d, 0x48, 0xaf, 0x27, 0x99, 0xce, 0x78, 0x34, 0x95, 0x22, 0x0b, 0xfa, 0x18, 0x45,
+	0x30, 0xa2, 0xaf, 0xa5, 0xc8, 0xf4, 0xa3, 0x69, 0x01, 0x4a, 0xe0, 0xf3, 0xe8, 0x53, 0xcf, 0x08,
+	0xce, 0x85, 0xb1, 0xd6, 0x85, 0x68, 0xbc, 0xd9, 0x31, 0xde, 0x38, 0xd1, 0x59, 0x42, 0x8e, 0xe1,
+	0x96, 0x2d, 0x6f, 0x4b, 0x9d, 0x06, 0xb8, 0x8b, 0xc0, 0xf7, 0x8c, 0x8a, 0x3a, 0xcd, 0x59, 0x42,
+	0x1e, 0xc3, 0x8e, 0x62, 0x71, 0x16, 0xb9, 0x25, 0x82, 0xe1, 0x5a, 0x11, 0x99, 0x43, 0x9c, 0xb3,
+	0x38, 0xa3, 0x03, 0x8d, 0xa4, 0x16, 0x48, 0x5e, 0xc2, 0x30, 0xe1, 0x45, 0xc6, 0x0b, 0xcd, 0xa4,
+	0xf6, 0x3a, 0xef, 0x8d, 0x1a, 0x5b, 0x78, 0xec, 0x99, 0x83, 0x19, 0x5b, 0xc3, 0x26, 0x7b, 0xc9,
+	0xaa, 0x54, 0x57, 0x97, 0x50, 0x73, 0x26, 0xa3, 0xef, 0x0a, 0x91, 0x07, 0x30, 0x6a, 0x1c, 0x0d,
+	0xa8, 0x8f, 0x92, 0x6f, 0x0a, 0x91, 0x87, 0x7f, 0x6a, 0xc0, 0xfe, 0xd5, 0xcb, 0x99, 0xa8, 0xe1,
+	0xb9, 0xab, 0x92, 0xf5, 0x8c, 0xe0, 0x2c, 0xc1, 0xe7, 0xdf, 0x18, 0xc5, 0x69, 0x94, 0xb1, 0xa2,
+	0x88, 0x67, 0x0c, 0x4b, 0xd4, 0xa7, 0xc3, 0x4a, 0xf1, 0xad, 0x91, 0xeb, 0x5b, 0xa6, 0x99, 0x81,
+	0x61, 0xa5, 0xfa, 0xd4, 0x4c, 0xbe, 0x69, 0x7b, 0xcd, 0x61, 0x2b, 0x7c, 0x0d, 0x83, 0x7a, 0x52,
+	0xbf, 0x47, 0x8b, 0x74, 0x00, 0xbe, 0x29, 0x00, 0x77, 0x3b, 0x7c, 0xea, 0x19, 0xc1, 0x59, 0x12,
+	0x9e, 0xc3, 0xed, 0xad, 0xac, 0x43, 0x9e, 0x40, 0xbf, 0x60, 0xf2, 0x82, 0xc9, 0x48, 0xb7, 0x0b,
+	0x41, 0xe3, 0xda, 0xbb, 0x03, 0x06, 0xfe, 0x2c, 0x56, 0x2c, 0xfc, 0x57, 0x15, 0xb1, 0x6d, 0x0f,
+	0xc9, 0xc6, 0xed, 0x76, 0xfd, 0x57, 0xf3, 0xba, 0xfe, 0xcb, 0xf5, 0x2c, 0xad, 0x5a, 0xcf, 0xf2,
+	0xc3, 0x2e, 0x78, 0xad, 0x67, 0xea, 0xdc, 0xb8, 0x67, 0x0a, 0xff, 0xd9, 0x70, 0x59, 0xb0, 0x95,
+	0xf3, 0x7f, 0x3d, 0xc9, 0xd6, 0xf4, 0xaf, 0xa6, 0xaf, 0xb3, 0x9a, 0x3e, 0xf2, 0x18, 0xfc, 0xa2,
+	0x1c, 0x67, 0x5c, 0xdd, 0xac, 0xf9, 0x5b, 0x82, 0xc3, 0x7f, 0xb7, 0xe0, 0xe0, 0x1d, 0x6f, 0xd7,
+	0xc6, 0xc9, 0x56, 0xaa, 0xbc, 0xb9, 0x56, 0xe5, 0x87, 0xd0, 0xe7, 0x79, 0x24, 0xd9, 0x22, 0xbd,
+	0xd4, 0xd4, 0x61, 0x28, 0xd8, 0xe7, 0x39, 0xd5, 0x92, 0x73, 0x71, 0xf3, 0x06, 0xdb, 0x85, 0xa5,
+	0x53, 0x0b, 0x0b, 0x81, 0xf6, 0x22, 0x56, 0x73, 0x3c, 0x9e, 0x4f, 0x71, 0xac, 0xbf, 0x7c, 0x16,
+	0xa2, 0xe0, 0xda, 0x53, 0x24, 0xce, 0x0e, 0xad, 0xe6, 0xfa, 0xca, 0x09, 0xc9, 0x67, 0x3c, 0x8f,
+	0xd3, 0xa8, 0x02, 0x79, 0x08, 0x1a, 0x3a, 0xc5, 0x6f, 0x1d, 0x78, 0x25, 0xba, 0xfe, 0x5a, 0x74,
+	0x1f, 0x00, 0xa9, 0x56, 0x5a, 0xa2, 0xc0, 0xdc, 0x5e, 0xa7, 0x39, 0x75, 0xe8, 0x03, 0xf0, 0x13,
+	0x3e, 0x9d, 0x46, 0xf3, 0x32, 0x7f, 0x63, 0xc9, 0xd5, 0xd3, 0x82, 0x5f, 0x97, 0xf9, 0x9b, 0x7a,
+	0x95, 0x0e, 0x7e, 0x50, 0x95, 0xee, 0xdc, 0xbc, 0x4a, 0x1f, 0x01, 0x2c, 0x03, 0xbb, 0xed, 0x29,
+	0x4f, 0xc5, 0x8c, 0xe7, 0xee, 0x29, 0xc7, 0x49, 0xf8, 0x05, 0xc0, 0x92, 0x6e, 0xb7, 0x75, 0x0d,
+	0x45, 0x5a, 0xce, 0x1c, 0xad, 0xe8, 0x71, 0xf8, 0xe7, 0x06, 0x76, 0x1a, 0x55, 0xc1, 0x7c, 0x6c,
+	0xa9, 0xc7, 0x30, 0xc5, 0xb0, 0x9e, 0x63, 0xca, 0x16, 0xc2, 0x92, 0xd1, 0xa7, 0xd5, 0x93, 0x65,
+	0xae, 0x08, 0xa9, 0xe3, 0xd6, 0xde, 0xab, 0xfb, 0x7a, 0xc5, 0xa9, 0xfb, 0x06, 0xdd, 0x5b, 0x5d,
+	0x71, 0x4a, 0x51, 0x19, 0x7e, 0x09, 0x3d, 0xbb, 0x03, 0xb9, 0x0b, 0xbd, 0x99, 0x88, 0x2a, 0x27,
+	0x7c, 0xda, 0x9d, 0x09, 0x54, 0x6c, 0x6b, 0x7a, 0x12, 0xf0, 0xab, 0x1d, 0xf1, 0x7c, 0xf3, 0xf8,
+	0xa1, 0x35, 0xc3, 0xb1, 0x6e, 0xad, 0x64, 0xfc, 0x16, 0x6d, 0x06, 0x54, 0x0f, 0x75, 0xa3, 0x8d,
+	0x09, 0x56, 0x92, 0xb1, 0xa0, 0xb5, 0xd9, 0x18, 0x3c, 0xe3, 0xd3, 0xe9, 0xb9, 0x64, 0xcc, 0xa4,
+	0x5d, 0x8f, 0xc2, 0x27, 0xd0, 0xaf, 0x29, 0xc8, 0x03, 0x68, 0x4f, 0x79, 0xaa, 0xd9, 0x74, 0xe3,
+	0x1b, 0xd9, 0x61, 0xbe, 0xe6, 0x29, 0xa3, 0x88, 0x0a, 0x33, 0xd8, 0x5b, 0x53, 0x68, 0x47, 0xed,
+	0x02, 0xe8, 0xa8, 0x1e, 0xeb, 0x84, 0xc6, 0x49, 0xc2, 0xdc, 0xad, 0x34, 0x13, 0x12, 0x40, 0xcf,
+	0x7e, 0x5e, 0xba, 0x8e, 0xc8, 0x4e, 0x75, 0x23, 0x36, 0xe6, 0x79, 0x2c, 0x2f, 0xf1, 0x3a, 0x7a,
+	0xd4, 0xce, 0xc2, 0x7f, 0xe8, 0x3f, 0x06, 0x2b, 0x3f, 0x1a, 0xf4, 0x22, 0x0b, 0x29, 0xbe, 0x63,
+	0x13, 0x65, 0x77, 0x74, 0x53, 0xf2, 0xc0, 0x7c, 0x41, 0x70, 0x55, 0x04, 0xcd, 0x51, 0xeb, 0x8a,
+	0x44, 0x3a, 0xc8, 0x8d, 0x32, 0x49, 0x7e, 0x06, 0x9e, 0x6d, 0xb2, 0xdd, 0x4f, 0x84, 0x7b, 0x6b,
+	0x3f, 0x40, 0x4e, 0x5f, 0x58, 0x96, 0x2a, 0x68, 0x05, 0x0d, 0xff, 0xd8, 0x80, 0xe1, 0xba, 0x1a,
+	0xaf, 0x75, 0x1a, 0xd9, 0x7e, 0xb3, 0x61, 0x08, 0x62, 0x92, 0xbe, 0xac, 0xfe, 0x0d, 0x64, 0x4c,
+	0xc5, 0x11, 0xa6, 0xdc, 0xd4, 0x84, 0xa7, 0x05, 0xaf, 0x74, 0xda, 0x1f, 0xd5, 0xbc, 0x30, 0xee,
+	0xde, 0x59, 0xf7, 0xc2, 0xa8, 0x6b, 0x2e, 0xfc, 0xb7, 0x09, 0x3b, 0x2b, 0x3a, 0x9d, 0xa7, 0xb2,
+	0xb4, 0x57, 0xc8, 0xa7, 0x38, 0xd6, 0x0d, 0xd4, 0x22, 0x96, 0xba, 0x89, 0x45, 0x95, 0xd9, 0x18,
+	0x8c, 0xe8, 0x75, 0x69, 0x28, 0x76, 0x11, 0xab, 0xc9, 0x3c, 0x2a, 0x98, 0xb2, 0xbf, 0x33, 0x3c,
+	0x14, 0xbc, 0x62, 0xaa, 0xca, 0x7c, 0xbb, 0x96, 0x79, 0x02, 0xed, 0x94, 0xe7, 0x0c, 0xd9, 0xb2,
+	0x43, 0x71, 0x4c, 0x1e, 0x42, 0x47, 0xea, 0xbe, 0xdb, 0xbe, 0x06, 0x07, 0x57, 0x38, 0xaf, 0x21,
+	0xd4, 0x20, 0xb1, 0xfa, 0x79, 0xc2, 0x2c, 0x91, 0xe2, 0x58, 0xfb, 0x12, 0x97, 0x6a, 0x2e, 0x6a,
+	0x9d, 0xa7, 0x67, 0x04, 0xa6, 0xa7, 0x7e, 0x2b, 0xb9, 0x52, 0x2c, 0x0f, 0xfc, 0xeb, 0x69, 0xc9,
+	0x42, 0x75, 0x31, 0xb9, 0x06, 0xc8, 0x50, 0xa8, 0x9b, 0x92, 0x43, 0x80, 0x32, 0x97, 0xac, 0x10,
+	0xe9, 0x05, 0x4b, 0x90, 0x3a, 0x3d, 0x5a, 0x93, 0xe8, 0xf6, 0x5e, 0xb2, 0x0b, 0x93, 0x2f, 0xd3,
+	0x96, 0xf6, 0x24, 0xbb, 0xd0, 0xe9, 0x0a, 0xff, 0xd2, 0x00, 0xb2, 0x79, 0x32, 0xdd, 0xd1, 0x15,
+	0x2a, 0x96, 0x2a, 0xc2, 0xf8, 0x98, 0x02, 0xf0, 0x51, 0xf2, 0x82, 0xe7, 0x35, 0xf5, 0x64, 0x1e,
+	0x9b, 0xc7, 0xda, 0xa9, 0x4f, 0xe7, 0xb1, 0xd4, 0xfb, 0xb1, 0x3c, 0x31, 0xb6, 0x26, 0x0f, 0x3d,
+	0x96, 0x27, 0x68, 0x69, 0x55, 0x68, 0xd7, 0xae, 0x54, 0xda, 0x2a, 0x3c, 0x86, 0xae, 0xa9, 0x67,
+	0xa4, 0x0e, 0x36, 0xb5, 0xc9, 0xd7, 0xc3, 0x8a, 0x60, 0x9a, 0x4b, 0x82, 0x19, 0x77, 0x31, 0x58,
+	0x3f, 0xf9, 0xdf, 0x00, 0x59, 0xa2, 0x66, 0x57, 0xf4, 0x14, 0x00, 0x00,
 }
@@ -20,33 +20,75 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang/protobuf/proto"
 	"golang.org/x/build/maintner/maintpb"
 	"golang.org/x/build/maintner/reclog"
+	"golang.org/x/sync/errgroup"
 )
 
 // NewNetworkMutationSource returns a mutation source from a master server.
 // The server argument should be a URL to the JSON logs index.
-func NewNetworkMutationSource(server, cacheDir string) MutationSource {
+func NewNetworkMutationSource(server, cacheDir string, opts ...NetworkOption) MutationSource {
 	base, err := url.Parse(server)
 	if err != nil {
 		panic(fmt.Sprintf("invalid URL: %q", server))
 	}
-	return &netMutSource{
-		server:   server,
-		base:     base,
-		cacheDir: cacheDir,
+	ns := &netMutSource{
+		server:      server,
+		base:        base,
+		cacheDir:    cacheDir,
+		concurrency: 4,
 	}
+	for _, opt := range opts {
+		opt(ns)
+	}
+	return ns
+}
+
+// A NetworkOption configures a mutation source returned by
+// NewNetworkMutationSource.
+type NetworkOption func(*netMutSource)
+
+// WithDownloadConcurrency sets how many log segments are downloaded
+// at once. The default is 4.
+func WithDownloadConcurrency(n int) NetworkOption {
+	return func(ns *netMutSource) {
+		if n < 1 {
+			n = 1
+		}
+		ns.concurrency = n
+	}
+}
+
+// WithDownloadProgress sets a function to be called as log segments
+// are synced to the cache directory, such as to show a progress bar
+// during the first, large download. The calls are not concurrent.
+func WithDownloadProgress(fn func(DownloadProgress)) NetworkOption {
+	return func(ns *netMutSource) { ns.progress = fn }
+}
+
+// DownloadProgress is the progress of syncing the server's log
+// segments to the cache directory. Segments already in the cache
+// count as done.
+type DownloadProgress struct {
+	Segments     int   // number of segments in the server's log
+	SegmentsDone int   // number of them synced
+	Bytes        int64 // total size of the server's log
+	BytesDone    int64 // bytes of it synced
 }
 
 type netMutSource struct {
-	server   string
-	base     *url.URL
-	cacheDir string
+	server      string
+	base        *url.URL
+	cacheDir    string
+	concurrency int                    // segments to download at once
+	progress    func(DownloadProgress) // or nil
 
-	last []fileSeg
+	last    []fileSeg
+	resumed bool // last is from resumeAfter; don't wait for new segments
 
 	// Hooks for testing. If nil, unused:
 	testHookGetServerSegments      func(context.Context, int64) ([]LogSegmentJSON, error)
@@ -201,40 +243,46 @@ func (ns *netMutSource) getServerSegments(ctx context.Context, waitSizeNot int64
 func (ns *netMutSource) getNewSegments(ctx context.Context) ([]fileSeg, error) {
 	for {
 		sumLast := sumSegSize(ns.last)
+		waitSizeNot := sumLast
+		if ns.resumed {
+			// Resuming after a snapshot; return what's new
+			// without waiting for more.
+			waitSizeNot = 0
+		}
 
-		segs, err := ns.getServerSegments(ctx, sumLast)
+		segs, err := ns.getServerSegments(ctx, waitSizeNot)
+		var fileSegs []fileSeg
 		if isNoInternetError(err) {
 			if sumLast == 0 {
 				return ns.locallyCachedSegments()
 			}
-			log.Printf("No internet; blocking.")
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(15 * time.Second):
-				continue
+			if !ns.resumed {
+				log.Printf("No internet; blocking.")
+				select {
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				case <-time.After(15 * time.Second):
+					continue
+				}
 			}
+			fileSegs, err = ns.locallyCachedSegments()
+		} else if err == nil {
+			fileSegs, err = ns.syncSegs(ctx, segs)
 		}
 		if err != nil {
 			return nil, err
 		}
-		// TODO: optimization: if already on GCE, skip sync to disk part and just
-		// read from network. fast & free network inside.
-
-		var fileSegs []fileSeg
-		for _, seg := range segs {
-			fileSeg, err := ns.syncSeg(ctx, seg)
-			if err != nil {
-				return nil, fmt.Errorf("syncing segment %d: %v", seg.Number, err)
-			}
-			fileSegs = append(fileSegs, fileSeg)
-		}
 		sumCommon := ns.sumCommonPrefixSize(fileSegs, ns.last)
 		if sumLast != sumCommon {
 			return nil, ErrSplit
 		}
 		sumCur := sumSegSize(fileSegs)
 		if sumCommon == sumCur {
+			if ns.resumed {
+				// Nothing new since the snapshot.
+				ns.resumed = false
+				return nil, nil
+			}
 			// Nothing new. This shouldn't happen once the
 			// server is updated to respect the
 			// "?waitsizenot=NNN" long polling parameter.
@@ -251,6 +299,7 @@ func (ns *netMutSource) getNewSegments(ctx context.Context) ([]fileSeg, error) {
 			}
 			continue
 		}
+		ns.resumed = false
 		ns.last = fileSegs
 
 		newSegs := trimLeadingSegBytes(fileSegs, sumCommon)
@@ -258,6 +307,174 @@ func (ns *netMutSource) getNewSegments(ctx context.Context) ([]fileSeg, error) {
 	}
 }
 
+// syncSegs syncs the server's segments segs to the cache directory,
+// downloading up to ns.concurrency of them at once.
+func (ns *netMutSource) syncSegs(ctx context.Context, segs []LogSegmentJSON) ([]fileSeg, error) {
+	// TODO: optimization: if already on GCE, skip sync to disk part and just
+	// read from network. fast & free network inside.
+
+	pt := &progressTracker{fn: ns.progress}
+	pt.p.Segments = len(segs)
+	for _, seg := range segs {
+		pt.p.Bytes += seg.Size
+	}
+	pt.report()
+
+	concurrency := ns.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	fileSegs := make([]fileSeg, len(segs))
+	grp, ctx := errgroup.WithContext(ctx)
+	for i, seg := range segs {
+		i, seg := i, seg
+		grp.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			defer func() { <-sem }()
+			fs, err := ns.syncSeg(ctx, seg, pt)
+			if err != nil {
+				return fmt.Errorf("syncing segment %d: %v", seg.Number, err)
+			}
+			fileSegs[i] = fs
+			pt.segDone(seg.Number, seg.Size)
+			return nil
+		})
+	}
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+	return fileSegs, nil
+}
+
+// progressTracker tracks and reports the progress of syncSegs.
+type progressTracker struct {
+	fn func(DownloadProgress) // or nil
+
+	mu      sync.Mutex
+	p       DownloadProgress
+	segDown map[int]int64 // segment number => bytes of it counted in p.BytesDone
+}
+
+func (pt *progressTracker) report() {
+	if pt.fn != nil {
+		pt.fn(pt.p)
+	}
+}
+
+// add records that seg now has n more bytes.
+func (pt *progressTracker) add(seg int, n int64) {
+	if pt == nil {
+		return
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if pt.segDown == nil {
+		pt.segDown = map[int]int64{}
+	}
+	pt.segDown[seg] += n
+	pt.p.BytesDone += n
+	pt.report()
+}
+
+// reset records that the bytes downloaded of seg were discarded.
+func (pt *progressTracker) reset(seg int) {
+	if pt == nil {
+		return
+	}
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.p.BytesDone -= pt.segDown[seg]
+	delete(pt.segDown, seg)
+}
+
+// segDone records that seg, of the given size, is synced.
+func (pt *progressTracker) segDone(seg int, size int64) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	pt.p.SegmentsDone++
+	pt.p.BytesDone += size - pt.segDown[seg]
+	delete(pt.segDown, seg)
+	pt.report()
+}
+
+// progressWriter is an io.Writer that reports the bytes written of a
+// segment to a progressTracker.
+type progressWriter struct {
+	w   io.Writer
+	pt  *progressTracker // or nil
+	seg int
+}
+
+func (w progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.pt.add(w.seg, int64(n))
+	return n, err
+}
+
+// snapshotDir implements snapshotSource. Snapshots are kept with the
+// cached log segments.
+func (ns *netMutSource) snapshotDir() string { return ns.cacheDir }
+
+// logPrefix implements snapshotSource. Segments are named by their
+// numbers.
+func (ns *netMutSource) logPrefix(pos logPos) ([]logSeg, error) {
+	var prefix []logSeg
+	for _, seg := range ns.last {
+		name := strconv.Itoa(seg.seg)
+		if name != pos.seg {
+			prefix = append(prefix, logSeg{Name: name, Size: seg.size, SHA224: seg.sha224})
+			continue
+		}
+		sum := seg.sha224
+		if pos.off != seg.size {
+			sum = ns.filePrefixSum224(seg.file, pos.off)
+		}
+		if sum == "" {
+			return nil, fmt.Errorf("can't hash first %d bytes of segment %s", pos.off, name)
+		}
+		return append(prefix, logSeg{Name: name, Size: pos.off, SHA224: sum}), nil
+	}
+	return nil, fmt.Errorf("segment %s not found", pos.seg)
+}
+
+// resumeAfter implements snapshotSource. It syncs the server's log
+// segments, or uses the cached ones if offline, to check that the log
+// still begins with prefix.
+func (ns *netMutSource) resumeAfter(ctx context.Context, prefix []logSeg) error {
+	ns.last, ns.resumed = nil, false
+	if len(prefix) == 0 {
+		return nil
+	}
+	want := make([]fileSeg, len(prefix))
+	for i, p := range prefix {
+		num, err := strconv.Atoi(p.Name)
+		if err != nil || num != i {
+			return fmt.Errorf("bad segment name %q", p.Name)
+		}
+		want[i] = fileSeg{seg: num, size: p.Size, sha224: p.SHA224}
+	}
+	segs, err := ns.getServerSegments(ctx, 0)
+	var have []fileSeg
+	if isNoInternetError(err) {
+		have, err = ns.locallyCachedSegments()
+	} else if err == nil {
+		have, err = ns.syncSegs(ctx, segs)
+	}
+	if err != nil {
+		return err
+	}
+	if ns.sumCommonPrefixSize(want, have) != sumSegSize(want) {
+		return errors.New("log doesn't begin with the snapshot's prefix")
+	}
+	ns.last, ns.resumed = want, true
+	return nil
+}
+
 func trimLeadingSegBytes(in []fileSeg, trim int64) []fileSeg {
 	// First trim off whole segments, sharing the same underlying memory.
 	for len(in) > 0 && trim >= in[0].size {
@@ -347,13 +564,19 @@ func (ns *netMutSource) sendMutations(ctx context.Context, ch chan<- MutationStr
 				return err
 			}
 		}
+		name := strconv.Itoa(seg.seg)
+		prev := seg.skip
 		return reclog.ForeachRecord(io.LimitReader(f, seg.size-seg.skip), seg.skip, func(off int64, hdr, rec []byte) error {
 			m := new(maintpb.Mutation)
 			if err := proto.Unmarshal(rec, m); err != nil {
 				return err
 			}
+			// n includes any checksum record before this one.
+			end := off + int64(len(hdr)+len(rec))
+			n := end - prev
+			prev = end
 			select {
-			case ch <- MutationStreamEvent{Mutation: m}:
+			case ch <- MutationStreamEvent{Mutation: m, pos: logPos{name, end}, n: n}:
 				return nil
 			case <-ctx.Done():
 				return ctx.Err()
@@ -381,12 +604,16 @@ type fileSeg struct {
 	size   int64
 }
 
-func (ns *netMutSource) syncSeg(ctx context.Context, seg LogSegmentJSON) (fileSeg, error) {
+// syncSeg syncs the server's segment seg to the cache directory,
+// reporting bytes downloaded to pt, which may be nil.
+func (ns *netMutSource) syncSeg(ctx context.Context, seg LogSegmentJSON, pt *progressTracker) (fileSeg, error) {
 	if fn := ns.testHookSyncSeg; fn != nil {
 		return fn(ctx, seg)
 	}
 
-	isFinalSeg := !strings.HasPrefix(seg.URL, "https://storage.googleapis.com/")
+	// Frozen segments are served from URLs naming them by their
+	// hash, wherever they're stored; the growing one isn't.
+	isFinalSeg := !strings.Contains(seg.URL, seg.SHA224)
 	relURL, err := url.Parse(seg.URL)
 	if err != nil {
 		return fileSeg{}, err
@@ -401,19 +628,16 @@ func (ns *netMutSource) syncSeg(ctx context.Context, seg LogSegmentJSON) (fileSe
 		return fileSeg{seg: seg.Number, file: frozen, size: fi.Size(), sha224: seg.SHA224}, nil
 	}
 
-	// See how much data we already have in the partial growing file.
 	partial := filepath.Join(ns.cacheDir, fmt.Sprintf("%04d.growing.mutlog", seg.Number))
+	if !isFinalSeg {
+		return ns.syncFrozenSeg(ctx, seg, segURL.String(), frozen, partial, pt)
+	}
+
+	// See how much data we already have in the partial growing file.
 	have, _ := ioutil.ReadFile(partial)
 	if int64(len(have)) == seg.Size {
 		got224 := fmt.Sprintf("%x", sha256.Sum224(have))
 		if got224 == seg.SHA224 {
-			if !isFinalSeg {
-				// This was growing for us, but the server started a new growing segment.
-				if err := os.Rename(partial, frozen); err != nil {
-					return fileSeg{}, err
-				}
-				return fileSeg{seg: seg.Number, file: frozen, sha224: seg.SHA224, size: seg.Size}, nil
-			}
 			return fileSeg{seg: seg.Number, file: partial, sha224: seg.SHA224, size: seg.Size}, nil
 		}
 	}
@@ -439,6 +663,7 @@ func (ns *netMutSource) syncSeg(ctx context.Context, seg LogSegmentJSON) (fileSe
 	if err != nil {
 		return fileSeg{}, err
 	}
+	pt.add(seg.Number, int64(len(slurp)))
 
 	var newContents []byte
 	if int64(len(slurp)) == seg.Size {
@@ -453,7 +678,8 @@ func (ns *netMutSource) syncSeg(ctx context.Context, seg LogSegmentJSON) (fileSe
 		}
 		// Try again
 		os.Remove(partial)
-		return ns.syncSeg(ctx, seg)
+		pt.reset(seg.Number)
+		return ns.syncSeg(ctx, seg, pt)
 	}
 	tf, err := ioutil.TempFile(ns.cacheDir, "tempseg")
 	if err != nil {
@@ -465,15 +691,136 @@ func (ns *netMutSource) syncSeg(ctx context.Context, seg LogSegmentJSON) (fileSe
 	if err := tf.Close(); err != nil {
 		return fileSeg{}, err
 	}
-	finalName := partial
-	if !isFinalSeg {
-		finalName = frozen
-	}
-	if err := os.Rename(tf.Name(), finalName); err != nil {
+	if err := os.Rename(tf.Name(), partial); err != nil {
 		return fileSeg{}, err
 	}
-	log.Printf("wrote %v", finalName)
-	return fileSeg{seg: seg.Number, file: finalName, size: seg.Size, sha224: seg.SHA224}, nil
+	log.Printf("wrote %v", partial)
+	return fileSeg{seg: seg.Number, file: partial, size: seg.Size, sha224: seg.SHA224}, nil
+}
+
+// syncFrozenSeg downloads the frozen segment seg from segURL to the
+// file frozen. The download goes to a partial file first, which
+// later syncs resume if it's interrupted; if the segment was growing
+// when last synced, its growing file is the start of the download.
+func (ns *netMutSource) syncFrozenSeg(ctx context.Context, seg LogSegmentJSON, segURL, frozen, growing string, pt *progressTracker) (fileSeg, error) {
+	partial := strings.TrimSuffix(frozen, ".mutlog") + ".partial"
+	if _, err := os.Stat(partial); os.IsNotExist(err) {
+		if fi, err := os.Stat(growing); err == nil && fi.Size() <= seg.Size {
+			if err := os.Rename(growing, partial); err != nil {
+				return fileSeg{}, err
+			}
+		}
+	}
+	const maxTries = 3
+	for try := 1; ; try++ {
+		resumed, err := ns.downloadSeg(ctx, seg, segURL, partial, pt)
+		if err == nil {
+			var got224 string
+			got224, err = fileSum224(partial)
+			if err == nil && got224 != seg.SHA224 {
+				os.Remove(partial)
+				pt.reset(seg.Number)
+				if !resumed {
+					return fileSeg{}, errors.New("corrupt download")
+				}
+				// What we had didn't start the segment;
+				// start over.
+				err = fmt.Errorf("SHA-224 %s of resumed download; want %s", got224, seg.SHA224)
+			}
+		}
+		if err == nil {
+			if err := os.Rename(partial, frozen); err != nil {
+				return fileSeg{}, err
+			}
+			log.Printf("wrote %v", frozen)
+			return fileSeg{seg: seg.Number, file: frozen, size: seg.Size, sha224: seg.SHA224}, nil
+		}
+		if ctx.Err() != nil || try == maxTries {
+			return fileSeg{}, err
+		}
+		log.Printf("Downloading %s: %v; retrying", segURL, err)
+	}
+}
+
+// downloadSeg downloads the rest of seg from segURL, appending it to
+// the file partial. It reports whether partial already had some of
+// seg's data.
+func (ns *netMutSource) downloadSeg(ctx context.Context, seg LogSegmentJSON, segURL, partial string, pt *progressTracker) (resumed bool, err error) {
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	have := fi.Size()
+	if have > seg.Size {
+		if err := f.Truncate(0); err != nil {
+			return false, err
+		}
+		have = 0
+	}
+	pt.reset(seg.Number)
+	pt.add(seg.Number, have)
+	if have == seg.Size {
+		return true, nil
+	}
+
+	req, err := http.NewRequest("GET", segURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	if have > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", have))
+	}
+	log.Printf("Downloading %d bytes of %s ...", seg.Size-have, segURL)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return have > 0, err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusPartialContent && have > 0:
+	case res.StatusCode == http.StatusOK:
+		// The server sent the whole segment.
+		if have > 0 {
+			if err := f.Truncate(0); err != nil {
+				return false, err
+			}
+			pt.reset(seg.Number)
+			have = 0
+		}
+	default:
+		return have > 0, fmt.Errorf("%s: %s", segURL, res.Status)
+	}
+	if _, err := f.Seek(have, io.SeekStart); err != nil {
+		return have > 0, err
+	}
+	n, err := io.Copy(progressWriter{f, pt, seg.Number}, io.LimitReader(res.Body, seg.Size-have))
+	if err == nil && have+n < seg.Size {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return have > 0, err
+	}
+	return have > 0, f.Close()
+}
+
+// fileSum224 returns the lowercase hex SHA-224 of file's contents.
+func fileSum224(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New224()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
 }
 
 type LogSegmentJSON struct {
@@ -5,10 +5,22 @@
 package maintner
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestSumSegSize(t *testing.T) {
@@ -350,3 +362,187 @@ func TestGetNewSegments(t *testing.T) {
 		})
 	}
 }
+
+func TestNetResumeAfter(t *testing.T) {
+	serverSegs := []LogSegmentJSON{
+		{Number: 0, Size: 100, SHA224: "abc"},
+		{Number: 1, Size: 50, SHA224: "def"},
+	}
+	newSource := func() *netMutSource {
+		return &netMutSource{
+			testHookGetServerSegments: func(_ context.Context, waitSizeNot int64) ([]LogSegmentJSON, error) {
+				if waitSizeNot != 0 {
+					t.Errorf("getServerSegments(%d) after resume; want no long poll", waitSizeNot)
+				}
+				return serverSegs, nil
+			},
+			testHookSyncSeg: func(_ context.Context, seg LogSegmentJSON) (fileSeg, error) {
+				return fileSeg{
+					seg:    seg.Number,
+					size:   seg.Size,
+					sha224: seg.SHA224,
+					file:   fmt.Sprintf("/fake/%04d.mutlog", seg.Number),
+				}, nil
+			},
+			testHookFilePrefixSum224: func(file string, n int64) string {
+				if file == "/fake/0001.mutlog" && n == 30 {
+					return "de"
+				}
+				return "XXXX"
+			},
+		}
+	}
+	ctx := context.Background()
+
+	// Resuming in the middle of a segment returns the rest of the log.
+	ns := newSource()
+	prefix := []logSeg{{Name: "0", Size: 100, SHA224: "abc"}, {Name: "1", Size: 30, SHA224: "de"}}
+	if err := ns.resumeAfter(ctx, prefix); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ns.getNewSegments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []fileSeg{{seg: 1, size: 50, sha224: "def", skip: 30, file: "/fake/0001.mutlog"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("after resume, got %+v; want %+v", got, want)
+	}
+	if got, err := ns.logPrefix(logPos{"1", 30}); err != nil || !reflect.DeepEqual(got, prefix) {
+		t.Errorf("logPrefix = %+v, %v; want %+v", got, err, prefix)
+	}
+
+	// Resuming at the end of the log returns nothing, without waiting.
+	ns = newSource()
+	if err := ns.resumeAfter(ctx, []logSeg{{Name: "0", Size: 100, SHA224: "abc"}, {Name: "1", Size: 50, SHA224: "def"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ns.getNewSegments(ctx); err != nil || got != nil {
+		t.Errorf("at end of log, got %+v, %v; want nothing", got, err)
+	}
+
+	// A prefix the log doesn't begin with is rejected.
+	ns = newSource()
+	if err := ns.resumeAfter(ctx, []logSeg{{Name: "0", Size: 100, SHA224: "xyz"}}); err == nil {
+		t.Error("resumeAfter with forked prefix succeeded; want error")
+	}
+}
+
+func TestSyncSegsResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-netsource")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sum224 := func(b []byte) string { return fmt.Sprintf("%x", sha256.Sum224(b)) }
+	seg0 := bytes.Repeat([]byte("zero "), 1000)
+	seg1 := bytes.Repeat([]byte("one "), 1000)
+	seg2 := []byte("two, growing")
+	objs := map[string][]byte{
+		fmt.Sprintf("/logs/0000.%s.mutlog", sum224(seg0)): seg0,
+		fmt.Sprintf("/logs/0001.%s.mutlog", sum224(seg1)): seg1,
+		"/logs/2": seg2,
+	}
+	var mu sync.Mutex
+	var ranges []string
+	inFlight, maxInFlight := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		data, ok := objs[r.URL.Path]
+		ranges = append(ranges, r.URL.Path+" "+r.Header.Get("Range"))
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+	serverSegs := func() []LogSegmentJSON {
+		mu.Lock()
+		defer mu.Unlock()
+		var segs []LogSegmentJSON
+		for path, data := range objs {
+			num, _ := strconv.Atoi(strings.SplitN(strings.TrimPrefix(path, "/logs/"), ".", 2)[0])
+			segs = append(segs, LogSegmentJSON{Number: num, Size: int64(len(data)), SHA224: sum224(data), URL: path})
+		}
+		sort.Slice(segs, func(i, j int) bool { return segs[i].Number < segs[j].Number })
+		return segs
+	}
+
+	// Segment 0 was half downloaded; segment 1's partial file is bogus.
+	if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("0000.%s.partial", sum224(seg0))), seg0[:2000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("0001.%s.partial", sum224(seg1))), []byte("bogus"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var last DownloadProgress
+	ns := NewNetworkMutationSource(srv.URL+"/logs", dir,
+		WithDownloadConcurrency(2),
+		WithDownloadProgress(func(p DownloadProgress) { last = p }),
+	).(*netMutSource)
+	ctx := context.Background()
+	check := func(segs []LogSegmentJSON, wantRanges []string) {
+		t.Helper()
+		mu.Lock()
+		ranges = nil
+		mu.Unlock()
+		fileSegs, err := ns.syncSegs(ctx, segs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, fs := range fileSegs {
+			data, err := ioutil.ReadFile(fs.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sum224(data); got != segs[i].SHA224 || fs.size != segs[i].Size {
+				t.Errorf("segment %d: file %s has SHA-224 %s, size %d; want %s, %d", i, fs.file, got, fs.size, segs[i].SHA224, segs[i].Size)
+			}
+		}
+		mu.Lock()
+		sort.Strings(ranges)
+		if !reflect.DeepEqual(ranges, wantRanges) {
+			t.Errorf("requests = %q; want %q", ranges, wantRanges)
+		}
+		mu.Unlock()
+		want := DownloadProgress{Segments: len(segs), SegmentsDone: len(segs), Bytes: sumSegSize(fileSegs), BytesDone: sumSegSize(fileSegs)}
+		if last != want {
+			t.Errorf("progress = %+v; want %+v", last, want)
+		}
+		if partials, _ := filepath.Glob(filepath.Join(dir, "*.partial")); len(partials) > 0 {
+			t.Errorf("partial files left: %q", partials)
+		}
+	}
+	segs := serverSegs()
+	check(segs, []string{
+		segs[0].URL + " bytes=2000-",
+		segs[1].URL + " ",
+		segs[1].URL + " bytes=5-",
+		"/logs/2 bytes=0-11",
+	})
+	if maxInFlight > 2 {
+		t.Errorf("%d concurrent downloads; want at most 2", maxInFlight)
+	}
+
+	// Segment 2 grows and is frozen; only its new bytes are downloaded.
+	mu.Lock()
+	delete(objs, "/logs/2")
+	seg2 = append(seg2, " and frozen"...)
+	objs[fmt.Sprintf("/logs/0002.%s.mutlog", sum224(seg2))] = seg2
+	mu.Unlock()
+	segs = serverSegs()
+	check(segs, []string{segs[2].URL + " bytes=12-"})
+}
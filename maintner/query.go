@@ -0,0 +1,572 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Query is a parsed search query over GitHub issues or Gerrit CLs,
+// in a syntax like GitHub's and Gerrit's own search boxes:
+//
+//	repo:golang/go is:open label:NeedsFix milestone:Go1.12 updated:>2018-06-01
+//	project:go status:new hashtag:wait-release
+//
+// A query is a list of space-separated terms, all of which must
+// match. A term is either key:value or a bare word, which matches
+// issue titles and CL subjects case-insensitively. A leading '-'
+// negates a term. Values may be double-quoted to include spaces, as
+// in label:"help wanted". Names are matched case-insensitively.
+//
+// Issue queries (see Corpus.ForeachIssueMatching) support:
+//
+//	repo:owner/name
+//	is:open, is:closed, is:pr, is:issue, is:locked
+//	label:name, milestone:title, author:login, assignee:login
+//	no:milestone, no:label, no:assignee
+//	created:, updated:, closed: followed by a date (YYYY-MM-DD),
+//	    optionally prefixed by >, >=, < or <=, or a range a..b
+//
+// CL queries (see Corpus.ForeachCLMatching) support:
+//
+//	project:name (like "go") or project:server/name
+//	status:new, status:merged, status:abandoned, status:draft
+//	is:open (same as status:new), is:merged, is:abandoned, is:wip, is:private
+//	hashtag:name, branch:name, topic:name
+//	owner:ID or owner:name-or-email-substring
+//	created:, updated: as for issues
+//
+// The maintnerd API never returns private CLs, so is:private is only
+// useful to callers with their own Corpus.
+//
+// Queries are answered from indexes maintained as the corpus is
+// updated, and then by checking each candidate against the query.
+type Query struct {
+	raw   string
+	terms []queryTerm
+}
+
+type queryTerm struct {
+	neg bool
+	key string // "" for a bare word
+	val string // lowercased
+
+	// For date terms:
+	from, to time.Time // half-open range; zero means unbounded
+}
+
+// String returns the query as it was parsed.
+func (q *Query) String() string { return q.raw }
+
+var dateKeys = map[string]bool{"created": true, "updated": true, "closed": true}
+
+// ParseQuery parses a query. See Query for the syntax.
+func ParseQuery(s string) (*Query, error) {
+	q := &Query{raw: s}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var tok string
+		tok, s = nextQueryToken(s)
+		var t queryTerm
+		if strings.HasPrefix(tok, "-") && len(tok) > 1 {
+			t.neg = true
+			tok = tok[1:]
+		}
+		if i := strings.IndexByte(tok, ':'); i > 0 {
+			t.key, t.val = strings.ToLower(tok[:i]), tok[i+1:]
+		} else {
+			t.val = tok
+		}
+		t.val = strings.ToLower(unquote(t.val))
+		if t.key != "" && t.val == "" {
+			return nil, fmt.Errorf("query term %q lacks a value", tok)
+		}
+		if dateKeys[t.key] {
+			var err error
+			t.from, t.to, err = parseDateRange(t.val)
+			if err != nil {
+				return nil, fmt.Errorf("query term %q: %v", tok, err)
+			}
+		}
+		q.terms = append(q.terms, t)
+	}
+	return q, nil
+}
+
+// nextQueryToken splits off the first space-separated token of s,
+// which may contain double-quoted spaces.
+func nextQueryToken(s string) (tok, rest string) {
+	inQuote := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == ' ' && !inQuote:
+			return s[:i], s[i+1:]
+		}
+	}
+	return s, ""
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+const queryDateLayout = "2006-01-02"
+
+// parseDateRange parses a date query value into a half-open range
+// [from, to) of times. Either may be zero, for an unbounded range.
+func parseDateRange(v string) (from, to time.Time, err error) {
+	day := func(s string) (time.Time, error) {
+		return time.Parse(queryDateLayout, s)
+	}
+	if i := strings.Index(v, ".."); i >= 0 {
+		if from, err = day(v[:i]); err != nil {
+			return
+		}
+		if to, err = day(v[i+2:]); err != nil {
+			return
+		}
+		return from, to.AddDate(0, 0, 1), nil
+	}
+	switch {
+	case strings.HasPrefix(v, ">="):
+		from, err = day(v[2:])
+	case strings.HasPrefix(v, "<="):
+		to, err = day(v[2:])
+		to = to.AddDate(0, 0, 1)
+	case strings.HasPrefix(v, ">"):
+		from, err = day(v[1:])
+		from = from.AddDate(0, 0, 1)
+	case strings.HasPrefix(v, "<"):
+		to, err = day(v[1:])
+	default:
+		from, err = day(v)
+		to = from.AddDate(0, 0, 1)
+	}
+	return
+}
+
+// inRange reports whether t is in the term's date range.
+func (t *queryTerm) inRange(tm time.Time) bool {
+	if tm.IsZero() {
+		return false
+	}
+	return (t.from.IsZero() || !tm.Before(t.from)) && (t.to.IsZero() || tm.Before(t.to))
+}
+
+var errUnknownTerm = errors.New("unknown query term")
+
+// ForeachIssueMatching calls fn for each issue matching q, in order
+// of repo and issue number. Issues found not to exist never match.
+//
+// If fn returns an error, iteration ends and ForeachIssueMatching
+// returns with that error. It also returns an error if q has terms
+// that don't apply to issues.
+func (c *Corpus) ForeachIssueMatching(q *Query, fn func(*GitHubRepo, *GitHubIssue) error) error {
+	var repos []string
+	for _, t := range q.terms {
+		if _, err := matchIssueTerm(nil, nil, &t); err == errUnknownTerm {
+			return fmt.Errorf("unknown issue query term %s:%s", t.key, t.val)
+		}
+		if t.key == "repo" && !t.neg {
+			repos = append(repos, t.val)
+		}
+	}
+	var matchRepos []*GitHubRepo
+	c.GitHub().ForeachRepo(func(gr *GitHubRepo) error {
+		for _, r := range repos {
+			if strings.ToLower(gr.id.String()) != r {
+				return nil
+			}
+		}
+		matchRepos = append(matchRepos, gr)
+		return nil
+	})
+	for _, gr := range matchRepos {
+		var issues []*GitHubIssue
+		for _, gi := range gr.issueCandidates(q) {
+			if gr.matchIssue(gi, q) {
+				issues = append(issues, gi)
+			}
+		}
+		sort.Slice(issues, func(i, j int) bool { return issues[i].Number < issues[j].Number })
+		for _, gi := range issues {
+			if err := fn(gr, gi); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// issueCandidates returns a superset of the repo's issues matching
+// q, using the repo's index for the query's positive indexed terms.
+func (gr *GitHubRepo) issueCandidates(q *Query) []*GitHubIssue {
+	var sets []map[interface{}]struct{}
+	for _, t := range q.terms {
+		if t.neg {
+			continue
+		}
+		if set, ok := gr.lookupIssueTerm(t); ok {
+			sets = append(sets, set)
+		}
+	}
+	if len(sets) == 0 {
+		s := make([]*GitHubIssue, 0, len(gr.issues))
+		for _, gi := range gr.issues {
+			s = append(s, gi)
+		}
+		return s
+	}
+	var s []*GitHubIssue
+	for item := range intersectSets(sets) {
+		s = append(s, item.(*GitHubIssue))
+	}
+	return s
+}
+
+// lookupIssueTerm returns the set of issues indexed under t, and
+// whether t is indexed at all.
+func (gr *GitHubRepo) lookupIssueTerm(t queryTerm) (map[interface{}]struct{}, bool) {
+	switch t.key {
+	case "is":
+		switch t.val {
+		case "open", "closed", "pr", "issue":
+			return gr.index.lookup("is:" + t.val), true
+		}
+	case "no":
+		if t.val == "milestone" {
+			return gr.index.lookup("no:milestone"), true
+		}
+	case "label":
+		var ids []int64
+		for id, lb := range gr.labels {
+			if strings.ToLower(lb.Name) == t.val {
+				ids = append(ids, id)
+			}
+		}
+		return gr.unionIssueTerms("label:", ids), true
+	case "milestone":
+		var ids []int64
+		for id, ms := range gr.milestones {
+			if strings.ToLower(ms.Title) == t.val {
+				ids = append(ids, id)
+			}
+		}
+		return gr.unionIssueTerms("milestone:", ids), true
+	case "author", "assignee":
+		return gr.unionIssueTerms(t.key+":", gr.github.userIDsWithLogin(t.val)), true
+	}
+	return nil, false
+}
+
+func (gr *GitHubRepo) unionIssueTerms(prefix string, ids []int64) map[interface{}]struct{} {
+	if len(ids) == 1 {
+		return gr.index.lookup(prefix + strconv.FormatInt(ids[0], 10))
+	}
+	u := make(map[interface{}]struct{})
+	for _, id := range ids {
+		for item := range gr.index.lookup(prefix + strconv.FormatInt(id, 10)) {
+			u[item] = struct{}{}
+		}
+	}
+	return u
+}
+
+// userIDsWithLogin returns the IDs of users with the given lowercase
+// login.
+func (g *GitHub) userIDsWithLogin(login string) []int64 {
+	var ids []int64
+	for id, u := range g.users {
+		if strings.ToLower(u.Login) == login {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// intersectSets returns the items in all of sets.
+func intersectSets(sets []map[interface{}]struct{}) map[interface{}]struct{} {
+	sort.Slice(sets, func(i, j int) bool { return len(sets[i]) < len(sets[j]) })
+	out := make(map[interface{}]struct{})
+Items:
+	for item := range sets[0] {
+		for _, s := range sets[1:] {
+			if _, ok := s[item]; !ok {
+				continue Items
+			}
+		}
+		out[item] = struct{}{}
+	}
+	return out
+}
+
+func (gr *GitHubRepo) matchIssue(gi *GitHubIssue, q *Query) bool {
+	if gi.NotExist {
+		return false
+	}
+	for i := range q.terms {
+		t := &q.terms[i]
+		ok, _ := matchIssueTerm(gr, gi, t)
+		if ok == t.neg {
+			return false
+		}
+	}
+	return true
+}
+
+// matchIssueTerm reports whether gi in gr matches t, ignoring t.neg.
+// It returns errUnknownTerm if t doesn't apply to issues; a nil gi
+// may be used to check that.
+func matchIssueTerm(gr *GitHubRepo, gi *GitHubIssue, t *queryTerm) (bool, error) {
+	switch t.key {
+	case "":
+	case "repo":
+	case "is":
+		switch t.val {
+		case "open", "closed", "pr", "issue", "locked":
+		default:
+			return false, errUnknownTerm
+		}
+	case "no":
+		switch t.val {
+		case "milestone", "label", "assignee":
+		default:
+			return false, errUnknownTerm
+		}
+	case "label", "milestone", "author", "assignee", "created", "updated", "closed":
+	default:
+		return false, errUnknownTerm
+	}
+	if gi == nil {
+		return false, nil
+	}
+
+	switch t.key {
+	case "":
+		return strings.Contains(strings.ToLower(gi.Title), t.val), nil
+	case "repo":
+		return strings.ToLower(gr.id.String()) == t.val, nil
+	case "is":
+		switch t.val {
+		case "open":
+			return !gi.Closed, nil
+		case "closed":
+			return gi.Closed, nil
+		case "pr":
+			return gi.PullRequest, nil
+		case "issue":
+			return !gi.PullRequest, nil
+		case "locked":
+			return gi.Locked, nil
+		}
+	case "no":
+		switch t.val {
+		case "milestone":
+			return gi.Milestone.IsNone(), nil
+		case "label":
+			return len(gi.Labels) == 0, nil
+		case "assignee":
+			return len(gi.Assignees) == 0, nil
+		}
+	case "label":
+		for _, lb := range gi.Labels {
+			if strings.ToLower(lb.Name) == t.val {
+				return true, nil
+			}
+		}
+	case "milestone":
+		ms := gi.Milestone
+		return !ms.IsNone() && !ms.IsUnknown() && strings.ToLower(ms.Title) == t.val, nil
+	case "author":
+		return gi.User != nil && strings.ToLower(gi.User.Login) == t.val, nil
+	case "assignee":
+		for _, u := range gi.Assignees {
+			if strings.ToLower(u.Login) == t.val {
+				return true, nil
+			}
+		}
+	case "created":
+		return t.inRange(gi.Created), nil
+	case "updated":
+		return t.inRange(gi.Updated), nil
+	case "closed":
+		return gi.Closed && t.inRange(gi.ClosedAt), nil
+	}
+	return false, nil
+}
+
+// ForeachCLMatching calls fn for each complete CL matching q,
+// including private ones, in order of project and CL number.
+//
+// If fn returns an error, iteration ends and ForeachCLMatching
+// returns with that error. It also returns an error if q has terms
+// that don't apply to CLs.
+func (c *Corpus) ForeachCLMatching(q *Query, fn func(*GerritCL) error) error {
+	var projects []string
+	for _, t := range q.terms {
+		if _, err := matchCLTerm(nil, &t); err == errUnknownTerm {
+			return fmt.Errorf("unknown CL query term %s:%s", t.key, t.val)
+		}
+		if t.key == "project" && !t.neg {
+			projects = append(projects, t.val)
+		}
+	}
+	var gps []*GerritProject
+	c.Gerrit().ForeachProjectUnsorted(func(gp *GerritProject) error {
+		for _, p := range projects {
+			if !gp.matchesName(p) {
+				return nil
+			}
+		}
+		gps = append(gps, gp)
+		return nil
+	})
+	sort.Slice(gps, func(i, j int) bool { return gps[i].proj < gps[j].proj })
+	for _, gp := range gps {
+		var cls []*GerritCL
+		for _, cl := range gp.clCandidates(q) {
+			if cl.complete() && matchCL(cl, q) {
+				cls = append(cls, cl)
+			}
+		}
+		sort.Slice(cls, func(i, j int) bool { return cls[i].Number < cls[j].Number })
+		for _, cl := range cls {
+			if err := fn(cl); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchesName reports whether the lowercase name is the project's
+// name or its server/name.
+func (gp *GerritProject) matchesName(name string) bool {
+	return strings.ToLower(gp.proj) == name || strings.ToLower(gp.Project()) == name
+}
+
+// clCandidates returns a superset of the project's CLs matching q,
+// using the project's index for the query's positive indexed terms.
+func (gp *GerritProject) clCandidates(q *Query) []*GerritCL {
+	var sets []map[interface{}]struct{}
+	for _, t := range q.terms {
+		if t.neg {
+			continue
+		}
+		var term string
+		switch t.key {
+		case "status", "hashtag", "branch", "topic":
+			term = t.key + ":" + t.val
+		case "is":
+			switch t.val {
+			case "open":
+				term = "status:new"
+			case "merged", "abandoned":
+				term = "status:" + t.val
+			}
+		case "owner":
+			if _, err := strconv.Atoi(t.val); err == nil {
+				term = "owner:" + t.val
+			}
+		}
+		if term != "" {
+			sets = append(sets, gp.index.lookup(term))
+		}
+	}
+	if len(sets) == 0 {
+		s := make([]*GerritCL, 0, len(gp.cls))
+		for _, cl := range gp.cls {
+			s = append(s, cl)
+		}
+		return s
+	}
+	var s []*GerritCL
+	for item := range intersectSets(sets) {
+		s = append(s, item.(*GerritCL))
+	}
+	return s
+}
+
+func matchCL(cl *GerritCL, q *Query) bool {
+	for i := range q.terms {
+		t := &q.terms[i]
+		ok, _ := matchCLTerm(cl, t)
+		if ok == t.neg {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCLTerm reports whether cl matches t, ignoring t.neg. It
+// returns errUnknownTerm if t doesn't apply to CLs; a nil cl may be
+// used to check that.
+func matchCLTerm(cl *GerritCL, t *queryTerm) (bool, error) {
+	switch t.key {
+	case "", "project", "status", "hashtag", "branch", "topic", "owner", "created", "updated":
+	case "is":
+		switch t.val {
+		case "open", "merged", "abandoned", "wip", "private":
+		default:
+			return false, errUnknownTerm
+		}
+	default:
+		return false, errUnknownTerm
+	}
+	if cl == nil {
+		return false, nil
+	}
+
+	switch t.key {
+	case "":
+		return strings.Contains(strings.ToLower(cl.Subject()), t.val), nil
+	case "project":
+		return cl.Project.matchesName(t.val), nil
+	case "status":
+		return cl.Status == t.val, nil
+	case "is":
+		switch t.val {
+		case "open":
+			return cl.Status == "new", nil
+		case "merged", "abandoned":
+			return cl.Status == t.val, nil
+		case "wip":
+			return cl.WorkInProgress(), nil
+		case "private":
+			return cl.Private, nil
+		}
+	case "hashtag":
+		return cl.Hashtags().Match(func(h string) bool {
+			return strings.ToLower(h) == t.val
+		}), nil
+	case "branch":
+		return strings.ToLower(cl.Branch()) == t.val, nil
+	case "topic":
+		return strings.ToLower(cl.Topic()) == t.val, nil
+	case "owner":
+		if id, err := strconv.Atoi(t.val); err == nil {
+			return cl.OwnerID() == id, nil
+		}
+		if strings.Contains(strings.ToLower(cl.OwnerName()), t.val) {
+			return true, nil
+		}
+		owner := cl.Owner()
+		return owner != nil && strings.Contains(strings.ToLower(owner.Email()), t.val), nil
+	case "created":
+		return t.inRange(cl.Created), nil
+	case "updated":
+		return t.inRange(cl.Meta.Commit.CommitTime), nil
+	}
+	return false, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+func TestParseQuery(t *testing.T) {
+	q, err := ParseQuery(`repo:golang/go -label:"Help Wanted" updated:>2018-06-01 printf`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.terms) != 4 {
+		t.Fatalf("got %d terms; want 4", len(q.terms))
+	}
+	if tm := q.terms[1]; !tm.neg || tm.key != "label" || tm.val != "help wanted" {
+		t.Errorf("label term = %+v", tm)
+	}
+	up := q.terms[2]
+	if up.inRange(t3339("2018-06-01T23:59:59Z")) || !up.inRange(t3339("2018-06-02T00:00:00Z")) || !up.to.IsZero() {
+		t.Errorf("updated term = %+v; want from 2018-06-02, unbounded", up)
+	}
+	if tm := q.terms[3]; tm.key != "" || tm.val != "printf" {
+		t.Errorf("bare term = %+v", tm)
+	}
+
+	for _, bad := range []string{"label:", "updated:>June", "created:2018-01-01..soon"} {
+		if _, err := ParseQuery(bad); err == nil {
+			t.Errorf("ParseQuery(%q) succeeded; want error", bad)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		v       string
+		in, out string
+	}{
+		{"2018-06-01", "2018-06-01T12:00:00Z", "2018-06-02T00:00:00Z"},
+		{">=2018-06-01", "2018-06-01T00:00:00Z", "2018-05-31T23:59:59Z"},
+		{"<2018-06-01", "2018-05-31T23:59:59Z", "2018-06-01T00:00:00Z"},
+		{"<=2018-06-01", "2018-06-01T23:59:59Z", "2018-06-02T00:00:00Z"},
+		{"2018-01-01..2018-01-31", "2018-01-31T10:00:00Z", "2018-02-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		var qt queryTerm
+		var err error
+		qt.from, qt.to, err = parseDateRange(tt.v)
+		if err != nil {
+			t.Errorf("parseDateRange(%q): %v", tt.v, err)
+			continue
+		}
+		if !qt.inRange(t3339(tt.in)) {
+			t.Errorf("%q doesn't include %s", tt.v, tt.in)
+		}
+		if qt.inRange(t3339(tt.out)) {
+			t.Errorf("%q includes %s", tt.v, tt.out)
+		}
+	}
+}
+
+func issueNumbers(t *testing.T, c *Corpus, query string) string {
+	t.Helper()
+	q, err := ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var nums []string
+	err = c.ForeachIssueMatching(q, func(gr *GitHubRepo, gi *GitHubIssue) error {
+		nums = append(nums, fmt.Sprintf("%s#%d", gr.ID().Repo, gi.Number))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("query %q: %v", query, err)
+	}
+	return strings.Join(nums, ",")
+}
+
+func TestForeachIssueMatching(t *testing.T) {
+	c := new(Corpus)
+	needsFix := &maintpb.GithubLabel{Id: 1, Name: "NeedsFix"}
+	help := &maintpb.GithubLabel{Id: 2, Name: "help wanted"}
+	issue := func(repo string, num int32, updated string, mod func(m *maintpb.GithubIssueMutation)) {
+		m := &maintpb.GithubIssueMutation{
+			Owner:   "golang",
+			Repo:    repo,
+			Number:  num,
+			Title:   fmt.Sprintf("issue %d", num),
+			User:    &maintpb.GithubUser{Id: 100, Login: "gopherbot"},
+			Created: p3339("2018-01-01T00:00:00Z"),
+			Updated: p3339(updated),
+		}
+		if mod != nil {
+			mod(m)
+		}
+		c.processMutationLocked(&maintpb.Mutation{GithubIssue: m})
+	}
+	issue("go", 1, "2018-07-01T00:00:00Z", func(m *maintpb.GithubIssueMutation) {
+		m.AddLabel = []*maintpb.GithubLabel{needsFix}
+		m.MilestoneId, m.MilestoneTitle = 50, "Go1.12"
+	})
+	issue("go", 2, "2018-05-01T00:00:00Z", func(m *maintpb.GithubIssueMutation) {
+		m.AddLabel = []*maintpb.GithubLabel{needsFix, help}
+		m.MilestoneId, m.MilestoneTitle = 50, "Go1.12"
+	})
+	issue("go", 3, "2018-07-01T00:00:00Z", func(m *maintpb.GithubIssueMutation) {
+		m.AddLabel = []*maintpb.GithubLabel{needsFix}
+		m.Closed = &maintpb.BoolChange{Val: true}
+		m.Title = "fmt: Printf is slow"
+	})
+	issue("net", 4, "2018-07-01T00:00:00Z", func(m *maintpb.GithubIssueMutation) {
+		m.PullRequest = true
+		m.NoMilestone = true
+		m.Assignees = []*maintpb.GithubUser{{Id: 200, Login: "rsc"}}
+	})
+
+	tests := []struct {
+		query, want string
+	}{
+		{"repo:golang/go is:open label:NeedsFix milestone:Go1.12 updated:>2018-06-01", "go#1"},
+		{"repo:golang/go label:needsfix", "go#1,go#2,go#3"},
+		{"label:needsfix -label:\"help wanted\"", "go#1,go#3"},
+		{"is:closed", "go#3"},
+		{"printf", "go#3"},
+		{"is:pr no:milestone assignee:RSC", "net#4"},
+		{"author:gopherbot -repo:golang/go", "net#4"},
+		{"no:assignee is:open", "go#1,go#2"},
+		{"label:NoSuchLabel", ""},
+	}
+	for _, tt := range tests {
+		if got := issueNumbers(t, c, tt.query); got != tt.want {
+			t.Errorf("%q = %q; want %q", tt.query, got, tt.want)
+		}
+	}
+
+	// Indexes follow later mutations.
+	issue("go", 1, "2018-07-02T00:00:00Z", func(m *maintpb.GithubIssueMutation) {
+		m.RemoveLabel = []int64{1}
+		m.Closed = &maintpb.BoolChange{Val: true}
+	})
+	if got, want := issueNumbers(t, c, "label:NeedsFix is:open"), "go#2"; got != want {
+		t.Errorf("after update, query = %q; want %q", got, want)
+	}
+
+	q, _ := ParseQuery("hashtag:foo")
+	if err := c.ForeachIssueMatching(q, func(*GitHubRepo, *GitHubIssue) error { return nil }); err == nil {
+		t.Error("CL-only term in issue query succeeded; want error")
+	}
+}
+
+func TestForeachCLMatching(t *testing.T) {
+	c := new(Corpus)
+	c.initGerrit()
+	newCL := func(proj string, num int32, status string, metaMsgs ...string) {
+		gp := c.gerrit.getOrCreateProject(proj)
+		cl := gp.getOrCreateCL(num)
+		cl.Status = status
+		cl.Commit = &GitCommit{Msg: fmt.Sprintf("subject of %d\n", num)}
+		for _, msg := range metaMsgs {
+			m := newGerritMeta(&GitCommit{
+				Author: &GitPerson{Str: "Rick Sanchez <137@62eb7196>"},
+				Msg:    msg,
+			}, cl)
+			cl.Metas = append(cl.Metas, m)
+		}
+		cl.Meta = cl.Metas[len(cl.Metas)-1]
+		cl.updateBranch()
+		cl.updateTopic()
+		gp.updateCLIndex(cl)
+	}
+	const create = "Create change\n\nPatch-set: 1\nBranch: refs/heads/master\nStatus: new"
+	const tag = "Set hashtags\n\nPatch-set: 1\nHashtags: wait-release, Performance\nTag: autogenerated:gerrit:setHashtag"
+	newCL("go.googlesource.com/go", 10, "new", create, tag)
+	newCL("go.googlesource.com/go", 11, "new", create, "Set topic\n\nPatch-set: 1\nTopic: Generics")
+	newCL("go.googlesource.com/go", 12, "merged", create, tag)
+	newCL("go.googlesource.com/net", 13, "new", create, tag)
+
+	tests := []struct {
+		query, want string
+	}{
+		{"project:go status:new hashtag:wait-release", "10"},
+		{"hashtag:performance", "10,12,13"},
+		{"is:open -hashtag:wait-release", "11"},
+		{"project:go.googlesource.com/net branch:master", "13"},
+		{"topic:generics", "11"},
+		{"owner:137 status:merged", "12"},
+		{"owner:rick project:go", "10,11,12"},
+		{"\"subject of 11\"", "11"},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var nums []string
+		if err := c.ForeachCLMatching(q, func(cl *GerritCL) error {
+			nums = append(nums, fmt.Sprint(cl.Number))
+			return nil
+		}); err != nil {
+			t.Fatalf("query %q: %v", tt.query, err)
+		}
+		if got := strings.Join(nums, ","); got != tt.want {
+			t.Errorf("%q = %q; want %q", tt.query, got, tt.want)
+		}
+	}
+}
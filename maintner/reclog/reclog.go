@@ -9,7 +9,9 @@ package reclog
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 	"strconv"
@@ -23,12 +25,27 @@ import (
 // the 0+ YYY digits are the hex length of the blob. After the YYY
 // digits there is a '=' byte before the YYY bytes of blob. There is
 // no record footer.
+//
+// A record may be preceded by a checksum record, whose blob is
+// checksumTag followed by the little-endian CRC-32C (Castagnoli) of
+// the next record's header and blob. The blob is the protobuf
+// encoding of the fixed32 checksum field of maintpb.Mutation, so
+// readers that predate checksums and decode blobs as mutations see
+// an empty mutation, and skip it.
 var (
 	headerPrefix = []byte("REC@")
 	headerSuffix = []byte("=")
 	plus         = []byte("+")
 )
 
+// checksumTag is the protobuf key of field 5 with wire type 5
+// (fixed32).
+var checksumTag = []byte{5<<3 | 5}
+
+const checksumRecordLen = 1 + 4 // len(checksumTag) + CRC-32C
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
 // RecordCallback is the callback signature accepted by
 // ForeachFileRecord and ForeachRecord, which read the mutation log
 // format used by DiskMutationLogger.
@@ -43,6 +60,9 @@ var (
 // rec is the proto3 binary marshalled representation of
 // *maintpb.Mutation.
 //
+// Checksum records are verified and skipped, so the record at off
+// may be preceded by one that fn isn't called with.
+//
 // If the callback returns an error, iteration stops.
 type RecordCallback func(off int64, hdr, rec []byte) error
 
@@ -65,57 +85,266 @@ func ForeachFileRecord(path string, fn RecordCallback) error {
 // Calls to fn are made serially.
 // If fn returns an error, iteration ends and that error is returned.
 // The startOffset be 0 if reading from the beginning of a file.
+//
+// It fails at the first malformed or truncated record, or record
+// whose checksum doesn't match.
 func ForeachRecord(r io.Reader, startOffset int64, fn RecordCallback) error {
-	off := startOffset
-	br := bufio.NewReader(r)
-	var buf bytes.Buffer
-	var hdrBuf bytes.Buffer
+	rr := newRecordReader(r, startOffset)
 	for {
-		startOff := off
-		hdr, err := br.ReadSlice('=')
+		off, hdr, rec, err := rr.next()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			if err == io.EOF && len(hdr) == 0 {
-				return nil
+			return err
+		}
+		if err := fn(off, hdr, rec); err != nil {
+			return err
+		}
+	}
+}
+
+// ForeachRecordResync is like ForeachRecord, but recovers from
+// corruption. When it finds a malformed or truncated record, or a
+// checksum mismatch, it scans forward to the next valid record, one
+// whose header has the offset it's at, and calls corrupt with the
+// offsets of the bytes skipped and the first error found in them. If
+// corrupt returns an error, iteration ends and that error is
+// returned.
+//
+// Records without checksums can't be verified, so fn may be called
+// with the garbled blob of a record whose header is intact.
+func ForeachRecordResync(r io.Reader, startOffset int64, fn RecordCallback, corrupt func(start, end int64, err error) error) error {
+	rr := newRecordReader(r, startOffset)
+	rr.keep = true
+	var (
+		badStart int64
+		badErr   error // first error since badStart, or nil
+		badRec   int64 = -1
+	)
+	for {
+		off, hdr, rec, err := rr.next()
+		if badErr != nil && err == nil && off == badRec {
+			// The record whose checksum didn't match,
+			// now read without its checksum record.
+			err = corruptf("checksum mismatch for record at offset %v", off)
+		}
+		if ce, ok := err.(*corruptError); ok {
+			if badErr == nil {
+				badStart, badErr = rr.start, err
 			}
+			if ce.badRec >= 0 {
+				badRec = ce.badRec
+			}
+			rr.skipByte()
+			continue
+		}
+		if err != nil && err != io.EOF {
 			return err
 		}
-		if len(hdr) > 40 {
-			return fmt.Errorf("malformed overlong header %q at offset %v", hdr[:40], startOff)
+		if badErr != nil {
+			end := rr.start
+			if err == io.EOF {
+				end = rr.off
+			}
+			if err := corrupt(badStart, end, badErr); err != nil {
+				return err
+			}
+			badErr = nil
 		}
-		hdrBuf.Reset()
-		hdrBuf.Write(hdr)
-		if !bytes.HasPrefix(hdr, headerPrefix) || !bytes.HasSuffix(hdr, headerSuffix) || bytes.Count(hdr, plus) != 1 {
-			return fmt.Errorf("malformed header %q at offset %v", hdr, startOff)
+		if err == io.EOF {
+			return nil
 		}
-		plusPos := bytes.IndexByte(hdr, '+')
-		hdrOff, err := strconv.ParseInt(string(hdr[len(headerPrefix):plusPos]), 16, 64)
-		if err != nil {
-			return fmt.Errorf("malformed header %q (malformed offset) at offset %v", hdr, startOff)
+		if err := fn(off, hdr, rec); err != nil {
+			return err
 		}
-		if hdrOff != startOff {
-			return fmt.Errorf("malformed header %q with offset %v doesn't match expected offset %v", hdr, hdrOff, startOff)
+	}
+}
+
+// A corruptError is an error due to malformed log contents, rather
+// than failure to read them.
+type corruptError struct {
+	msg    string
+	badRec int64 // offset of a record whose checksum didn't match, or -1
+}
+
+func (e *corruptError) Error() string { return e.msg }
+
+func corruptf(format string, args ...interface{}) error {
+	return &corruptError{msg: fmt.Sprintf(format, args...), badRec: -1}
+}
+
+// A recordReader reads records.
+type recordReader struct {
+	br    *bufio.Reader
+	back  []byte       // bytes to read before br's
+	start int64        // offset of the current record
+	off   int64        // offset of the next byte
+	buf   bytes.Buffer // blob of the last record read
+
+	// cur holds the headers read since the start of the current
+	// record and, if keep is set, their blobs, so that they can
+	// be read again when resyncing after corruption.
+	cur  bytes.Buffer
+	keep bool
+}
+
+func newRecordReader(r io.Reader, off int64) *recordReader {
+	return &recordReader{br: bufio.NewReader(r), start: off, off: off}
+}
+
+func (r *recordReader) ReadByte() (byte, error) {
+	var b byte
+	if len(r.back) > 0 {
+		b, r.back = r.back[0], r.back[1:]
+	} else {
+		var err error
+		if b, err = r.br.ReadByte(); err != nil {
+			return 0, err
 		}
-		hdrSize, err := strconv.ParseInt(string(hdr[plusPos+1:len(hdr)-1]), 16, 64)
+	}
+	r.cur.WriteByte(b)
+	r.off++
+	return b, nil
+}
+
+func (r *recordReader) Read(p []byte) (n int, err error) {
+	if len(r.back) > 0 {
+		n = copy(p, r.back)
+		r.back = r.back[n:]
+	} else {
+		n, err = r.br.Read(p)
+	}
+	if r.keep {
+		r.cur.Write(p[:n])
+	}
+	r.off += int64(n)
+	return n, err
+}
+
+// skipByte arranges for the bytes read since the start of the
+// current record, except the first, to be read again. r.keep must
+// be set.
+func (r *recordReader) skipByte() {
+	cur := r.cur.Bytes()
+	if len(cur) == 0 {
+		// Only possible at EOF, which next reports next time.
+		return
+	}
+	back := make([]byte, 0, len(cur)-1+len(r.back))
+	back = append(back, cur[1:]...)
+	r.back = append(back, r.back...)
+	r.start++
+	r.off = r.start
+	r.cur.Reset()
+}
+
+// next returns the next record other than a checksum record. It
+// returns io.EOF at the end of the input, and a *corruptError for
+// bad records.
+func (r *recordReader) next() (off int64, hdr, rec []byte, err error) {
+	r.start = r.off
+	r.cur.Reset()
+	hdr, err = r.readRecord()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	if !isChecksumRecord(r.buf.Bytes()) {
+		return r.start, hdr, r.buf.Bytes(), nil
+	}
+	want := binary.LittleEndian.Uint32(r.buf.Bytes()[len(checksumTag):])
+	off = r.off
+	hdr, err = r.readRecord()
+	if err == io.EOF {
+		return 0, nil, nil, corruptf("checksum record at offset %v at end of log", r.start)
+	}
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	rec = r.buf.Bytes()
+	if isChecksumRecord(rec) {
+		return 0, nil, nil, corruptf("consecutive checksum records at offset %v", r.start)
+	}
+	crc := crc32.Update(crc32.Checksum(hdr, castagnoli), castagnoli, rec)
+	if crc != want {
+		msg := fmt.Sprintf("checksum mismatch for record at offset %v", off)
+		return 0, nil, nil, &corruptError{msg: msg, badRec: off}
+	}
+	return off, hdr, rec, nil
+}
+
+// readRecord reads a record at r.off into r.buf, returning its
+// header, which is only valid until r.cur is next reset.
+func (r *recordReader) readRecord() (hdr []byte, err error) {
+	startOff := r.off
+	hdrStart := r.cur.Len()
+	for {
+		b, err := r.ReadByte()
 		if err != nil {
-			return fmt.Errorf("malformed header %q (bad size) at offset %v", hdr, startOff)
+			if err == io.EOF {
+				if r.cur.Len() == hdrStart {
+					return nil, io.EOF
+				}
+				return nil, corruptf("truncated header %q at offset %v", r.cur.Bytes()[hdrStart:], startOff)
+			}
+			return nil, err
 		}
-		off += int64(len(hdr))
-
-		buf.Reset()
-		if _, err := io.CopyN(&buf, br, hdrSize); err != nil {
-			return fmt.Errorf("truncated record at offset %v: %v", startOff, err)
+		if n := r.cur.Len() - hdrStart; n <= len(headerPrefix) && b != headerPrefix[n-1] {
+			return nil, corruptf("malformed header %q at offset %v", r.cur.Bytes()[hdrStart:], startOff)
 		}
-		off += hdrSize
-		if err := fn(startOff, hdrBuf.Bytes(), buf.Bytes()); err != nil {
-			return err
+		if b == '=' {
+			break
+		}
+		if r.cur.Len()-hdrStart >= 40 {
+			return nil, corruptf("malformed overlong header %q at offset %v", r.cur.Bytes()[hdrStart:], startOff)
+		}
+	}
+	hdr = r.cur.Bytes()[hdrStart:]
+	if !bytes.HasPrefix(hdr, headerPrefix) || !bytes.HasSuffix(hdr, headerSuffix) || bytes.Count(hdr, plus) != 1 {
+		return nil, corruptf("malformed header %q at offset %v", hdr, startOff)
+	}
+	plusPos := bytes.IndexByte(hdr, '+')
+	hdrOff, err := strconv.ParseInt(string(hdr[len(headerPrefix):plusPos]), 16, 64)
+	if err != nil {
+		return nil, corruptf("malformed header %q (malformed offset) at offset %v", hdr, startOff)
+	}
+	if hdrOff != startOff {
+		return nil, corruptf("malformed header %q with offset %v doesn't match expected offset %v", hdr, hdrOff, startOff)
+	}
+	hdrSize, err := strconv.ParseInt(string(hdr[plusPos+1:len(hdr)-1]), 16, 64)
+	if err != nil || hdrSize < 0 {
+		return nil, corruptf("malformed header %q (bad size) at offset %v", hdr, startOff)
+	}
+
+	r.buf.Reset()
+	if _, err := io.CopyN(&r.buf, r, hdrSize); err != nil {
+		if err == io.EOF {
+			return nil, corruptf("truncated record at offset %v: %v", startOff, io.ErrUnexpectedEOF)
 		}
+		return nil, fmt.Errorf("reading record at offset %v: %v", startOff, err)
 	}
+	// r.cur may have grown, so find the header again.
+	return r.cur.Bytes()[hdrStart : hdrStart+len(hdr)], nil
+}
+
+func isChecksumRecord(rec []byte) bool {
+	return len(rec) == checksumRecordLen && bytes.HasPrefix(rec, checksumTag)
 }
 
 // AppendRecordToFile opens the named filename for append (creating it
 // if necessary) and adds the provided data record to the end.
 // The caller is responsible for file locking.
 func AppendRecordToFile(filename string, data []byte) error {
+	return appendToFile(filename, data, WriteRecord)
+}
+
+// AppendChecksummedRecordToFile is like AppendRecordToFile, but
+// writes the record with a checksum, as WriteChecksummedRecord does.
+func AppendChecksummedRecordToFile(filename string, data []byte) error {
+	return appendToFile(filename, data, WriteChecksummedRecord)
+}
+
+func appendToFile(filename string, data []byte, write func(io.Writer, int64, []byte) error) error {
 	f, err := os.OpenFile(filename, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
 	if err != nil {
 		return err
@@ -131,7 +360,7 @@ func AppendRecordToFile(filename string, data []byte) error {
 	if off != st.Size() {
 		return fmt.Errorf("Size %v != offset %v", st.Size(), off)
 	}
-	if err := WriteRecord(f, off, data); err != nil {
+	if err := write(f, off, data); err != nil {
 		f.Close()
 		return err
 	}
@@ -146,3 +375,22 @@ func WriteRecord(w io.Writer, off int64, data []byte) error {
 	_, err := fmt.Fprintf(w, "REC@%x+%x=%s", off, len(data), data)
 	return err
 }
+
+// WriteChecksummedRecord is like WriteRecord, but precedes the
+// record with a checksum record, which ForeachRecord verifies. As
+// with WriteRecord, exactly one Write call will be made to w.
+func WriteChecksummedRecord(w io.Writer, off int64, data []byte) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "REC@%x+%x=", off, checksumRecordLen)
+	recOff := off + int64(buf.Len()+checksumRecordLen)
+	hdr := fmt.Sprintf("REC@%x+%x=", recOff, len(data))
+	crc := crc32.Update(crc32.Checksum([]byte(hdr), castagnoli), castagnoli, data)
+	buf.Write(checksumTag)
+	var sum [4]byte
+	binary.LittleEndian.PutUint32(sum[:], crc)
+	buf.Write(sum[:])
+	buf.WriteString(hdr)
+	buf.Write(data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
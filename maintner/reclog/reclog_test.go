@@ -0,0 +1,169 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reclog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// testLog returns a log of the records, with checksums on those at
+// odd indexes.
+func testLog(t *testing.T, recs ...string) []byte {
+	var buf bytes.Buffer
+	for i, rec := range recs {
+		write := WriteRecord
+		if i%2 == 1 {
+			write = WriteChecksummedRecord
+		}
+		if err := write(&buf, int64(buf.Len()), []byte(rec)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+func readAll(data []byte) ([]string, error) {
+	var recs []string
+	err := ForeachRecord(bytes.NewReader(data), 0, func(off int64, hdr, rec []byte) error {
+		if want := fmt.Sprintf("REC@%x+%x=", off, len(rec)); string(hdr) != want {
+			return fmt.Errorf("header %q; want %q", hdr, want)
+		}
+		recs = append(recs, string(rec))
+		return nil
+	})
+	return recs, err
+}
+
+func TestChecksummedRecords(t *testing.T) {
+	recs := []string{"one", "two", "three", "", "five"}
+	data := testLog(t, recs...)
+	got, err := readAll(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, recs) {
+		t.Errorf("records = %q; want %q", got, recs)
+	}
+
+	// Flipping a byte of a checksummed record is detected.
+	i := bytes.Index(data, []byte("two"))
+	data[i] = 'T'
+	if _, err := readAll(data); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("reading corrupt record: err = %v; want checksum mismatch", err)
+	}
+}
+
+// Checksum records decode as mutations with only their checksum
+// field set, which readers that don't know about them ignore.
+func TestChecksumRecordIsMutation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteChecksummedRecord(&buf, 0, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	// The checksum record is the first record.
+	data := buf.Bytes()
+	start := bytes.IndexByte(data, '=') + 1
+	blob := data[start : start+checksumRecordLen]
+	if !isChecksumRecord(blob) {
+		t.Fatalf("first record %q isn't a checksum record", blob)
+	}
+	var m maintpb.Mutation
+	if err := proto.Unmarshal(blob, &m); err != nil {
+		t.Fatal(err)
+	}
+	want := maintpb.Mutation{Checksum: binary.LittleEndian.Uint32(blob[len(checksumTag):])}
+	if !proto.Equal(&m, &want) {
+		t.Errorf("checksum record decodes as %v; want %v", &m, &want)
+	}
+}
+
+func TestForeachRecordResync(t *testing.T) {
+	recs := []string{"one", "two", "three", "four", "five"}
+	good := testLog(t, recs...)
+
+	tests := []struct {
+		name    string
+		corrupt func([]byte) []byte
+		want    []string
+		damaged int
+	}{
+		{
+			name:    "intact",
+			corrupt: func(b []byte) []byte { return b },
+			want:    recs,
+		},
+		{
+			name:    "torn write",
+			corrupt: func(b []byte) []byte { return b[:len(b)-2] },
+			want:    recs[:4],
+			damaged: 1,
+		},
+		{
+			name: "bit flip in checksummed record",
+			corrupt: func(b []byte) []byte {
+				b[bytes.Index(b, []byte("four"))] ^= 1
+				return b
+			},
+			want:    []string{"one", "two", "three", "five"},
+			damaged: 1,
+		},
+		{
+			name: "bad length",
+			corrupt: func(b []byte) []byte {
+				// Make the length of "four" too long, so it
+				// swallows the following record.
+				i := bytes.Index(b, []byte("+4=four"))
+				b[i+1] = 'f'
+				return b
+			},
+			want:    []string{"one", "two", "three", "five"},
+			damaged: 1,
+		},
+		{
+			name: "garbage",
+			corrupt: func(b []byte) []byte {
+				i := bytes.Index(b, []byte("two"))
+				return append(b[:i:i], append([]byte("REC@0+4=junk"), b[i:]...)...)
+			},
+			// The offsets of the records after the garbage
+			// are wrong, so they're lost too.
+			want:    []string{"one"},
+			damaged: 1,
+		},
+	}
+	for _, tt := range tests {
+		data := tt.corrupt(append([]byte(nil), good...))
+		var got []string
+		var damaged []string
+		err := ForeachRecordResync(bytes.NewReader(data), 0, func(off int64, hdr, rec []byte) error {
+			got = append(got, string(rec))
+			return nil
+		}, func(start, end int64, err error) error {
+			if start >= end || end > int64(len(data)) {
+				t.Errorf("%s: bad damaged range %d-%d in %d bytes", tt.name, start, end, len(data))
+			}
+			damaged = append(damaged, fmt.Sprintf("%d-%d: %v", start, end, err))
+			return nil
+		})
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: records = %q; want %q", tt.name, got, tt.want)
+		}
+		if len(damaged) != tt.damaged {
+			t.Errorf("%s: damaged ranges = %q; want %d", tt.name, damaged, tt.damaged)
+		}
+	}
+}
@@ -0,0 +1,523 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Full-text search over issues and CLs.
+
+package maintner
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Term weights of the parts of a document. Matches in an issue title
+// or CL subject count for more than matches in the text below it.
+const (
+	searchTitleWeight = 3
+	searchTextWeight  = 1
+)
+
+// BM25 ranking parameters.
+const (
+	searchK1 = 1.2
+	searchB  = 0.75
+)
+
+// searchSaveInterval is the minimum time between writes of the
+// search index file as the corpus is updated.
+const searchSaveInterval = 10 * time.Minute
+
+// searchFileVersion is the version of the search index file format.
+// Files of other versions are ignored and rebuilt.
+const searchFileVersion = 1
+
+// searchStopWords are terms too common to be worth indexing.
+var searchStopWords = map[string]bool{
+	"an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "no": true,
+	"not": true, "of": true, "on": true, "or": true, "such": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "to": true, "was": true,
+	"will": true, "with": true,
+}
+
+// A SearchResult is an issue or CL matched by Corpus.Search.
+type SearchResult struct {
+	// Exactly one of Issue or CL is set. Repo is the repo of Issue.
+	Repo  *GitHubRepo
+	Issue *GitHubIssue
+	CL    *GerritCL
+
+	// Score is the relevance of the match to the query. It's only
+	// meaningful relative to the scores of other results for the
+	// same query.
+	Score float64
+}
+
+// searchIndex is an inverted index of the text of issues and CLs.
+type searchIndex struct {
+	file     string               // if non-empty, where the index is persisted
+	docs     []*searchDoc         // by doc ID; nil for free IDs
+	free     []int32              // free doc IDs
+	byKey    map[string]int32     // searchDoc.key => doc ID
+	postings map[string][]int32   // term => doc IDs, unordered
+	totalLen float64              // sum of docs' len
+	dirty    map[interface{}]bool // GitHubIssueRef or *GerritCL => true
+
+	lastSave time.Time
+	unsaved  bool // changes since last save
+	saving   bool // a save is in progress
+}
+
+// searchDoc is the indexed text of an issue or CL. It's immutable once
+// added to the index; a changed document is replaced.
+type searchDoc struct {
+	key   string      // "golang/go#123" or "go.googlesource.com/go/456"
+	stamp string      // version of the document that was indexed
+	item  interface{} // GitHubIssueRef or *GerritCL; nil until resolved after a load
+	len   float32     // weighted number of terms
+	terms []string    // sorted
+	tfs   []float32   // weighted frequency of each of terms
+}
+
+// tf returns the weighted frequency of term in d.
+func (d *searchDoc) tf(term string) float64 {
+	i := sort.SearchStrings(d.terms, term)
+	if i < len(d.terms) && d.terms[i] == term {
+		return float64(d.tfs[i])
+	}
+	return 0
+}
+
+// EnableSearch enables full-text search of issues and CLs with
+// Corpus.Search. The index is built from the current state of the
+// corpus and kept up to date as it changes.
+//
+// If indexFile is non-empty, the index is loaded from that file, if
+// present, and saved to it periodically, so only the issues and CLs
+// changed since it was saved need to be indexed again. It's usually
+// kept next to the cached mutation logs.
+//
+// EnableSearch must be called after Initialize.
+func (c *Corpus) EnableSearch(indexFile string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.didInit {
+		return errors.New("maintner: EnableSearch called before Initialize")
+	}
+	if c.search != nil {
+		return errors.New("maintner: duplicate call to EnableSearch")
+	}
+	x := &searchIndex{file: indexFile}
+	if indexFile != "" {
+		if err := x.load(); err != nil && !os.IsNotExist(err) {
+			log.Printf("maintner: ignoring search index %s: %v", indexFile, err)
+			x = &searchIndex{file: indexFile}
+		}
+	}
+	c.search = x
+
+	t0 := time.Now()
+	c.GitHub().ForeachRepo(func(gr *GitHubRepo) error {
+		for n := range gr.issues {
+			c.markSearchDirty(GitHubIssueRef{gr, n})
+		}
+		return nil
+	})
+	c.Gerrit().ForeachProjectUnsorted(func(gp *GerritProject) error {
+		for _, cl := range gp.cls {
+			c.markSearchDirty(cl)
+		}
+		return nil
+	})
+	n := x.flush()
+	// Anything not seen in the corpus is gone from it.
+	for id, d := range x.docs {
+		if d != nil && d.item == nil {
+			x.remove(int32(id))
+		}
+	}
+	log.Printf("maintner: search index has %d documents; indexed %d in %v", len(x.byKey), n, time.Since(t0))
+	if indexFile == "" || !x.unsaved {
+		return nil
+	}
+	x.lastSave = time.Now()
+	return x.save(x.snapshot())
+}
+
+// markSearchDirty records that the text of item, a GitHubIssueRef or
+// *GerritCL, may have changed.
+//
+// c.mu must be held.
+func (c *Corpus) markSearchDirty(item interface{}) {
+	if c.search == nil {
+		return
+	}
+	if c.search.dirty == nil {
+		c.search.dirty = make(map[interface{}]bool)
+	}
+	c.search.dirty[item] = true
+}
+
+// finishSearch indexes the documents changed since the last call,
+// and starts saving the index if it's due.
+//
+// c.mu must be held.
+func (c *Corpus) finishSearch() {
+	x := c.search
+	if x == nil {
+		return
+	}
+	x.flush()
+	if x.file == "" || !x.unsaved || x.saving || time.Since(x.lastSave) < searchSaveInterval {
+		return
+	}
+	x.saving = true
+	docs := x.snapshot()
+	go func() {
+		if err := x.save(docs); err != nil {
+			log.Printf("maintner: saving search index: %v", err)
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		x.saving = false
+		x.lastSave = time.Now()
+	}()
+}
+
+// flush re-indexes the dirty documents and reports how many were
+// (re)built.
+func (x *searchIndex) flush() (n int) {
+	for item := range x.dirty {
+		key, stamp := searchDocVersion(item)
+		id, ok := x.byKey[key]
+		if ok && x.docs[id].stamp == stamp && stamp != "" {
+			x.docs[id].item = item
+			continue
+		}
+		if ok {
+			x.remove(id)
+		}
+		if stamp == "" {
+			continue
+		}
+		x.add(newSearchDoc(item, key, stamp))
+		n++
+	}
+	x.dirty = nil
+	return n
+}
+
+// searchDocVersion returns the index key of item and a string that
+// changes whenever its text might have. The stamp is empty if item has
+// no text to index, or is a private CL, whose text must not be
+// searchable.
+func searchDocVersion(item interface{}) (key, stamp string) {
+	switch item := item.(type) {
+	case GitHubIssueRef:
+		key = item.String()
+		gi := item.Repo.Issue(item.Number)
+		if gi == nil || gi.NotExist {
+			return key, ""
+		}
+		return key, fmt.Sprintf("%d/%d/%d", gi.LastModified().UnixNano(), len(gi.comments), len(gi.reviews))
+	case *GerritCL:
+		key = fmt.Sprintf("%s/%d", item.Project.ServerSlashProject(), item.Number)
+		if !item.complete() || item.Private {
+			return key, ""
+		}
+		return key, item.Meta.Commit.Hash.String() + "/" + item.commentsMeta.String()
+	}
+	panic(fmt.Sprintf("unexpected search item type %T", item))
+}
+
+// newSearchDoc tokenizes the text of item.
+func newSearchDoc(item interface{}, key, stamp string) *searchDoc {
+	tf := make(map[string]float32)
+	var total float32
+	add := func(s string, weight float32) {
+		searchTokens(s, func(t string) {
+			tf[t] += weight
+			total += weight
+		})
+	}
+	switch item := item.(type) {
+	case GitHubIssueRef:
+		gi := item.Repo.Issue(item.Number)
+		add(gi.Title, searchTitleWeight)
+		add(gi.GetBody(), searchTextWeight)
+		for _, c := range gi.comments {
+			add(c.GetBody(), searchTextWeight)
+		}
+		for _, r := range gi.reviews {
+			add(r.GetBody(), searchTextWeight)
+		}
+		for _, c := range gi.reviewComments {
+			add(c.GetBody(), searchTextWeight)
+		}
+	case *GerritCL:
+		add(item.Subject(), searchTitleWeight)
+		if item.Commit != nil {
+			add(item.Commit.GetMsg(), searchTextWeight)
+		}
+		for _, m := range item.Messages {
+			add(m.GetMessage(), searchTextWeight)
+		}
+		for _, c := range item.comments {
+			add(c.GetMessage(), searchTextWeight)
+		}
+	}
+	d := &searchDoc{
+		key:   key,
+		stamp: stamp,
+		item:  item,
+		len:   total,
+		terms: make([]string, 0, len(tf)),
+		tfs:   make([]float32, len(tf)),
+	}
+	for t := range tf {
+		d.terms = append(d.terms, t)
+	}
+	sort.Strings(d.terms)
+	for i, t := range d.terms {
+		d.tfs[i] = tf[t]
+	}
+	return d
+}
+
+// searchTokens calls fn with each indexable term of s: runs of letters
+// and digits, lowercased, excluding stop words and very short or long
+// ones (like git hashes).
+func searchTokens(s string, fn func(string)) {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	for {
+		i := strings.IndexFunc(s, isWord)
+		if i < 0 {
+			return
+		}
+		s = s[i:]
+		j := strings.IndexFunc(s, func(r rune) bool { return !isWord(r) })
+		if j < 0 {
+			j = len(s)
+		}
+		if t := strings.ToLower(s[:j]); len(t) >= 2 && len(t) <= 32 && !searchStopWords[t] {
+			fn(t)
+		}
+		s = s[j:]
+	}
+}
+
+func (x *searchIndex) add(d *searchDoc) {
+	if x.byKey == nil {
+		x.byKey = make(map[string]int32)
+		x.postings = make(map[string][]int32)
+	}
+	var id int32
+	if n := len(x.free); n > 0 {
+		id = x.free[n-1]
+		x.free = x.free[:n-1]
+		x.docs[id] = d
+	} else {
+		id = int32(len(x.docs))
+		x.docs = append(x.docs, d)
+	}
+	x.byKey[d.key] = id
+	for _, t := range d.terms {
+		x.postings[t] = append(x.postings[t], id)
+	}
+	x.totalLen += float64(d.len)
+	x.unsaved = true
+}
+
+func (x *searchIndex) remove(id int32) {
+	d := x.docs[id]
+	for _, t := range d.terms {
+		ids := x.postings[t]
+		for i, v := range ids {
+			if v == id {
+				ids[i] = ids[len(ids)-1]
+				ids = ids[:len(ids)-1]
+				break
+			}
+		}
+		if len(ids) == 0 {
+			delete(x.postings, t)
+		} else {
+			x.postings[t] = ids
+		}
+	}
+	delete(x.byKey, d.key)
+	x.docs[id] = nil
+	x.free = append(x.free, id)
+	x.totalLen -= float64(d.len)
+	x.unsaved = true
+}
+
+// Search returns up to max issues and CLs whose title, description,
+// comments or commit message match the words of query, most relevant
+// first. Documents need not contain all the words, but those that do
+// rank higher.
+//
+// Search requires a prior call to EnableSearch. If the corpus is being
+// updated concurrently, the caller must hold the read lock.
+func (c *Corpus) Search(query string, max int) ([]*SearchResult, error) {
+	x := c.search
+	if x == nil {
+		return nil, errors.New("maintner: search not enabled; see Corpus.EnableSearch")
+	}
+	seen := make(map[string]bool)
+	var terms []string
+	searchTokens(query, func(t string) {
+		if !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	})
+	if len(terms) == 0 {
+		return nil, errors.New("maintner: no searchable words in query")
+	}
+	n := float64(len(x.byKey))
+	if n == 0 {
+		return nil, nil
+	}
+	avgLen := x.totalLen / n
+	scores := make(map[int32]float64)
+	for _, t := range terms {
+		ids := x.postings[t]
+		df := float64(len(ids))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for _, id := range ids {
+			d := x.docs[id]
+			tf := d.tf(t)
+			scores[id] += idf * tf * (searchK1 + 1) / (tf + searchK1*(1-searchB+searchB*float64(d.len)/avgLen))
+		}
+	}
+
+	res := make([]*SearchResult, 0, len(scores))
+	for id, score := range scores {
+		r := &SearchResult{Score: score}
+		switch item := x.docs[id].item.(type) {
+		case GitHubIssueRef:
+			r.Repo, r.Issue = item.Repo, item.Repo.Issue(item.Number)
+		case *GerritCL:
+			r.CL = item
+		}
+		res = append(res, r)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return searchResultKey(res[i]) < searchResultKey(res[j])
+	})
+	if max > 0 && len(res) > max {
+		res = res[:max]
+	}
+	return res, nil
+}
+
+// searchResultKey orders results of equal score.
+func searchResultKey(r *SearchResult) string {
+	if r.CL != nil {
+		return fmt.Sprintf("%s/%09d", r.CL.Project.ServerSlashProject(), r.CL.Number)
+	}
+	return fmt.Sprintf("%s#%09d", r.Repo.ID(), r.Issue.Number)
+}
+
+// savedSearchIndex is the gob-encoded, gzipped contents of a search
+// index file.
+type savedSearchIndex struct {
+	Version int
+	Docs    []savedSearchDoc
+}
+
+type savedSearchDoc struct {
+	Key, Stamp string
+	Terms      []string
+	TFs        []float32
+}
+
+// snapshot returns the current documents, for save.
+func (x *searchIndex) snapshot() []*searchDoc {
+	docs := make([]*searchDoc, 0, len(x.byKey))
+	for _, d := range x.docs {
+		if d != nil {
+			docs = append(docs, d)
+		}
+	}
+	x.unsaved = false
+	return docs
+}
+
+// save writes docs to x.file. It may run without Corpus.mu held, as
+// docs are immutable and x.file is never changed.
+func (x *searchIndex) save(docs []*searchDoc) error {
+	si := savedSearchIndex{Version: searchFileVersion, Docs: make([]savedSearchDoc, len(docs))}
+	for i, d := range docs {
+		si.Docs[i] = savedSearchDoc{Key: d.key, Stamp: d.stamp, Terms: d.terms, TFs: d.tfs}
+	}
+	f, err := ioutil.TempFile(filepath.Dir(x.file), ".search-index-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	zw := gzip.NewWriter(f)
+	err = gob.NewEncoder(zw).Encode(&si)
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), x.file)
+}
+
+// load reads the documents of x.file into x. Their items are resolved
+// by the caller.
+func (x *searchIndex) load() error {
+	f, err := os.Open(x.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	var si savedSearchIndex
+	if err := gob.NewDecoder(zr).Decode(&si); err != nil {
+		return err
+	}
+	if si.Version != searchFileVersion {
+		return fmt.Errorf("file version %d; want %d", si.Version, searchFileVersion)
+	}
+	for _, sd := range si.Docs {
+		if len(sd.Terms) != len(sd.TFs) {
+			return fmt.Errorf("malformed document %q", sd.Key)
+		}
+		d := &searchDoc{key: sd.Key, stamp: sd.Stamp, terms: sd.Terms, tfs: sd.TFs}
+		for _, tf := range d.tfs {
+			d.len += tf
+		}
+		x.add(d)
+	}
+	x.unsaved = false
+	x.lastSave = time.Now()
+	return nil
+}
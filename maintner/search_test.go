@@ -0,0 +1,177 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+func TestSearchTokens(t *testing.T) {
+	var got []string
+	searchTokens("The strings.Builder is a WIN for 123 x 7b9fb2a4b8f1c2e1f3c02d7f2c6e4a1a0f4d2c10", func(t string) {
+		got = append(got, t)
+	})
+	want := []string{"strings", "builder", "win", "123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("searchTokens = %q; want %q", got, want)
+	}
+}
+
+// newSearchTestCorpus returns a corpus with a few issues, as if
+// loaded by Initialize.
+func newSearchTestCorpus() *Corpus {
+	c := new(Corpus)
+	for i, text := range []struct{ title, body string }{
+		{"net/http: Transport leaks goroutines", "Each request leaks a goroutine when the body isn't closed."},
+		{"fmt: Printf is slow", "Printf allocates too much."},
+		{"runtime: crash in goroutine scheduler", "See attached stack trace."},
+	} {
+		c.processMutationLocked(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+			Owner:   "golang",
+			Repo:    "go",
+			Number:  int32(i + 1),
+			User:    &maintpb.GithubUser{Id: 100, Login: "gopherbot"},
+			Title:   text.title,
+			Body:    text.body,
+			Created: p3339("2018-01-01T00:00:00Z"),
+			Updated: p3339("2018-01-01T00:00:00Z"),
+		}})
+	}
+	c.didInit = true
+	return c
+}
+
+func searchNumbers(t *testing.T, c *Corpus, query string) string {
+	t.Helper()
+	res, err := c.Search(query, 10)
+	if err != nil {
+		t.Fatalf("Search(%q): %v", query, err)
+	}
+	var nums []string
+	for _, r := range res {
+		nums = append(nums, fmt.Sprint(r.Issue.Number))
+	}
+	return strings.Join(nums, ",")
+}
+
+func addSearchTestComment(c *Corpus, num int32, body string) {
+	c.processMutationLocked(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+		Owner:  "golang",
+		Repo:   "go",
+		Number: num,
+		Comment: []*maintpb.GithubIssueCommentMutation{{
+			Id:      int64(num) * 1000,
+			User:    &maintpb.GithubUser{Id: 101, Login: "kevinburke"},
+			Body:    body,
+			Created: p3339("2018-02-01T00:00:00Z"),
+			Updated: p3339("2018-02-01T00:00:00Z"),
+		}},
+	}})
+	c.finishProcessing()
+}
+
+func TestSearch(t *testing.T) {
+	c := newSearchTestCorpus()
+	if _, err := c.Search("goroutine", 10); err == nil {
+		t.Error("Search before EnableSearch succeeded; want error")
+	}
+	if err := c.EnableSearch(""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A title match ranks above a body match.
+	if got, want := searchNumbers(t, c, "goroutine"), "3,1"; got != want {
+		t.Errorf("goroutine = %q; want %q", got, want)
+	}
+	if got, want := searchNumbers(t, c, "Printf"), "2"; got != want {
+		t.Errorf("Printf = %q; want %q", got, want)
+	}
+	if got, want := searchNumbers(t, c, "transport goroutine"), "1,3"; got != want {
+		t.Errorf("transport goroutine = %q; want %q", got, want)
+	}
+	if _, err := c.Search("the a", 10); err == nil {
+		t.Error("Search of stop words succeeded; want error")
+	}
+
+	// Comments are indexed as they arrive.
+	addSearchTestComment(c, 2, "Could use a sync.Pool for the buffers.")
+	if got, want := searchNumbers(t, c, "pool"), "2"; got != want {
+		t.Errorf("pool = %q; want %q", got, want)
+	}
+}
+
+func TestSearchDocVersionPrivateCL(t *testing.T) {
+	c := new(Corpus)
+	c.initGerrit()
+	cl := c.gerrit.getOrCreateProject("go.googlesource.com/go").getOrCreateCL(10)
+	cl.Commit = &GitCommit{Msg: "crypto/tls: fix embargoed vulnerability\n"}
+	cl.Meta = newGerritMeta(&GitCommit{Msg: "Create change\n\nPatch-set: 1\nStatus: new"}, cl)
+	cl.Metas = []*GerritMeta{cl.Meta}
+	if _, stamp := searchDocVersion(cl); stamp == "" {
+		t.Fatal("stamp of complete CL is empty")
+	}
+	cl.Private = true
+	if _, stamp := searchDocVersion(cl); stamp != "" {
+		t.Errorf("stamp of private CL = %q; want empty", stamp)
+	}
+}
+
+func TestSearchIndexFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "search-index")
+
+	c := newSearchTestCorpus()
+	if err := c.EnableSearch(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("index not saved: %v", err)
+	}
+
+	// A new process loads the same log, plus a new comment.
+	c = newSearchTestCorpus()
+	addSearchTestComment(c, 3, "Bisected to the new preemption code.")
+	if err := c.EnableSearch(file); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(c.search.byKey), 3; got != want {
+		t.Errorf("loaded index has %d documents; want %d", got, want)
+	}
+	for _, d := range c.search.docs {
+		if d.item == nil {
+			t.Errorf("document %q not resolved", d.key)
+		}
+	}
+	if got, want := searchNumbers(t, c, "goroutine"), "3,1"; got != want {
+		t.Errorf("goroutine = %q; want %q", got, want)
+	}
+	if got, want := searchNumbers(t, c, "preemption"), "3"; got != want {
+		t.Errorf("preemption = %q; want %q", got, want)
+	}
+
+	// A corrupt file is ignored and the index rebuilt.
+	if err := ioutil.WriteFile(file, []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c = newSearchTestCorpus()
+	if err := c.EnableSearch(file); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := searchNumbers(t, c, "printf"), "2"; got != want {
+		t.Errorf("printf after rebuild = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,1420 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Corpus snapshots.
+//
+// Replaying the mutation log is most of the time Initialize takes. A
+// snapshot is the processed state of a Corpus as of a position in its
+// log, so a later Initialize can load the newest snapshot and replay
+// only the mutations logged after it.
+
+package maintner
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// snapshotVersion is the version of the snapshot format. Snapshots of
+// other versions are ignored.
+const snapshotVersion = 2
+
+// snapshotMinTail is how many bytes of log Initialize or Update must
+// have processed since the last snapshot (or the start of the log)
+// before a new snapshot is written.
+var snapshotMinTail int64 = 64 << 20
+
+// snapshotsKept is how many of the newest snapshots are kept. Older
+// ones are removed when a new one is written.
+const snapshotsKept = 2
+
+// snapshotChunkItems is roughly how many items (commits, issues,
+// CLs, ...) are encoded per snapshotChunk.
+const snapshotChunkItems = 1000
+
+// A logPos is a position in a mutation log.
+type logPos struct {
+	seg string // log file or segment name; empty if unknown
+	off int64  // offset within seg
+}
+
+// A logSeg is one file or segment of a prefix of a mutation log.
+type logSeg struct {
+	Name   string
+	Size   int64  // bytes of the file or segment in the prefix
+	SHA224 string // of the Size bytes, in lowercase hex; empty if unchecked
+}
+
+// A snapshotSource is a MutationSource that supports snapshots: it
+// can describe the log up to a position, and resume GetMutations
+// after such a prefix.
+type snapshotSource interface {
+	MutationSource
+
+	// snapshotDir returns the directory snapshots are kept in.
+	snapshotDir() string
+
+	// logPrefix describes the log up to pos.
+	logPrefix(pos logPos) ([]logSeg, error)
+
+	// resumeAfter arranges for the next GetMutations to start
+	// after prefix, or at the beginning of the log if prefix is
+	// nil. It returns an error if the log doesn't begin with
+	// prefix.
+	resumeAfter(ctx context.Context, prefix []logSeg) error
+}
+
+// snapshotName returns the file name of the snapshot of prefix.
+// Names sort in log order.
+func snapshotName(prefix []logSeg) string {
+	var n int64
+	for _, s := range prefix {
+		n += s.Size
+	}
+	return fmt.Sprintf("snapshot-%016x", n)
+}
+
+// listSnapshots returns the names of the snapshots in dir, newest
+// first.
+func listSnapshots(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fi := range fis {
+		name := fi.Name()
+		hex := strings.TrimPrefix(name, "snapshot-")
+		if len(hex) != 16 || hex == name {
+			continue
+		}
+		if _, err := strconv.ParseUint(hex, 16, 64); err != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// snapshotOffset returns the log offset of the snapshot with the
+// given name, as returned by listSnapshots.
+func snapshotOffset(name string) int64 {
+	n, _ := strconv.ParseUint(strings.TrimPrefix(name, "snapshot-"), 16, 64)
+	return int64(n)
+}
+
+// loadSnapshot loads the newest valid snapshot from ss into c and
+// arranges for ss to resume after it. Snapshots that can't be used
+// are logged and skipped; if there are none, c is unchanged and the
+// whole log is replayed.
+func (c *Corpus) loadSnapshot(ctx context.Context, ss snapshotSource) {
+	dir := ss.snapshotDir()
+	names, err := listSnapshots(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("maintner: listing snapshots: %v", err)
+		}
+		return
+	}
+	for _, name := range names {
+		file := filepath.Join(dir, name)
+		t0 := time.Now()
+		sc, pos, err := readSnapshotFile(ctx, file, ss, c.text)
+		if err != nil {
+			log.Printf("maintner: ignoring snapshot %s: %v", file, err)
+			if err := ss.resumeAfter(ctx, nil); err != nil {
+				log.Printf("maintner: resetting %T: %v", ss, err)
+			}
+			continue
+		}
+		c.mu.Lock()
+		c.adoptSnapshot(sc)
+		c.logPos = pos
+		c.logOff = snapshotOffset(name)
+		c.mu.Unlock()
+		log.Printf("maintner: loaded snapshot %s in %v", file, time.Since(t0))
+		return
+	}
+}
+
+// readSnapshotFile reads the snapshot in file into a new Corpus,
+// arranging for ss to resume after it. The new Corpus keeps its text
+// in ts, if non-nil. It returns the log position the snapshot was
+// taken at.
+func readSnapshotFile(ctx context.Context, file string, ss snapshotSource, ts *textStore) (*Corpus, logPos, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, logPos{}, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, logPos{}, err
+	}
+	dec := gob.NewDecoder(zr)
+	var hdr snapshotHeader
+	if err := dec.Decode(&hdr); err != nil {
+		return nil, logPos{}, err
+	}
+	if hdr.Version != snapshotVersion {
+		return nil, logPos{}, fmt.Errorf("version %d; want %d", hdr.Version, snapshotVersion)
+	}
+	if len(hdr.Prefix) == 0 {
+		return nil, logPos{}, errors.New("empty log prefix")
+	}
+	if err := ss.resumeAfter(ctx, hdr.Prefix); err != nil {
+		return nil, logPos{}, err
+	}
+	sc, err := decodeSnapshot(dec, &hdr, ts)
+	if err != nil {
+		return nil, logPos{}, err
+	}
+	last := hdr.Prefix[len(hdr.Prefix)-1]
+	return sc, logPos{last.Name, last.Size}, nil
+}
+
+// adoptSnapshot replaces the state of c with that of sc, a Corpus
+// read from a snapshot. Repos and projects tracked by c before
+// Initialize are re-pointed at their counterparts in sc.
+//
+// c.mu must be held for writing.
+func (c *Corpus) adoptSnapshot(sc *Corpus) {
+	c.strIntern = sc.strIntern
+	c.gitPeople = sc.gitPeople
+	c.gitCommit = sc.gitCommit
+	c.gitCommitTodo = sc.gitCommitTodo
+	c.gitOfHg = sc.gitOfHg
+	c.zoneCache = sc.zoneCache
+	for _, r := range sc.gitRepos {
+		r.c = c
+	}
+	c.gitRepos = sc.gitRepos
+	for i, r := range c.watchedGitRepos {
+		sr := c.getOrCreateGitRepo(r.name)
+		sr.url = r.url
+		c.watchedGitRepos[i] = sr
+	}
+	c.numMutations = sc.numMutations
+	if cl := c.changes; cl != nil {
+		cl.mu.Lock()
+		cl.oldest, cl.next = c.numMutations, c.numMutations
+		cl.mu.Unlock()
+	}
+	if sc.github != nil {
+		sc.github.c = c
+		c.github = sc.github
+		for i, w := range c.watchedGithubRepos {
+			c.watchedGithubRepos[i].gr = c.github.getOrCreateRepo(w.gr.id.Owner, w.gr.id.Repo)
+		}
+	}
+	if sc.gerrit != nil {
+		sc.gerrit.c = c
+		c.gerrit = sc.gerrit
+		for i, w := range c.watchedGerritRepos {
+			c.watchedGerritRepos[i].project = c.gerrit.getOrCreateProject(w.project.proj)
+		}
+	}
+}
+
+// maybeWriteSnapshot writes a snapshot of c if its mutation source
+// supports them and enough of the log has been processed since the
+// last one. Failures are only logged; snapshots are an optimization.
+func (c *Corpus) maybeWriteSnapshot() {
+	ss, ok := c.mutationSource.(snapshotSource)
+	if !ok {
+		return
+	}
+	// The snapshot is copied under the lock, and only encoded and
+	// written once it's released, so mutations aren't held up by
+	// the much slower compression.
+	c.mu.RLock()
+	tail := c.logTail
+	if c.logPos.seg == "" || tail < snapshotMinTail {
+		c.mu.RUnlock()
+		return
+	}
+	t0 := time.Now()
+	prefix, err := ss.logPrefix(c.logPos)
+	var hdr *snapshotHeader
+	var chunks []snapshotChunk
+	if err == nil {
+		hdr, chunks = c.snapshot(prefix)
+	}
+	c.mu.RUnlock()
+	if err != nil {
+		log.Printf("maintner: writing snapshot: %v", err)
+		return
+	}
+	copyDur := time.Since(t0)
+	dir := ss.snapshotDir()
+	file, err := writeSnapshot(dir, hdr, chunks)
+	if err != nil {
+		log.Printf("maintner: writing snapshot: %v", err)
+		return
+	}
+	log.Printf("maintner: wrote snapshot %s in %v (%v copying)", file, time.Since(t0), copyDur)
+
+	c.mu.Lock()
+	c.logTail -= tail
+	c.mu.Unlock()
+
+	names, err := listSnapshots(dir)
+	if err != nil {
+		log.Printf("maintner: listing snapshots: %v", err)
+		return
+	}
+	for i, name := range names {
+		if i >= snapshotsKept {
+			os.Remove(filepath.Join(dir, name))
+		}
+	}
+}
+
+// writeSnapshot writes the snapshot with the header hdr and chunks
+// to dir and returns its file name.
+func writeSnapshot(dir string, hdr *snapshotHeader, chunks []snapshotChunk) (string, error) {
+	file := filepath.Join(dir, snapshotName(hdr.Prefix))
+	f, err := ioutil.TempFile(dir, ".snapshot-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	zw := gzip.NewWriter(f)
+	err = encodeSnapshotChunks(zw, hdr, chunks)
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	return file, os.Rename(f.Name(), file)
+}
+
+// A snapshot is a gob stream of a snapshotHeader followed by
+// snapshotChunks, the last of which has End set. Pointers between
+// items are encoded as hashes, IDs or keys, except that git people,
+// GitHub users and GitHub teams are referred to by their 1-based
+// index in the order the snapshot lists them, with 0 meaning nil.
+//
+// Derived state, like CL messages and metas and the query indexes,
+// isn't stored; it's rebuilt when the snapshot is loaded.
+
+type snapshotHeader struct {
+	Version   int
+	Prefix    []logSeg // of the log the snapshot is as of
+	Written   time.Time
+	Mutations int64 // number of mutations in the log prefix
+	GitHub    bool  // whether the corpus has GitHub state
+	Gerrit    bool  // whether the corpus has Gerrit state
+}
+
+// A snapshotChunk holds the next items of the snapshot. The items of
+// each kind may refer to those of the kinds above it, in this chunk
+// or earlier ones.
+type snapshotChunk struct {
+	People   []string // GitPerson.Str
+	Commits  []snapCommit
+	HgHashes []snapHgHash
+	GitTodo  []GitHash
+	GitRepos []snapGitRepo
+
+	Users  []GitHubUser
+	Teams  []GitHubTeam
+	Repos  []snapRepo
+	Issues []snapIssue
+
+	Projects []snapProject
+	CLs      []snapCL
+	IssueCLs []snapIssueCLs
+
+	End bool
+}
+
+type snapCommit struct {
+	Hash        GitHash
+	Placeholder bool // if true, the other fields are unset
+	Tree        GitHash
+	Parents     []GitHash
+	Author      int
+	AuthorTime  snapGitTime
+	Committer   int
+	CommitTime  snapGitTime
+	Msg         string
+	Files       []*maintpb.GitDiffTreeFile
+
+	// In compact mode, Msg and Files are left empty until the
+	// chunk is encoded; see snapshotChunk.loadText.
+	msg, files textRef
+}
+
+// snapGitTime is a git commit time, which is in whole seconds and has
+// the committer's time zone.
+type snapGitTime struct {
+	Unix int64
+	Zone string // like "+0530"; empty for the zero time
+}
+
+type snapHgHash struct {
+	Hg  string
+	Git GitHash
+}
+
+type snapGitRepo struct {
+	Name string
+	Refs []snapRef
+}
+
+type snapRepo struct {
+	Owner, Repo string
+	Labels      []GitHubLabel
+	Milestones  []GitHubMilestone
+}
+
+type snapIssue struct {
+	Owner, Repo string
+
+	ID          int64
+	Number      int32
+	NotExist    bool
+	Closed      bool
+	Locked      bool
+	PullRequest bool
+	User        int
+	Assignees   []int
+	Created     time.Time
+	Updated     time.Time
+	ClosedAt    time.Time
+	ClosedBy    int
+	Title       string
+	Body        string
+	body        textRef
+	Milestone   int64 // ID, or 0 if unknown or none
+	NoMilestone bool
+	Labels      []int64
+
+	CommentsUpdatedTil       time.Time
+	CommentsSyncedAsOf       time.Time
+	EventMaxTime             time.Time
+	EventsSyncedAsOf         time.Time
+	ReviewsSyncedAsOf        time.Time
+	ReviewCommentsUpdatedTil time.Time
+
+	Comments       []snapComment
+	Events         []snapEvent
+	Reviews        []snapReview
+	ReviewComments []snapReviewComment
+}
+
+type snapComment struct {
+	ID      int64
+	User    int
+	Created time.Time
+	Updated time.Time
+	Body    string
+	body    textRef
+}
+
+type snapEvent struct {
+	ID                  int64
+	Type                string
+	OtherJSON           string
+	Created             time.Time
+	Actor               int
+	Label               string
+	Assignee            int
+	Assigner            int
+	Milestone           string
+	From, To            string
+	CommitID, CommitURL string
+	Reviewer            int
+	TeamReviewer        int
+	ReviewRequester     int
+	DismissedReview     *GitHubDismissedReviewEvent
+}
+
+type snapReview struct {
+	ID        int64
+	User      int
+	Body      string
+	body      textRef
+	State     string
+	CommitID  string
+	Submitted time.Time
+}
+
+type snapReviewComment struct {
+	ID               int64
+	ReviewID         int64
+	InReplyTo        int64
+	User             int
+	Created          time.Time
+	Updated          time.Time
+	Body             string
+	body             textRef
+	Path             string
+	DiffHunk         string
+	Position         int
+	OriginalPosition int
+	CommitID         string
+	OriginalCommitID string
+}
+
+type snapProject struct {
+	Proj            string
+	NumLabelChanges int
+	Commits         []GitHash // keys of GerritProject.commit
+	Need            []GitHash
+	Refs            []snapRef
+	Remote          []snapRemote
+}
+
+type snapRef struct {
+	Name string
+	Hash GitHash
+}
+
+type snapRemote struct {
+	CL, Version int32
+	Hash        GitHash
+}
+
+type snapCL struct {
+	Proj         string
+	Number       int32
+	Version      int32
+	Commit       GitHash // or empty if nil
+	Meta         GitHash // commit of Meta, or empty if nil
+	Status       string
+	Private      bool
+	IssueRefs    []snapIssueRef
+	Comments     []GerritComment // without Author, which is rebuilt
+	CommentsMeta GitHash
+}
+
+type snapIssueRef struct {
+	Owner, Repo string
+	Number      int32
+}
+
+// snapIssueCLs is an entry of Gerrit.clsReferencingGithubIssue.
+type snapIssueCLs struct {
+	Issue snapIssueRef
+	CLs   []snapCLRef
+}
+
+type snapCLRef struct {
+	Proj   string
+	Number int32
+}
+
+// encodeSnapshot writes a snapshot of c, as of the log prefix, to w.
+//
+// c.mu must be held.
+func (c *Corpus) encodeSnapshot(w io.Writer, prefix []logSeg) error {
+	hdr, chunks := c.snapshot(prefix)
+	return encodeSnapshotChunks(w, hdr, chunks)
+}
+
+// snapshot returns the header and chunks of a snapshot of c as of
+// the log prefix. They only share immutable data with c, so they can
+// be encoded after c.mu is released. In compact mode, they refer to
+// text in c's text store, which is never rewritten, rather than
+// holding copies of it.
+//
+// c.mu must be held.
+func (c *Corpus) snapshot(prefix []logSeg) (*snapshotHeader, []snapshotChunk) {
+	e := &snapshotEncoder{
+		c:      c,
+		people: make(map[*GitPerson]int),
+		users:  make(map[*GitHubUser]int),
+		teams:  make(map[*GitHubTeam]int),
+	}
+	hdr := &snapshotHeader{
+		Version:   snapshotVersion,
+		Prefix:    prefix,
+		Written:   time.Now().UTC(),
+		Mutations: c.numMutations,
+		GitHub:    c.github != nil,
+		Gerrit:    c.gerrit != nil,
+	}
+	e.encodeGit()
+	if c.github != nil {
+		e.encodeGitHub()
+	}
+	if c.gerrit != nil {
+		e.encodeGerrit()
+	}
+	e.flush()
+	e.chunk.End = true
+	e.chunks = append(e.chunks, e.chunk)
+	return hdr, e.chunks
+}
+
+// encodeSnapshotChunks writes the snapshot with the header hdr and
+// chunks to w.
+func encodeSnapshotChunks(w io.Writer, hdr *snapshotHeader, chunks []snapshotChunk) error {
+	enc := gob.NewEncoder(w)
+	if err := enc.Encode(hdr); err != nil {
+		return err
+	}
+	for i := range chunks {
+		ch := &chunks[i]
+		ch.loadText(true)
+		err := enc.Encode(ch)
+		ch.loadText(false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadText reads the text of ch that's in a compact corpus's text
+// store into its fields, if load is true, or clears those fields
+// again, if it's false. That way only one chunk's text is in memory
+// at a time.
+func (ch *snapshotChunk) loadText(load bool) {
+	for i := range ch.Commits {
+		sc := &ch.Commits[i]
+		loadSnapText(&sc.Msg, sc.msg, load)
+		if sc.files.ts != nil {
+			sc.Files = nil
+			if load {
+				sc.Files = diffTreeFiles(sc.Hash, sc.files)
+			}
+		}
+	}
+	for i := range ch.Issues {
+		si := &ch.Issues[i]
+		loadSnapText(&si.Body, si.body, load)
+		for j := range si.Comments {
+			sc := &si.Comments[j]
+			loadSnapText(&sc.Body, sc.body, load)
+		}
+		for j := range si.Reviews {
+			sr := &si.Reviews[j]
+			loadSnapText(&sr.Body, sr.body, load)
+		}
+		for j := range si.ReviewComments {
+			sc := &si.ReviewComments[j]
+			loadSnapText(&sc.Body, sc.body, load)
+		}
+	}
+	for i := range ch.CLs {
+		for j := range ch.CLs[i].Comments {
+			gc := &ch.CLs[i].Comments[j]
+			loadSnapText(&gc.Message, gc.message, load)
+		}
+	}
+}
+
+// loadSnapText sets *field to the text of ref, or clears it if load
+// is false. It does nothing if the text isn't in a text store.
+func loadSnapText(field *string, ref textRef, load bool) {
+	if ref.ts == nil {
+		return
+	}
+	*field = ""
+	if load {
+		*field = ref.String()
+	}
+}
+
+type snapshotEncoder struct {
+	c      *Corpus
+	chunks []snapshotChunk // full chunks
+	chunk  snapshotChunk
+	n      int // items in chunk
+
+	people map[*GitPerson]int
+	users  map[*GitHubUser]int
+	teams  map[*GitHubTeam]int
+}
+
+// added notes that n items were added to e.chunk, and adds it to
+// e.chunks if it's full.
+func (e *snapshotEncoder) added(n int) {
+	e.n += n
+	if e.n >= snapshotChunkItems {
+		e.flush()
+	}
+}
+
+func (e *snapshotEncoder) flush() {
+	if e.n == 0 {
+		return
+	}
+	e.chunks = append(e.chunks, e.chunk)
+	e.chunk = snapshotChunk{}
+	e.n = 0
+}
+
+func (e *snapshotEncoder) person(p *GitPerson) int {
+	if p == nil {
+		return 0
+	}
+	ref, ok := e.people[p]
+	if !ok {
+		e.chunk.People = append(e.chunk.People, p.Str)
+		ref = len(e.people) + 1
+		e.people[p] = ref
+	}
+	return ref
+}
+
+func (e *snapshotEncoder) user(u *GitHubUser) int {
+	if u == nil {
+		return 0
+	}
+	ref, ok := e.users[u]
+	if !ok {
+		e.chunk.Users = append(e.chunk.Users, *u)
+		ref = len(e.users) + 1
+		e.users[u] = ref
+	}
+	return ref
+}
+
+func (e *snapshotEncoder) team(t *GitHubTeam) int {
+	if t == nil {
+		return 0
+	}
+	ref, ok := e.teams[t]
+	if !ok {
+		e.chunk.Teams = append(e.chunk.Teams, *t)
+		ref = len(e.teams) + 1
+		e.teams[t] = ref
+	}
+	return ref
+}
+
+func snapTime(t time.Time) snapGitTime {
+	if t.IsZero() {
+		return snapGitTime{}
+	}
+	return snapGitTime{Unix: t.Unix(), Zone: t.Location().String()}
+}
+
+func (e *snapshotEncoder) encodeGit() {
+	c := e.c
+	for _, p := range c.gitPeople {
+		e.person(p)
+		e.added(1)
+	}
+	for h, gc := range c.gitCommit {
+		sc := snapCommit{Hash: h}
+		if gc.Committer == placeholderCommitter {
+			sc.Placeholder = true
+		} else {
+			sc.Tree = gc.Tree
+			sc.Parents = make([]GitHash, len(gc.Parents))
+			for i, p := range gc.Parents {
+				sc.Parents[i] = p.Hash
+			}
+			sc.Author = e.person(gc.Author)
+			sc.AuthorTime = snapTime(gc.AuthorTime)
+			sc.Committer = e.person(gc.Committer)
+			sc.CommitTime = snapTime(gc.CommitTime)
+			sc.Msg, sc.msg = gc.Msg, gc.msg
+			sc.Files, sc.files = gc.Files, gc.files
+		}
+		e.chunk.Commits = append(e.chunk.Commits, sc)
+		e.added(1)
+	}
+	for hg, h := range c.gitOfHg {
+		e.chunk.HgHashes = append(e.chunk.HgHashes, snapHgHash{hg, h})
+		e.added(1)
+	}
+	for h := range c.gitCommitTodo {
+		e.chunk.GitTodo = append(e.chunk.GitTodo, h)
+		e.added(1)
+	}
+	for _, r := range c.gitRepos {
+		sr := snapGitRepo{Name: r.name}
+		for name, h := range r.ref {
+			sr.Refs = append(sr.Refs, snapRef{name, h})
+		}
+		e.chunk.GitRepos = append(e.chunk.GitRepos, sr)
+		e.added(1)
+	}
+}
+
+func (e *snapshotEncoder) encodeGitHub() {
+	g := e.c.github
+	for _, u := range g.users {
+		e.user(u)
+		e.added(1)
+	}
+	for _, t := range g.teams {
+		e.team(t)
+		e.added(1)
+	}
+	for id, gr := range g.repos {
+		sr := snapRepo{Owner: id.Owner, Repo: id.Repo}
+		for _, lb := range gr.labels {
+			sr.Labels = append(sr.Labels, *lb)
+		}
+		for _, ms := range gr.milestones {
+			sr.Milestones = append(sr.Milestones, *ms)
+		}
+		e.chunk.Repos = append(e.chunk.Repos, sr)
+		e.added(1 + len(sr.Labels) + len(sr.Milestones))
+	}
+	for id, gr := range g.repos {
+		for _, gi := range gr.issues {
+			e.chunk.Issues = append(e.chunk.Issues, e.issue(id, gi))
+			e.added(1 + len(gi.comments) + len(gi.events) + len(gi.reviews) + len(gi.reviewComments))
+		}
+	}
+}
+
+func (e *snapshotEncoder) issue(id GitHubRepoID, gi *GitHubIssue) snapIssue {
+	si := snapIssue{
+		Owner:       id.Owner,
+		Repo:        id.Repo,
+		ID:          gi.ID,
+		Number:      gi.Number,
+		NotExist:    gi.NotExist,
+		Closed:      gi.Closed,
+		Locked:      gi.Locked,
+		PullRequest: gi.PullRequest,
+		User:        e.user(gi.User),
+		Created:     gi.Created,
+		Updated:     gi.Updated,
+		ClosedAt:    gi.ClosedAt,
+		ClosedBy:    e.user(gi.ClosedBy),
+		Title:       gi.Title,
+		Body:        gi.Body,
+		body:        gi.body,
+
+		CommentsUpdatedTil:       gi.commentsUpdatedTil,
+		CommentsSyncedAsOf:       gi.commentsSyncedAsOf,
+		EventMaxTime:             gi.eventMaxTime,
+		EventsSyncedAsOf:         gi.eventsSyncedAsOf,
+		ReviewsSyncedAsOf:        gi.reviewsSyncedAsOf,
+		ReviewCommentsUpdatedTil: gi.reviewCommentsUpdatedTil,
+	}
+	for _, u := range gi.Assignees {
+		si.Assignees = append(si.Assignees, e.user(u))
+	}
+	switch {
+	case gi.Milestone.IsNone():
+		si.NoMilestone = true
+	case gi.Milestone != nil:
+		si.Milestone = gi.Milestone.ID
+	}
+	for id := range gi.Labels {
+		si.Labels = append(si.Labels, id)
+	}
+	for _, gc := range gi.comments {
+		si.Comments = append(si.Comments, snapComment{
+			ID:      gc.ID,
+			User:    e.user(gc.User),
+			Created: gc.Created,
+			Updated: gc.Updated,
+			Body:    gc.Body,
+			body:    gc.body,
+		})
+	}
+	for _, ev := range gi.events {
+		si.Events = append(si.Events, snapEvent{
+			ID:              ev.ID,
+			Type:            ev.Type,
+			OtherJSON:       ev.OtherJSON,
+			Created:         ev.Created,
+			Actor:           e.user(ev.Actor),
+			Label:           ev.Label,
+			Assignee:        e.user(ev.Assignee),
+			Assigner:        e.user(ev.Assigner),
+			Milestone:       ev.Milestone,
+			From:            ev.From,
+			To:              ev.To,
+			CommitID:        ev.CommitID,
+			CommitURL:       ev.CommitURL,
+			Reviewer:        e.user(ev.Reviewer),
+			TeamReviewer:    e.team(ev.TeamReviewer),
+			ReviewRequester: e.user(ev.ReviewRequester),
+			DismissedReview: ev.DismissedReview,
+		})
+	}
+	for _, rv := range gi.reviews {
+		si.Reviews = append(si.Reviews, snapReview{
+			ID:        rv.ID,
+			User:      e.user(rv.User),
+			Body:      rv.Body,
+			body:      rv.body,
+			State:     rv.State,
+			CommitID:  rv.CommitID,
+			Submitted: rv.Submitted,
+		})
+	}
+	for _, rc := range gi.reviewComments {
+		si.ReviewComments = append(si.ReviewComments, snapReviewComment{
+			ID:               rc.ID,
+			ReviewID:         rc.ReviewID,
+			InReplyTo:        rc.InReplyTo,
+			User:             e.user(rc.User),
+			Created:          rc.Created,
+			Updated:          rc.Updated,
+			Body:             rc.Body,
+			body:             rc.body,
+			Path:             rc.Path,
+			DiffHunk:         rc.DiffHunk,
+			Position:         rc.Position,
+			OriginalPosition: rc.OriginalPosition,
+			CommitID:         rc.CommitID,
+			OriginalCommitID: rc.OriginalCommitID,
+		})
+	}
+	return si
+}
+
+func (e *snapshotEncoder) encodeGerrit() {
+	g := e.c.gerrit
+	for _, gp := range g.projects {
+		sp := snapProject{Proj: gp.proj, NumLabelChanges: gp.numLabelChanges}
+		for h := range gp.commit {
+			sp.Commits = append(sp.Commits, h)
+		}
+		for h := range gp.need {
+			sp.Need = append(sp.Need, h)
+		}
+		for name, h := range gp.ref {
+			sp.Refs = append(sp.Refs, snapRef{name, h})
+		}
+		for clv, h := range gp.remote {
+			sp.Remote = append(sp.Remote, snapRemote{clv.CLNumber, clv.Version, h})
+		}
+		e.chunk.Projects = append(e.chunk.Projects, sp)
+		e.added(1 + len(sp.Commits)/100)
+	}
+	for _, gp := range g.projects {
+		for _, cl := range gp.cls {
+			scl := snapCL{
+				Proj:         gp.proj,
+				Number:       cl.Number,
+				Version:      cl.Version,
+				Status:       cl.Status,
+				Private:      cl.Private,
+				CommentsMeta: cl.commentsMeta,
+			}
+			if cl.Commit != nil {
+				scl.Commit = cl.Commit.Hash
+			}
+			if cl.Meta != nil {
+				scl.Meta = cl.Meta.Commit.Hash
+			}
+			for _, ref := range cl.GitHubIssueRefs {
+				scl.IssueRefs = append(scl.IssueRefs, snapIssueRefOf(ref))
+			}
+			for _, gc := range cl.comments {
+				sc := *gc
+				sc.Author = nil
+				scl.Comments = append(scl.Comments, sc)
+			}
+			e.chunk.CLs = append(e.chunk.CLs, scl)
+			e.added(1 + len(scl.Comments))
+		}
+	}
+	for ref, cls := range g.clsReferencingGithubIssue {
+		ic := snapIssueCLs{Issue: snapIssueRefOf(ref)}
+		for _, cl := range cls {
+			ic.CLs = append(ic.CLs, snapCLRef{cl.Project.proj, cl.Number})
+		}
+		e.chunk.IssueCLs = append(e.chunk.IssueCLs, ic)
+		e.added(1)
+	}
+}
+
+func snapIssueRefOf(ref GitHubIssueRef) snapIssueRef {
+	id := ref.Repo.ID()
+	return snapIssueRef{id.Owner, id.Repo, ref.Number}
+}
+
+// decodeSnapshot reads the chunks of a snapshot with the header hdr
+// from dec into a new Corpus, which keeps its text in ts if ts is
+// non-nil.
+func decodeSnapshot(dec *gob.Decoder, hdr *snapshotHeader, ts *textStore) (*Corpus, error) {
+	c := &Corpus{text: ts}
+	c.numMutations = hdr.Mutations
+	c.gitCommit = make(map[GitHash]*GitCommit)
+	if hdr.GitHub {
+		c.initGithub()
+	}
+	if hdr.Gerrit {
+		c.initGerrit()
+	}
+	d := &snapshotDecoder{c: c, numLabelChanges: make(map[*GerritProject]int)}
+	for {
+		var ch snapshotChunk
+		if err := dec.Decode(&ch); err != nil {
+			return nil, err
+		}
+		if err := d.decodeChunk(&ch); err != nil {
+			return nil, err
+		}
+		if ch.End {
+			break
+		}
+	}
+	if err := d.finish(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type snapshotDecoder struct {
+	c      *Corpus
+	people []*GitPerson
+	users  []*GitHubUser
+	teams  []*GitHubTeam
+
+	numLabelChanges map[*GerritProject]int
+}
+
+func (d *snapshotDecoder) person(ref int) (*GitPerson, error) {
+	if ref < 0 || ref > len(d.people) {
+		return nil, fmt.Errorf("bad git person reference %d", ref)
+	}
+	if ref == 0 {
+		return nil, nil
+	}
+	return d.people[ref-1], nil
+}
+
+// user returns the GitHub user referred to by ref. If ref is
+// invalid, it returns nil and sets *bad to ref.
+func (d *snapshotDecoder) user(ref int, bad *int) *GitHubUser {
+	if ref < 0 || ref > len(d.users) {
+		*bad = ref
+		return nil
+	}
+	if ref == 0 {
+		return nil
+	}
+	return d.users[ref-1]
+}
+
+func (d *snapshotDecoder) hash(h GitHash) GitHash {
+	if h == "" {
+		return ""
+	}
+	return GitHash(d.c.str(string(h)))
+}
+
+// commit returns the commit with hash h, creating an empty one to be
+// filled in by a later snapCommit if needed.
+func (d *snapshotDecoder) commit(h GitHash) *GitCommit {
+	gc := d.c.gitCommit[h]
+	if gc == nil {
+		gc = &GitCommit{Hash: d.hash(h)}
+		d.c.gitCommit[gc.Hash] = gc
+	}
+	return gc
+}
+
+func (d *snapshotDecoder) gitTime(t snapGitTime) time.Time {
+	if t.Zone == "" {
+		return time.Time{}
+	}
+	return time.Unix(t.Unix, 0).In(d.c.gitLocation([]byte(t.Zone)))
+}
+
+func (d *snapshotDecoder) decodeChunk(ch *snapshotChunk) error {
+	c := d.c
+	for _, s := range ch.People {
+		p := &GitPerson{Str: s}
+		if c.gitPeople == nil {
+			c.gitPeople = make(map[string]*GitPerson)
+		}
+		c.gitPeople[s] = p
+		d.people = append(d.people, p)
+	}
+	for _, sc := range ch.Commits {
+		if err := d.decodeCommit(&sc); err != nil {
+			return err
+		}
+	}
+	for _, hh := range ch.HgHashes {
+		if c.gitOfHg == nil {
+			c.gitOfHg = make(map[string]GitHash)
+		}
+		c.gitOfHg[hh.Hg] = d.hash(hh.Git)
+	}
+	for _, h := range ch.GitTodo {
+		if c.gitCommitTodo == nil {
+			c.gitCommitTodo = make(map[GitHash]bool)
+		}
+		c.gitCommitTodo[d.hash(h)] = true
+	}
+	for _, sr := range ch.GitRepos {
+		r := c.getOrCreateGitRepo(sr.Name)
+		for _, ref := range sr.Refs {
+			r.ref[c.str(ref.Name)] = d.hash(ref.Hash)
+		}
+	}
+
+	if len(ch.Users)+len(ch.Teams)+len(ch.Repos)+len(ch.Issues) > 0 && c.github == nil {
+		return errors.New("GitHub data in snapshot without GitHub state")
+	}
+	for _, u := range ch.Users {
+		u := u
+		if c.github.users == nil {
+			c.github.users = make(map[int64]*GitHubUser)
+		}
+		c.github.users[u.ID] = &u
+		d.users = append(d.users, &u)
+	}
+	for _, t := range ch.Teams {
+		t := t
+		if c.github.teams == nil {
+			c.github.teams = make(map[int64]*GitHubTeam)
+		}
+		c.github.teams[t.ID] = &t
+		d.teams = append(d.teams, &t)
+	}
+	for _, sr := range ch.Repos {
+		gr := c.github.getOrCreateRepo(sr.Owner, sr.Repo)
+		if gr == nil {
+			return fmt.Errorf("bad GitHub repo %s/%s", sr.Owner, sr.Repo)
+		}
+		for _, lb := range sr.Labels {
+			lb := lb
+			if gr.labels == nil {
+				gr.labels = make(map[int64]*GitHubLabel)
+			}
+			gr.labels[lb.ID] = &lb
+		}
+		for _, ms := range sr.Milestones {
+			ms := ms
+			if gr.milestones == nil {
+				gr.milestones = make(map[int64]*GitHubMilestone)
+			}
+			gr.milestones[ms.ID] = &ms
+		}
+	}
+	for i := range ch.Issues {
+		if err := d.decodeIssue(&ch.Issues[i]); err != nil {
+			return err
+		}
+	}
+
+	if len(ch.Projects)+len(ch.CLs)+len(ch.IssueCLs) > 0 && c.gerrit == nil {
+		return errors.New("Gerrit data in snapshot without Gerrit state")
+	}
+	for i := range ch.Projects {
+		if err := d.decodeProject(&ch.Projects[i]); err != nil {
+			return err
+		}
+	}
+	for i := range ch.CLs {
+		if err := d.decodeCL(&ch.CLs[i]); err != nil {
+			return err
+		}
+	}
+	for _, ic := range ch.IssueCLs {
+		ref, err := d.issueRef(ic.Issue)
+		if err != nil {
+			return err
+		}
+		var cls []*GerritCL
+		for _, r := range ic.CLs {
+			gp := c.gerrit.projects[r.Proj]
+			if gp == nil || gp.cls[r.Number] == nil {
+				return fmt.Errorf("unknown CL %s/%d", r.Proj, r.Number)
+			}
+			cls = append(cls, gp.cls[r.Number])
+		}
+		c.gerrit.clsReferencingGithubIssue[ref] = cls
+	}
+	return nil
+}
+
+func (d *snapshotDecoder) decodeCommit(sc *snapCommit) error {
+	gc := d.commit(sc.Hash)
+	if sc.Placeholder {
+		gc.Committer = placeholderCommitter
+		return nil
+	}
+	var err error
+	if gc.Author, err = d.person(sc.Author); err != nil {
+		return err
+	}
+	if gc.Committer, err = d.person(sc.Committer); err != nil {
+		return err
+	}
+	gc.Tree = d.hash(sc.Tree)
+	gc.Parents = make([]*GitCommit, 0, len(sc.Parents))
+	for _, h := range sc.Parents {
+		gc.Parents = append(gc.Parents, d.commit(h))
+	}
+	gc.AuthorTime = d.gitTime(sc.AuthorTime)
+	gc.CommitTime = d.gitTime(sc.CommitTime)
+	if d.c.text == nil {
+		gc.Msg = d.c.str(sc.Msg)
+	} else {
+		d.c.setText(&gc.Msg, &gc.msg, sc.Msg)
+	}
+	if reviewer, _ := lineValue(sc.Msg, "Reviewer: "); reviewer != "" {
+		gc.Reviewer = &GitPerson{Str: d.c.strb([]byte(reviewer))}
+	}
+	d.c.setFiles(gc, sc.Files)
+	return nil
+}
+
+func (d *snapshotDecoder) decodeIssue(si *snapIssue) error {
+	gr := d.c.github.Repo(si.Owner, si.Repo)
+	if gr == nil {
+		return fmt.Errorf("issue %d of unknown repo %s/%s", si.Number, si.Owner, si.Repo)
+	}
+	bad := 0
+	gi := &GitHubIssue{
+		ID:          si.ID,
+		Number:      si.Number,
+		NotExist:    si.NotExist,
+		Closed:      si.Closed,
+		Locked:      si.Locked,
+		PullRequest: si.PullRequest,
+		User:        d.user(si.User, &bad),
+		Created:     si.Created,
+		Updated:     si.Updated,
+		ClosedAt:    si.ClosedAt,
+		ClosedBy:    d.user(si.ClosedBy, &bad),
+		Title:       si.Title,
+
+		commentsUpdatedTil:       si.CommentsUpdatedTil,
+		commentsSyncedAsOf:       si.CommentsSyncedAsOf,
+		eventMaxTime:             si.EventMaxTime,
+		eventsSyncedAsOf:         si.EventsSyncedAsOf,
+		reviewsSyncedAsOf:        si.ReviewsSyncedAsOf,
+		reviewCommentsUpdatedTil: si.ReviewCommentsUpdatedTil,
+	}
+	d.c.setText(&gi.Body, &gi.body, si.Body)
+	for _, ref := range si.Assignees {
+		gi.Assignees = append(gi.Assignees, d.user(ref, &bad))
+	}
+	switch {
+	case si.NoMilestone:
+		gi.Milestone = noMilestone
+	case si.Milestone != 0:
+		if gi.Milestone = gr.milestones[si.Milestone]; gi.Milestone == nil {
+			return fmt.Errorf("issue %v/%d has unknown milestone %d", gr.id, si.Number, si.Milestone)
+		}
+	}
+	for _, id := range si.Labels {
+		lb := gr.labels[id]
+		if lb == nil {
+			return fmt.Errorf("issue %v/%d has unknown label %d", gr.id, si.Number, id)
+		}
+		if gi.Labels == nil {
+			gi.Labels = make(map[int64]*GitHubLabel)
+		}
+		gi.Labels[id] = lb
+	}
+	for _, sc := range si.Comments {
+		if gi.comments == nil {
+			gi.comments = make(map[int64]*GitHubComment)
+		}
+		gc := &GitHubComment{
+			ID:      sc.ID,
+			User:    d.user(sc.User, &bad),
+			Created: sc.Created,
+			Updated: sc.Updated,
+		}
+		d.c.setText(&gc.Body, &gc.body, sc.Body)
+		gi.comments[sc.ID] = gc
+	}
+	for _, se := range si.Events {
+		if se.TeamReviewer < 0 || se.TeamReviewer > len(d.teams) {
+			bad = se.TeamReviewer
+		}
+		var team *GitHubTeam
+		if se.TeamReviewer > 0 && bad == 0 {
+			team = d.teams[se.TeamReviewer-1]
+		}
+		if gi.events == nil {
+			gi.events = make(map[int64]*GitHubIssueEvent)
+		}
+		gi.events[se.ID] = &GitHubIssueEvent{
+			ID:              se.ID,
+			Type:            se.Type,
+			OtherJSON:       se.OtherJSON,
+			Created:         se.Created,
+			Actor:           d.user(se.Actor, &bad),
+			Label:           d.c.str(se.Label),
+			Assignee:        d.user(se.Assignee, &bad),
+			Assigner:        d.user(se.Assigner, &bad),
+			Milestone:       d.c.str(se.Milestone),
+			From:            se.From,
+			To:              se.To,
+			CommitID:        se.CommitID,
+			CommitURL:       se.CommitURL,
+			Reviewer:        d.user(se.Reviewer, &bad),
+			TeamReviewer:    team,
+			ReviewRequester: d.user(se.ReviewRequester, &bad),
+			DismissedReview: se.DismissedReview,
+		}
+	}
+	for _, sr := range si.Reviews {
+		if gi.reviews == nil {
+			gi.reviews = make(map[int64]*GitHubReview)
+		}
+		rv := &GitHubReview{
+			ID:        sr.ID,
+			User:      d.user(sr.User, &bad),
+			State:     sr.State,
+			CommitID:  sr.CommitID,
+			Submitted: sr.Submitted,
+		}
+		d.c.setText(&rv.Body, &rv.body, sr.Body)
+		gi.reviews[sr.ID] = rv
+	}
+	for _, sc := range si.ReviewComments {
+		if gi.reviewComments == nil {
+			gi.reviewComments = make(map[int64]*GitHubReviewComment)
+		}
+		gi.reviewComments[sc.ID] = &GitHubReviewComment{
+			ID:               sc.ID,
+			ReviewID:         sc.ReviewID,
+			InReplyTo:        sc.InReplyTo,
+			User:             d.user(sc.User, &bad),
+			Created:          sc.Created,
+			Updated:          sc.Updated,
+			Path:             sc.Path,
+			DiffHunk:         sc.DiffHunk,
+			Position:         sc.Position,
+			OriginalPosition: sc.OriginalPosition,
+			CommitID:         sc.CommitID,
+			OriginalCommitID: sc.OriginalCommitID,
+		}
+		rc := gi.reviewComments[sc.ID]
+		d.c.setText(&rc.Body, &rc.body, sc.Body)
+	}
+	if bad != 0 {
+		return fmt.Errorf("issue %v/%d has bad user or team reference %d", gr.id, si.Number, bad)
+	}
+	gr.issues[gi.Number] = gi
+	gr.updateIssueIndex(gi)
+	return nil
+}
+
+func (d *snapshotDecoder) decodeProject(sp *snapProject) error {
+	c := d.c
+	gp := c.gerrit.getOrCreateProject(sp.Proj)
+	for _, h := range sp.Commits {
+		gc := c.gitCommit[h]
+		if gc == nil {
+			return fmt.Errorf("project %s has unknown commit %v", sp.Proj, h)
+		}
+		gp.commit[gc.Hash] = gc
+	}
+	for _, h := range sp.Need {
+		gp.need[d.hash(h)] = true
+	}
+	for _, r := range sp.Refs {
+		gp.ref[r.Name] = d.hash(r.Hash)
+	}
+	for _, r := range sp.Remote {
+		h := d.hash(r.Hash)
+		gp.remote[gerritCLVersion{r.CL, r.Version}] = h
+		if r.Version != 0 {
+			gp.clOfCommit[h] = gp.getOrCreateCL(r.CL)
+		}
+	}
+	d.numLabelChanges[gp] = sp.NumLabelChanges
+	return nil
+}
+
+func (d *snapshotDecoder) decodeCL(scl *snapCL) error {
+	c := d.c
+	gp := c.gerrit.projects[scl.Proj]
+	if gp == nil {
+		return fmt.Errorf("CL %d of unknown project %s", scl.Number, scl.Proj)
+	}
+	cl := gp.getOrCreateCL(scl.Number)
+	cl.Version = scl.Version
+	cl.Status = scl.Status
+	cl.Private = scl.Private
+	if scl.Commit != "" {
+		if cl.Commit = c.gitCommit[scl.Commit]; cl.Commit == nil {
+			return fmt.Errorf("CL %s/%d has unknown commit %v", scl.Proj, scl.Number, scl.Commit)
+		}
+		cl.updateChangeID()
+	}
+	if scl.Meta != "" {
+		gc := c.gitCommit[scl.Meta]
+		if gc == nil {
+			return fmt.Errorf("CL %s/%d has unknown meta commit %v", scl.Proj, scl.Number, scl.Meta)
+		}
+		cl.Meta = newGerritMeta(gc, cl)
+	}
+	for _, sr := range scl.IssueRefs {
+		ref, err := d.issueRef(sr)
+		if err != nil {
+			return err
+		}
+		cl.GitHubIssueRefs = append(cl.GitHubIssueRefs, ref)
+	}
+	for _, gc := range scl.Comments {
+		gc := gc
+		if cl.comments == nil {
+			cl.comments = make(map[string]*GerritComment)
+		}
+		d.c.setText(&gc.Message, &gc.message, gc.Message)
+		cl.comments[gc.UUID] = &gc
+	}
+	cl.commentsMeta = d.hash(scl.CommentsMeta)
+	return nil
+}
+
+func (d *snapshotDecoder) issueRef(sr snapIssueRef) (GitHubIssueRef, error) {
+	if d.c.github == nil {
+		return GitHubIssueRef{}, errors.New("GitHub issue reference in snapshot without GitHub state")
+	}
+	gr := d.c.github.getOrCreateRepo(sr.Owner, sr.Repo)
+	if gr == nil {
+		return GitHubIssueRef{}, fmt.Errorf("bad GitHub repo %s/%s", sr.Owner, sr.Repo)
+	}
+	return GitHubIssueRef{gr, sr.Number}, nil
+}
+
+// finish rebuilds the state derived from the decoded snapshot.
+func (d *snapshotDecoder) finish() error {
+	for h, gc := range d.c.gitCommit {
+		// Every commit but a placeholder has non-nil Parents.
+		if gc.Parents == nil && gc.Committer != placeholderCommitter {
+			return fmt.Errorf("commit %v referenced but not in snapshot", h)
+		}
+	}
+	if g := d.c.gerrit; g != nil {
+		for _, gp := range g.projects {
+			for _, cl := range gp.cls {
+				if cl.Meta != nil {
+					gp.finishProcessingCL(cl)
+				}
+			}
+			// finishProcessingCL counted label changes again.
+			gp.numLabelChanges = d.numLabelChanges[gp]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,329 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/build/maintner/maintpb"
+	"golang.org/x/build/maintner/reclog"
+)
+
+// testRawCommit returns the raw form of a git commit.
+func testRawCommit(parent, who string, unix int64, tz, msg string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "tree %s\n", strings.Repeat("7", 40))
+	if parent != "" {
+		fmt.Fprintf(&buf, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&buf, "author %s %d %s\n", who, unix, tz)
+	fmt.Fprintf(&buf, "committer Gerrit Code Review <noreply-gerritcodereview@google.com> %d %s\n", unix, tz)
+	fmt.Fprintf(&buf, "\n%s", msg)
+	return buf.Bytes()
+}
+
+// newSnapshotTestCorpus returns a corpus with GitHub and Gerrit state,
+// built by processing mutations.
+func newSnapshotTestCorpus(t *testing.T) *Corpus {
+	c := new(Corpus)
+	fillSnapshotTestCorpus(c)
+	return c
+}
+
+// fillSnapshotTestCorpus processes the mutations of the snapshot test
+// corpus into c.
+func fillSnapshotTestCorpus(c *Corpus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.processMutationLocked(&maintpb.Mutation{Github: &maintpb.GithubMutation{
+		Owner:      "golang",
+		Repo:       "go",
+		Labels:     []*maintpb.GithubLabel{{Id: 1, Name: "NeedsFix"}},
+		Milestones: []*maintpb.GithubMilestone{{Id: 50, Title: "Go1.12", Number: 3}},
+	}})
+	c.processMutationLocked(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+		Owner:       "golang",
+		Repo:        "go",
+		Number:      1,
+		Id:          1001,
+		User:        &maintpb.GithubUser{Id: 100, Login: "gopherbot"},
+		Assignees:   []*maintpb.GithubUser{{Id: 200, Login: "rsc"}},
+		Title:       "net/http: Transport leaks goroutines",
+		Body:        "Each request leaks a goroutine.",
+		Created:     p3339("2018-01-01T00:00:00Z"),
+		Updated:     p3339("2018-02-01T00:00:00Z"),
+		AddLabel:    []*maintpb.GithubLabel{{Id: 1, Name: "NeedsFix"}},
+		MilestoneId: 50,
+		Comment: []*maintpb.GithubIssueCommentMutation{{
+			Id:      5,
+			User:    &maintpb.GithubUser{Id: 101, Login: "kevinburke"},
+			Body:    "I see this too.",
+			Created: p3339("2018-01-02T00:00:00Z"),
+			Updated: p3339("2018-01-02T00:00:00Z"),
+		}},
+		Event: []*maintpb.GithubIssueEvent{{
+			Id:           6,
+			EventType:    "review_requested",
+			ActorId:      100,
+			Created:      p3339("2018-01-03T00:00:00Z"),
+			TeamReviewer: &maintpb.GithubTeam{Id: 7, Slug: "runtime"},
+		}, {
+			Id:        8,
+			EventType: "labeled",
+			ActorId:   200,
+			Label:     &maintpb.GithubLabel{Name: "NeedsFix"},
+			Created:   p3339("2018-01-04T00:00:00Z"),
+		}},
+	}})
+	c.processMutationLocked(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+		Owner:       "golang",
+		Repo:        "net",
+		Number:      2,
+		PullRequest: true,
+		NoMilestone: true,
+		User:        &maintpb.GithubUser{Id: 0, Login: "ghost"},
+		Title:       "http2: fix a race",
+		Created:     p3339("2018-01-01T00:00:00Z"),
+		Review: []*maintpb.GithubReview{{
+			Id:        9,
+			User:      &maintpb.GithubUser{Id: 200},
+			State:     "APPROVED",
+			CommitId:  strings.Repeat("c", 40),
+			Submitted: p3339("2018-01-05T00:00:00Z"),
+		}},
+		ReviewComment: []*maintpb.GithubReviewCommentMutation{{
+			Id:       10,
+			ReviewId: 9,
+			User:     &maintpb.GithubUser{Id: 200},
+			Body:     "nit: comment",
+			Path:     "http2/server.go",
+			Position: 4,
+			Created:  p3339("2018-01-05T00:00:00Z"),
+			Updated:  p3339("2018-01-05T00:00:00Z"),
+		}},
+	}})
+
+	code := strings.Repeat("1c", 20)
+	meta1 := strings.Repeat("a1", 20)
+	meta2 := strings.Repeat("a2", 20)
+	c.processMutationLocked(&maintpb.Mutation{Gerrit: &maintpb.GerritMutation{
+		Project: "go.googlesource.com/go",
+		Commits: []*maintpb.GitCommit{{
+			// Its parent isn't in the log, so it's a placeholder.
+			Sha1: code,
+			Raw: testRawCommit(strings.Repeat("0f", 20), "Gopher <gopher@golang.org>", 1514764800, "+0530",
+				"net/http: close idle connections\n\nFixes #1\n"),
+			DiffTree: &maintpb.GitDiffTree{File: []*maintpb.GitDiffTreeFile{{File: "src/net/http/transport.go", Added: 3, Deleted: 1}}},
+		}, {
+			Sha1: meta1,
+			Raw: testRawCommit("", "Rick Sanchez <137@62eb7196>", 1514764800, "+0000",
+				"Create change\n\nUploaded patch set 1.\n\nPatch-set: 1\nBranch: refs/heads/master\nStatus: new\nHashtags: wait-release\n"),
+		}, {
+			Sha1: meta2,
+			Raw: testRawCommit(meta1, "Morty Smith <5206@62eb7196>", 1514851200, "-0700",
+				"Update patch set 1\n\nPatch Set 1: Code-Review+2\n\nLooks good.\n\nPatch-set: 1\nReviewer: Morty Smith <5206@62eb7196>\nLabel: Code-Review=+2\n"),
+		}},
+		Refs: []*maintpb.GitRef{
+			{Ref: "refs/heads/master", Sha1: code},
+			{Ref: "refs/changes/34/1234/1", Sha1: code},
+			{Ref: "refs/changes/34/1234/meta", Sha1: meta2},
+		},
+		Comments: []*maintpb.GerritCLComments{{
+			ClNumber: 1234,
+			MetaSha1: meta2,
+			Comments: []*maintpb.GerritComment{{
+				Uuid:       "9f8a1c4e_1d2b7a36",
+				PatchSet:   1,
+				File:       "src/net/http/transport.go",
+				Line:       37,
+				AuthorId:   5206,
+				Written:    p3339("2018-01-02T00:00:00Z"),
+				Message:    "Why?",
+				Unresolved: true,
+				Range:      &maintpb.GerritCommentRange{StartLine: 37, EndLine: 37, EndChar: 10},
+			}},
+		}},
+	}})
+	c.processMutationLocked(&maintpb.Mutation{Git: &maintpb.GitMutation{
+		Repo: &maintpb.GitRepo{Name: "internal/tools"},
+		Refs: []*maintpb.GitRef{{Ref: "refs/tags/v1.0", Sha1: code}},
+	}})
+	c.finishProcessing()
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	c := newSnapshotTestCorpus(t)
+	if cl := c.Gerrit().Project("go.googlesource.com", "go").CL(1234); cl == nil || len(cl.Metas) != 2 {
+		t.Fatalf("test corpus CL = %+v; want CL with 2 metas", cl)
+	}
+
+	var buf bytes.Buffer
+	prefix := []logSeg{{Name: "maintner-2018-01-01.mutlog", Size: 1234}}
+	if err := c.encodeSnapshot(&buf, prefix); err != nil {
+		t.Fatal(err)
+	}
+	dec := gob.NewDecoder(&buf)
+	var hdr snapshotHeader
+	if err := dec.Decode(&hdr); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(hdr.Prefix, prefix) || !hdr.GitHub || !hdr.Gerrit {
+		t.Errorf("header = %+v", hdr)
+	}
+	got, err := decodeSnapshot(dec, &hdr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The query indexes are rebuilt.
+	if nums := issueNumbers(t, got, "label:NeedsFix milestone:Go1.12 assignee:rsc"); nums != "go#1" {
+		t.Errorf("issue query after load = %q; want go#1", nums)
+	}
+	q, _ := ParseQuery("owner:137 status:new")
+	var cls []int32
+	got.ForeachCLMatching(q, func(cl *GerritCL) error {
+		cls = append(cls, cl.Number)
+		return nil
+	})
+	if len(cls) != 1 || cls[0] != 1234 {
+		t.Errorf("CL query after load = %v; want [1234]", cls)
+	}
+
+	// Otherwise the state is as it was. Indexes map from pointers,
+	// so can't be compared, and only some strings are interned.
+	issueCLs := func(c *Corpus) map[string][]int32 {
+		m := map[string][]int32{}
+		for ref, cls := range c.gerrit.clsReferencingGithubIssue {
+			for _, cl := range cls {
+				m[ref.String()] = append(m[ref.String()], cl.Number)
+			}
+		}
+		return m
+	}
+	if g, w := issueCLs(got), issueCLs(c); len(w) == 0 || !reflect.DeepEqual(g, w) {
+		t.Errorf("CLs referencing issues = %v; want %v", g, w)
+	}
+	for _, c := range []*Corpus{c, got} {
+		c.strIntern = nil
+		c.gerrit.clsReferencingGithubIssue = nil
+		c.github.c = nil
+		c.gerrit.c = nil
+		for _, r := range c.gitRepos {
+			r.c = nil
+		}
+		for _, gr := range c.github.repos {
+			gr.index = termIndex{}
+		}
+		for _, gp := range c.gerrit.projects {
+			gp.index = termIndex{}
+		}
+	}
+	for _, f := range []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"gitPeople", got.gitPeople, c.gitPeople},
+		{"gitCommit", got.gitCommit, c.gitCommit},
+		{"gitCommitTodo", got.gitCommitTodo, c.gitCommitTodo},
+		{"gitOfHg", got.gitOfHg, c.gitOfHg},
+		{"gitRepos", got.gitRepos, c.gitRepos},
+		{"github", got.github, c.github},
+		{"gerrit", got.gerrit, c.gerrit},
+	} {
+		if !reflect.DeepEqual(f.got, f.want) {
+			t.Errorf("%s differs after snapshot round trip", f.name)
+		}
+	}
+}
+
+// appendTestIssue appends a mutation creating issue num to the log
+// file.
+func appendTestIssue(t *testing.T, file string, num int32) {
+	data, err := proto.Marshal(&maintpb.Mutation{GithubIssue: &maintpb.GithubIssueMutation{
+		Owner:   "golang",
+		Repo:    "go",
+		Number:  num,
+		Title:   fmt.Sprintf("issue %d", num),
+		Created: p3339("2018-01-01T00:00:00Z"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reclog.AppendRecordToFile(file, data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskSnapshotResume(t *testing.T) {
+	defer func(n int64) { snapshotMinTail = n }(snapshotMinTail)
+	snapshotMinTail = 1
+
+	dir, err := ioutil.TempDir("", "maintner-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	old := filepath.Join(dir, "maintner-2018-01-01.mutlog")
+	cur := filepath.Join(dir, "maintner-2018-01-02.mutlog")
+	appendTestIssue(t, old, 1)
+	appendTestIssue(t, cur, 2)
+
+	ctx := context.Background()
+	c := new(Corpus)
+	if err := c.Initialize(ctx, NewDiskMutationLogger(dir)); err != nil {
+		t.Fatal(err)
+	}
+	snaps, err := listSnapshots(dir)
+	if err != nil || len(snaps) != 1 {
+		t.Fatalf("after Initialize, snapshots = %q, %v; want 1", snaps, err)
+	}
+
+	// Garble the start of the log, so only a corpus that resumes
+	// after the snapshot can load it. Add a newer, unusable
+	// snapshot too.
+	slurp, err := ioutil.ReadFile(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(old, bytes.Repeat([]byte("x"), len(slurp)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "snapshot-7fffffffffffffff"), []byte("junk"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	appendTestIssue(t, cur, 3)
+
+	c = new(Corpus)
+	if err := c.Initialize(ctx, NewDiskMutationLogger(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if nums := issueNumbers(t, c, "repo:golang/go"); nums != "go#1,go#2,go#3" {
+		t.Errorf("after resuming, issues = %q; want go#1,go#2,go#3", nums)
+	}
+
+	// A snapshot isn't used if the log no longer begins with what
+	// it read.
+	if err := os.Remove(old); err != nil {
+		t.Fatal(err)
+	}
+	c = new(Corpus)
+	if err := c.Initialize(ctx, NewDiskMutationLogger(dir)); err != nil {
+		t.Fatal(err)
+	}
+	if nums := issueNumbers(t, c, "repo:golang/go"); nums != "go#2,go#3" {
+		t.Errorf("after removing a log file, issues = %q; want go#2,go#3", nums)
+	}
+}
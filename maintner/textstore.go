@@ -0,0 +1,164 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/build/maintner/maintpb"
+)
+
+// EnableCompactMode makes the corpus keep large text fields, such as
+// issue and comment bodies, commit messages and diff trees, in a file
+// in dir rather than in memory, reading them back when they're
+// accessed. That saves most of the corpus's memory, at the cost of
+// slower access to text, so it suits read-only consumers that look at
+// little of it.
+//
+// In compact mode, the fields holding the text are empty, so code
+// that reads them directly sees no text. Use their accessors instead,
+// such as GitCommit.GetMsg and GitHubIssue.GetBody, which work in
+// either mode. If the file can't be read, the accessors log the error
+// and return no text. The file is deleted when the process exits,
+// where the operating system allows it.
+//
+// It must be called before Initialize.
+func (c *Corpus) EnableCompactMode(dir string) error {
+	ts, err := newTextStore(dir)
+	if err != nil {
+		return err
+	}
+	c.text = ts
+	return nil
+}
+
+// textStoreBufSize is how much text a textStore buffers before
+// writing it to its file.
+const textStoreBufSize = 1 << 20
+
+// A textStore holds the large text fields of a compact corpus. Text
+// is appended to a file and read back when it's accessed.
+type textStore struct {
+	mu      sync.Mutex
+	f       *os.File
+	written int64  // bytes written to f
+	buf     []byte // text after the written bytes
+	werr    error  // error writing f, after which text stays in buf
+}
+
+func newTextStore(dir string) (*textStore, error) {
+	f, err := ioutil.TempFile(dir, "maintner-text-")
+	if err != nil {
+		return nil, err
+	}
+	// The file is only needed while it's open. Removing an open
+	// file fails on Windows, leaving it behind.
+	os.Remove(f.Name())
+	return &textStore{f: f}, nil
+}
+
+// A textRef refers to text in a textStore. A textRef with a nil store
+// means the text is kept in a struct field instead.
+type textRef struct {
+	ts  *textStore
+	off int64
+	n   int
+}
+
+// add stores s and returns a reference to it.
+func (ts *textStore) add(s string) textRef {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	r := textRef{ts: ts, off: ts.written + int64(len(ts.buf)), n: len(s)}
+	ts.buf = append(ts.buf, s...)
+	if len(ts.buf) >= textStoreBufSize && ts.werr == nil {
+		if _, err := ts.f.WriteAt(ts.buf, ts.written); err != nil {
+			log.Printf("maintner: writing text store: %v; keeping text in memory", err)
+			ts.werr = err
+			return r
+		}
+		ts.written += int64(len(ts.buf))
+		ts.buf = ts.buf[:0]
+	}
+	return r
+}
+
+// String returns the text r refers to. If it can't be read, String
+// logs the error and returns the empty string.
+func (r textRef) String() string {
+	ts := r.ts
+	if ts == nil || r.n == 0 {
+		return ""
+	}
+	b := make([]byte, r.n)
+	ts.mu.Lock()
+	if r.off >= ts.written {
+		copy(b, ts.buf[r.off-ts.written:])
+		ts.mu.Unlock()
+		return string(b)
+	}
+	ts.mu.Unlock()
+	// Text once written is never rewritten, so it can be read
+	// without the lock.
+	if _, err := ts.f.ReadAt(b, r.off); err != nil {
+		log.Printf("maintner: reading text store: %v", err)
+		return ""
+	}
+	return string(b)
+}
+
+// slice returns a reference to the text r refers to from byte i up
+// to byte j.
+func (r textRef) slice(i, j int) textRef {
+	if i < 0 || j < i || j > r.n {
+		panic("textRef.slice: bad range")
+	}
+	return textRef{ts: r.ts, off: r.off + int64(i), n: j - i}
+}
+
+// textOf returns the text of a field kept as field, or in the text
+// store as ref.
+func textOf(field string, ref textRef) string {
+	if ref.ts != nil {
+		return ref.String()
+	}
+	return field
+}
+
+// setText sets a text field, which is kept as *field, or in compact
+// mode in the text store as *ref.
+//
+// c.mu must be held for writing.
+func (c *Corpus) setText(field *string, ref *textRef, s string) {
+	if c.text == nil || s == "" {
+		*field, *ref = s, textRef{}
+		return
+	}
+	*field, *ref = "", c.text.add(s)
+}
+
+// setFiles sets the diff tree of gc, which is kept in gc.Files, or in
+// compact mode in the text store.
+//
+// c.mu must be held for writing.
+func (c *Corpus) setFiles(gc *GitCommit, files []*maintpb.GitDiffTreeFile) {
+	if c.text == nil || len(files) == 0 {
+		for _, f := range files {
+			f.File = c.str(f.File) // intern the string
+		}
+		gc.Files, gc.files = files, textRef{}
+		return
+	}
+	data, err := proto.Marshal(&maintpb.GitDiffTree{File: files})
+	if err != nil {
+		panic(fmt.Sprintf("maintner: marshaling diff tree: %v", err))
+	}
+	gc.Files, gc.files = nil, c.text.add(string(data))
+}
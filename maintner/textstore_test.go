@@ -0,0 +1,208 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package maintner
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTextStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts, err := newTextStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ts.f.Close()
+
+	// Add enough text that most of it is written to the file, and
+	// the rest stays buffered.
+	var texts []string
+	var refs []textRef
+	for i := 0; len(texts) == 0 || ts.written < 2*textStoreBufSize; i++ {
+		s := fmt.Sprintf("text %d: %s", i, strings.Repeat("x", i%5000))
+		texts = append(texts, s)
+		refs = append(refs, ts.add(s))
+	}
+	texts = append(texts, "buffered")
+	refs = append(refs, ts.add("buffered"))
+	if len(ts.buf) == 0 {
+		t.Fatal("no text buffered")
+	}
+	for i, r := range refs {
+		if got := r.String(); got != texts[i] {
+			t.Fatalf("text %d = %.40q...; want %.40q...", i, got, texts[i])
+		}
+	}
+	last := refs[len(refs)-1]
+	if got, want := last.slice(5, 8).String(), texts[len(texts)-1][5:8]; got != want {
+		t.Errorf("slice = %q; want %q", got, want)
+	}
+	if got := (textRef{}).String(); got != "" {
+		t.Errorf("zero textRef = %q; want empty", got)
+	}
+}
+
+func TestTextStoreErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ts, err := newTextStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := strings.Repeat("x", textStoreBufSize)
+	written := ts.add(big)
+	if ts.written == 0 {
+		t.Fatal("text not written")
+	}
+	ts.f.Close()
+
+	// Text that can't be read is empty, and text that can't be
+	// written stays in memory.
+	if got := written.String(); got != "" {
+		t.Errorf("text after read error = %.40q...; want empty", got)
+	}
+	buffered := ts.add(big)
+	if ts.werr == nil {
+		t.Fatal("write to closed file succeeded")
+	}
+	if got := buffered.String(); got != big {
+		t.Errorf("text after write error = %.40q...; want %.40q...", got, big)
+	}
+}
+
+func TestCompactMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "maintner-text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	want := newSnapshotTestCorpus(t)
+	c := new(Corpus)
+	if err := c.EnableCompactMode(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer c.text.f.Close()
+	fillSnapshotTestCorpus(c)
+
+	check := func(c *Corpus) {
+		t.Helper()
+		gi := c.GitHub().Repo("golang", "go").Issue(1)
+		wi := want.GitHub().Repo("golang", "go").Issue(1)
+		if gi.Body != "" {
+			t.Errorf("issue Body = %q; want empty in compact mode", gi.Body)
+		}
+		if g, w := gi.GetBody(), wi.GetBody(); g != w {
+			t.Errorf("issue body = %q; want %q", g, w)
+		}
+		for id, wc := range wi.comments {
+			if g, w := gi.comments[id].GetBody(), wc.GetBody(); g != w {
+				t.Errorf("comment %d body = %q; want %q", id, g, w)
+			}
+		}
+		gpr := c.GitHub().Repo("golang", "net").Issue(2)
+		wpr := want.GitHub().Repo("golang", "net").Issue(2)
+		for id, wc := range wpr.reviewComments {
+			if g, w := gpr.reviewComments[id].GetBody(), wc.GetBody(); g != w {
+				t.Errorf("review comment %d body = %q; want %q", id, g, w)
+			}
+		}
+
+		for hash, wc := range want.gitCommit {
+			gc := c.gitCommit[hash]
+			if gc.Msg != "" || gc.Files != nil {
+				t.Errorf("commit %v keeps text in memory in compact mode", hash)
+			}
+			if g, w := gc.GetMsg(), wc.GetMsg(); g != w {
+				t.Errorf("commit %v message = %q; want %q", hash, g, w)
+			}
+			if g, w := gc.GetFiles(), wc.GetFiles(); !reflect.DeepEqual(g, w) {
+				t.Errorf("commit %v files = %v; want %v", hash, g, w)
+			}
+		}
+
+		gcl := c.Gerrit().Project("go.googlesource.com", "go").CL(1234)
+		wcl := want.Gerrit().Project("go.googlesource.com", "go").CL(1234)
+		if len(gcl.Messages) != len(wcl.Messages) {
+			t.Fatalf("got %d CL messages; want %d", len(gcl.Messages), len(wcl.Messages))
+		}
+		for i, wm := range wcl.Messages {
+			if g, w := gcl.Messages[i].GetMessage(), wm.GetMessage(); g != w {
+				t.Errorf("CL message %d = %q; want %q", i, g, w)
+			}
+		}
+		for uuid, wc := range wcl.comments {
+			gc := gcl.comments[uuid]
+			if gc.Message != "" {
+				t.Errorf("comment %s Message = %q; want empty in compact mode", uuid, gc.Message)
+			}
+			if g, w := gc.GetMessage(), wc.GetMessage(); g != w {
+				t.Errorf("comment %s message = %q; want %q", uuid, g, w)
+			}
+		}
+	}
+	check(c)
+
+	// A snapshot of a compact corpus holds its text, and loads into
+	// a compact corpus. Its chunks only read the text while they're
+	// encoded.
+	hdr0, chunks := c.snapshot(nil)
+	checkNoText := func(when string) {
+		t.Helper()
+		for _, ch := range chunks {
+			for _, sc := range ch.Commits {
+				if sc.Msg != "" || sc.Files != nil {
+					t.Errorf("%s encoding, snapshot of commit %v holds its text", when, sc.Hash)
+				}
+			}
+			for _, si := range ch.Issues {
+				if si.Body != "" {
+					t.Errorf("%s encoding, snapshot of issue %d holds its body", when, si.Number)
+				}
+				for _, sc := range si.Comments {
+					if sc.Body != "" {
+						t.Errorf("%s encoding, snapshot of comment %d holds its body", when, sc.ID)
+					}
+				}
+			}
+			for _, scl := range ch.CLs {
+				for _, gc := range scl.Comments {
+					if gc.Message != "" {
+						t.Errorf("%s encoding, snapshot of comment %s holds its message", when, gc.UUID)
+					}
+				}
+			}
+		}
+	}
+	checkNoText("before")
+	var buf bytes.Buffer
+	if err := encodeSnapshotChunks(&buf, hdr0, chunks); err != nil {
+		t.Fatal(err)
+	}
+	checkNoText("after")
+	dec := gob.NewDecoder(&buf)
+	var hdr snapshotHeader
+	if err := dec.Decode(&hdr); err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodeSnapshot(dec, &hdr, c.text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(got)
+}
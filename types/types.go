@@ -116,6 +116,12 @@ type ReverseBuilder struct {
 	BusySec      float64 `json:",omitempty"`
 	Version      string  // buildlet version
 	Busy         bool
+	Draining     bool `json:",omitempty"` // host is drained and won't be given new work
+
+	// Quarantined is whether the host has been taken out of
+	// rotation for misbehaving, for the reason in QuarantineReason.
+	Quarantined      bool   `json:",omitempty"`
+	QuarantineReason string `json:",omitempty"`
 }
 
 // ReverseHostStatus is part of ReverseBuilderStatus.
@@ -125,7 +131,12 @@ type ReverseHostStatus struct {
 	Expect    int    // expected number, from dashboard.Hosts config
 	Idle      int
 	Busy      int
-	Waiters   int // number of builds waiting on a buildlet host of this type
+	Waiters   int  // number of builds waiting on a buildlet host of this type
+	Draining  bool `json:",omitempty"` // host type is draining; no new builds start on it
+
+	// Quarantined is the number of connected buildlets that are
+	// quarantined for misbehaving. They count as Idle or Busy too.
+	Quarantined int `json:",omitempty"`
 
 	// Machines are all connected buildlets of this host type,
 	// keyed by machine self-reported unique name.